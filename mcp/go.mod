@@ -1,3 +1,7 @@
 module github.com/relay/mcp
 
 go 1.21
+
+require github.com/relay/mcp/shared v0.0.0
+
+replace github.com/relay/mcp/shared => ./shared
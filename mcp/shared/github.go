@@ -30,6 +30,14 @@ type GitHubService struct {
 	repository string
 }
 
+// PullRequest is a GitHub pull request
+type PullRequest struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	State  string `json:"state"`
+}
+
 // FileChange represents a file change in git
 type FileChange struct {
 	Status   string // A=added, M=modified, D=deleted, R=renamed
@@ -110,6 +118,50 @@ func (gs *GitHubService) GetIssue(number int) (*GitHubIssue, error) {
 	return &issue, nil
 }
 
+// ListIssues lists issues in the repository. State is "open", "closed" or "all";
+// an empty label matches every issue.
+func (gs *GitHubService) ListIssues(state, label string, limit int) ([]GitHubIssue, error) {
+	if state == "" {
+		state = "open"
+	}
+	if limit <= 0 {
+		limit = 30
+	}
+
+	args := []string{"issue", "list",
+		"--repo", gs.repository,
+		"--state", state,
+		"--limit", strconv.Itoa(limit),
+		"--json", "number,title,body,state,labels,url,createdAt,updatedAt,closedAt"}
+	if label != "" {
+		args = append(args, "--label", label)
+	}
+
+	cmd := exec.Command("gh", args...)
+	cmd.Dir = gs.workingDir
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list GitHub issues: %w", err)
+	}
+
+	var raw []map[string]interface{}
+	if err := json.Unmarshal(output, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub issues JSON: %w", err)
+	}
+
+	issues := make([]GitHubIssue, 0, len(raw))
+	for _, item := range raw {
+		issue, err := gs.parseGitHubIssue(item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse GitHub issue: %w", err)
+		}
+		issues = append(issues, issue)
+	}
+
+	return issues, nil
+}
+
 // UpdateIssueBody updates the body of a GitHub issue
 func (gs *GitHubService) UpdateIssueBody(number int, newBody string) error {
 	cmd := exec.Command("gh", "issue", "edit", strconv.Itoa(number),
@@ -125,10 +177,12 @@ func (gs *GitHubService) UpdateIssueBody(number int, newBody string) error {
 	return nil
 }
 
-// CreatePullRequest creates a pull request using gh CLI
-func (gs *GitHubService) CreatePullRequest(title, body string) (string, error) {
+// CreatePullRequest creates a pull request from head into base using gh CLI
+func (gs *GitHubService) CreatePullRequest(base, head, title, body string) (string, error) {
 	cmd := exec.Command("gh", "pr", "create",
 		"--repo", gs.repository,
+		"--base", base,
+		"--head", head,
 		"--title", title,
 		"--body", body)
 	cmd.Dir = gs.workingDir
@@ -141,6 +195,47 @@ func (gs *GitHubService) CreatePullRequest(title, body string) (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
+// FindPullRequest returns the open pull request for a branch, or nil if there is none
+func (gs *GitHubService) FindPullRequest(branch string) (*PullRequest, error) {
+	cmd := exec.Command("gh", "pr", "list",
+		"--repo", gs.repository,
+		"--head", branch,
+		"--state", "open",
+		"--json", "number,title,url,state")
+	cmd.Dir = gs.workingDir
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	var prs []PullRequest
+	if err := json.Unmarshal(output, &prs); err != nil {
+		return nil, fmt.Errorf("failed to parse pull requests JSON: %w", err)
+	}
+	if len(prs) == 0 {
+		return nil, nil
+	}
+
+	return &prs[0], nil
+}
+
+// UpdatePullRequest replaces the title and body of a pull request
+func (gs *GitHubService) UpdatePullRequest(number int, title, body string) error {
+	cmd := exec.Command("gh", "pr", "edit", strconv.Itoa(number),
+		"--repo", gs.repository,
+		"--title", title,
+		"--body", body)
+	cmd.Dir = gs.workingDir
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to update pull request #%d: %s", number, string(output))
+	}
+
+	return nil
+}
+
 // parseGitHubIssue converts raw JSON data to GitHubIssue struct
 func (gs *GitHubService) parseGitHubIssue(raw map[string]interface{}) (GitHubIssue, error) {
 	issue := GitHubIssue{}
@@ -307,7 +402,9 @@ func GenerateChangeSummary(changes []FileChange) string {
 	// Generate summary based on file types and patterns
 	summary.WriteString("## Changes\n\n")
 
-	for category, files := range categories {
+	// Fixed order so the summary is stable between runs
+	for _, category := range []string{"Added", "Modified", "Deleted", "Renamed"} {
+		files := categories[category]
 		if len(files) == 0 {
 			continue
 		}
@@ -1,3 +1,3 @@
-module shared
+module github.com/relay/mcp/shared
 
 go 1.21
\ No newline at end of file
@@ -0,0 +1,250 @@
+package shared
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// MCPProtocolVersion is the MCP protocol revision implemented by MCPServer
+const MCPProtocolVersion = "2024-11-05"
+
+// JSON-RPC 2.0 error codes
+const (
+	RPCParseError     = -32700
+	RPCInvalidRequest = -32600
+	RPCMethodNotFound = -32601
+	RPCInvalidParams  = -32602
+	RPCInternalError  = -32603
+)
+
+// RPCRequest is a JSON-RPC 2.0 request. Requests without an ID are notifications.
+type RPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// RPCResponse is a JSON-RPC 2.0 response
+type RPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError is a JSON-RPC 2.0 error object
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Tool describes an MCP tool in tools/list
+type Tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// ToolContent is a single content block of a tool result
+type ToolContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// ToolResult is the result of tools/call
+type ToolResult struct {
+	Content []ToolContent `json:"content"`
+	IsError bool          `json:"isError,omitempty"`
+}
+
+// ToolHandler runs a tool with its raw JSON arguments
+type ToolHandler func(arguments json.RawMessage) (*ToolResult, error)
+
+// TextResult wraps text in a successful tool result
+func TextResult(text string) *ToolResult {
+	return &ToolResult{Content: []ToolContent{{Type: "text", Text: text}}}
+}
+
+// JSONResult formats v as indented JSON in a successful tool result
+func JSONResult(v interface{}) (*ToolResult, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return TextResult(string(data)), nil
+}
+
+// MCPServer is a minimal MCP server speaking newline-delimited JSON-RPC over stdio
+type MCPServer struct {
+	name     string
+	version  string
+	tools    []Tool
+	handlers map[string]ToolHandler
+}
+
+// NewMCPServer creates a server that reports the given name and version on initialize
+func NewMCPServer(name, version string) *MCPServer {
+	return &MCPServer{
+		name:     name,
+		version:  version,
+		handlers: make(map[string]ToolHandler),
+	}
+}
+
+// RegisterTool adds a tool and its handler
+func (s *MCPServer) RegisterTool(tool Tool, handler ToolHandler) {
+	s.tools = append(s.tools, tool)
+	s.handlers[tool.Name] = handler
+}
+
+// Serve reads requests from r until EOF and writes responses to w, one JSON message per line
+func (s *MCPServer) Serve(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+
+	var writeMu sync.Mutex
+	write := func(v interface{}) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		_, err = w.Write(append(data, '\n'))
+		return err
+	}
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		if response := s.HandleMessage(line); response != nil {
+			if err := write(response); err != nil {
+				return fmt.Errorf("failed to write response: %w", err)
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+// HandleMessage handles one message (a request or a batch) and returns the
+// response to send, or nil when there is nothing to send
+func (s *MCPServer) HandleMessage(message []byte) interface{} {
+	if message[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(message, &batch); err != nil {
+			return errorResponse(nil, RPCParseError, "parse error: "+err.Error())
+		}
+		if len(batch) == 0 {
+			return errorResponse(nil, RPCInvalidRequest, "empty batch")
+		}
+
+		var responses []*RPCResponse
+		for _, item := range batch {
+			if response := s.handleRequest(item); response != nil {
+				responses = append(responses, response)
+			}
+		}
+		if len(responses) == 0 {
+			return nil
+		}
+		return responses
+	}
+
+	if response := s.handleRequest(message); response != nil {
+		return response
+	}
+	return nil
+}
+
+func errorResponse(id json.RawMessage, code int, message string) *RPCResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &RPCResponse{JSONRPC: "2.0", ID: id, Error: &RPCError{Code: code, Message: message}}
+}
+
+func (s *MCPServer) handleRequest(message []byte) *RPCResponse {
+	var request RPCRequest
+	if err := json.Unmarshal(message, &request); err != nil {
+		return errorResponse(nil, RPCParseError, "parse error: "+err.Error())
+	}
+	if request.JSONRPC != "2.0" || request.Method == "" {
+		return errorResponse(request.ID, RPCInvalidRequest, "invalid request")
+	}
+
+	result, rpcErr := s.dispatch(request)
+
+	// Notifications never get a response
+	if request.ID == nil {
+		return nil
+	}
+	if rpcErr != nil {
+		return &RPCResponse{JSONRPC: "2.0", ID: request.ID, Error: rpcErr}
+	}
+	return &RPCResponse{JSONRPC: "2.0", ID: request.ID, Result: result}
+}
+
+func (s *MCPServer) dispatch(request RPCRequest) (interface{}, *RPCError) {
+	switch request.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"protocolVersion": MCPProtocolVersion,
+			"capabilities": map[string]interface{}{
+				"tools": map[string]interface{}{},
+			},
+			"serverInfo": map[string]string{
+				"name":    s.name,
+				"version": s.version,
+			},
+		}, nil
+
+	case "notifications/initialized", "notifications/cancelled":
+		return nil, nil
+
+	case "ping":
+		return map[string]interface{}{}, nil
+
+	case "tools/list":
+		tools := s.tools
+		if tools == nil {
+			tools = []Tool{}
+		}
+		return map[string]interface{}{"tools": tools}, nil
+
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return nil, &RPCError{Code: RPCInvalidParams, Message: "invalid params: " + err.Error()}
+		}
+
+		handler, ok := s.handlers[params.Name]
+		if !ok {
+			return nil, &RPCError{Code: RPCInvalidParams, Message: fmt.Sprintf("unknown tool: %s", params.Name)}
+		}
+
+		if len(params.Arguments) == 0 || string(params.Arguments) == "null" {
+			params.Arguments = json.RawMessage("{}")
+		}
+
+		result, err := handler(params.Arguments)
+		if err != nil {
+			// Tool failures are reported in the result so the model can see them
+			return &ToolResult{Content: []ToolContent{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+		}
+		return result, nil
+	}
+
+	return nil, &RPCError{Code: RPCMethodNotFound, Message: fmt.Sprintf("method not found: %s", request.Method)}
+}
@@ -0,0 +1,55 @@
+package shared
+
+import (
+	"strings"
+)
+
+// Markers delimiting the plan section Relay maintains in an issue body. The
+// server imports this package too, so plans written by either are read by both.
+const (
+	PlanSectionStart = "<!-- relay:plan:start -->"
+	PlanSectionEnd   = "<!-- relay:plan:end -->"
+)
+
+// MergePlanSection replaces the marked plan section of an issue body, or appends
+// one if the body has none. Content outside the markers is left untouched.
+func MergePlanSection(body, plan string) string {
+	section := PlanSectionStart + "\n## Plan\n\n" + strings.TrimSpace(plan) + "\n" + PlanSectionEnd
+
+	start := strings.Index(body, PlanSectionStart)
+	if start != -1 {
+		end := strings.Index(body[start:], PlanSectionEnd)
+		if end != -1 {
+			end += start + len(PlanSectionEnd)
+			return body[:start] + section + body[end:]
+		}
+		// Unterminated section: put the plan at the marker and keep what follows
+		return body[:start] + section + body[start+len(PlanSectionStart):]
+	}
+
+	trimmed := strings.TrimRight(body, "\n ")
+	if trimmed == "" {
+		return section
+	}
+	return trimmed + "\n\n" + section
+}
+
+// ExtractPlanSection returns the plan inside the marked section, if any. A
+// start marker without an end marker is not a section: what follows it is
+// the user's text, not a plan.
+func ExtractPlanSection(body string) (string, bool) {
+	start := strings.Index(body, PlanSectionStart)
+	if start == -1 {
+		return "", false
+	}
+	content := body[start+len(PlanSectionStart):]
+	end := strings.Index(content, PlanSectionEnd)
+	if end == -1 {
+		return "", false
+	}
+	content = content[:end]
+
+	content = strings.TrimSpace(content)
+	content = strings.TrimSpace(strings.TrimPrefix(content, "## Plan"))
+	return content, true
+}
@@ -33,6 +33,46 @@ func GetCurrentBranch(workingDir string) (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
+// GetWorkingTreeChanges lists uncommitted changes (staged, unstaged and untracked)
+func GetWorkingTreeChanges(workingDir string) ([]FileChange, error) {
+	cmd := exec.Command("git", "status", "--porcelain", "--untracked-files=all")
+	cmd.Dir = workingDir
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working tree status: %w", err)
+	}
+
+	var changes []FileChange
+	for _, line := range strings.Split(string(output), "\n") {
+		if len(line) < 4 {
+			continue
+		}
+
+		code := strings.TrimSpace(line[:2])
+		path := line[3:]
+
+		change := FileChange{Status: code, FilePath: path}
+		switch {
+		case code == "??":
+			change.Status = "A"
+		case strings.Contains(code, "R"):
+			// Renames are reported as "old -> new"
+			if oldPath, newPath, ok := strings.Cut(path, " -> "); ok {
+				change.FilePath = oldPath
+				change.NewPath = newPath
+			}
+			change.Status = "R"
+		default:
+			change.Status = code[:1]
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
 // ExtractIssueNumbersFromText extracts issue numbers from text content
 // Supports patterns like: #16, Issue #16, Issue: 16, etc.
 func ExtractIssueNumbersFromText(text string) []int {
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/relay/mcp/shared"
+)
+
+// FinishService is the subset of GitHub operations needed to finish an issue
+type FinishService interface {
+	GetIssue(number int) (*shared.GitHubIssue, error)
+	FindPullRequest(branch string) (*shared.PullRequest, error)
+	CreatePullRequest(base, head, title, body string) (string, error)
+	UpdatePullRequest(number int, title, body string) error
+}
+
+// Finisher implements the finish_issue tool
+type Finisher struct {
+	// newService creates a GitHub service for a working directory
+	newService func(workingDir string) (FinishService, error)
+	// push pushes the current branch to origin
+	push func(workingDir string) error
+}
+
+// NewFinisher creates a finisher backed by git and the gh CLI
+func NewFinisher() *Finisher {
+	return &Finisher{
+		newService: func(workingDir string) (FinishService, error) {
+			service, err := shared.NewGitHubService(workingDir)
+			if err != nil {
+				return nil, err
+			}
+			return service, nil
+		},
+		push: shared.GitPush,
+	}
+}
+
+// finishArgs are the arguments of finish_issue
+type finishArgs struct {
+	WorkingDir    string `json:"workingDir"`
+	IssueNumber   int    `json:"issueNumber"`
+	CommitMessage string `json:"commitMessage"`
+	BaseBranch    string `json:"baseBranch"`
+	DryRun        bool   `json:"dry_run"`
+}
+
+// FinishResult is returned as JSON by finish_issue
+type FinishResult struct {
+	IssueNumber int    `json:"issueNumber"`
+	Branch      string `json:"branch"`
+	Committed   bool   `json:"committed"`
+	Pushed      bool   `json:"pushed"`
+	PRURL       string `json:"prUrl,omitempty"`
+	PRUpdated   bool   `json:"prUpdated"`
+	PRTitle     string `json:"prTitle"`
+	PRBody      string `json:"prBody"`
+	DryRun      bool   `json:"dryRun"`
+}
+
+// BuildPullRequestBody combines the closing keyword, grouped change summary and commit list
+func BuildPullRequestBody(issueNumber int, changes []shared.FileChange, commits []string) string {
+	var body strings.Builder
+
+	body.WriteString(fmt.Sprintf("Closes #%d\n\n", issueNumber))
+	body.WriteString(shared.GenerateChangeSummary(changes))
+
+	if !strings.HasSuffix(body.String(), "\n\n") {
+		body.WriteString("\n\n")
+	}
+
+	body.WriteString("## Commits\n\n")
+	if len(commits) == 0 {
+		body.WriteString("- No commits yet\n")
+	}
+	for _, commit := range commits {
+		body.WriteString(fmt.Sprintf("- %s\n", commit))
+	}
+
+	return body.String()
+}
+
+// FinishIssue commits remaining changes, pushes and opens or updates the PR
+func (f *Finisher) FinishIssue(arguments json.RawMessage) (*shared.ToolResult, error) {
+	var args finishArgs
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if args.WorkingDir == "" {
+		dir, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get working directory: %w", err)
+		}
+		args.WorkingDir = dir
+	}
+	if args.BaseBranch == "" {
+		args.BaseBranch = "main"
+	}
+
+	branch, err := shared.GetCurrentBranch(args.WorkingDir)
+	if err != nil {
+		return nil, err
+	}
+	if branch == args.BaseBranch {
+		return nil, fmt.Errorf("refusing to finish an issue from the base branch %s", branch)
+	}
+
+	if args.IssueNumber <= 0 {
+		args.IssueNumber, err = shared.ExtractIssueNumberFromBranch(branch)
+		if err != nil {
+			return nil, fmt.Errorf("issueNumber not given and %w", err)
+		}
+	}
+
+	service, err := f.newService(args.WorkingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	issue, err := service.GetIssue(args.IssueNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	commitMessage := args.CommitMessage
+	if commitMessage == "" {
+		commitMessage = fmt.Sprintf("Finish #%d: %s", issue.Number, issue.Title)
+	}
+
+	pending, err := shared.GetWorkingTreeChanges(args.WorkingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := FinishResult{
+		IssueNumber: issue.Number,
+		Branch:      branch,
+		PRTitle:     issue.Title,
+		DryRun:      args.DryRun,
+	}
+
+	if len(pending) > 0 && !args.DryRun {
+		if err := shared.GitAdd(args.WorkingDir); err != nil {
+			return nil, err
+		}
+		if err := shared.GitCommit(args.WorkingDir, commitMessage); err != nil {
+			return nil, err
+		}
+		result.Committed = true
+	}
+
+	changes, err := shared.GetCommitFileChanges(args.WorkingDir, args.BaseBranch)
+	if err != nil {
+		return nil, err
+	}
+	commits, err := shared.GetCommitMessages(args.WorkingDir, args.BaseBranch)
+	if err != nil {
+		return nil, err
+	}
+
+	// A dry run describes the commit it would make alongside the existing ones
+	if args.DryRun && len(pending) > 0 {
+		changes = append(changes, pending...)
+		commits = append([]string{commitMessage + " (not yet committed)"}, commits...)
+	}
+
+	if len(changes) == 0 && len(commits) == 0 {
+		return nil, fmt.Errorf("nothing to finish: branch %s has no changes against %s", branch, args.BaseBranch)
+	}
+
+	result.PRBody = BuildPullRequestBody(issue.Number, changes, commits)
+
+	if args.DryRun {
+		return shared.JSONResult(result)
+	}
+
+	if err := f.push(args.WorkingDir); err != nil {
+		return nil, err
+	}
+	result.Pushed = true
+
+	existing, err := service.FindPullRequest(branch)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil {
+		if err := service.UpdatePullRequest(existing.Number, result.PRTitle, result.PRBody); err != nil {
+			return nil, err
+		}
+		result.PRURL = existing.URL
+		result.PRUpdated = true
+	} else {
+		url, err := service.CreatePullRequest(args.BaseBranch, branch, result.PRTitle, result.PRBody)
+		if err != nil {
+			return nil, err
+		}
+		result.PRURL = url
+	}
+
+	return shared.JSONResult(result)
+}
+
+// NewServer registers finish_issue on an MCP server
+func NewServer(finisher *Finisher) *shared.MCPServer {
+	server := shared.NewMCPServer("issue-finisher", "1.0.0")
+
+	server.RegisterTool(shared.Tool{
+		Name: "finish_issue",
+		Description: "Finish the issue for the current branch: commit remaining changes, push, and open or update " +
+			"a pull request that closes the issue. Returns the PR URL. Use dry_run to preview the PR body without side effects.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"workingDir": map[string]interface{}{
+					"type":        "string",
+					"description": "Working directory (usually the issue's worktree), defaults to the current directory",
+				},
+				"issueNumber": map[string]interface{}{
+					"type":        "integer",
+					"description": "Issue number, auto-detected from the branch (feature/issue-16 → #16) if not provided",
+				},
+				"commitMessage": map[string]interface{}{
+					"type":        "string",
+					"description": "Message for committing remaining changes, defaults to \"Finish #N: <issue title>\"",
+				},
+				"baseBranch": map[string]interface{}{
+					"type":        "string",
+					"description": "Branch the PR merges into, defaults to main",
+				},
+				"dry_run": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Return the would-be PR body without committing, pushing or touching GitHub",
+				},
+			},
+		},
+	}, finisher.FinishIssue)
+
+	return server
+}
+
+func main() {
+	// stdout carries the protocol, so diagnostics go to stderr
+	logger := log.New(os.Stderr, "[issue-finisher] ", log.LstdFlags)
+
+	server := NewServer(NewFinisher())
+	if err := server.Serve(os.Stdin, os.Stdout); err != nil {
+		logger.Fatalf("Server error: %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relay/mcp/shared"
+)
+
+// fakeGitHub records pull request operations
+type fakeGitHub struct {
+	pr      *shared.PullRequest
+	body    string
+	created int
+	updated int
+	base    string // Base and head of the last PR created
+	head    string
+}
+
+func (f *fakeGitHub) GetIssue(number int) (*shared.GitHubIssue, error) {
+	return &shared.GitHubIssue{Number: number, Title: "Add export command", State: "open"}, nil
+}
+
+func (f *fakeGitHub) FindPullRequest(branch string) (*shared.PullRequest, error) {
+	return f.pr, nil
+}
+
+func (f *fakeGitHub) CreatePullRequest(base, head, title, body string) (string, error) {
+	f.created++
+	f.base, f.head = base, head
+	f.body = body
+	f.pr = &shared.PullRequest{Number: 3, Title: title, URL: "https://github.com/relay/demo/pull/3", State: "OPEN"}
+	return f.pr.URL, nil
+}
+
+func (f *fakeGitHub) UpdatePullRequest(number int, title, body string) error {
+	f.updated++
+	f.body = body
+	return nil
+}
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// newTestRepo creates a repo on feature/issue-7 with one commit ahead of main and a bare origin
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	root := t.TempDir()
+	remote := filepath.Join(root, "origin.git")
+	repo := filepath.Join(root, "repo")
+
+	git(t, root, "init", "--bare", "-b", "main", remote)
+	git(t, root, "init", "-b", "main", repo)
+	git(t, repo, "remote", "add", "origin", remote)
+
+	os.WriteFile(filepath.Join(repo, "README.md"), []byte("# Demo\n"), 0644)
+	git(t, repo, "add", ".")
+	git(t, repo, "commit", "-m", "Initial commit")
+
+	git(t, repo, "checkout", "-b", "feature/issue-7")
+	os.WriteFile(filepath.Join(repo, "export.go"), []byte("package main\n"), 0644)
+	git(t, repo, "add", ".")
+	git(t, repo, "commit", "-m", "Add export skeleton")
+
+	// Left uncommitted for finish_issue to pick up
+	os.WriteFile(filepath.Join(repo, "export_test.go"), []byte("package main\n"), 0644)
+
+	return repo
+}
+
+func finish(t *testing.T, finisher *Finisher, args map[string]interface{}) (FinishResult, error) {
+	t.Helper()
+	data, _ := json.Marshal(args)
+	toolResult, err := finisher.FinishIssue(data)
+	if err != nil {
+		return FinishResult{}, err
+	}
+	var result FinishResult
+	if err := json.Unmarshal([]byte(toolResult.Content[0].Text), &result); err != nil {
+		t.Fatalf("Invalid result: %v", err)
+	}
+	return result, nil
+}
+
+// TestFinishIssueDryRun tests that a dry run previews the PR body without side effects
+func TestFinishIssueDryRun(t *testing.T) {
+	repo := newTestRepo(t)
+	github := &fakeGitHub{}
+	finisher := &Finisher{
+		newService: func(string) (FinishService, error) { return github, nil },
+		push: func(string) error {
+			t.Error("Dry run must not push")
+			return nil
+		},
+	}
+
+	result, err := finish(t, finisher, map[string]interface{}{"workingDir": repo, "dry_run": true})
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+
+	for _, want := range []string{"Closes #7", "### Added Files", "export.go", "export_test.go", "- Finish #7: Add export command (not yet committed)", "- Add export skeleton"} {
+		if !strings.Contains(result.PRBody, want) {
+			t.Errorf("Expected %q in PR body:\n%s", want, result.PRBody)
+		}
+	}
+	if result.Committed || result.Pushed || github.created != 0 {
+		t.Errorf("Dry run had side effects: %+v", result)
+	}
+	if status := git(t, repo, "status", "--porcelain"); !strings.Contains(status, "export_test.go") {
+		t.Errorf("Dry run committed pending changes: %q", status)
+	}
+}
+
+// TestFinishIssue tests commit, push, PR creation and the PR update on a second run
+func TestFinishIssue(t *testing.T) {
+	repo := newTestRepo(t)
+	github := &fakeGitHub{}
+	finisher := &Finisher{
+		newService: func(string) (FinishService, error) { return github, nil },
+		push:       shared.GitPush,
+	}
+
+	result, err := finish(t, finisher, map[string]interface{}{"workingDir": repo})
+	if err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	if !result.Committed || !result.Pushed || result.PRURL != "https://github.com/relay/demo/pull/3" || result.PRUpdated {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if status := git(t, repo, "status", "--porcelain"); status != "" {
+		t.Errorf("Expected clean tree, got %q", status)
+	}
+	if remote := git(t, repo, "ls-remote", "origin", "feature/issue-7"); remote == "" {
+		t.Error("Branch was not pushed")
+	}
+	if github.base != "main" || github.head != "feature/issue-7" {
+		t.Errorf("PR opened from %q into %q, want feature/issue-7 into main", github.head, github.base)
+	}
+	if !strings.Contains(github.body, "- Finish #7: Add export command\n") {
+		t.Errorf("Expected new commit in PR body:\n%s", github.body)
+	}
+
+	// A second finish updates the existing PR rather than opening another
+	os.WriteFile(filepath.Join(repo, "EXPORT.md"), []byte("docs\n"), 0644)
+	result, err = finish(t, finisher, map[string]interface{}{"workingDir": repo, "commitMessage": "Document export"})
+	if err != nil {
+		t.Fatalf("Second finish failed: %v", err)
+	}
+	if !result.PRUpdated || github.created != 1 || github.updated != 1 || !strings.Contains(github.body, "- Document export") {
+		t.Errorf("Expected PR update, got %+v (created %d, updated %d)", result, github.created, github.updated)
+	}
+}
+
+// TestFinishIssueFromBaseBranch tests that finishing from the base branch is refused
+func TestFinishIssueFromBaseBranch(t *testing.T) {
+	repo := newTestRepo(t)
+	git(t, repo, "stash", "-u")
+	git(t, repo, "checkout", "main")
+
+	finisher := &Finisher{newService: func(string) (FinishService, error) { return &fakeGitHub{}, nil }}
+	if _, err := finish(t, finisher, map[string]interface{}{"workingDir": repo, "issueNumber": 7}); err == nil {
+		t.Error("Expected error when finishing from main")
+	}
+}
+
+// TestFinishToolListed tests that the server advertises finish_issue
+func TestFinishToolListed(t *testing.T) {
+	response := NewServer(NewFinisher()).HandleMessage([]byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	data, _ := json.Marshal(response)
+	if !strings.Contains(string(data), `"name":"finish_issue"`) || !strings.Contains(string(data), `"dry_run"`) {
+		t.Errorf("Unexpected tools/list response: %s", data)
+	}
+}
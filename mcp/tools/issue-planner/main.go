@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/relay/mcp/shared"
+)
+
+// IssueService is the subset of GitHub operations the planner needs
+type IssueService interface {
+	GetIssue(number int) (*shared.GitHubIssue, error)
+	UpdateIssueBody(number int, body string) error
+	ListIssues(state, label string, limit int) ([]shared.GitHubIssue, error)
+}
+
+// Planner implements the issue-planner tools
+type Planner struct {
+	// newService creates an issue service for a working directory
+	newService func(workingDir string) (IssueService, error)
+	// currentBranch returns the checked out branch of a working directory
+	currentBranch func(workingDir string) (string, error)
+}
+
+// NewPlanner creates a planner backed by the gh CLI
+func NewPlanner() *Planner {
+	return &Planner{
+		newService: func(workingDir string) (IssueService, error) {
+			service, err := shared.NewGitHubService(workingDir)
+			if err != nil {
+				return nil, err
+			}
+			return service, nil
+		},
+		currentBranch: shared.GetCurrentBranch,
+	}
+}
+
+// issueArgs are the arguments shared by the issue tools
+type issueArgs struct {
+	WorkingDir  string `json:"workingDir"`
+	IssueNumber int    `json:"issueNumber"`
+}
+
+// resolve returns the issue service and issue number for a working directory,
+// detecting the issue from the branch when it isn't given
+func (p *Planner) resolve(args issueArgs) (IssueService, int, error) {
+	workingDir := args.WorkingDir
+	if workingDir == "" {
+		dir, err := os.Getwd()
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get working directory: %w", err)
+		}
+		workingDir = dir
+	}
+
+	issueNumber := args.IssueNumber
+	if issueNumber <= 0 {
+		branch, err := p.currentBranch(workingDir)
+		if err != nil {
+			return nil, 0, err
+		}
+		issueNumber, err = shared.ExtractIssueNumberFromBranch(branch)
+		if err != nil {
+			return nil, 0, fmt.Errorf("issueNumber not given and %w", err)
+		}
+	}
+
+	service, err := p.newService(workingDir)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return service, issueNumber, nil
+}
+
+// UpdateIssuePlan merges a plan into the issue body's plan section
+func (p *Planner) UpdateIssuePlan(arguments json.RawMessage) (*shared.ToolResult, error) {
+	var args struct {
+		issueArgs
+		Plan string `json:"plan"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if strings.TrimSpace(args.Plan) == "" {
+		return nil, fmt.Errorf("plan is required")
+	}
+
+	service, number, err := p.resolve(args.issueArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	issue, err := service.GetIssue(number)
+	if err != nil {
+		return nil, err
+	}
+
+	body := shared.MergePlanSection(issue.Body, args.Plan)
+	if err := service.UpdateIssueBody(number, body); err != nil {
+		return nil, err
+	}
+
+	return shared.TextResult(fmt.Sprintf("Updated plan on issue #%d: %s", number, issue.Title)), nil
+}
+
+// GetIssue returns an issue, detecting it from the branch when not given
+func (p *Planner) GetIssue(arguments json.RawMessage) (*shared.ToolResult, error) {
+	var args issueArgs
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	service, number, err := p.resolve(args)
+	if err != nil {
+		return nil, err
+	}
+
+	issue, err := service.GetIssue(number)
+	if err != nil {
+		return nil, err
+	}
+
+	return shared.JSONResult(issue)
+}
+
+// ListIssues lists repository issues by state and label
+func (p *Planner) ListIssues(arguments json.RawMessage) (*shared.ToolResult, error) {
+	var args struct {
+		WorkingDir string `json:"workingDir"`
+		State      string `json:"state"`
+		Label      string `json:"label"`
+		Limit      int    `json:"limit"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	switch args.State {
+	case "", "open", "closed", "all":
+	default:
+		return nil, fmt.Errorf("invalid state '%s'. Valid states: open, closed, all", args.State)
+	}
+
+	if args.WorkingDir == "" {
+		dir, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get working directory: %w", err)
+		}
+		args.WorkingDir = dir
+	}
+
+	service, err := p.newService(args.WorkingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	issues, err := service.ListIssues(args.State, args.Label, args.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return shared.JSONResult(issues)
+}
+
+// NewServer registers the planner's tools on an MCP server
+func NewServer(planner *Planner) *shared.MCPServer {
+	server := shared.NewMCPServer("issue-planner", "1.0.0")
+
+	workingDirProperty := map[string]interface{}{
+		"type":        "string",
+		"description": "Working directory, defaults to the current directory",
+	}
+	issueNumberProperty := map[string]interface{}{
+		"type":        "integer",
+		"description": "Issue number, auto-detected from the branch (feature/issue-16 → #16) if not provided",
+	}
+
+	server.RegisterTool(shared.Tool{
+		Name:        "update_issue_plan",
+		Description: "Save a plan to the GitHub issue body. The plan replaces the issue's plan section; other content is preserved.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"plan": map[string]interface{}{
+					"type":        "string",
+					"description": "The plan content to add to the issue",
+				},
+				"workingDir":  workingDirProperty,
+				"issueNumber": issueNumberProperty,
+			},
+			"required": []string{"plan"},
+		},
+	}, planner.UpdateIssuePlan)
+
+	server.RegisterTool(shared.Tool{
+		Name:        "get_issue",
+		Description: "Get a GitHub issue with its title, body, state and labels",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"workingDir":  workingDirProperty,
+				"issueNumber": issueNumberProperty,
+			},
+		},
+	}, planner.GetIssue)
+
+	server.RegisterTool(shared.Tool{
+		Name:        "list_issues",
+		Description: "List GitHub issues in the repository",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"workingDir": workingDirProperty,
+				"state": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"open", "closed", "all"},
+					"description": "Issue state, defaults to open",
+				},
+				"label": map[string]interface{}{
+					"type":        "string",
+					"description": "Only list issues with this label",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum number of issues, defaults to 30",
+				},
+			},
+		},
+	}, planner.ListIssues)
+
+	return server
+}
+
+func main() {
+	// stdout carries the protocol, so diagnostics go to stderr
+	logger := log.New(os.Stderr, "[issue-planner] ", log.LstdFlags)
+
+	server := NewServer(NewPlanner())
+	if err := server.Serve(os.Stdin, os.Stdout); err != nil {
+		logger.Fatalf("Server error: %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/relay/mcp/shared"
+)
+
+// fakeIssues is an in-memory IssueService
+type fakeIssues struct {
+	issues map[int]*shared.GitHubIssue
+}
+
+func (f *fakeIssues) GetIssue(number int) (*shared.GitHubIssue, error) {
+	issue, ok := f.issues[number]
+	if !ok {
+		return nil, fmt.Errorf("issue #%d not found", number)
+	}
+	copied := *issue
+	return &copied, nil
+}
+
+func (f *fakeIssues) UpdateIssueBody(number int, body string) error {
+	issue, ok := f.issues[number]
+	if !ok {
+		return fmt.Errorf("issue #%d not found", number)
+	}
+	issue.Body = body
+	return nil
+}
+
+func (f *fakeIssues) ListIssues(state, label string, limit int) ([]shared.GitHubIssue, error) {
+	var issues []shared.GitHubIssue
+	for _, issue := range f.issues {
+		if state == "" || state == "all" || issue.State == state {
+			issues = append(issues, *issue)
+		}
+	}
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Number > issues[j].Number })
+	return issues, nil
+}
+
+// testClient drives a server over a pair of pipes, as an MCP host would over stdio
+type testClient struct {
+	t       *testing.T
+	stdin   io.WriteCloser
+	scanner *bufio.Scanner
+}
+
+func startServer(t *testing.T, issues *fakeIssues, branch string) *testClient {
+	t.Helper()
+
+	planner := &Planner{
+		newService:    func(string) (IssueService, error) { return issues, nil },
+		currentBranch: func(string) (string, error) { return branch, nil },
+	}
+
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- NewServer(planner).Serve(serverIn, serverOut)
+		serverOut.Close()
+	}()
+
+	t.Cleanup(func() {
+		clientOut.Close()
+		if err := <-done; err != nil {
+			t.Errorf("Serve returned error: %v", err)
+		}
+	})
+
+	return &testClient{t: t, stdin: clientOut, scanner: bufio.NewScanner(clientIn)}
+}
+
+// send writes a raw line and returns the decoded response line
+func (c *testClient) send(line string) map[string]interface{} {
+	c.t.Helper()
+	if _, err := io.WriteString(c.stdin, line+"\n"); err != nil {
+		c.t.Fatalf("Write failed: %v", err)
+	}
+	if !c.scanner.Scan() {
+		c.t.Fatalf("No response to %s: %v", line, c.scanner.Err())
+	}
+	var response map[string]interface{}
+	if err := json.Unmarshal(c.scanner.Bytes(), &response); err != nil {
+		c.t.Fatalf("Invalid response %s: %v", c.scanner.Text(), err)
+	}
+	return response
+}
+
+// call invokes a tool and returns its text content and error flag
+func (c *testClient) call(id int, name string, arguments interface{}) (string, bool) {
+	c.t.Helper()
+	params, _ := json.Marshal(map[string]interface{}{"name": name, "arguments": arguments})
+	response := c.send(fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":%s}`, id, params))
+
+	result, ok := response["result"].(map[string]interface{})
+	if !ok {
+		c.t.Fatalf("Expected result for %s, got %v", name, response)
+	}
+	content := result["content"].([]interface{})
+	text := content[0].(map[string]interface{})["text"].(string)
+	isError, _ := result["isError"].(bool)
+	return text, isError
+}
+
+func newFakeIssues() *fakeIssues {
+	return &fakeIssues{issues: map[int]*shared.GitHubIssue{
+		1:  {Number: 1, Title: "Old bug", State: "closed"},
+		16: {Number: 16, Title: "Add planner", State: "open", Body: "Original description\n\n- [ ] checklist item"},
+	}}
+}
+
+// TestProtocolHandshake tests initialize, notifications and tools/list
+func TestProtocolHandshake(t *testing.T) {
+	client := startServer(t, newFakeIssues(), "main")
+
+	response := client.send(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test","version":"0"}}}`)
+	result := response["result"].(map[string]interface{})
+	if result["protocolVersion"] != shared.MCPProtocolVersion {
+		t.Errorf("Unexpected protocol version: %v", result["protocolVersion"])
+	}
+	if name := result["serverInfo"].(map[string]interface{})["name"]; name != "issue-planner" {
+		t.Errorf("Unexpected server name: %v", name)
+	}
+	if response["id"].(float64) != 1 || response["jsonrpc"] != "2.0" {
+		t.Errorf("Unexpected envelope: %v", response)
+	}
+
+	// Notifications get no response, so the next line answers the ping
+	response = client.send(`{"jsonrpc":"2.0","method":"notifications/initialized"}` + "\n" + `{"jsonrpc":"2.0","id":"ping-1","method":"ping"}`)
+	if response["id"] != "ping-1" {
+		t.Errorf("Expected ping response after notification, got %v", response)
+	}
+
+	response = client.send(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	tools := response["result"].(map[string]interface{})["tools"].([]interface{})
+	var names []string
+	for _, tool := range tools {
+		tool := tool.(map[string]interface{})
+		names = append(names, tool["name"].(string))
+		if _, ok := tool["inputSchema"].(map[string]interface{}); !ok {
+			t.Errorf("Tool %s has no input schema", tool["name"])
+		}
+	}
+	if strings.Join(names, ",") != "update_issue_plan,get_issue,list_issues" {
+		t.Errorf("Unexpected tools: %v", names)
+	}
+}
+
+// TestProtocolErrors tests JSON-RPC error codes
+func TestProtocolErrors(t *testing.T) {
+	client := startServer(t, newFakeIssues(), "main")
+
+	tests := []struct {
+		line string
+		code float64
+	}{
+		{`{not json`, shared.RPCParseError},
+		{`{"jsonrpc":"1.0","id":1,"method":"ping"}`, shared.RPCInvalidRequest},
+		{`{"jsonrpc":"2.0","id":2,"method":"resources/list"}`, shared.RPCMethodNotFound},
+		{`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"nope"}}`, shared.RPCInvalidParams},
+	}
+
+	for _, tt := range tests {
+		response := client.send(tt.line)
+		rpcErr, ok := response["error"].(map[string]interface{})
+		if !ok || rpcErr["code"].(float64) != tt.code {
+			t.Errorf("Expected error %v for %s, got %v", tt.code, tt.line, response)
+		}
+	}
+}
+
+// TestUpdateIssuePlan tests branch detection and merging into the plan section
+func TestUpdateIssuePlan(t *testing.T) {
+	issues := newFakeIssues()
+	client := startServer(t, issues, "feature/issue-16")
+
+	text, isError := client.call(1, "update_issue_plan", map[string]interface{}{"plan": "1. Parse\n2. Test"})
+	if isError || !strings.Contains(text, "#16") {
+		t.Fatalf("Unexpected result: %s", text)
+	}
+
+	text, isError = client.call(2, "update_issue_plan", map[string]interface{}{"plan": "1. Parse\n2. Test\n3. Ship"})
+	if isError {
+		t.Fatalf("Second update failed: %s", text)
+	}
+
+	body := issues.issues[16].Body
+	if !strings.HasPrefix(body, "Original description\n\n- [ ] checklist item\n\n") {
+		t.Errorf("Existing content was not preserved: %q", body)
+	}
+	if strings.Count(body, shared.PlanSectionStart) != 1 || strings.Contains(body, "1. Parse\n2. Test\n"+shared.PlanSectionEnd) {
+		t.Errorf("Expected a single, replaced plan section: %q", body)
+	}
+	if plan, ok := shared.ExtractPlanSection(body); !ok || plan != "1. Parse\n2. Test\n3. Ship" {
+		t.Errorf("Unexpected plan section %q", plan)
+	}
+
+	// Content added after the section survives later updates
+	issues.issues[16].Body += "\n\nFollow-up notes"
+	client.call(3, "update_issue_plan", map[string]interface{}{"plan": "Done", "issueNumber": 16})
+	if !strings.HasSuffix(issues.issues[16].Body, "\n\nFollow-up notes") {
+		t.Errorf("Trailing content lost: %q", issues.issues[16].Body)
+	}
+
+	// A start marker whose end marker was deleted keeps the text after it
+	issues.issues[16].Body = "Intro\n" + shared.PlanSectionStart + "\nOld plan\n\nUser notes"
+	client.call(4, "update_issue_plan", map[string]interface{}{"plan": "New plan", "issueNumber": 16})
+	body = issues.issues[16].Body
+	if !strings.HasPrefix(body, "Intro\n") || !strings.HasSuffix(body, "\nOld plan\n\nUser notes") {
+		t.Errorf("Unterminated section clobbered the body: %q", body)
+	}
+	if plan, ok := shared.ExtractPlanSection(body); !ok || plan != "New plan" {
+		t.Errorf("Unexpected plan after an unterminated section %q", plan)
+	}
+	if _, ok := shared.ExtractPlanSection("Notes\n" + shared.PlanSectionStart + "\nno end"); ok {
+		t.Error("An unterminated section should not be extracted")
+	}
+
+	if text, isError := client.call(5, "update_issue_plan", map[string]interface{}{"plan": ""}); !isError {
+		t.Errorf("Expected error for empty plan, got %s", text)
+	}
+}
+
+// TestIssueTools tests get_issue and list_issues
+func TestIssueTools(t *testing.T) {
+	client := startServer(t, newFakeIssues(), "main")
+
+	text, isError := client.call(1, "get_issue", map[string]interface{}{"issueNumber": 16})
+	var issue shared.GitHubIssue
+	if isError || json.Unmarshal([]byte(text), &issue) != nil || issue.Title != "Add planner" {
+		t.Errorf("Unexpected get_issue result: %s", text)
+	}
+
+	// Branch "main" has no issue number to detect
+	if text, isError := client.call(2, "get_issue", map[string]interface{}{}); !isError || !strings.Contains(text, "no issue number") {
+		t.Errorf("Expected detection error, got %s", text)
+	}
+
+	text, isError = client.call(3, "list_issues", map[string]interface{}{"state": "all"})
+	var issues []shared.GitHubIssue
+	if isError || json.Unmarshal([]byte(text), &issues) != nil || len(issues) != 2 {
+		t.Errorf("Unexpected list_issues result: %s", text)
+	}
+
+	if text, isError := client.call(4, "list_issues", map[string]interface{}{"state": "merged"}); !isError {
+		t.Errorf("Expected invalid state error, got %s", text)
+	}
+}
@@ -0,0 +1,448 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults for the agent scheduler, overridable with RELAY_MAX_AGENTS,
+// RELAY_LLM_RPM and RELAY_LLM_MAX_IN_FLIGHT
+const (
+	defaultMaxAgents         = 4
+	defaultLLMRequestsPerMin = 30
+)
+
+// LLMRateLimiter paces LLM requests shared by every agent: requests are spaced
+// to stay under a per-minute rate, and at most maxInFlight run at once
+type LLMRateLimiter struct {
+	interval time.Duration // Minimum spacing between requests; 0 means unlimited
+	slots    chan struct{} // Nil means no in-flight limit
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// NewLLMRateLimiter creates a limiter; zero disables either limit
+func NewLLMRateLimiter(requestsPerMinute, maxInFlight int) *LLMRateLimiter {
+	l := &LLMRateLimiter{}
+	if requestsPerMinute > 0 {
+		l.interval = time.Minute / time.Duration(requestsPerMinute)
+	}
+	if maxInFlight > 0 {
+		l.slots = make(chan struct{}, maxInFlight)
+	}
+	return l
+}
+
+// Acquire waits for a request slot. The returned function releases it.
+func (l *LLMRateLimiter) Acquire(ctx context.Context) (func(), error) {
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	release := func() {
+		if l.slots != nil {
+			<-l.slots
+		}
+	}
+
+	if l.interval > 0 {
+		l.mu.Lock()
+		at := l.next
+		if now := time.Now(); at.Before(now) {
+			at = now
+		}
+		l.next = at.Add(l.interval)
+		l.mu.Unlock()
+
+		if wait := time.Until(at); wait > 0 {
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				release()
+				return nil, ctx.Err()
+			}
+		}
+	}
+
+	return release, nil
+}
+
+// AgentUsage counts an agent's LLM requests and estimated token spend
+type AgentUsage struct {
+	Requests     atomic.Int64
+	InputTokens  atomic.Int64
+	OutputTokens atomic.Int64
+}
+
+// estimateTokens approximates a token count from text length; providers
+// don't all report usage, so spend is shown as an estimate
+func estimateTokens(text string) int64 {
+	return int64(len(text)+3) / 4
+}
+
+// limitedProvider routes a provider's requests through the shared limiter and
+// counts them against an agent
+type limitedProvider struct {
+	LLMProvider
+	limiter *LLMRateLimiter
+	usage   *AgentUsage
+}
+
+func (p *limitedProvider) SendMessage(ctx context.Context, message string) (string, error) {
+	return p.send(ctx, message, func() (string, error) {
+		return p.LLMProvider.SendMessage(ctx, message)
+	})
+}
+
+func (p *limitedProvider) SendMessageWithSession(ctx context.Context, message string, sessionID string) (string, error) {
+	return p.send(ctx, message, func() (string, error) {
+		return p.LLMProvider.SendMessageWithSession(ctx, message, sessionID)
+	})
+}
+
+func (p *limitedProvider) send(ctx context.Context, message string, call func() (string, error)) (string, error) {
+	release, err := p.limiter.Acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	p.usage.Requests.Add(1)
+	p.usage.InputTokens.Add(estimateTokens(message))
+	response, err := call()
+	p.usage.OutputTokens.Add(estimateTokens(response))
+	return response, err
+}
+
+// Agent is a headless issue run managed by the scheduler
+type Agent struct {
+	Project     string
+	IssueNumber int
+	Runner      *IssueRunner
+	Usage       *AgentUsage
+	QueuedAt    time.Time
+
+	started  bool
+	promoted bool
+}
+
+// AgentStatus is a snapshot of an agent for display
+type AgentStatus struct {
+	Project      string         `json:"project"`
+	IssueNumber  int            `json:"issue_number"`
+	Queued       bool           `json:"queued"`
+	Promoted     bool           `json:"promoted"`
+	Run          IssueRunStatus `json:"run"`
+	QueuedAt     time.Time      `json:"queued_at"`
+	Elapsed      time.Duration  `json:"elapsed"`
+	Requests     int64          `json:"requests"`
+	InputTokens  int64          `json:"input_tokens"`
+	OutputTokens int64          `json:"output_tokens"`
+}
+
+// AgentScheduler runs headless issue agents concurrently in their own
+// worktrees, within a global cap and a cap per project. Agents share one LLM
+// rate limiter, and a failing or panicking agent doesn't affect the others.
+type AgentScheduler struct {
+	mu           sync.Mutex
+	globalLimit  int
+	limits       map[string]int
+	defaultLimit int
+	limiter      *LLMRateLimiter
+	agents       []*Agent // In submission order, finished ones included until cleared
+	closed       bool
+}
+
+// NewAgentScheduler creates a scheduler running at most globalLimit agents at once
+func NewAgentScheduler(globalLimit int, limiter *LLMRateLimiter) *AgentScheduler {
+	if globalLimit < 1 {
+		globalLimit = 1
+	}
+	return &AgentScheduler{
+		globalLimit:  globalLimit,
+		limits:       make(map[string]int),
+		defaultLimit: 1,
+		limiter:      limiter,
+	}
+}
+
+// NewAgentSchedulerFromEnv creates a scheduler with the default limits or the
+// ones set in RELAY_MAX_AGENTS, RELAY_LLM_RPM and RELAY_LLM_MAX_IN_FLIGHT
+func NewAgentSchedulerFromEnv() *AgentScheduler {
+	envInt := func(name string, fallback int) int {
+		if value, err := strconv.Atoi(os.Getenv(name)); err == nil && value >= 0 {
+			return value
+		}
+		return fallback
+	}
+
+	limiter := NewLLMRateLimiter(envInt("RELAY_LLM_RPM", defaultLLMRequestsPerMin), envInt("RELAY_LLM_MAX_IN_FLIGHT", 0))
+	return NewAgentScheduler(envInt("RELAY_MAX_AGENTS", defaultMaxAgents), limiter)
+}
+
+// SetConcurrencyLimit sets how many agents may run at once for a project
+func (s *AgentScheduler) SetConcurrencyLimit(project string, limit int) {
+	if limit < 1 {
+		limit = 1
+	}
+	s.mu.Lock()
+	s.limits[project] = limit
+	s.mu.Unlock()
+	s.dispatch()
+}
+
+// Submit queues a run and starts it when the caps allow. An issue that
+// already has a queued or running agent gets that agent back.
+func (s *AgentScheduler) Submit(runner *IssueRunner) *Agent {
+	s.mu.Lock()
+	if agent := s.findLocked(runner.projectName, runner.issueNumber); agent != nil && !agent.Runner.IsFinished() {
+		s.mu.Unlock()
+		return agent
+	}
+
+	agent := &Agent{
+		Project:     runner.projectName,
+		IssueNumber: runner.issueNumber,
+		Runner:      runner,
+		Usage:       &AgentUsage{},
+		QueuedAt:    time.Now(),
+	}
+
+	// Every agent's LLM requests go through the shared limiter
+	runner.planner = &limitedProvider{LLMProvider: runner.planner, limiter: s.limiter, usage: agent.Usage}
+	newExecutor := runner.newExecutor
+	runner.newExecutor = func(dir string) (LLMProvider, error) {
+		provider, err := newExecutor(dir)
+		if err != nil {
+			return nil, err
+		}
+		return &limitedProvider{LLMProvider: provider, limiter: s.limiter, usage: agent.Usage}, nil
+	}
+
+	s.agents = append(s.agents, agent)
+	s.mu.Unlock()
+
+	runner.logf("Queued for an agent slot")
+	s.dispatch()
+	return agent
+}
+
+// Cancel stops an agent, or drops it from the queue if it hasn't started
+func (s *AgentScheduler) Cancel(project string, issueNumber int) error {
+	s.mu.Lock()
+	agent := s.findLocked(project, issueNumber)
+	if agent == nil || agent.Runner.IsFinished() {
+		s.mu.Unlock()
+		return fmt.Errorf("no active agent for %s #%d", project, issueNumber)
+	}
+	started := agent.started
+	agent.started = true // A queued agent must not be dispatched once cancelled
+	s.mu.Unlock()
+
+	if started {
+		agent.Runner.Cancel()
+	} else {
+		agent.Runner.finish(context.Canceled)
+	}
+	s.dispatch()
+	return nil
+}
+
+// Promote starts a queued agent right away, past the concurrency caps
+func (s *AgentScheduler) Promote(project string, issueNumber int) error {
+	s.mu.Lock()
+	agent := s.findLocked(project, issueNumber)
+	if agent == nil || agent.Runner.IsFinished() {
+		s.mu.Unlock()
+		return fmt.Errorf("no active agent for %s #%d", project, issueNumber)
+	}
+	if agent.started {
+		s.mu.Unlock()
+		return fmt.Errorf("agent for %s #%d is already running", project, issueNumber)
+	}
+	agent.promoted = true
+	s.mu.Unlock()
+
+	s.dispatch()
+	return nil
+}
+
+// Agent returns the latest agent for an issue, if any
+func (s *AgentScheduler) Agent(project string, issueNumber int) *Agent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.findLocked(project, issueNumber)
+}
+
+// Statuses returns a snapshot of every agent in submission order
+func (s *AgentScheduler) Statuses() []AgentStatus {
+	s.mu.Lock()
+	agents := append([]*Agent(nil), s.agents...)
+	started := make(map[*Agent]bool)
+	promoted := make(map[*Agent]bool)
+	for _, agent := range agents {
+		started[agent] = agent.started
+		promoted[agent] = agent.promoted
+	}
+	s.mu.Unlock()
+
+	statuses := make([]AgentStatus, 0, len(agents))
+	for _, agent := range agents {
+		run := agent.Runner.Status()
+		run.Logs = nil
+
+		status := AgentStatus{
+			Project:      agent.Project,
+			IssueNumber:  agent.IssueNumber,
+			Queued:       !started[agent] && run.FinishedAt == nil,
+			Promoted:     promoted[agent],
+			Run:          run,
+			QueuedAt:     agent.QueuedAt,
+			Requests:     agent.Usage.Requests.Load(),
+			InputTokens:  agent.Usage.InputTokens.Load(),
+			OutputTokens: agent.Usage.OutputTokens.Load(),
+		}
+		switch {
+		case status.Queued:
+			status.Elapsed = time.Since(agent.QueuedAt)
+		case run.FinishedAt != nil && !run.StartedAt.IsZero():
+			status.Elapsed = run.FinishedAt.Sub(run.StartedAt)
+		case !run.StartedAt.IsZero():
+			status.Elapsed = time.Since(run.StartedAt)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// ClearFinished forgets finished agents
+func (s *AgentScheduler) ClearFinished() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var active []*Agent
+	for _, agent := range s.agents {
+		if !agent.Runner.IsFinished() {
+			active = append(active, agent)
+		}
+	}
+	s.agents = active
+}
+
+// CancelProject stops a project's agents and waits for them, for when its
+// session closes
+func (s *AgentScheduler) CancelProject(project string) {
+	s.mu.Lock()
+	var agents []*Agent
+	for _, agent := range s.agents {
+		if agent.Project == project {
+			agents = append(agents, agent)
+		}
+	}
+	s.mu.Unlock()
+
+	s.stop(agents)
+}
+
+// Close stops every agent
+func (s *AgentScheduler) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	agents := append([]*Agent(nil), s.agents...)
+	s.mu.Unlock()
+
+	s.stop(agents)
+	return nil
+}
+
+func (s *AgentScheduler) stop(agents []*Agent) {
+	for _, agent := range agents {
+		if agent.Runner.IsFinished() {
+			continue
+		}
+
+		s.mu.Lock()
+		started := agent.started
+		if !started {
+			// Mark it started so dispatch never picks it up
+			agent.started = true
+		}
+		s.mu.Unlock()
+
+		if started {
+			agent.Runner.Cancel()
+			agent.Runner.Wait()
+		} else {
+			agent.Runner.finish(context.Canceled)
+		}
+	}
+}
+
+func (s *AgentScheduler) findLocked(project string, issueNumber int) *Agent {
+	for i := len(s.agents) - 1; i >= 0; i-- {
+		if s.agents[i].Project == project && s.agents[i].IssueNumber == issueNumber {
+			return s.agents[i]
+		}
+	}
+	return nil
+}
+
+// dispatch starts promoted agents, then queued agents in order while the caps allow
+func (s *AgentScheduler) dispatch() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+
+	running := 0
+	perProject := make(map[string]int)
+	for _, agent := range s.agents {
+		if agent.started && !agent.Runner.IsFinished() {
+			running++
+			perProject[agent.Project]++
+		}
+	}
+
+	var start []*Agent
+	for _, promoted := range []bool{true, false} {
+		for _, agent := range s.agents {
+			if agent.started || agent.promoted != promoted || agent.Runner.IsFinished() {
+				continue
+			}
+
+			limit := s.defaultLimit
+			if l, ok := s.limits[agent.Project]; ok {
+				limit = l
+			}
+			if !agent.promoted && (running >= s.globalLimit || perProject[agent.Project] >= limit) {
+				continue
+			}
+
+			agent.started = true
+			running++
+			perProject[agent.Project]++
+			start = append(start, agent)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, agent := range start {
+		agent.Runner.Start(context.Background())
+		go func(agent *Agent) {
+			agent.Runner.Wait()
+			s.dispatch()
+		}(agent)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newBlockingAgentRunner builds a test run whose planner waits for release
+func newBlockingAgentRunner(t *testing.T, project string, issueNumber int, release <-chan struct{}) *IssueRunner {
+	t.Helper()
+	runner, store, _ := newTestIssueRunner(t)
+	store.issues[issueNumber] = &Issue{Number: issueNumber, Title: "Agent work", Body: "", State: "open"}
+	runner.projectName = project
+	runner.issueNumber = issueNumber
+	runner.status.IssueNumber = issueNumber
+	runner.status.ApprovalsEnabled = false
+	runner.planner = &funcProvider{send: func(ctx context.Context, message string) (string, error) {
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		return "1. Add feature.txt", nil
+	}}
+	return runner
+}
+
+func waitForAgents(t *testing.T, scheduler *AgentScheduler, what string, ready func(map[string]AgentStatus) bool) map[string]AgentStatus {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		statuses := make(map[string]AgentStatus)
+		for _, status := range scheduler.Statuses() {
+			statuses[fmt.Sprintf("%s#%d", status.Project, status.IssueNumber)] = status
+		}
+		if ready(statuses) {
+			return statuses
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s: %+v", what, statuses)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestAgentScheduler tests the global and per-project caps, promotion,
+// cancelling a queued agent and isolating a panicking agent
+func TestAgentScheduler(t *testing.T) {
+	scheduler := NewAgentScheduler(2, NewLLMRateLimiter(0, 0))
+	defer scheduler.Close()
+	scheduler.SetConcurrencyLimit("alpha", 1)
+	scheduler.SetConcurrencyLimit("beta", 2)
+
+	release := make(chan struct{})
+	panicking := newBlockingAgentRunner(t, "beta", 7, release)
+	panicking.planner = &funcProvider{send: func(ctx context.Context, message string) (string, error) {
+		<-release
+		panic("provider exploded")
+	}}
+
+	scheduler.Submit(newBlockingAgentRunner(t, "alpha", 7, release))
+	scheduler.Submit(newBlockingAgentRunner(t, "alpha", 8, release))
+	scheduler.Submit(panicking)
+	scheduler.Submit(newBlockingAgentRunner(t, "beta", 8, release))
+
+	statuses := waitForAgents(t, scheduler, "two agents to start", func(s map[string]AgentStatus) bool {
+		return s["alpha#7"].Run.State == RunRunning && s["beta#7"].Run.State == RunRunning
+	})
+	if !statuses["alpha#8"].Queued || !statuses["beta#8"].Queued {
+		t.Fatalf("Expected alpha#8 held by the project cap and beta#8 by the global cap, got %+v", statuses)
+	}
+
+	if err := scheduler.Promote("alpha", 8); err != nil {
+		t.Fatalf("Promote failed: %v", err)
+	}
+	if err := scheduler.Cancel("beta", 8); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	if err := scheduler.Promote("alpha", 7); err == nil {
+		t.Error("Expected promoting a running agent to fail")
+	}
+
+	statuses = waitForAgents(t, scheduler, "promoted agent to start", func(s map[string]AgentStatus) bool {
+		return !s["alpha#8"].Queued && s["beta#8"].Run.State == RunCancelled
+	})
+	if statuses["beta#8"].Requests != 0 {
+		t.Errorf("Expected the cancelled agent never to run, got %+v", statuses["beta#8"])
+	}
+
+	close(release)
+	statuses = waitForAgents(t, scheduler, "agents to finish", func(s map[string]AgentStatus) bool {
+		for _, status := range s {
+			if status.Run.FinishedAt == nil {
+				return false
+			}
+		}
+		return true
+	})
+
+	if status := statuses["beta#7"]; status.Run.State != RunFailed || !strings.Contains(status.Run.Error, "provider exploded") {
+		t.Errorf("Expected the panicking agent to fail alone, got %s: %s", status.Run.State, status.Run.Error)
+	}
+	for _, key := range []string{"alpha#7", "alpha#8"} {
+		status := statuses[key]
+		if status.Run.State != RunSucceeded {
+			t.Errorf("Expected %s to succeed, got %s: %s", key, status.Run.State, status.Run.Error)
+		}
+		if status.Requests < 2 || status.InputTokens == 0 || status.OutputTokens == 0 {
+			t.Errorf("Expected %s usage to be counted, got %+v", key, status)
+		}
+	}
+
+	scheduler.ClearFinished()
+	if len(scheduler.Statuses()) != 0 {
+		t.Error("Expected finished agents to be cleared")
+	}
+}
+
+// TestAgentCancelBeforeStart tests a cancel that lands after dispatch marks
+// an agent started but before its runner starts
+func TestAgentCancelBeforeStart(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	runner := newBlockingAgentRunner(t, "alpha", 7, release)
+
+	runner.Cancel()
+	runner.Start(context.Background())
+	done := make(chan struct{})
+	go func() {
+		runner.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("The cancelled run kept going")
+	}
+	if status := runner.Status(); status.State != RunCancelled {
+		t.Errorf("Expected the run to stop as it started, got %s: %s", status.State, status.Error)
+	}
+}
+
+// TestLLMRateLimiter tests request spacing and the in-flight cap
+func TestLLMRateLimiter(t *testing.T) {
+	limiter := NewLLMRateLimiter(1200, 1) // One request every 50ms
+	ctx := context.Background()
+
+	start := time.Now()
+	release, err := limiter.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+
+	// The only in-flight slot is taken
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.Acquire(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the in-flight cap to block, got %v", err)
+	}
+
+	release()
+	for i := 0; i < 2; i++ {
+		release, err := limiter.Acquire(ctx)
+		if err != nil {
+			t.Fatalf("Acquire failed: %v", err)
+		}
+		release()
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected three requests to be spaced over 100ms, took %v", elapsed)
+	}
+}
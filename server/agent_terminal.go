@@ -0,0 +1,261 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Agent sessions run interactively in a pseudo-terminal owned by Relay and
+// are shown in the TUI's terminal pane, one tab per issue. They keep running
+// while detached. With RELAY_AGENT_TMUX=1 and tmux installed they run inside
+// a tmux session instead, which also survives Relay exiting.
+
+// AgentTerminal is an interactive agent session running in a pseudo-terminal
+type AgentTerminal struct {
+	ID          int
+	Project     string
+	IssueNumber int
+	Title       string
+	Dir         string
+	TmuxSession string // Set when the session runs inside tmux
+	Started     time.Time
+
+	cmd    *exec.Cmd
+	pty    *os.File
+	screen *vtScreen
+	done   chan struct{}
+
+	mu      sync.Mutex
+	exitErr error
+}
+
+// AgentTerminalSpec describes a session to start
+type AgentTerminalSpec struct {
+	Project     string
+	IssueNumber int
+	Title       string
+	Dir         string
+	Command     string // Shell command to run
+	Rows, Cols  int
+}
+
+// Write sends keyboard input to the session
+func (t *AgentTerminal) Write(p []byte) (int, error) {
+	if !t.Running() {
+		return 0, errors.New("agent session has exited")
+	}
+	return t.pty.Write(p)
+}
+
+// Resize resizes the screen and tells the program about the new size
+func (t *AgentTerminal) Resize(rows, cols int) {
+	t.screen.Resize(rows, cols)
+	if t.Running() {
+		resizePTY(t.pty, rows, cols)
+	}
+}
+
+// Screen returns the emulated screen of the session
+func (t *AgentTerminal) Screen() *vtScreen {
+	return t.screen
+}
+
+// Running reports whether the session's process is still alive
+func (t *AgentTerminal) Running() bool {
+	select {
+	case <-t.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Done is closed when the session's process exits
+func (t *AgentTerminal) Done() <-chan struct{} {
+	return t.done
+}
+
+// Status describes the session for tab and detail views
+func (t *AgentTerminal) Status() string {
+	if t.Running() {
+		return "live"
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.exitErr != nil {
+		return "exited: " + t.exitErr.Error()
+	}
+	return "exited"
+}
+
+// Label is the tab title
+func (t *AgentTerminal) Label() string {
+	if t.IssueNumber > 0 {
+		return fmt.Sprintf("#%d", t.IssueNumber)
+	}
+	return t.Title
+}
+
+// Stop kills the session's process. A tmux session is only detached from.
+func (t *AgentTerminal) Stop() {
+	if t.Running() && t.cmd.Process != nil {
+		t.cmd.Process.Signal(syscall.SIGHUP)
+		select {
+		case <-t.done:
+		case <-time.After(2 * time.Second):
+			t.cmd.Process.Kill()
+		}
+	}
+}
+
+func (t *AgentTerminal) wait() {
+	// Output ends when the process and its children close the terminal
+	io.Copy(t.screen, t.pty)
+	err := t.cmd.Wait()
+	t.pty.Close()
+
+	t.mu.Lock()
+	t.exitErr = err
+	t.mu.Unlock()
+	close(t.done)
+}
+
+// AgentTerminalManager keeps the agent sessions of this process
+type AgentTerminalManager struct {
+	mu       sync.Mutex
+	sessions []*AgentTerminal
+	nextID   int
+	useTmux  bool
+}
+
+// NewAgentTerminalManagerFromEnv uses tmux when RELAY_AGENT_TMUX is set and
+// tmux is installed
+func NewAgentTerminalManagerFromEnv() *AgentTerminalManager {
+	useTmux, _ := strconv.ParseBool(os.Getenv("RELAY_AGENT_TMUX"))
+	if useTmux {
+		if _, err := exec.LookPath("tmux"); err != nil {
+			useTmux = false
+		}
+	}
+	return &AgentTerminalManager{useTmux: useTmux}
+}
+
+// tmuxSessionName is the stable tmux session name of an issue
+func tmuxSessionName(project string, issueNumber int) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '.' || r == ':' || r == ' ' {
+			return '-'
+		}
+		return r
+	}, project)
+	return fmt.Sprintf("relay-%s-%d", name, issueNumber)
+}
+
+// Start starts a session, or returns the live session of the same issue
+func (m *AgentTerminalManager) Start(spec AgentTerminalSpec) (*AgentTerminal, error) {
+	if existing := m.ForIssue(spec.Project, spec.IssueNumber); existing != nil && existing.Running() {
+		return existing, nil
+	}
+
+	rows, cols := max(spec.Rows, 5), max(spec.Cols, 20)
+	term := &AgentTerminal{
+		Project:     spec.Project,
+		IssueNumber: spec.IssueNumber,
+		Title:       spec.Title,
+		Dir:         spec.Dir,
+		Started:     time.Now(),
+		screen:      newVTScreen(rows, cols, defaultScrollback),
+		done:        make(chan struct{}),
+	}
+
+	var cmd *exec.Cmd
+	if m.useTmux && spec.IssueNumber > 0 {
+		// -A attaches to the issue's tmux session if it is still running
+		term.TmuxSession = tmuxSessionName(spec.Project, spec.IssueNumber)
+		cmd = exec.Command("tmux", "new-session", "-A", "-s", term.TmuxSession, "-c", spec.Dir, spec.Command)
+	} else {
+		cmd = exec.Command("sh", "-c", spec.Command)
+	}
+	cmd.Dir = spec.Dir
+	cmd.Env = append(os.Environ(), "TERM=xterm-256color", fmt.Sprintf("LINES=%d", rows), fmt.Sprintf("COLUMNS=%d", cols))
+
+	pty, err := startPTY(cmd, rows, cols)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start agent session: %w", err)
+	}
+	term.cmd, term.pty = cmd, pty
+
+	m.mu.Lock()
+	m.nextID++
+	term.ID = m.nextID
+	m.sessions = append(m.sessions, term)
+	m.mu.Unlock()
+
+	go term.wait()
+	return term, nil
+}
+
+// Sessions returns the sessions in the order they were started
+func (m *AgentTerminalManager) Sessions() []*AgentTerminal {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*AgentTerminal(nil), m.sessions...)
+}
+
+// Get returns the session with the given id, or nil
+func (m *AgentTerminalManager) Get(id int) *AgentTerminal {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, term := range m.sessions {
+		if term.ID == id {
+			return term
+		}
+	}
+	return nil
+}
+
+// ForIssue returns the latest session of an issue, or nil
+func (m *AgentTerminalManager) ForIssue(project string, issueNumber int) *AgentTerminal {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.sessions) - 1; i >= 0; i-- {
+		if term := m.sessions[i]; term.Project == project && term.IssueNumber == issueNumber {
+			return term
+		}
+	}
+	return nil
+}
+
+// Remove stops a session and closes its tab
+func (m *AgentTerminalManager) Remove(id int) {
+	term := m.Get(id)
+	if term == nil {
+		return
+	}
+	term.Stop()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, session := range m.sessions {
+		if session.ID == id {
+			m.sessions = append(m.sessions[:i], m.sessions[i+1:]...)
+			break
+		}
+	}
+}
+
+// Close stops every session; sessions inside tmux keep running there
+func (m *AgentTerminalManager) Close() error {
+	for _, term := range m.Sessions() {
+		term.Stop()
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAgentTerminal tests running a program in a pseudo-terminal, typing into
+// it and reusing the live session of an issue
+func TestAgentTerminal(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("embedded terminals need a Unix pseudo-terminal")
+	}
+
+	manager := &AgentTerminalManager{}
+	defer manager.Close()
+
+	spec := AgentTerminalSpec{
+		Project:     "alpha",
+		IssueNumber: 7,
+		Dir:         t.TempDir(),
+		Command:     `stty size; read answer; echo "got $answer"`,
+		Rows:        12,
+		Cols:        40,
+	}
+	term, err := manager.Start(spec)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	waitForScreen := func(text string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !strings.Contains(term.Screen().Text(), text) {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected %q on the screen, got:\n%s", text, term.Screen().Text())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	waitForScreen("12 40")
+	if again, err := manager.Start(spec); err != nil || again != term {
+		t.Errorf("Expected the live session to be reused, got %v (%v)", again, err)
+	}
+
+	term.Write([]byte("hi\r"))
+	waitForScreen("got hi")
+
+	select {
+	case <-term.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the session to exit")
+	}
+	if term.Status() != "exited" || manager.ForIssue("alpha", 7) != term {
+		t.Errorf("Expected the exited session to stay listed, got %q", term.Status())
+	}
+
+	manager.Remove(term.ID)
+	if len(manager.Sessions()) != 0 {
+		t.Error("Expected the session to be removed")
+	}
+}
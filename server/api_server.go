@@ -0,0 +1,817 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// apiVersionPrefix is the prefix for every versioned API route
+const apiVersionPrefix = "/api/v1"
+
+// APIServer exposes projects, issues, git status, the LLM REPL and the job
+// queue over HTTP, with live events over SSE and WebSocket. It drives the same
+// managers as the TUI, so both front ends behave identically.
+type APIServer struct {
+	pm       *ProjectManager
+	queue    *JobQueue
+	agents   *AgentScheduler
+	events   *EventBus
+	token    string
+	origins  []string // Browser origins allowed to open WebSockets besides the server's own
+	logger   *slog.Logger
+	mu       sync.Mutex
+	sessions map[string]*REPLSession
+}
+
+// APIError is the JSON body returned for failed requests
+type APIError struct {
+	Error string `json:"error"`
+}
+
+// ReplRequest is the body of POST /projects/{project}/repl
+type ReplRequest struct {
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// ReplResponse is the reply to a REPL message
+type ReplResponse struct {
+	RequestID string `json:"request_id"`
+	Provider  string `json:"provider"`
+	Response  string `json:"response"`
+}
+
+// IssueUpdateRequest is the body of PATCH /projects/{project}/issues/{number}.
+// Omitted fields are left unchanged.
+type IssueUpdateRequest struct {
+	Title  *string   `json:"title,omitempty"`
+	Body   *string   `json:"body,omitempty"`
+	State  *string   `json:"state,omitempty"`
+	Labels *[]string `json:"labels,omitempty"`
+}
+
+// NewAPIServer creates an API server over a shared project manager and job queue
+func NewAPIServer(pm *ProjectManager, queue *JobQueue, events *EventBus, token string) *APIServer {
+	s := &APIServer{
+		pm:       pm,
+		queue:    queue,
+		agents:   NewAgentSchedulerFromEnv(),
+		events:   events,
+		token:    token,
+		logger:   componentLogger("API"),
+		sessions: make(map[string]*REPLSession),
+	}
+
+	// Job progress is streamed like any other event
+	queue.Subscribe(func(job Job) {
+		events.Publish(EventJobUpdated, job.Project, job)
+	})
+
+	return s
+}
+
+// SetAllowedOrigins lets pages served from other origins, such as a web UI
+// on another port, open WebSockets
+func (s *APIServer) SetAllowedOrigins(origins []string) {
+	s.origins = origins
+}
+
+// generateAPIToken returns a random token for servers started without one
+func generateAPIToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Handler returns the HTTP handler with all routes registered
+func (s *APIServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET "+apiVersionPrefix+"/health", s.handleHealth)
+
+	mux.Handle("GET "+apiVersionPrefix+"/projects", s.authorized(s.handleListProjects))
+	mux.Handle("GET "+apiVersionPrefix+"/projects/{project}", s.authorized(s.handleGetProject))
+
+	mux.Handle("GET "+apiVersionPrefix+"/projects/{project}/issues", s.authorized(s.handleListIssues))
+	mux.Handle("POST "+apiVersionPrefix+"/projects/{project}/issues", s.authorized(s.handleCreateIssue))
+	mux.Handle("GET "+apiVersionPrefix+"/projects/{project}/issues/{number}", s.authorized(s.handleGetIssue))
+	mux.Handle("PATCH "+apiVersionPrefix+"/projects/{project}/issues/{number}", s.authorized(s.handleUpdateIssue))
+	mux.Handle("POST "+apiVersionPrefix+"/projects/{project}/issues/{number}/close", s.authorized(s.handleCloseIssue))
+
+	mux.Handle("GET "+apiVersionPrefix+"/projects/{project}/git/status", s.authorized(s.handleGitStatus))
+	mux.Handle("POST "+apiVersionPrefix+"/projects/{project}/repl", s.authorized(s.handleRepl))
+
+	mux.Handle("GET "+apiVersionPrefix+"/projects/{project}/jobs", s.authorized(s.handleListJobs))
+	mux.Handle("POST "+apiVersionPrefix+"/projects/{project}/jobs", s.authorized(s.handleEnqueueJob))
+	mux.Handle("GET "+apiVersionPrefix+"/jobs/{id}", s.authorized(s.handleGetJob))
+	mux.Handle("DELETE "+apiVersionPrefix+"/jobs/{id}", s.authorized(s.handleCancelJob))
+	mux.Handle("GET "+apiVersionPrefix+"/jobs/{id}/summary", s.authorized(s.handleJobSummary))
+	mux.Handle("POST "+apiVersionPrefix+"/projects/{project}/summarize", s.authorized(s.handleSummarize))
+
+	mux.Handle("GET "+apiVersionPrefix+"/events", s.authorized(s.handleEventStream))
+	mux.Handle("GET "+apiVersionPrefix+"/ws", s.authorized(s.handleWebSocket))
+
+	return mux
+}
+
+// authorized wraps a handler with bearer token authentication. Browsers cannot
+// set headers on EventSource or WebSocket, so ?token= is accepted as well.
+func (s *APIServer) authorized(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if provided == "" || provided == r.Header.Get("Authorization") {
+			provided = r.URL.Query().Get("token")
+		}
+
+		if s.token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="relay"`)
+			writeAPIError(w, http.StatusUnauthorized, errors.New("missing or invalid API token"))
+			return
+		}
+
+		next(w, r)
+	})
+}
+
+// session returns the project's session, creating it on first use
+func (s *APIServer) session(projectName string) (*REPLSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session, ok := s.sessions[projectName]; ok {
+		return session, nil
+	}
+
+	project, err := s.pm.GetProject(projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := newProjectSession(s.pm, project, s.queue, s.agents, s.events)
+	if err != nil {
+		return nil, err
+	}
+
+	s.sessions[projectName] = session
+	return session, nil
+}
+
+// Close releases every project session and stops their agents. The shared project
+// manager and queue are owned by the caller.
+func (s *APIServer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for name, session := range s.sessions {
+		if err := session.closeProjectServices(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+		delete(s.sessions, name)
+	}
+	s.agents.Close()
+
+	if len(errs) > 0 {
+		return fmt.Errorf("session close errors: %v", errs)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, APIError{Error: err.Error()})
+}
+
+// decodeJSON reads a bounded JSON request body into v
+func decodeJSON(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(io.LimitReader(r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+// projectSession resolves the {project} path value, writing an error response on failure
+func (s *APIServer) projectSession(w http.ResponseWriter, r *http.Request) (*REPLSession, bool) {
+	name := r.PathValue("project")
+	if _, err := s.pm.GetProject(name); err != nil {
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("project '%s' not found", name))
+		return nil, false
+	}
+
+	session, err := s.session(name)
+	if err != nil {
+		writeAPIError(w, http.StatusServiceUnavailable, fmt.Errorf("failed to open project '%s': %w", name, err))
+		return nil, false
+	}
+	return session, true
+}
+
+// issueNumber parses the {number} path value, writing an error response on failure
+func issueNumber(w http.ResponseWriter, r *http.Request) (int, bool) {
+	number, err := parseIssueID(r.PathValue("number"))
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return 0, false
+	}
+	return number, true
+}
+
+func (s *APIServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok", "version": "v1"})
+}
+
+func (s *APIServer) handleListProjects(w http.ResponseWriter, r *http.Request) {
+	projects, err := s.pm.ListProjects()
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if projects == nil {
+		projects = []*Project{}
+	}
+	writeJSON(w, http.StatusOK, projects)
+}
+
+func (s *APIServer) handleGetProject(w http.ResponseWriter, r *http.Request) {
+	project, err := s.pm.GetProject(r.PathValue("project"))
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, project)
+}
+
+func (s *APIServer) handleListIssues(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.projectSession(w, r)
+	if !ok {
+		return
+	}
+
+	issues := session.issueManager.ListIssues(r.URL.Query().Get("state"), r.URL.Query().Get("label"))
+	if issues == nil {
+		issues = []Issue{}
+	}
+	writeJSON(w, http.StatusOK, issues)
+}
+
+func (s *APIServer) handleCreateIssue(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.projectSession(w, r)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Title string `json:"title"`
+	}
+	if err := decodeJSON(r, &request); err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	issue, err := session.issueManager.AddIssue(request.Title)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, issue)
+}
+
+func (s *APIServer) handleGetIssue(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.projectSession(w, r)
+	if !ok {
+		return
+	}
+	number, ok := issueNumber(w, r)
+	if !ok {
+		return
+	}
+
+	issue, err := session.issueManager.GetIssue(number)
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, issue)
+}
+
+func (s *APIServer) handleUpdateIssue(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.projectSession(w, r)
+	if !ok {
+		return
+	}
+	number, ok := issueNumber(w, r)
+	if !ok {
+		return
+	}
+
+	var request IssueUpdateRequest
+	if err := decodeJSON(r, &request); err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	im := session.issueManager
+	if request.Title != nil {
+		if err := im.UpdateIssueTitle(number, *request.Title); err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if request.Body != nil {
+		if err := im.UpdateIssueBody(number, *request.Body); err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if request.State != nil {
+		if err := im.UpdateIssueStatus(number, *request.State); err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if request.Labels != nil {
+		if err := im.UpdateIssueLabels(number, *request.Labels); err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	issue, err := im.GetIssue(number)
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, issue)
+}
+
+func (s *APIServer) handleCloseIssue(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.projectSession(w, r)
+	if !ok {
+		return
+	}
+	number, ok := issueNumber(w, r)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Reason string `json:"reason"`
+	}
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &request); err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if request.Reason == "" {
+		request.Reason = "completed"
+	}
+
+	if err := session.issueManager.CloseIssue(number, request.Reason); err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	issue, err := session.issueManager.GetIssue(number)
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, issue)
+}
+
+func (s *APIServer) handleGitStatus(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.projectSession(w, r)
+	if !ok {
+		return
+	}
+
+	status, err := session.gitOps.WorkingTreeStatus(r.Context())
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// handleRepl sends a message to the executing provider. Tokens are published as
+// llm.token events; clients that accept text/event-stream also get them inline.
+func (s *APIServer) handleRepl(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.projectSession(w, r)
+	if !ok {
+		return
+	}
+
+	var request ReplRequest
+	if err := decodeJSON(r, &request); err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	if strings.TrimSpace(request.Message) == "" {
+		writeAPIError(w, http.StatusBadRequest, errors.New("message is required"))
+		return
+	}
+	if request.RequestID == "" {
+		id, err := generateAPIToken()
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+		request.RequestID = id[:12]
+	}
+
+	project := session.currentProject.Name
+	provider := session.llmManager.GetExecutingProvider()
+	session.checkpoint(r.Context(), "prompt: "+request.Message)
+
+	stream := strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+	var sse *sseWriter
+	if stream {
+		var err error
+		if sse, err = newSSEWriter(w); err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	response, err := StreamOrSend(session.auditContext(r.Context()), provider, request.Message, func(token string) {
+		event := map[string]string{"request_id": request.RequestID, "token": token}
+		s.events.Publish(EventLLMToken, project, event)
+		if sse != nil {
+			sse.Send(Event{Type: EventLLMToken, Project: project, Time: time.Now(), Data: event})
+		}
+	})
+
+	result := ReplResponse{RequestID: request.RequestID, Provider: provider.GetProviderName(), Response: response}
+	done := map[string]interface{}{"request_id": request.RequestID, "response": response}
+	if err != nil {
+		done["error"] = err.Error()
+	}
+	s.events.Publish(EventLLMDone, project, done)
+
+	if sse != nil {
+		sse.Send(Event{Type: EventLLMDone, Project: project, Time: time.Now(), Data: done})
+		return
+	}
+
+	if err != nil {
+		writeAPIError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *APIServer) handleListJobs(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("project")
+	if _, err := s.pm.GetProject(name); err != nil {
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("project '%s' not found", name))
+		return
+	}
+
+	limit := 50
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid limit '%s'", value))
+			return
+		}
+		limit = parsed
+	}
+
+	jobs, err := s.queue.List(name, limit)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if jobs == nil {
+		jobs = []*Job{}
+	}
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+func (s *APIServer) handleEnqueueJob(w http.ResponseWriter, r *http.Request) {
+	// Opening the session registers it as the project's executor
+	session, ok := s.projectSession(w, r)
+	if !ok {
+		return
+	}
+
+	var request Job
+	if err := decodeJSON(r, &request); err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	job := &Job{
+		Project:     session.currentProject.Name,
+		Kind:        request.Kind,
+		Action:      request.Action,
+		Input:       request.Input,
+		IssueNumber: request.IssueNumber,
+		DependsOn:   request.DependsOn,
+		Condition:   request.Condition,
+	}
+
+	queued, err := s.queue.Enqueue(job)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, queued)
+}
+
+// jobID parses the {id} path value, writing an error response on failure
+func jobID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.PathValue("id"), "#"), 10, 64)
+	if err != nil || id <= 0 {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid job ID '%s'", r.PathValue("id")))
+		return 0, false
+	}
+	return id, true
+}
+
+func (s *APIServer) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := jobID(w, r)
+	if !ok {
+		return
+	}
+
+	job, err := s.queue.Get(id)
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+func (s *APIServer) handleCancelJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := jobID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.queue.Cancel(id); err != nil {
+		writeAPIError(w, http.StatusConflict, err)
+		return
+	}
+
+	job, err := s.queue.Get(id)
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// handleJobSummary returns a spoken summary of a job, with ?verbosity=brief|normal|detailed
+func (s *APIServer) handleJobSummary(w http.ResponseWriter, r *http.Request) {
+	id, ok := jobID(w, r)
+	if !ok {
+		return
+	}
+
+	verbosity, err := ParseVerbosity(r.URL.Query().Get("verbosity"))
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	job, err := s.queue.Get(id)
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, err)
+		return
+	}
+
+	session, err := s.session(job.Project)
+	if err != nil {
+		writeAPIError(w, http.StatusServiceUnavailable, fmt.Errorf("failed to open project '%s': %w", job.Project, err))
+		return
+	}
+
+	summary, err := session.SummarizeJob(r.Context(), job, verbosity)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// handleSummarize summarizes arbitrary text, such as a REPL reply, for speech
+func (s *APIServer) handleSummarize(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.projectSession(w, r)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Text      string `json:"text"`
+		Headline  string `json:"headline"`
+		Verbosity string `json:"verbosity"`
+	}
+	if err := decodeJSON(r, &request); err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	verbosity, err := ParseVerbosity(request.Verbosity)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	if strings.TrimSpace(request.Text) == "" && strings.TrimSpace(request.Headline) == "" {
+		writeAPIError(w, http.StatusBadRequest, errors.New("text is required"))
+		return
+	}
+
+	summary := session.summarizer.Summarize(r.Context(), SummaryInput{Headline: request.Headline, Text: request.Text}, verbosity)
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// sseWriter writes server-sent events to a flushable response
+type sseWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func newSSEWriter(w http.ResponseWriter) (*sseWriter, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, errors.New("streaming is not supported by this connection")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return &sseWriter{w: w, flusher: flusher}, nil
+}
+
+// Send writes one event, using the event type as the SSE event name
+func (s *sseWriter) Send(event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// KeepAlive writes an SSE comment so proxies don't time out an idle stream
+func (s *sseWriter) KeepAlive() error {
+	if _, err := io.WriteString(s.w, ": keep-alive\n\n"); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// eventMatches reports whether an event should be delivered for a project filter
+func eventMatches(event Event, project string) bool {
+	return project == "" || event.Project == "" || event.Project == project
+}
+
+// handleEventStream streams events over SSE, optionally filtered with ?project=
+func (s *APIServer) handleEventStream(w http.ResponseWriter, r *http.Request) {
+	project := r.URL.Query().Get("project")
+
+	events, unsubscribe := s.events.Subscribe(256)
+	defer unsubscribe()
+
+	sse, err := newSSEWriter(w)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	keepAlive := time.NewTicker(15 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if err := sse.KeepAlive(); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if !eventMatches(event, project) {
+				continue
+			}
+			if err := sse.Send(event); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handleWebSocket streams events over a WebSocket. Clients may change the
+// project filter by sending {"type":"subscribe","project":"name"}.
+func (s *APIServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	if err := checkWebSocketOrigin(r, s.origins); err != nil {
+		writeAPIError(w, http.StatusForbidden, err)
+		return
+	}
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer conn.Close()
+
+	events, unsubscribe := s.events.Subscribe(256)
+	defer unsubscribe()
+
+	var filterMu sync.Mutex
+	project := r.URL.Query().Get("project")
+
+	// Reader: handles control frames and subscription changes
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			var command struct {
+				Type    string `json:"type"`
+				Project string `json:"project"`
+			}
+			if err := json.Unmarshal(message, &command); err != nil {
+				conn.WriteJSON(APIError{Error: "invalid message: " + err.Error()})
+				continue
+			}
+
+			switch command.Type {
+			case "subscribe":
+				filterMu.Lock()
+				project = command.Project
+				filterMu.Unlock()
+				conn.WriteJSON(map[string]string{"type": "subscribed", "project": command.Project})
+			case "ping":
+				conn.WriteJSON(map[string]string{"type": "pong"})
+			default:
+				conn.WriteJSON(APIError{Error: fmt.Sprintf("unknown message type '%s'", command.Type)})
+			}
+		}
+	}()
+
+	keepAlive := time.NewTicker(30 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if err := conn.Ping(); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			filterMu.Lock()
+			matches := eventMatches(event, project)
+			filterMu.Unlock()
+			if !matches {
+				continue
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		}
+	}
+}
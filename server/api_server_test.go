@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestAPIServer(t *testing.T) (*APIServer, *httptest.Server) {
+	t.Helper()
+	queue, db := newTestQueue(t)
+
+	if err := db.AddProject("demo", t.TempDir()); err != nil {
+		t.Fatalf("Failed to add project: %v", err)
+	}
+	project, err := db.GetProject("demo")
+	if err != nil {
+		t.Fatalf("Failed to get project: %v", err)
+	}
+
+	pm := &ProjectManager{db: db}
+	server := NewAPIServer(pm, queue, NewEventBus(), "secret")
+	// Stand in for a real session so no GitHub or LLM access is needed
+	server.sessions["demo"] = &REPLSession{currentProject: project, projectManager: pm}
+	queue.SetExecutor("demo", &fakeExecutor{})
+
+	httpServer := httptest.NewServer(server.Handler())
+	t.Cleanup(httpServer.Close)
+	return server, httpServer
+}
+
+func apiRequest(t *testing.T, method, url, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// TestAPIServerAuth tests that every route except health requires the token
+func TestAPIServerAuth(t *testing.T) {
+	_, httpServer := newTestAPIServer(t)
+
+	resp, err := http.Get(httpServer.URL + "/api/v1/health")
+	if err != nil {
+		t.Fatalf("Health request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected health to be public, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(httpServer.URL + "/api/v1/projects")
+	if err != nil {
+		t.Fatalf("Projects request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without token, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(httpServer.URL + "/api/v1/projects?token=secret")
+	if err != nil {
+		t.Fatalf("Projects request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var projects []Project
+	if err := json.NewDecoder(resp.Body).Decode(&projects); err != nil || len(projects) != 1 || projects[0].Name != "demo" {
+		t.Errorf("Expected demo project via query token, got %v (%v)", projects, err)
+	}
+}
+
+// TestAPIServerJobs tests enqueueing, fetching and listing jobs over HTTP
+func TestAPIServerJobs(t *testing.T) {
+	_, httpServer := newTestAPIServer(t)
+
+	resp := apiRequest(t, "POST", httpServer.URL+"/api/v1/projects/demo/jobs", `{"kind":"check","input":"go vet"}`)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d", resp.StatusCode)
+	}
+	var job Job
+	if err := json.NewDecoder(resp.Body).Decode(&job); err != nil {
+		t.Fatalf("Failed to decode job: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Status != JobSucceeded && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+		resp := apiRequest(t, "GET", httpServer.URL+"/api/v1/jobs/"+jsonNumber(job.ID), "")
+		json.NewDecoder(resp.Body).Decode(&job)
+	}
+	if job.Status != JobSucceeded || job.Output != "ok: go vet" {
+		t.Errorf("Expected succeeded job with output, got %s %q", job.Status, job.Output)
+	}
+
+	resp = apiRequest(t, "GET", httpServer.URL+"/api/v1/jobs/"+jsonNumber(job.ID)+"/summary?verbosity=brief", "")
+	var summary Summary
+	if err := json.NewDecoder(resp.Body).Decode(&summary); err != nil {
+		t.Fatalf("Failed to decode summary: %v", err)
+	}
+	if summary.Spoken != "The check finished successfully." || summary.Source != SummarySourceFallback {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+
+	resp = apiRequest(t, "POST", httpServer.URL+"/api/v1/projects/demo/jobs", `{"kind":"deploy"}`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid job, got %d", resp.StatusCode)
+	}
+
+	resp = apiRequest(t, "GET", httpServer.URL+"/api/v1/projects/missing/jobs", "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown project, got %d", resp.StatusCode)
+	}
+}
+
+func jsonNumber(id int64) string {
+	data, _ := json.Marshal(id)
+	return string(data)
+}
+
+// TestAPIServerEventStream tests that job events are delivered over SSE
+func TestAPIServerEventStream(t *testing.T) {
+	server, httpServer := newTestAPIServer(t)
+
+	req, _ := http.NewRequest("GET", httpServer.URL+"/api/v1/events?project=demo", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Event stream request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	server.events.Publish(EventIssueClosed, "other", map[string]int{"number": 1})
+	server.events.Publish(EventIssueClosed, "demo", map[string]int{"number": 2})
+
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Stream ended before event: %v", err)
+		}
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+			t.Fatalf("Invalid event payload: %v", err)
+		}
+		if event.Project != "demo" || event.Type != EventIssueClosed {
+			t.Errorf("Expected filtered demo event, got %+v", event)
+		}
+		return
+	}
+}
+
+// dialTestWebSocket opens a WebSocket handshake by hand, with an Origin
+// header unless origin is empty
+func dialTestWebSocket(t *testing.T, httpServer *httptest.Server, origin string) (net.Conn, *bufio.Reader, *http.Response) {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(httpServer.URL, "http://"))
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	handshake := "GET /api/v1/ws?token=secret HTTP/1.1\r\n" +
+		"Host: relay\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n"
+	if origin != "" {
+		handshake += "Origin: " + origin + "\r\n"
+	}
+	if _, err := conn.Write([]byte(handshake + "\r\n")); err != nil {
+		t.Fatalf("Handshake write failed: %v", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("Handshake read failed: %v", err)
+	}
+	return conn, reader, resp
+}
+
+// TestAPIServerWebSocket tests the handshake, server frames and client subscribe messages
+func TestAPIServerWebSocket(t *testing.T) {
+	server, httpServer := newTestAPIServer(t)
+
+	conn, reader, resp := dialTestWebSocket(t, httpServer, "")
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected 101, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("Unexpected accept key %q", got)
+	}
+
+	// Client frames must be masked
+	subscribe := `{"type":"subscribe","project":"demo"}`
+	if _, err := conn.Write(encodeWebSocketFrame(wsOpText, []byte(subscribe), []byte{1, 2, 3, 4})); err != nil {
+		t.Fatalf("Subscribe write failed: %v", err)
+	}
+
+	_, opcode, payload, err := readWebSocketFrame(reader, false)
+	if err != nil || opcode != wsOpText || !strings.Contains(string(payload), `"subscribed"`) {
+		t.Fatalf("Expected subscribe acknowledgement, got %d %s (%v)", opcode, payload, err)
+	}
+
+	server.events.Publish(EventIssueCreated, "other", nil)
+	server.events.Publish(EventIssueCreated, "demo", map[string]int{"number": 7})
+
+	_, _, payload, err = readWebSocketFrame(reader, false)
+	if err != nil {
+		t.Fatalf("Event read failed: %v", err)
+	}
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil || event.Project != "demo" {
+		t.Errorf("Expected demo event, got %s (%v)", payload, err)
+	}
+}
+
+// TestWebSocketOriginAndMasking tests that other sites can't open a socket
+// and that an unmasked client frame closes it with a protocol error
+func TestWebSocketOriginAndMasking(t *testing.T) {
+	server, httpServer := newTestAPIServer(t)
+	server.SetAllowedOrigins([]string{"http://localhost:3000"})
+
+	origins := []struct {
+		origin string
+		want   int
+	}{
+		{"https://evil.example", http.StatusForbidden},
+		{"http://relay", http.StatusSwitchingProtocols},
+		{"http://localhost:3000", http.StatusSwitchingProtocols},
+	}
+	for _, tt := range origins {
+		if _, _, resp := dialTestWebSocket(t, httpServer, tt.origin); resp.StatusCode != tt.want {
+			t.Errorf("Origin %s: got %d, want %d", tt.origin, resp.StatusCode, tt.want)
+		}
+	}
+
+	conn, reader, _ := dialTestWebSocket(t, httpServer, "")
+	if _, err := conn.Write(encodeWebSocketFrame(wsOpText, []byte(`{"type":"subscribe"}`), nil)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	_, opcode, payload, err := readWebSocketFrame(reader, false)
+	if err != nil || opcode != wsOpClose || len(payload) < 2 || binary.BigEndian.Uint16(payload) != 1002 {
+		t.Errorf("Expected a 1002 close, got %d %v (%v)", opcode, payload, err)
+	}
+}
+
+// TestParseGitStatus tests parsing of porcelain output with a branch header
+func TestParseGitStatus(t *testing.T) {
+	status := parseGitStatus("## main...origin/main [ahead 2, behind 1]\n M server/main.go\n?? notes.txt\n")
+	if status.Branch != "main" || status.Upstream != "origin/main" || status.Ahead != 2 || status.Behind != 1 {
+		t.Errorf("Unexpected branch info: %+v", status)
+	}
+	if len(status.Files) != 2 || status.Files[0].Worktree != "M" || status.Files[1].Index != "?" || status.Clean {
+		t.Errorf("Unexpected files: %+v", status.Files)
+	}
+}
@@ -0,0 +1,705 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AuditKind is the kind of action recorded in the audit log
+type AuditKind string
+
+const (
+	AuditKindLLM     AuditKind = "llm"     // A prompt and its response
+	AuditKindTool    AuditKind = "tool"    // A tool call made outside an LLM request
+	AuditKindCommand AuditKind = "command" // An external command such as git or gh
+)
+
+// Actors that act on a project
+const (
+	AuditActorUser  = "user"
+	AuditActorAgent = "agent"
+)
+
+// AuditToolCall is a tool the model called while answering a prompt
+type AuditToolCall struct {
+	Name      string        `json:"name"`
+	Arguments string        `json:"arguments"`
+	Output    string        `json:"output"`
+	Error     string        `json:"error,omitempty"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// AuditEvent is one entry of the audit log
+type AuditEvent struct {
+	ID          int64           `json:"id"`
+	Timestamp   time.Time       `json:"timestamp"`
+	Project     string          `json:"project,omitempty"`
+	IssueNumber int             `json:"issue_number,omitempty"`
+	Actor       string          `json:"actor"`
+	Kind        AuditKind       `json:"kind"`
+	Name        string          `json:"name"` // Provider, tool or program
+	Args        []string        `json:"args,omitempty"`
+	Dir         string          `json:"dir,omitempty"`
+	Prompt      string          `json:"prompt,omitempty"`
+	Response    string          `json:"response,omitempty"`
+	ToolCalls   []AuditToolCall `json:"tool_calls,omitempty"`
+	ExitCode    int             `json:"exit_code"`
+	Duration    time.Duration   `json:"duration"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// Summary describes the event in one line
+func (e *AuditEvent) Summary() string {
+	switch e.Kind {
+	case AuditKindCommand:
+		return strings.Join(e.Args, " ")
+	case AuditKindTool:
+		return e.Name + " " + e.Prompt
+	default:
+		return e.Prompt
+	}
+}
+
+// AuditFilter narrows an audit query; zero values match everything
+type AuditFilter struct {
+	Project     string
+	IssueNumber int
+	Actor       string
+	Kind        AuditKind
+	Since       time.Time
+	Search      string // Substring of the prompt, response or command
+	Limit       int
+}
+
+// AuditConfig is read from ~/.relay/audit.json
+type AuditConfig struct {
+	Disabled bool `json:"disabled"`
+
+	// Redact holds extra regular expressions to mask. When a pattern has a
+	// group, the first group is kept, e.g. "(password=)\\S+".
+	Redact []string `json:"redact"`
+
+	// RedactEnv names environment variables whose values are masked
+	RedactEnv []string `json:"redact_env"`
+}
+
+// defaultRedactPatterns mask common credentials
+var defaultRedactPatterns = []string{
+	`sk-ant-[A-Za-z0-9_-]{10,}`,
+	`sk-[A-Za-z0-9_-]{20,}`,
+	`gh[pousr]_[A-Za-z0-9]{20,}`,
+	`github_pat_[A-Za-z0-9_]{20,}`,
+	`AKIA[0-9A-Z]{16}`,
+	`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]{8,}`,
+	`(?i)((?:api[_-]?key|access[_-]?token|auth[_-]?token|secret|password|passwd)["']?\s*[:=]\s*["']?)[^\s"',;]+`,
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`,
+}
+
+// defaultRedactEnv are environment variables that hold secrets
+var defaultRedactEnv = []string{"ANTHROPIC_API_KEY", "OPENAI_API_KEY", "RELAY_API_TOKEN", "GITHUB_TOKEN", "GH_TOKEN"}
+
+const redactedText = "[REDACTED]"
+
+// Redactor masks secrets before they are written to the audit log
+type Redactor struct {
+	patterns []*regexp.Regexp
+	secrets  []string
+}
+
+// NewRedactor builds a redactor from the defaults plus the configured patterns
+func NewRedactor(config AuditConfig) (*Redactor, error) {
+	r := &Redactor{}
+	for _, pattern := range append(append([]string(nil), defaultRedactPatterns...), config.Redact...) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	for _, name := range append(append([]string(nil), defaultRedactEnv...), config.RedactEnv...) {
+		// Short values would mask ordinary words
+		if value := os.Getenv(name); len(value) >= 8 {
+			r.secrets = append(r.secrets, value)
+		}
+	}
+	return r, nil
+}
+
+// Redact masks secrets in text
+func (r *Redactor) Redact(text string) string {
+	if r == nil || text == "" {
+		return text
+	}
+	for _, secret := range r.secrets {
+		text = strings.ReplaceAll(text, secret, redactedText)
+	}
+	for _, re := range r.patterns {
+		if re.NumSubexp() > 0 {
+			text = re.ReplaceAllString(text, "${1}"+redactedText)
+		} else {
+			text = re.ReplaceAllLiteralString(text, redactedText)
+		}
+	}
+	return text
+}
+
+func (r *Redactor) redactEvent(event *AuditEvent) {
+	event.Prompt = r.Redact(event.Prompt)
+	event.Response = r.Redact(event.Response)
+	event.Error = r.Redact(event.Error)
+	for i := range event.Args {
+		event.Args[i] = r.Redact(event.Args[i])
+	}
+	for i := range event.ToolCalls {
+		event.ToolCalls[i].Arguments = r.Redact(event.ToolCalls[i].Arguments)
+		event.ToolCalls[i].Output = r.Redact(event.ToolCalls[i].Output)
+		event.ToolCalls[i].Error = r.Redact(event.ToolCalls[i].Error)
+	}
+}
+
+// AuditLog is an append-only record of prompts, responses and the commands
+// Relay ran, kept in its own SQLite database
+type AuditLog struct {
+	conn     *sql.DB
+	redactor *Redactor
+}
+
+// relayHomeDir returns ~/.relay, creating it if needed
+func relayHomeDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	relayDir := filepath.Join(homeDir, ".relay")
+	if err := os.MkdirAll(relayDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create relay directory: %w", err)
+	}
+	return relayDir, nil
+}
+
+// LoadAuditConfig reads ~/.relay/audit.json; a missing file means the defaults
+func LoadAuditConfig(relayDir string) (AuditConfig, error) {
+	var config AuditConfig
+	data, err := os.ReadFile(filepath.Join(relayDir, "audit.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		return config, fmt.Errorf("failed to read audit config: %w", err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to parse audit config: %w", err)
+	}
+	return config, nil
+}
+
+// NewAuditLog opens ~/.relay/audit.db. It returns nil, nil when auditing is
+// disabled in ~/.relay/audit.json.
+func NewAuditLog() (*AuditLog, error) {
+	relayDir, err := relayHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	config, err := LoadAuditConfig(relayDir)
+	if err != nil {
+		return nil, err
+	}
+	if config.Disabled {
+		return nil, nil
+	}
+	redactor, err := NewRedactor(config)
+	if err != nil {
+		return nil, err
+	}
+	return OpenAuditLog(filepath.Join(relayDir, "audit.db"), redactor)
+}
+
+// OpenAuditLog opens (and migrates) the audit database at the given path
+func OpenAuditLog(path string, redactor *Redactor) (*AuditLog, error) {
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	// Agents record concurrently; a single connection serializes the writes
+	conn.SetMaxOpenConns(1)
+
+	schema := `
+	CREATE TABLE IF NOT EXISTS audit_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME NOT NULL,
+		project TEXT,
+		issue_number INTEGER DEFAULT 0,
+		actor TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		name TEXT,
+		args TEXT,
+		dir TEXT,
+		prompt TEXT,
+		response TEXT,
+		tool_calls TEXT,
+		exit_code INTEGER DEFAULT 0,
+		duration_ms INTEGER DEFAULT 0,
+		error TEXT
+	);
+	CREATE INDEX IF NOT EXISTS idx_audit_events_project ON audit_events(project, timestamp);
+	CREATE TRIGGER IF NOT EXISTS audit_events_no_update BEFORE UPDATE ON audit_events
+	BEGIN SELECT RAISE(ABORT, 'the audit log is append-only'); END;
+	CREATE TRIGGER IF NOT EXISTS audit_events_no_delete BEFORE DELETE ON audit_events
+	BEGIN SELECT RAISE(ABORT, 'the audit log is append-only'); END;`
+
+	if _, err := conn.Exec(schema); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create audit_events table: %w", err)
+	}
+
+	return &AuditLog{conn: conn, redactor: redactor}, nil
+}
+
+// Record redacts and appends an event
+func (l *AuditLog) Record(event *AuditEvent) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	if event.Actor == "" {
+		event.Actor = AuditActorUser
+	}
+	l.redactor.redactEvent(event)
+
+	args, err := json.Marshal(event.Args)
+	if err != nil {
+		return fmt.Errorf("failed to encode args: %w", err)
+	}
+	toolCalls, err := json.Marshal(event.ToolCalls)
+	if err != nil {
+		return fmt.Errorf("failed to encode tool calls: %w", err)
+	}
+
+	query := `
+	INSERT INTO audit_events (timestamp, project, issue_number, actor, kind, name, args, dir,
+		prompt, response, tool_calls, exit_code, duration_ms, error)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := l.conn.Exec(query, event.Timestamp, event.Project, event.IssueNumber, event.Actor,
+		string(event.Kind), event.Name, string(args), event.Dir, event.Prompt, event.Response,
+		string(toolCalls), event.ExitCode, event.Duration.Milliseconds(), event.Error)
+	if err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+
+	event.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// Query returns matching events, newest first
+func (l *AuditLog) Query(filter AuditFilter) ([]*AuditEvent, error) {
+	query := `
+	SELECT id, timestamp, project, issue_number, actor, kind, name, args, dir,
+		prompt, response, tool_calls, exit_code, duration_ms, error
+	FROM audit_events WHERE 1 = 1`
+	var args []interface{}
+
+	if filter.Project != "" {
+		query += ` AND project = ?`
+		args = append(args, filter.Project)
+	}
+	if filter.IssueNumber > 0 {
+		query += ` AND issue_number = ?`
+		args = append(args, filter.IssueNumber)
+	}
+	if filter.Actor != "" {
+		query += ` AND actor = ?`
+		args = append(args, filter.Actor)
+	}
+	if filter.Kind != "" {
+		query += ` AND kind = ?`
+		args = append(args, string(filter.Kind))
+	}
+	if !filter.Since.IsZero() {
+		query += ` AND timestamp >= ?`
+		args = append(args, filter.Since)
+	}
+	if filter.Search != "" {
+		query += ` AND (instr(prompt, ?) > 0 OR instr(response, ?) > 0 OR instr(args, ?) > 0)`
+		args = append(args, filter.Search, filter.Search, filter.Search)
+	}
+	query += ` ORDER BY id DESC`
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(` LIMIT %d`, filter.Limit)
+	}
+
+	rows, err := l.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*AuditEvent
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// Get returns a single event, or nil if there is none with that ID
+func (l *AuditLog) Get(id int64) (*AuditEvent, error) {
+	query := `
+	SELECT id, timestamp, project, issue_number, actor, kind, name, args, dir,
+		prompt, response, tool_calls, exit_code, duration_ms, error
+	FROM audit_events WHERE id = ?`
+
+	event, err := scanAuditEvent(l.conn.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return event, err
+}
+
+func scanAuditEvent(row rowScanner) (*AuditEvent, error) {
+	var event AuditEvent
+	var project, name, args, dir, prompt, response, toolCalls, errText sql.NullString
+	var kind string
+	var durationMs int64
+
+	err := row.Scan(&event.ID, &event.Timestamp, &project, &event.IssueNumber, &event.Actor, &kind,
+		&name, &args, &dir, &prompt, &response, &toolCalls, &event.ExitCode, &durationMs, &errText)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan audit event: %w", err)
+	}
+
+	event.Kind = AuditKind(kind)
+	event.Project = project.String
+	event.Name = name.String
+	event.Dir = dir.String
+	event.Prompt = prompt.String
+	event.Response = response.String
+	event.Error = errText.String
+	event.Duration = time.Duration(durationMs) * time.Millisecond
+	if args.Valid {
+		json.Unmarshal([]byte(args.String), &event.Args)
+	}
+	if toolCalls.Valid {
+		json.Unmarshal([]byte(toolCalls.String), &event.ToolCalls)
+	}
+
+	return &event, nil
+}
+
+func (l *AuditLog) Close() error {
+	return l.conn.Close()
+}
+
+// parseAuditSince reads a duration ago (e.g. 24h) or a date (2006-01-02)
+func parseAuditSince(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: use a duration like 24h or a date like 2006-01-02", value)
+}
+
+// formatAuditLine describes an event in one line for listings
+func formatAuditLine(event *AuditEvent) string {
+	where := event.Project
+	if event.IssueNumber > 0 {
+		where += fmt.Sprintf("#%d", event.IssueNumber)
+	}
+	outcome := "ok"
+	if event.Error != "" || event.ExitCode != 0 {
+		outcome = fmt.Sprintf("exit %d", event.ExitCode)
+	}
+	return fmt.Sprintf("%6d  %s  %-5s  %-20s  %-7s  %-10s  %7s  %s",
+		event.ID, event.Timestamp.Format("2006-01-02 15:04:05"), event.Actor, truncateText(where, 20),
+		event.Kind, truncateText(event.Name, 10), outcome, truncateText(event.Summary(), 60))
+}
+
+// formatAuditEvent describes an event in full
+func formatAuditEvent(event *AuditEvent) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Event %d (%s by %s)\n", event.ID, event.Kind, event.Actor)
+	fmt.Fprintf(&b, "Time:     %s\n", event.Timestamp.Format("2006-01-02 15:04:05"))
+	if event.Project != "" {
+		fmt.Fprintf(&b, "Project:  %s\n", event.Project)
+	}
+	if event.IssueNumber > 0 {
+		fmt.Fprintf(&b, "Issue:    #%d\n", event.IssueNumber)
+	}
+	fmt.Fprintf(&b, "Name:     %s\n", event.Name)
+	if len(event.Args) > 0 {
+		fmt.Fprintf(&b, "Command:  %s\n", strings.Join(event.Args, " "))
+	}
+	if event.Dir != "" {
+		fmt.Fprintf(&b, "Dir:      %s\n", event.Dir)
+	}
+	fmt.Fprintf(&b, "Exit:     %d\n", event.ExitCode)
+	fmt.Fprintf(&b, "Duration: %s\n", event.Duration)
+	if event.Error != "" {
+		fmt.Fprintf(&b, "Error:    %s\n", event.Error)
+	}
+	if event.Prompt != "" {
+		fmt.Fprintf(&b, "\n--- Prompt ---\n%s\n", event.Prompt)
+	}
+	for i, call := range event.ToolCalls {
+		fmt.Fprintf(&b, "\n--- Tool call %d: %s (%s) ---\n%s\n", i+1, call.Name, call.Duration, call.Arguments)
+		if call.Error != "" {
+			fmt.Fprintf(&b, "Error: %s\n", call.Error)
+		} else {
+			fmt.Fprintf(&b, "%s\n", call.Output)
+		}
+	}
+	if event.Response != "" {
+		fmt.Fprintf(&b, "\n--- Response ---\n%s\n", event.Response)
+	}
+	return b.String()
+}
+
+// ExportAuditEvents writes events as JSON lines or CSV
+func ExportAuditEvents(w io.Writer, events []*AuditEvent, format string) error {
+	switch format {
+	case "jsonl", "json":
+		encoder := json.NewEncoder(w)
+		for _, event := range events {
+			if err := encoder.Encode(event); err != nil {
+				return fmt.Errorf("failed to export event %d: %w", event.ID, err)
+			}
+		}
+		return nil
+
+	case "csv":
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"id", "timestamp", "project", "issue_number", "actor", "kind", "name",
+			"args", "dir", "prompt", "response", "tool_calls", "exit_code", "duration_ms", "error"})
+		for _, event := range events {
+			args, _ := json.Marshal(event.Args)
+			toolCalls, _ := json.Marshal(event.ToolCalls)
+			writer.Write([]string{
+				strconv.FormatInt(event.ID, 10), event.Timestamp.Format(time.RFC3339), event.Project,
+				strconv.Itoa(event.IssueNumber), event.Actor, string(event.Kind), event.Name,
+				string(args), event.Dir, event.Prompt, event.Response, string(toolCalls),
+				strconv.Itoa(event.ExitCode), strconv.FormatInt(event.Duration.Milliseconds(), 10), event.Error,
+			})
+		}
+		writer.Flush()
+		return writer.Error()
+
+	default:
+		return fmt.Errorf("unknown export format %q: use jsonl or csv", format)
+	}
+}
+
+// defaultAuditLog receives the events recorded by providers and commands;
+// nothing is recorded until it is set
+var defaultAuditLog atomic.Pointer[AuditLog]
+
+// SetAuditLog makes l the log that events are recorded to
+func SetAuditLog(l *AuditLog) {
+	defaultAuditLog.Store(l)
+}
+
+// AuditScope says who acted, and on which project and issue
+type AuditScope struct {
+	Project     string
+	IssueNumber int
+	Actor       string
+}
+
+type auditScopeKey struct{}
+
+// WithAuditScope attaches the scope that events recorded under ctx belong to
+func WithAuditScope(ctx context.Context, scope AuditScope) context.Context {
+	return context.WithValue(ctx, auditScopeKey{}, scope)
+}
+
+func auditScopeFrom(ctx context.Context) AuditScope {
+	scope, _ := ctx.Value(auditScopeKey{}).(AuditScope)
+	return scope
+}
+
+// recordAudit fills in the event's scope from ctx and appends it to the audit log
+func recordAudit(ctx context.Context, event *AuditEvent) {
+	l := defaultAuditLog.Load()
+	if l == nil {
+		return
+	}
+
+	scope := auditScopeFrom(ctx)
+	if event.Project == "" {
+		event.Project = scope.Project
+	}
+	if event.IssueNumber == 0 {
+		event.IssueNumber = scope.IssueNumber
+	}
+	if event.Actor == "" {
+		event.Actor = scope.Actor
+	}
+
+	if err := l.Record(event); err != nil {
+		slog.Warn("Failed to write audit log", "error", err)
+	}
+}
+
+// recordCommand records an external command that has finished with err
+func recordCommand(ctx context.Context, cmd *exec.Cmd, start time.Time, err error) {
+	event := &AuditEvent{
+		Kind:     AuditKindCommand,
+		Name:     filepath.Base(cmd.Path),
+		Args:     append([]string(nil), cmd.Args...),
+		Dir:      cmd.Dir,
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		event.Error = err.Error()
+		event.ExitCode = -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			event.ExitCode = exitErr.ExitCode()
+		}
+	}
+	recordAudit(ctx, event)
+}
+
+// auditedRun runs cmd and records it in the audit log
+func auditedRun(ctx context.Context, cmd *exec.Cmd) error {
+	start := time.Now()
+	err := cmd.Run()
+	recordCommand(ctx, cmd, start, err)
+	return err
+}
+
+// auditedOutput runs cmd, records it and returns its standard output
+func auditedOutput(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	start := time.Now()
+	output, err := cmd.Output()
+	recordCommand(ctx, cmd, start, err)
+	return output, err
+}
+
+// auditedCombinedOutput runs cmd, records it and returns its combined output
+func auditedCombinedOutput(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	start := time.Now()
+	output, err := cmd.CombinedOutput()
+	recordCommand(ctx, cmd, start, err)
+	return output, err
+}
+
+// auditCollector gathers the tool calls made while a prompt is answered
+type auditCollector struct {
+	mu    sync.Mutex
+	calls []AuditToolCall
+}
+
+type auditCollectorKey struct{}
+
+// auditedProvider records every prompt sent through a provider, with the
+// response and the tool calls made to produce it
+type auditedProvider struct {
+	LLMProvider
+}
+
+// newAuditedProvider wraps a provider so its requests are audited
+func newAuditedProvider(provider LLMProvider) LLMProvider {
+	return &auditedProvider{LLMProvider: provider}
+}
+
+func (p *auditedProvider) SendMessage(ctx context.Context, message string) (string, error) {
+	return p.record(ctx, message, func(ctx context.Context) (string, error) {
+		return p.LLMProvider.SendMessage(ctx, message)
+	})
+}
+
+func (p *auditedProvider) SendMessageWithSession(ctx context.Context, message string, sessionID string) (string, error) {
+	return p.record(ctx, message, func(ctx context.Context) (string, error) {
+		return p.LLMProvider.SendMessageWithSession(ctx, message, sessionID)
+	})
+}
+
+func (p *auditedProvider) StreamMessage(ctx context.Context, message string, onToken func(string)) (string, error) {
+	return p.record(ctx, message, func(ctx context.Context) (string, error) {
+		return StreamOrSend(ctx, p.LLMProvider, message, onToken)
+	})
+}
+
+// SetToolSet passes the tools on to the provider, auditing each call
+func (p *auditedProvider) SetToolSet(tools ToolSet) {
+	if user, ok := p.LLMProvider.(ToolUser); ok {
+		user.SetToolSet(&auditedToolSet{ToolSet: tools})
+	}
+}
+
+func (p *auditedProvider) record(ctx context.Context, message string, call func(context.Context) (string, error)) (string, error) {
+	collector := &auditCollector{}
+	start := time.Now()
+	response, err := call(context.WithValue(ctx, auditCollectorKey{}, collector))
+
+	event := &AuditEvent{
+		Kind:      AuditKindLLM,
+		Name:      p.GetProviderName(),
+		Prompt:    message,
+		Response:  response,
+		ToolCalls: collector.calls,
+		Duration:  time.Since(start),
+	}
+	if err != nil {
+		event.Error = err.Error()
+		event.ExitCode = 1
+	}
+	recordAudit(ctx, event)
+
+	return response, err
+}
+
+// auditedToolSet attaches tool calls to the prompt being answered, or
+// records them on their own
+type auditedToolSet struct {
+	ToolSet
+}
+
+func (t *auditedToolSet) CallTool(ctx context.Context, name string, arguments json.RawMessage) (string, error) {
+	start := time.Now()
+	output, err := t.ToolSet.CallTool(ctx, name, arguments)
+
+	call := AuditToolCall{Name: name, Arguments: string(arguments), Output: output, Duration: time.Since(start)}
+	if err != nil {
+		call.Error = err.Error()
+	}
+
+	if collector, ok := ctx.Value(auditCollectorKey{}).(*auditCollector); ok {
+		collector.mu.Lock()
+		collector.calls = append(collector.calls, call)
+		collector.mu.Unlock()
+	} else {
+		event := &AuditEvent{Kind: AuditKindTool, Name: name, Prompt: call.Arguments, Response: output, Duration: call.Duration, Error: call.Error}
+		if err != nil {
+			event.ExitCode = 1
+		}
+		recordAudit(ctx, event)
+	}
+
+	return output, err
+}
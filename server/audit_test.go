@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// toolCallingProvider calls a tool before answering
+type toolCallingProvider struct {
+	funcProvider
+	tools ToolSet
+}
+
+func (p *toolCallingProvider) SetToolSet(tools ToolSet) { p.tools = tools }
+
+// echoToolSet has one tool that echoes its arguments
+type echoToolSet struct{}
+
+func (echoToolSet) Tools() []MCPTool { return nil }
+
+func (echoToolSet) CallTool(ctx context.Context, name string, arguments json.RawMessage) (string, error) {
+	if name != "echo" {
+		return "", errors.New("unknown tool")
+	}
+	return "echo: " + string(arguments), nil
+}
+
+func newTestAuditLog(t *testing.T, config AuditConfig) *AuditLog {
+	t.Helper()
+	redactor, err := NewRedactor(config)
+	if err != nil {
+		t.Fatalf("NewRedactor failed: %v", err)
+	}
+	auditLog, err := OpenAuditLog(filepath.Join(t.TempDir(), "audit.db"), redactor)
+	if err != nil {
+		t.Fatalf("OpenAuditLog failed: %v", err)
+	}
+	SetAuditLog(auditLog)
+	t.Cleanup(func() {
+		SetAuditLog(nil)
+		auditLog.Close()
+	})
+	return auditLog
+}
+
+// TestRedactor tests the default patterns, secret environment variables and
+// configured patterns
+func TestRedactor(t *testing.T) {
+	t.Setenv("RELAY_TEST_SECRET", "hunter2-hunter2")
+	redactor, err := NewRedactor(AuditConfig{
+		Redact:    []string{`(internal-host: )\S+`},
+		RedactEnv: []string{"RELAY_TEST_SECRET"},
+	})
+	if err != nil {
+		t.Fatalf("NewRedactor failed: %v", err)
+	}
+
+	input := "key sk-ant-REDACTED, token ghp_abcdefghijklmnopqrstuvwxyz123, " +
+		"password=letmein, Authorization: Bearer abc.def.ghi123, pass hunter2-hunter2, internal-host: db.corp"
+	redacted := redactor.Redact(input)
+
+	for _, secret := range []string{"sk-ant-api03", "ghp_abc", "letmein", "abc.def.ghi123", "hunter2", "db.corp"} {
+		if strings.Contains(redacted, secret) {
+			t.Errorf("Expected %q to be redacted: %s", secret, redacted)
+		}
+	}
+	for _, kept := range []string{"password=[REDACTED]", "Bearer [REDACTED]", "internal-host: [REDACTED]"} {
+		if !strings.Contains(redacted, kept) {
+			t.Errorf("Expected %q in %s", kept, redacted)
+		}
+	}
+
+	if _, err := NewRedactor(AuditConfig{Redact: []string{"("}}); err == nil {
+		t.Error("Expected an invalid pattern to fail")
+	}
+}
+
+// TestAuditLog tests recording prompts with their tool calls and commands,
+// querying, redaction and that the log is append-only
+func TestAuditLog(t *testing.T) {
+	auditLog := newTestAuditLog(t, AuditConfig{})
+
+	inner := &toolCallingProvider{}
+	inner.send = func(ctx context.Context, message string) (string, error) {
+		output, err := inner.tools.CallTool(ctx, "echo", json.RawMessage(`{"text":"hi"}`))
+		return "done after " + output, err
+	}
+	provider := newAuditedProvider(inner)
+	provider.(ToolUser).SetToolSet(echoToolSet{})
+
+	ctx := WithAuditScope(context.Background(), AuditScope{Project: "alpha", IssueNumber: 7, Actor: AuditActorAgent})
+	if _, err := provider.SendMessage(ctx, "Use api_key=abc123secret to fix it"); err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+
+	userCtx := WithAuditScope(context.Background(), AuditScope{Project: "beta", Actor: AuditActorUser})
+	if _, err := auditedCombinedOutput(userCtx, exec.Command("sh", "-c", "exit 3")); err == nil {
+		t.Fatal("Expected the command to fail")
+	}
+
+	events, err := auditLog.Query(AuditFilter{})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+
+	command, prompt := events[0], events[1]
+	if command.Kind != AuditKindCommand || command.ExitCode != 3 || command.Project != "beta" ||
+		command.Actor != AuditActorUser || strings.Join(command.Args, " ") != "sh -c exit 3" {
+		t.Errorf("Unexpected command event: %+v", command)
+	}
+
+	if prompt.Kind != AuditKindLLM || prompt.Project != "alpha" || prompt.IssueNumber != 7 || prompt.Actor != AuditActorAgent {
+		t.Errorf("Unexpected prompt event: %+v", prompt)
+	}
+	if strings.Contains(prompt.Prompt, "abc123secret") {
+		t.Errorf("Expected the prompt to be redacted: %s", prompt.Prompt)
+	}
+	if !strings.HasPrefix(prompt.Response, "done after echo:") {
+		t.Errorf("Unexpected response: %s", prompt.Response)
+	}
+	if len(prompt.ToolCalls) != 1 || prompt.ToolCalls[0].Name != "echo" || prompt.ToolCalls[0].Arguments != `{"text":"hi"}` {
+		t.Errorf("Expected the tool call to be attached, got %+v", prompt.ToolCalls)
+	}
+
+	filtered, _ := auditLog.Query(AuditFilter{Project: "alpha", Kind: AuditKindLLM, Search: "fix it"})
+	if len(filtered) != 1 || filtered[0].ID != prompt.ID {
+		t.Errorf("Expected the filter to find the prompt, got %d events", len(filtered))
+	}
+	if none, _ := auditLog.Query(AuditFilter{Since: time.Now().Add(time.Hour)}); len(none) != 0 {
+		t.Errorf("Expected no events in the future, got %d", len(none))
+	}
+
+	if _, err := auditLog.conn.Exec(`UPDATE audit_events SET prompt = 'changed'`); err == nil {
+		t.Error("Expected updates to be rejected")
+	}
+	if _, err := auditLog.conn.Exec(`DELETE FROM audit_events`); err == nil {
+		t.Error("Expected deletes to be rejected")
+	}
+
+	var jsonl bytes.Buffer
+	if err := ExportAuditEvents(&jsonl, events, "jsonl"); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if lines := strings.Count(jsonl.String(), "\n"); lines != 2 {
+		t.Errorf("Expected 2 JSON lines, got %d", lines)
+	}
+	var csvOut bytes.Buffer
+	if err := ExportAuditEvents(&csvOut, events, "csv"); err != nil || !strings.HasPrefix(csvOut.String(), "id,timestamp,project") {
+		t.Errorf("Unexpected CSV export (%v): %s", err, csvOut.String())
+	}
+}
+
+func TestParseAuditSince(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.Local)
+	if since, err := parseAuditSince("24h", now); err != nil || !since.Equal(now.Add(-24*time.Hour)) {
+		t.Errorf("Unexpected duration result: %v, %v", since, err)
+	}
+	if since, err := parseAuditSince("2024-05-01", now); err != nil || since.Day() != 1 {
+		t.Errorf("Unexpected date result: %v, %v", since, err)
+	}
+	if _, err := parseAuditSince("yesterday", now); err == nil {
+		t.Error("Expected an invalid value to fail")
+	}
+}
+
+// TestAuditScopeFill tests that services fill in only the scope fields their
+// caller left empty
+func TestAuditScopeFill(t *testing.T) {
+	gs := NewGitHubService(nil, "demo", "/work/checkout")
+	agent := WithAuditScope(context.Background(), AuditScope{Actor: AuditActorAgent})
+	if scope := auditScopeFrom(gs.scopeContext(agent, 7)); scope != (AuditScope{Project: "demo", IssueNumber: 7, Actor: AuditActorAgent}) {
+		t.Errorf("Unexpected GitHub scope: %+v", scope)
+	}
+	other := WithAuditScope(context.Background(), AuditScope{Project: "other", IssueNumber: 3})
+	if scope := auditScopeFrom(gs.scopeContext(other, 7)); scope.Project != "other" || scope.IssueNumber != 3 {
+		t.Errorf("The caller's scope was replaced: %+v", scope)
+	}
+
+	g, _ := NewGitOperations("demo", "/work/checkout", nil)
+	if scope := auditScopeFrom(g.auditContext(agent)); scope != (AuditScope{Project: "demo", Actor: AuditActorAgent}) {
+		t.Errorf("Unexpected git scope: %+v", scope)
+	}
+	if scope := auditScopeFrom(g.auditContext(context.Background())); scope != (AuditScope{Project: "demo", Actor: AuditActorUser}) {
+		t.Errorf("Unexpected default git scope: %+v", scope)
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// The board groups issues into the columns of .relay/config.json. Columns
+// follow labels, the issue's state, how far its branch and pull request got,
+// or the status field of a GitHub Projects v2 project. Moving a card makes the
+// change that puts the issue in the new column.
+
+// defaultBoardColumns are used when the config defines no columns
+var defaultBoardColumns = []BoardColumn{
+	{Name: "Todo"},
+	{Name: "In Progress", State: "worktree"},
+	{Name: "Review", State: "pr_open"},
+	{Name: "Done", State: "closed"},
+}
+
+// progressStates maps the progress names of column states to their states
+var progressStates = map[string]ProgressState{
+	"branch":    ProgressBranch,
+	"worktree":  ProgressWorktree,
+	"pushed":    ProgressPushed,
+	"pr_open":   ProgressPROpen,
+	"pr_merged": ProgressPRMerged,
+}
+
+// BoardColumns returns the configured columns, or the default ones
+func (b BoardConfig) BoardColumns() []BoardColumn {
+	if len(b.Columns) == 0 {
+		return defaultBoardColumns
+	}
+	return b.Columns
+}
+
+// statusField returns the project field status columns use
+func (b BoardConfig) statusField() string {
+	if b.StatusField == "" {
+		return "Status"
+	}
+	return b.StatusField
+}
+
+// usesProjectStatus reports whether any column follows the project's status field
+func (b BoardConfig) usesProjectStatus() bool {
+	for _, column := range b.BoardColumns() {
+		if column.Status != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the columns' criteria
+func (b BoardConfig) Validate() error {
+	for _, column := range b.BoardColumns() {
+		if column.State != "" && column.State != "open" && column.State != "closed" {
+			if _, ok := progressStates[column.State]; !ok {
+				return fmt.Errorf("board column %q has unknown state %q", column.Name, column.State)
+			}
+		}
+		if column.Status != "" && b.ProjectNumber == 0 {
+			return fmt.Errorf("board column %q uses a project status but board.project_number is not set", column.Name)
+		}
+	}
+	return nil
+}
+
+// catchAll reports whether the column takes the open issues no other column matches
+func (c BoardColumn) catchAll() bool {
+	return c.Label == "" && c.State == "" && c.Status == ""
+}
+
+// Matches reports whether an issue belongs in the column. A progress state
+// matches issues that got at least that far.
+func (c BoardColumn) Matches(issue Issue, progress IssueProgress, status string) bool {
+	if c.catchAll() {
+		return issue.State == "open"
+	}
+	if c.Label != "" && !containsString(issue.Labels, c.Label) {
+		return false
+	}
+	if c.Status != "" && !strings.EqualFold(c.Status, status) {
+		return false
+	}
+	switch c.State {
+	case "":
+	case "open", "closed":
+		if issue.State != c.State {
+			return false
+		}
+	default:
+		if progress.State() < progressStates[c.State] {
+			return false
+		}
+	}
+	return true
+}
+
+// groupBoardIssues puts every issue in the rightmost column it matches, so
+// an issue shows where it got furthest. Issues matching no column are left out.
+func groupBoardIssues(columns []BoardColumn, issues []Issue, progress func(int) IssueProgress, statuses map[int]string) [][]Issue {
+	grouped := make([][]Issue, len(columns))
+	for _, issue := range issues {
+		for i := len(columns) - 1; i >= 0; i-- {
+			if columns[i].Matches(issue, progress(issue.Number), statuses[issue.Number]) {
+				grouped[i] = append(grouped[i], issue)
+				break
+			}
+		}
+	}
+	return grouped
+}
+
+// BoardMove is the change that moves an issue to a column
+type BoardMove struct {
+	AddLabels    []string
+	RemoveLabels []string
+	State        string // "open" or "closed"; empty to leave it
+	Status       string // New project status; empty to leave it
+}
+
+// planBoardMove works out the change that moves an issue to column to: it
+// takes the labels of the other columns off, adds the column's label, opens
+// or closes the issue and sets the project status as the column requires.
+// It fails when the issue would still land in another column.
+func planBoardMove(columns []BoardColumn, issue Issue, progress IssueProgress, status string, to int) (BoardMove, error) {
+	var move BoardMove
+	target := columns[to]
+	if _, ok := progressStates[target.State]; ok {
+		return move, fmt.Errorf("column %q follows the issue's branch and pull request; work on the issue to move it there", target.Name)
+	}
+
+	for _, column := range columns {
+		if column.Label != "" && column.Label != target.Label && containsString(issue.Labels, column.Label) && !containsString(move.RemoveLabels, column.Label) {
+			move.RemoveLabels = append(move.RemoveLabels, column.Label)
+		}
+	}
+	if target.Label != "" && !containsString(issue.Labels, target.Label) {
+		move.AddLabels = append(move.AddLabels, target.Label)
+	}
+
+	switch {
+	case target.State == "closed" && issue.State != "closed":
+		move.State = "closed"
+	case target.State != "closed" && issue.State == "closed":
+		// Moving out of a closed column reopens the issue
+		move.State = "open"
+	}
+	if target.Status != "" && !strings.EqualFold(target.Status, status) {
+		move.Status = target.Status
+	}
+
+	// Check where the moved issue lands
+	moved := issue
+	moved.Labels = nil
+	for _, label := range issue.Labels {
+		if !containsString(move.RemoveLabels, label) {
+			moved.Labels = append(moved.Labels, label)
+		}
+	}
+	moved.Labels = append(moved.Labels, move.AddLabels...)
+	if move.State != "" {
+		moved.State = move.State
+	}
+	if move.Status != "" {
+		status = move.Status
+	}
+	grouped := groupBoardIssues(columns, []Issue{moved}, func(int) IssueProgress { return progress }, map[int]string{issue.Number: status})
+	if len(grouped[to]) == 0 {
+		for i := range columns {
+			if len(grouped[i]) > 0 {
+				return BoardMove{}, fmt.Errorf("issue #%d would stay in %q", issue.Number, columns[i].Name)
+			}
+		}
+		return BoardMove{}, fmt.Errorf("issue #%d would not match %q", issue.Number, target.Name)
+	}
+	return move, nil
+}
+
+// MoveIssue moves an issue to column to of the board by changing its
+// labels, state or project status
+func (im *IssueManager) MoveIssue(ctx context.Context, board BoardConfig, issue Issue, progress IssueProgress, status string, to int) error {
+	columns := board.BoardColumns()
+	move, err := planBoardMove(columns, issue, progress, status, to)
+	if err != nil {
+		return err
+	}
+	name := columns[to].Name
+
+	if len(move.AddLabels) > 0 || len(move.RemoveLabels) > 0 {
+		if err := im.githubService.EditLabels(ctx, issue.Number, move.AddLabels, move.RemoveLabels); err != nil {
+			return fmt.Errorf("failed to move issue #%d to %s: %w", issue.Number, name, err)
+		}
+	}
+	if move.State != "" {
+		if err := im.UpdateIssueStatus(issue.Number, move.State); err != nil {
+			return fmt.Errorf("failed to move issue #%d to %s: %w", issue.Number, name, err)
+		}
+	}
+	if move.Status != "" {
+		if err := im.githubService.SetProjectStatus(ctx, board.ProjectOwner, board.ProjectNumber, board.statusField(), issue, move.Status); err != nil {
+			return fmt.Errorf("failed to move issue #%d to %s: %w", issue.Number, name, err)
+		}
+	}
+
+	im.publish(EventIssueUpdated, map[string]interface{}{"number": issue.Number, "column": name})
+	return nil
+}
+
+// ProjectStatuses returns the project status of each issue on the board's
+// GitHub Projects v2 project
+func (im *IssueManager) ProjectStatuses(ctx context.Context, board BoardConfig) (map[int]string, error) {
+	items, err := im.githubService.ListProjectItems(ctx, board.ProjectOwner, board.ProjectNumber, board.statusField())
+	if err != nil {
+		return nil, err
+	}
+	statuses := make(map[int]string, len(items))
+	for _, item := range items {
+		statuses[item.IssueNumber] = item.Status
+	}
+	return statuses, nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var testBoardColumns = []BoardColumn{
+	{Name: "Todo"},
+	{Name: "Doing", Label: "doing"},
+	{Name: "Review", State: "pr_open"},
+	{Name: "Done", State: "closed"},
+}
+
+func TestGroupBoardIssues(t *testing.T) {
+	issues := []Issue{
+		{Number: 1, State: "open"},
+		{Number: 2, State: "open", Labels: []string{"doing"}},
+		{Number: 3, State: "open", Labels: []string{"doing"}},
+		{Number: 4, State: "closed", Labels: []string{"doing"}},
+	}
+	progress := func(n int) IssueProgress {
+		if n == 3 {
+			return IssueProgress{HasBranch: true, Pushed: true, PRNumber: 9, PRState: "MERGED"}
+		}
+		return IssueProgress{}
+	}
+
+	grouped := groupBoardIssues(testBoardColumns, issues, progress, nil)
+	want := [][]int{{1}, {2}, {3}, {4}}
+	for i := range want {
+		got := issueNumbers(grouped[i])
+		if len(got) != len(want[i]) || (len(got) > 0 && got[0] != want[i][0]) {
+			t.Errorf("Column %s: got %v, want %v", testBoardColumns[i].Name, got, want[i])
+		}
+	}
+
+	statusColumns := []BoardColumn{{Name: "Backlog", Status: "Todo"}, {Name: "Active", Status: "In Progress"}}
+	grouped = groupBoardIssues(statusColumns, issues, noProgress, map[int]string{1: "in progress", 2: "Todo"})
+	if got := issueNumbers(grouped[1]); len(got) != 1 || got[0] != 1 {
+		t.Errorf("Status column: got %v", got)
+	}
+	if got := issueNumbers(grouped[0]); len(got) != 1 || got[0] != 2 {
+		t.Errorf("Status column: got %v", got)
+	}
+}
+
+func TestPlanBoardMove(t *testing.T) {
+	todo := Issue{Number: 1, State: "open", Labels: []string{"bug"}}
+	doing := Issue{Number: 2, State: "open", Labels: []string{"bug", "doing"}}
+	done := Issue{Number: 3, State: "closed", Labels: []string{"doing"}}
+
+	move, err := planBoardMove(testBoardColumns, todo, IssueProgress{}, "", 1)
+	if err != nil || len(move.AddLabels) != 1 || move.AddLabels[0] != "doing" || move.State != "" {
+		t.Errorf("Todo to Doing: %+v, %v", move, err)
+	}
+
+	move, err = planBoardMove(testBoardColumns, doing, IssueProgress{}, "", 0)
+	if err != nil || len(move.RemoveLabels) != 1 || move.RemoveLabels[0] != "doing" || len(move.AddLabels) != 0 {
+		t.Errorf("Doing to Todo: %+v, %v", move, err)
+	}
+
+	move, err = planBoardMove(testBoardColumns, doing, IssueProgress{}, "", 3)
+	if err != nil || move.State != "closed" || len(move.RemoveLabels) != 1 {
+		t.Errorf("Doing to Done: %+v, %v", move, err)
+	}
+
+	move, err = planBoardMove(testBoardColumns, done, IssueProgress{}, "", 1)
+	if err != nil || move.State != "open" || len(move.AddLabels) != 0 {
+		t.Errorf("Done to Doing: %+v, %v", move, err)
+	}
+
+	if _, err := planBoardMove(testBoardColumns, doing, IssueProgress{}, "", 2); err == nil {
+		t.Error("Expected moving to a pull request column to fail")
+	}
+
+	// An open pull request keeps the issue in Review whatever its labels
+	inReview := IssueProgress{HasBranch: true, PRNumber: 5, PRState: "OPEN"}
+	if _, err := planBoardMove(testBoardColumns, doing, inReview, "", 0); err == nil || !strings.Contains(err.Error(), "Review") {
+		t.Errorf("Expected the issue to stay in Review, got %v", err)
+	}
+
+	statusColumns := []BoardColumn{{Name: "Backlog", Status: "Todo"}, {Name: "Active", Status: "In Progress"}}
+	move, err = planBoardMove(statusColumns, todo, IssueProgress{}, "Todo", 1)
+	if err != nil || move.Status != "In Progress" {
+		t.Errorf("Status move: %+v, %v", move, err)
+	}
+}
+
+func TestBoardConfigValidate(t *testing.T) {
+	if err := (BoardConfig{}).Validate(); err != nil {
+		t.Errorf("Default columns: %v", err)
+	}
+	if err := (BoardConfig{Columns: []BoardColumn{{Name: "x", State: "started"}}}).Validate(); err == nil {
+		t.Error("Expected an unknown state to fail")
+	}
+	if err := (BoardConfig{Columns: []BoardColumn{{Name: "x", Status: "Todo"}}}).Validate(); err == nil {
+		t.Error("Expected a status column without a project to fail")
+	}
+}
+
+func TestBoardModel(t *testing.T) {
+	m := BoardModel{
+		columns: testBoardColumns,
+		rows:    make([]int, len(testBoardColumns)),
+		back:    ViewIssueList,
+		width:   100,
+		height:  24,
+	}
+	m, _ = m.Update(issueListActionMsg{label: "Loading board"})
+	m, _ = m.Update(boardLoadedMsg{opID: m.op.id, issues: []Issue{
+		{Number: 1, Title: "Write docs", State: "open"},
+		{Number: 2, Title: "Fix crash", State: "open", Labels: []string{"doing"}},
+		{Number: 3, Title: "Old bug", State: "open"},
+	}})
+
+	view := m.View()
+	for _, want := range []string{"Todo (2)", "Doing (1)", "#2 Fix crash", "doing"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Board view is missing %q:\n%s", want, view)
+		}
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if issue, _ := m.selectedIssue(); issue.Number != 3 {
+		t.Errorf("Selected #%d, want #3", issue.Number)
+	}
+
+	// Moving to a pull request column fails without starting an operation
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRight})
+	m, cmd := m.Update(runes("L"))
+	if cmd != nil || m.op != nil || !strings.Contains(m.err, "Review") {
+		t.Errorf("Expected the move to be refused, got err %q", m.err)
+	}
+}
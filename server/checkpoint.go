@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Checkpoints are commits that snapshot a worktree, untracked files included,
+// kept under hidden refs so they never show up in branches or the log.
+// Restoring one first checkpoints the current state, so nothing is lost.
+const (
+	checkpointRefPrefix = "refs/relay/checkpoints/"
+	checkpointSubject   = "relay checkpoint: "
+	checkpointTrailer   = "Relay-Worktree: "
+
+	// maxCheckpoints bounds how many checkpoints a worktree keeps
+	maxCheckpoints = 200
+)
+
+// Checkpoint is a snapshot of a worktree taken before a risky operation
+type Checkpoint struct {
+	ID        string    `json:"id"`
+	Commit    string    `json:"commit"`
+	Head      string    `json:"head,omitempty"` // HEAD when the snapshot was taken
+	Trigger   string    `json:"trigger"`
+	Worktree  string    `json:"worktree"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Ref returns the hidden ref that keeps the checkpoint alive
+func (c *Checkpoint) Ref() string {
+	return checkpointRefPrefix + c.ID
+}
+
+// runCheckpointGit runs git with extra environment, returning trimmed output
+func runCheckpointGit(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// worktreeRoot resolves the top level of the worktree containing dir
+func worktreeRoot(ctx context.Context, dir string) (string, error) {
+	root, err := runCheckpointGit(ctx, dir, nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %s", dir)
+	}
+	return filepath.Clean(root), nil
+}
+
+// snapshotTree writes the worktree, untracked files included, to a tree
+// object using a scratch index so the real index is left alone
+func snapshotTree(ctx context.Context, root string) (string, error) {
+	indexPath, err := runCheckpointGit(ctx, root, nil, "rev-parse", "--path-format=absolute", "--git-path", "index")
+	if err != nil {
+		return "", err
+	}
+
+	scratch, err := os.CreateTemp("", "relay-checkpoint-index-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create scratch index: %w", err)
+	}
+	scratch.Close()
+	defer os.Remove(scratch.Name())
+
+	// Starting from the real index lets git skip rehashing unchanged files
+	if data, err := os.ReadFile(indexPath); err == nil {
+		if err := os.WriteFile(scratch.Name(), data, 0644); err != nil {
+			return "", fmt.Errorf("failed to copy index: %w", err)
+		}
+	} else {
+		os.Remove(scratch.Name())
+	}
+
+	env := []string{"GIT_INDEX_FILE=" + scratch.Name()}
+	if _, err := runCheckpointGit(ctx, root, env, "add", "-A"); err != nil {
+		return "", err
+	}
+	return runCheckpointGit(ctx, root, env, "write-tree")
+}
+
+// CreateCheckpoint snapshots the worktree containing dir. When nothing has
+// changed since the latest checkpoint, that checkpoint is returned instead.
+func CreateCheckpoint(ctx context.Context, dir, trigger string) (*Checkpoint, error) {
+	root, err := worktreeRoot(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := snapshotTree(ctx, root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot worktree: %w", err)
+	}
+	head, _ := runCheckpointGit(ctx, root, nil, "rev-parse", "--verify", "--quiet", "HEAD")
+
+	existing, err := ListCheckpoints(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 && existing[0].Head == head {
+		latestTree, err := runCheckpointGit(ctx, root, nil, "rev-parse", existing[0].Commit+"^{tree}")
+		if err == nil && latestTree == tree {
+			return existing[0], nil
+		}
+	}
+
+	trigger = strings.Join(strings.Fields(trigger), " ")
+	message := checkpointSubject + trigger + "\n\n" + checkpointTrailer + root + "\n"
+	args := []string{"commit-tree", tree, "-m", message}
+	if head != "" {
+		args = append(args, "-p", head)
+	}
+
+	// Checkpoints are internal objects, so they don't need the user's identity
+	identity := []string{
+		"GIT_AUTHOR_NAME=Relay", "GIT_AUTHOR_EMAIL=relay@localhost",
+		"GIT_COMMITTER_NAME=Relay", "GIT_COMMITTER_EMAIL=relay@localhost",
+	}
+	commit, err := runCheckpointGit(ctx, root, identity, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+
+	now := time.Now()
+	checkpoint := &Checkpoint{
+		ID:        strconv.FormatInt(now.UnixNano(), 10),
+		Commit:    commit,
+		Head:      head,
+		Trigger:   trigger,
+		Worktree:  root,
+		CreatedAt: now,
+	}
+	if _, err := runCheckpointGit(ctx, root, nil, "update-ref", checkpoint.Ref(), commit); err != nil {
+		return nil, fmt.Errorf("failed to save checkpoint ref: %w", err)
+	}
+
+	// Drop the oldest checkpoints beyond the limit
+	if len(existing)+1 > maxCheckpoints {
+		for _, old := range existing[maxCheckpoints-1:] {
+			runCheckpointGit(ctx, root, nil, "update-ref", "-d", old.Ref())
+		}
+	}
+
+	return checkpoint, nil
+}
+
+// ListCheckpoints returns the checkpoints of the worktree containing dir, newest first.
+// Refs are shared between worktrees of a repository, so each checkpoint
+// records the worktree it belongs to.
+func ListCheckpoints(ctx context.Context, dir string) ([]*Checkpoint, error) {
+	root, err := worktreeRoot(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	const separator = "\x1f"
+	format := strings.Join([]string{"%(refname)", "%(objectname)", "%(parent)", "%(subject)", "%(body)"}, separator) + "\x1e"
+	output, err := runCheckpointGit(ctx, root, nil, "for-each-ref", "--format="+format, checkpointRefPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+
+	var checkpoints []*Checkpoint
+	for _, record := range strings.Split(output, "\x1e") {
+		fields := strings.Split(strings.TrimSpace(record), separator)
+		if len(fields) != 5 {
+			continue
+		}
+
+		id := strings.TrimPrefix(fields[0], checkpointRefPrefix)
+		nanos, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		var worktree string
+		for _, line := range strings.Split(fields[4], "\n") {
+			if strings.HasPrefix(line, checkpointTrailer) {
+				worktree = strings.TrimSpace(strings.TrimPrefix(line, checkpointTrailer))
+			}
+		}
+		if worktree != root {
+			continue
+		}
+
+		checkpoints = append(checkpoints, &Checkpoint{
+			ID:        id,
+			Commit:    fields[1],
+			Head:      fields[2],
+			Trigger:   strings.TrimPrefix(fields[3], checkpointSubject),
+			Worktree:  worktree,
+			CreatedAt: time.Unix(0, nanos),
+		})
+	}
+
+	sort.Slice(checkpoints, func(i, j int) bool {
+		return checkpoints[i].CreatedAt.After(checkpoints[j].CreatedAt)
+	})
+	return checkpoints, nil
+}
+
+// FindCheckpoint looks a checkpoint up by its position in the list (1 is the
+// newest) or by an ID or commit prefix
+func FindCheckpoint(checkpoints []*Checkpoint, key string) (*Checkpoint, error) {
+	if n, err := strconv.Atoi(key); err == nil && n >= 1 && n <= len(checkpoints) {
+		return checkpoints[n-1], nil
+	}
+	var found *Checkpoint
+	for _, checkpoint := range checkpoints {
+		if checkpoint.ID == key || len(key) >= 7 && strings.HasPrefix(checkpoint.Commit, key) {
+			if found != nil && found != checkpoint {
+				return nil, fmt.Errorf("checkpoint '%s' is ambiguous", key)
+			}
+			found = checkpoint
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("checkpoint '%s' not found", key)
+	}
+	return found, nil
+}
+
+// CheckpointDiffstat summarizes what a checkpoint holds on top of the commit
+// that was checked out when it was taken
+func CheckpointDiffstat(ctx context.Context, checkpoint *Checkpoint) string {
+	base := checkpoint.Head
+	if base == "" {
+		// Empty tree, for a repository without commits
+		base = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+	}
+	stat, err := runCheckpointGit(ctx, checkpoint.Worktree, nil, "diff", "--shortstat", base, checkpoint.Commit)
+	if err != nil {
+		return ""
+	}
+	if stat == "" {
+		return "no uncommitted changes"
+	}
+	return stat
+}
+
+// RestoreCheckpoint puts the worktree back to a checkpoint: files, untracked
+// ones included, and the checked out commit. The current state is
+// checkpointed first, and later checkpoints are kept.
+func RestoreCheckpoint(ctx context.Context, dir string, checkpoint *Checkpoint) (*Checkpoint, error) {
+	root, err := worktreeRoot(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	safety, err := CreateCheckpoint(ctx, root, "before restoring checkpoint from "+checkpoint.CreatedAt.Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to checkpoint current state: %w", err)
+	}
+
+	// Move back to the commit that was checked out, keeping the files for now
+	if checkpoint.Head != "" {
+		if _, err := runCheckpointGit(ctx, root, nil, "reset", "--quiet", "--mixed", checkpoint.Head); err != nil {
+			return safety, err
+		}
+	}
+
+	// Remove files that didn't exist at the checkpoint
+	added, err := runCheckpointGit(ctx, root, nil, "diff", "--name-only", "--no-renames", "--diff-filter=A", checkpoint.Commit, safety.Commit)
+	if err != nil {
+		return safety, err
+	}
+	for _, path := range strings.Split(added, "\n") {
+		if path != "" {
+			if err := os.Remove(filepath.Join(root, path)); err != nil && !os.IsNotExist(err) {
+				return safety, fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+		}
+	}
+
+	// Write the checkpoint's files, then unstage them so files that were
+	// untracked at the checkpoint are untracked again
+	if _, err := runCheckpointGit(ctx, root, nil, "checkout", checkpoint.Commit, "--", "."); err != nil {
+		return safety, err
+	}
+	if checkpoint.Head != "" {
+		if _, err := runCheckpointGit(ctx, root, nil, "reset", "--quiet"); err != nil {
+			return safety, err
+		}
+	}
+
+	return safety, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newCheckpointTestRepo creates a repository with one commit
+func newCheckpointTestRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"config", "user.name", "Test"},
+		{"config", "user.email", "test@example.com"},
+	} {
+		runTestGit(t, dir, args...)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.txt"), []byte("v1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	commitAll(t, dir, "initial")
+	return dir
+}
+
+func commitAll(t *testing.T, dir, message string) {
+	t.Helper()
+	runTestGit(t, dir, "add", "-A")
+	runTestGit(t, dir, "commit", "--quiet", "-m", message)
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+// TestCheckpointRestore tests restoring tracked, untracked and added files
+// and the checked out commit, without losing later checkpoints
+func TestCheckpointRestore(t *testing.T) {
+	ctx := context.Background()
+	dir := newCheckpointTestRepo(t)
+
+	os.WriteFile(filepath.Join(dir, "main.txt"), []byte("v2\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("scratch\n"), 0644)
+
+	checkpoint, err := CreateCheckpoint(ctx, dir, "before prompt")
+	if err != nil {
+		t.Fatalf("CreateCheckpoint failed: %v", err)
+	}
+	again, err := CreateCheckpoint(ctx, dir, "nothing changed")
+	if err != nil {
+		t.Fatalf("CreateCheckpoint failed: %v", err)
+	}
+	if again.ID != checkpoint.ID {
+		t.Error("Expected an unchanged worktree to reuse the latest checkpoint")
+	}
+	if stat := CheckpointDiffstat(ctx, checkpoint); !strings.Contains(stat, "2 files changed") {
+		t.Errorf("Unexpected diffstat: %q", stat)
+	}
+
+	// The agent edits, adds a file and commits
+	os.WriteFile(filepath.Join(dir, "main.txt"), []byte("v3\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "added.txt"), []byte("new\n"), 0644)
+	commitAll(t, dir, "agent work")
+
+	checkpoints, err := ListCheckpoints(ctx, dir)
+	if err != nil {
+		t.Fatalf("ListCheckpoints failed: %v", err)
+	}
+	found, err := FindCheckpoint(checkpoints, "1")
+	if err != nil || found.ID != checkpoint.ID {
+		t.Fatalf("Expected position 1 to find the checkpoint, got %v, %v", found, err)
+	}
+	if found, err := FindCheckpoint(checkpoints, checkpoint.Commit[:8]); err != nil || found.ID != checkpoint.ID {
+		t.Errorf("Expected a commit prefix to find the checkpoint, got %v, %v", found, err)
+	}
+	if _, err := FindCheckpoint(checkpoints, "missing"); err == nil {
+		t.Error("Expected an unknown key to fail")
+	}
+
+	safety, err := RestoreCheckpoint(ctx, dir, checkpoint)
+	if err != nil {
+		t.Fatalf("RestoreCheckpoint failed: %v", err)
+	}
+
+	if content := readTestFile(t, filepath.Join(dir, "main.txt")); content != "v2\n" {
+		t.Errorf("Expected main.txt to be restored, got %q", content)
+	}
+	if content := readTestFile(t, filepath.Join(dir, "notes.txt")); content != "scratch\n" {
+		t.Errorf("Expected notes.txt to be restored, got %q", content)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "added.txt")); !os.IsNotExist(err) {
+		t.Error("Expected added.txt to be removed")
+	}
+
+	head, _ := runCheckpointGit(ctx, dir, nil, "rev-parse", "HEAD")
+	if head != checkpoint.Head {
+		t.Errorf("Expected HEAD to be %s, got %s", checkpoint.Head, head)
+	}
+	status, _ := runCheckpointGit(ctx, dir, nil, "status", "--porcelain")
+	if !strings.Contains(status, "?? notes.txt") || !strings.Contains(status, "M main.txt") {
+		t.Errorf("Expected notes.txt untracked and main.txt modified, got:\n%s", status)
+	}
+
+	// The state before the restore is still there to undo the undo
+	checkpoints, _ = ListCheckpoints(ctx, dir)
+	if len(checkpoints) != 2 || checkpoints[0].ID != safety.ID {
+		t.Fatalf("Expected the safety checkpoint to be the newest of two, got %d", len(checkpoints))
+	}
+	if _, err := RestoreCheckpoint(ctx, dir, safety); err != nil {
+		t.Fatalf("Restoring the safety checkpoint failed: %v", err)
+	}
+	if content := readTestFile(t, filepath.Join(dir, "added.txt")); content != "new\n" {
+		t.Errorf("Expected added.txt to come back, got %q", content)
+	}
+	if content := readTestFile(t, filepath.Join(dir, "main.txt")); content != "v3\n" {
+		t.Errorf("Expected main.txt at v3, got %q", content)
+	}
+}
@@ -1,16 +1,16 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
 	"os/exec"
 	"strings"
 )
 
 type ClaudeCLI struct {
-	logger         *log.Logger
+	logger         *slog.Logger
 	useSession     bool
 	sessionStarted bool
 	workingDir     string
@@ -22,9 +22,9 @@ type ClaudeResponse struct {
 }
 
 func NewClaudeCLI(useSession bool, workingDir string) (*ClaudeCLI, error) {
-	logger := log.New(os.Stdout, "[ClaudeCLI] ", log.LstdFlags)
+	logger := componentLogger("ClaudeCLI")
 
-	logger.Printf("Claude CLI client initialized (session: %v, workingDir: %s)", useSession, workingDir)
+	logger.Debug("Claude CLI client initialized", "session", useSession, "workingDir", workingDir)
 
 	return &ClaudeCLI{
 		logger:         logger,
@@ -35,14 +35,21 @@ func NewClaudeCLI(useSession bool, workingDir string) (*ClaudeCLI, error) {
 }
 
 func (c *ClaudeCLI) SendCommand(command string) (string, error) {
-	c.logger.Printf("Sending command: %s", command)
+	return c.SendCommandContext(context.Background(), command)
+}
+
+// SendCommandContext is SendCommand with a context that kills the claude
+// process when cancelled
+func (c *ClaudeCLI) SendCommandContext(ctx context.Context, command string) (string, error) {
+	// Prompts and responses go to the audit log, not here
+	c.logger.Debug("Sending command", "chars", len(command))
 
 	var cmd *exec.Cmd
 
 	if c.useSession && c.sessionStarted {
-		cmd = exec.Command("claude", "--print", "--output-format", "json", "--continue", command)
+		cmd = exec.CommandContext(ctx, "claude", "--print", "--output-format", "json", "--continue", command)
 	} else {
-		cmd = exec.Command("claude", "--print", "--output-format", "json", command)
+		cmd = exec.CommandContext(ctx, "claude", "--print", "--output-format", "json", command)
 		if c.useSession {
 			c.sessionStarted = true
 		}
@@ -55,6 +62,9 @@ func (c *ClaudeCLI) SendCommand(command string) (string, error) {
 
 	output, err := cmd.Output()
 	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
 		// If the command failed, try to get stderr for better error messages
 		if exitError, ok := err.(*exec.ExitError); ok {
 			return "", fmt.Errorf("claude command failed: %s (stderr: %s)", err.Error(), string(exitError.Stderr))
@@ -63,7 +73,7 @@ func (c *ClaudeCLI) SendCommand(command string) (string, error) {
 	}
 
 	responseText := strings.TrimSpace(string(output))
-	c.logger.Printf("Received raw response (%d chars): %s", len(responseText), responseText)
+	c.logger.Debug("Received raw response", "chars", len(responseText))
 
 	return c.parseResponse(responseText)
 }
@@ -77,12 +87,12 @@ func (c *ClaudeCLI) parseResponse(responseText string) (string, error) {
 	var claudeResp ClaudeResponse
 	err := json.Unmarshal([]byte(responseText), &claudeResp)
 	if err == nil {
-		c.logger.Printf("Parsed JSON response: %s", claudeResp.Result)
+		c.logger.Debug("Parsed JSON response", "chars", len(claudeResp.Result))
 		return claudeResp.Result, nil
 	}
 
 	// If JSON parsing fails, return raw text
-	c.logger.Printf("Using raw text response (JSON parse failed: %v)", err)
+	c.logger.Debug("Using raw text response", "error", err)
 	return responseText, nil
 }
 
@@ -100,6 +110,6 @@ func (c *ClaudeCLI) SendCommandInProject(command string, projectPath string) (st
 }
 
 func (c *ClaudeCLI) Close() error {
-	c.logger.Println("Claude CLI client closed")
+	c.logger.Debug("Claude CLI client closed")
 	return nil
 }
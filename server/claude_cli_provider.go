@@ -23,13 +23,13 @@ func NewClaudeCLIProvider(workingDir string) (*ClaudeCLIProvider, error) {
 
 // SendMessage sends a message to Claude via CLI
 func (p *ClaudeCLIProvider) SendMessage(ctx context.Context, message string) (string, error) {
-	return p.cli.SendCommand(message)
+	return p.cli.SendCommandContext(ctx, message)
 }
 
 // SendMessageWithSession sends a message with session continuity via CLI
 func (p *ClaudeCLIProvider) SendMessageWithSession(ctx context.Context, message string, sessionID string) (string, error) {
 	// CLI implementation already handles sessions internally
-	return p.cli.SendCommand(message)
+	return p.cli.SendCommandContext(ctx, message)
 }
 
 // GetProviderName returns the provider name
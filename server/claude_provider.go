@@ -6,9 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"strings"
 	"sync"
 	"time"
 )
@@ -22,10 +23,13 @@ const (
 type ClaudeProvider struct {
 	config     LLMProviderConfig
 	httpClient *http.Client
-	logger     *log.Logger
+	logger     *slog.Logger
 	workingDir string
 	sessions   map[string]*ClaudeSession
 	sessionMu  sync.RWMutex
+	apiURL     string
+	toolMu     sync.RWMutex // Guards toolSet: MCP servers set it once they start, mid-session
+	toolSet    ToolSet
 }
 
 // ClaudeSession represents a conversation session
@@ -35,10 +39,30 @@ type ClaudeSession struct {
 	mu       sync.Mutex
 }
 
-// ClaudeMessage represents a message in the conversation
+// ClaudeMessage represents a message in the conversation. Content is either
+// a string or a list of ClaudeContentBlock when tools are involved.
 type ClaudeMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string      `json:"role"`
+	Content interface{} `json:"content"`
+}
+
+// ClaudeContentBlock is a text, tool_use or tool_result content block
+type ClaudeContentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	IsError   bool            `json:"is_error,omitempty"`
+}
+
+// ClaudeTool describes a tool the model may call
+type ClaudeTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
 }
 
 // ClaudeRequest represents the request structure for Claude API
@@ -46,20 +70,19 @@ type ClaudeRequest struct {
 	Model     string          `json:"model"`
 	MaxTokens int             `json:"max_tokens"`
 	Messages  []ClaudeMessage `json:"messages"`
+	Tools     []ClaudeTool    `json:"tools,omitempty"`
+	Stream    bool            `json:"stream,omitempty"`
 }
 
 // ClaudeAPIResponse represents the response structure from Claude API
 type ClaudeAPIResponse struct {
-	ID      string `json:"id"`
-	Type    string `json:"type"`
-	Role    string `json:"role"`
-	Content []struct {
-		Type string `json:"type"`
-		Text string `json:"text"`
-	} `json:"content"`
-	Model        string `json:"model"`
-	StopReason   string `json:"stop_reason"`
-	StopSequence string `json:"stop_sequence"`
+	ID           string               `json:"id"`
+	Type         string               `json:"type"`
+	Role         string               `json:"role"`
+	Content      []ClaudeContentBlock `json:"content"`
+	Model        string               `json:"model"`
+	StopReason   string               `json:"stop_reason"`
+	StopSequence string               `json:"stop_sequence"`
 	Usage        struct {
 		InputTokens  int `json:"input_tokens"`
 		OutputTokens int `json:"output_tokens"`
@@ -68,7 +91,7 @@ type ClaudeAPIResponse struct {
 
 // NewClaudeProvider creates a new Claude API provider
 func NewClaudeProvider(config LLMProviderConfig, workingDir string) (*ClaudeProvider, error) {
-	logger := log.New(os.Stdout, "[ClaudeProvider] ", log.LstdFlags)
+	logger := componentLogger("ClaudeProvider")
 
 	// Use API key from config or environment
 	apiKey := config.APIKey
@@ -95,7 +118,12 @@ func NewClaudeProvider(config LLMProviderConfig, workingDir string) (*ClaudeProv
 		Timeout: 60 * time.Second,
 	}
 
-	logger.Printf("Claude API provider initialized (model: %s, maxTokens: %d)", config.Model, config.MaxTokens)
+	logger.Debug("Claude API provider initialized", "model", config.Model, "maxTokens", config.MaxTokens)
+
+	apiURL := claudeAPIURL
+	if config.BaseURL != "" {
+		apiURL = strings.TrimRight(config.BaseURL, "/") + "/v1/messages"
+	}
 
 	return &ClaudeProvider{
 		config:     config,
@@ -103,9 +131,42 @@ func NewClaudeProvider(config LLMProviderConfig, workingDir string) (*ClaudeProv
 		logger:     logger,
 		workingDir: workingDir,
 		sessions:   make(map[string]*ClaudeSession),
+		apiURL:     apiURL,
 	}, nil
 }
 
+// SetToolSet makes tools available to the model in subsequent requests
+func (p *ClaudeProvider) SetToolSet(tools ToolSet) {
+	p.toolMu.Lock()
+	defer p.toolMu.Unlock()
+	p.toolSet = tools
+}
+
+// currentToolSet returns the tools set so far; a request keeps the one it
+// started with
+func (p *ClaudeProvider) currentToolSet() ToolSet {
+	p.toolMu.RLock()
+	defer p.toolMu.RUnlock()
+	return p.toolSet
+}
+
+// tools returns the tool definitions to offer the model
+func (p *ClaudeProvider) tools(toolSet ToolSet) []ClaudeTool {
+	if toolSet == nil {
+		return nil
+	}
+
+	var tools []ClaudeTool
+	for _, tool := range toolSet.Tools() {
+		tools = append(tools, ClaudeTool{
+			Name:        tool.QualifiedName(),
+			Description: tool.Description,
+			InputSchema: tool.InputSchema,
+		})
+	}
+	return tools
+}
+
 // SendMessage sends a message to Claude API
 func (p *ClaudeProvider) SendMessage(ctx context.Context, message string) (string, error) {
 	messages := []ClaudeMessage{
@@ -152,24 +213,79 @@ func (p *ClaudeProvider) SendMessageWithSession(ctx context.Context, message str
 	return response, nil
 }
 
-// sendRequest sends a request to Claude API
+// sendRequest sends a request to Claude API, running any tools the model
+// calls and returning its final text response
 func (p *ClaudeProvider) sendRequest(ctx context.Context, messages []ClaudeMessage) (string, error) {
-	request := ClaudeRequest{
-		Model:     p.config.Model,
-		MaxTokens: p.config.MaxTokens,
-		Messages:  messages,
+	toolSet := p.currentToolSet()
+	tools := p.tools(toolSet)
+
+	// Tool rounds extend a copy so callers' histories only keep the final answer
+	messages = append([]ClaudeMessage(nil), messages...)
+
+	for round := 0; ; round++ {
+		claudeResp, err := p.post(ctx, ClaudeRequest{
+			Model:     p.config.Model,
+			MaxTokens: p.config.MaxTokens,
+			Messages:  messages,
+			Tools:     tools,
+		})
+		if err != nil {
+			return "", err
+		}
+
+		if claudeResp.StopReason != "tool_use" || round >= maxToolRounds {
+			if len(claudeResp.Content) == 0 {
+				return "", fmt.Errorf("empty response content from Claude API")
+			}
+
+			var response strings.Builder
+			for _, block := range claudeResp.Content {
+				if block.Type == "text" {
+					response.WriteString(block.Text)
+				}
+			}
+
+			p.logger.Debug("Received response from Claude API",
+				"chars", response.Len(), "inputTokens", claudeResp.Usage.InputTokens, "outputTokens", claudeResp.Usage.OutputTokens)
+			return response.String(), nil
+		}
+
+		var results []ClaudeContentBlock
+		for _, block := range claudeResp.Content {
+			if block.Type != "tool_use" {
+				continue
+			}
+
+			p.logger.Debug("Calling tool", "tool", block.Name)
+			output, err := toolSet.CallTool(ctx, block.Name, block.Input)
+			result := ClaudeContentBlock{Type: "tool_result", ToolUseID: block.ID, Content: output}
+			if err != nil {
+				result.Content = err.Error()
+				result.IsError = true
+			}
+			results = append(results, result)
+		}
+
+		messages = append(messages,
+			ClaudeMessage{Role: "assistant", Content: claudeResp.Content},
+			ClaudeMessage{Role: "user", Content: results},
+		)
 	}
+}
 
+// post sends a single request to Claude API
+func (p *ClaudeProvider) post(ctx context.Context, request ClaudeRequest) (*ClaudeAPIResponse, error) {
 	jsonData, err := json.Marshal(request)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	p.logger.Printf("Sending request to Claude API (model: %s, messages: %d)", p.config.Model, len(messages))
+	p.logger.Debug("Sending request to Claude API",
+		"model", p.config.Model, "messages", len(request.Messages), "tools", len(request.Tools))
 
-	req, err := http.NewRequestWithContext(ctx, "POST", claudeAPIURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", p.apiURL, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -178,33 +294,112 @@ func (p *ClaudeProvider) sendRequest(ctx context.Context, messages []ClaudeMessa
 
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("Claude API error (status %d): %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("Claude API error (status %d): %s", resp.StatusCode, string(body))
 	}
 
 	var claudeResp ClaudeAPIResponse
 	if err := json.Unmarshal(body, &claudeResp); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	if len(claudeResp.Content) == 0 {
-		return "", fmt.Errorf("empty response content from Claude API")
+	return &claudeResp, nil
+}
+
+// StreamMessage sends a message to Claude API and streams the response tokens
+func (p *ClaudeProvider) StreamMessage(ctx context.Context, message string, onToken func(string)) (string, error) {
+	// Tool rounds need whole responses, so with tools the answer arrives as one chunk
+	if len(p.tools(p.currentToolSet())) > 0 {
+		response, err := p.SendMessage(ctx, message)
+		if err == nil && onToken != nil {
+			onToken(response)
+		}
+		return response, err
 	}
 
-	response := claudeResp.Content[0].Text
-	p.logger.Printf("Received response from Claude API (%d chars, %d input tokens, %d output tokens)",
-		len(response), claudeResp.Usage.InputTokens, claudeResp.Usage.OutputTokens)
+	request := ClaudeRequest{
+		Model:     p.config.Model,
+		MaxTokens: p.config.MaxTokens,
+		Messages:  []ClaudeMessage{{Role: "user", Content: message}},
+		Stream:    true,
+	}
 
-	return response, nil
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	p.logger.Debug("Streaming request to Claude API", "model", p.config.Model)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.apiURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", p.config.APIKey)
+	req.Header.Set("anthropic-version", "2023-06-01")
+
+	// Streams can outlive the client timeout; rely on ctx for cancellation instead
+	client := &http.Client{Transport: p.httpClient.Transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("Claude API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var response strings.Builder
+	err = readSSEData(resp.Body, func(data string) (bool, error) {
+		var event struct {
+			Type  string `json:"type"`
+			Delta struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"delta"`
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return false, nil // Ignore keep-alives and unknown payloads
+		}
+
+		switch event.Type {
+		case "content_block_delta":
+			if event.Delta.Text != "" {
+				response.WriteString(event.Delta.Text)
+				if onToken != nil {
+					onToken(event.Delta.Text)
+				}
+			}
+		case "message_stop":
+			return true, nil
+		case "error":
+			return true, fmt.Errorf("Claude API stream error: %s", event.Error.Message)
+		}
+		return false, nil
+	})
+	if err != nil {
+		return response.String(), err
+	}
+
+	p.logger.Debug("Received streamed response from Claude API", "chars", response.Len())
+
+	return response.String(), nil
 }
 
 // GetProviderName returns the provider name
@@ -220,6 +415,6 @@ func (p *ClaudeProvider) Close() error {
 	// Clear all sessions
 	p.sessions = make(map[string]*ClaudeSession)
 
-	p.logger.Println("Claude API provider closed")
+	p.logger.Debug("Claude API provider closed")
 	return nil
 }
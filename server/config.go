@@ -3,14 +3,18 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
 )
 
 // Config represents the application configuration
 type Config struct {
-	IssueTracker IssueTrackerConfig `json:"issue_tracker"`
-	LLMs         LLMConfig          `json:"llms"`
+	IssueTracker  IssueTrackerConfig         `json:"issue_tracker"`
+	LLMs          LLMConfig                  `json:"llms"`
+	Queue         QueueConfig                `json:"queue"`
+	Runner        RunnerConfig               `json:"runner"`
+	Agents        AgentsConfig               `json:"agents"`
+	Board         BoardConfig                `json:"board"`
+	Notifications NotificationsConfig        `json:"notifications"`
+	MCPServers    map[string]MCPServerConfig `json:"mcpServers,omitempty"`
 }
 
 // IssueTrackerConfig contains issue tracker settings
@@ -34,39 +38,78 @@ type LLMConfig struct {
 	Executing LLMProviderConfig `json:"executing"` // Executing provider configuration
 }
 
-// ConfigManager manages application configuration
-type ConfigManager struct {
-	config   Config
-	dataFile string
+// QueueConfig contains job queue settings
+type QueueConfig struct {
+	MaxConcurrent int `json:"max_concurrent"` // Jobs allowed to run at once for this project
 }
 
-// NewConfigManager creates a new config manager
-func NewConfigManager(projectPath string) (*ConfigManager, error) {
-	configFile := filepath.Join(projectPath, ".relay", "config.json")
+// AgentsConfig contains settings for headless agents running in parallel
+type AgentsConfig struct {
+	MaxConcurrent int `json:"max_concurrent"` // Agents allowed to run at once for this project
+}
 
-	// Ensure the .relay directory exists
-	relayDir := filepath.Dir(configFile)
-	if err := os.MkdirAll(relayDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create config directory: %w", err)
-	}
+// RunnerConfig contains settings for headless issue runs (relay run-issue)
+type RunnerConfig struct {
+	BaseBranch     string   `json:"base_branch"`               // Branch worktrees start from and PRs target
+	Checks         []string `json:"checks"`                    // Commands that must pass before committing
+	FixAttempts    int      `json:"fix_attempts"`              // Times the executor may try to fix failing checks
+	Approvals      bool     `json:"approvals"`                 // Pause for human approval at checkpoints
+	ApprovalStages []string `json:"approval_stages,omitempty"` // "plan", "commit", "pr"; all of them when empty
+}
 
-	cm := &ConfigManager{
-		dataFile: configFile,
-		config:   getDefaultConfig(),
+// requiresApproval reports whether a stage is a human checkpoint
+func (c RunnerConfig) requiresApproval(stage RunStage) bool {
+	if len(c.ApprovalStages) == 0 {
+		return stage == RunStagePlan || stage == RunStageCommit || stage == RunStagePR
 	}
-
-	// Load existing config if it exists
-	if err := cm.loadConfig(); err != nil {
-		// If config doesn't exist, save default config
-		if os.IsNotExist(err) {
-			if saveErr := cm.saveConfig(); saveErr != nil {
-				return nil, fmt.Errorf("failed to save default config: %w", saveErr)
-			}
-		} else {
-			return nil, fmt.Errorf("failed to load config: %w", err)
+	for _, s := range c.ApprovalStages {
+		if RunStage(s) == stage {
+			return true
 		}
 	}
+	return false
+}
+
+// BoardConfig defines the columns of the board view
+type BoardConfig struct {
+	Columns       []BoardColumn `json:"columns,omitempty"`        // defaultBoardColumns when empty
+	ProjectOwner  string        `json:"project_owner,omitempty"`  // Owner of the GitHub Projects v2 project; the repository owner when empty
+	ProjectNumber int           `json:"project_number,omitempty"` // GitHub Projects v2 project that status columns use
+	StatusField   string        `json:"status_field,omitempty"`   // Single select field of the project; "Status" when empty
+}
+
+// BoardColumn is a column of the board. An issue matches when it meets every
+// criterion set; a column without criteria takes the remaining open issues.
+type BoardColumn struct {
+	Name   string `json:"name"`
+	Label  string `json:"label,omitempty"`  // Issues with this label
+	State  string `json:"state,omitempty"`  // "open", "closed", or how far the issue got: "branch", "worktree", "pushed", "pr_open", "pr_merged"
+	Status string `json:"status,omitempty"` // Value of the project's status field
+}
+
+// ConfigManager manages a project's configuration: the merge of its
+// settings layers (see Settings)
+type ConfigManager struct {
+	config   Config
+	settings *Settings
+}
 
+// NewConfigManager loads the settings of a project; db holds its machine-local
+// settings and may be nil to leave them out
+func NewConfigManager(project *Project, db *Database) (*ConfigManager, error) {
+	relayDir, err := relayHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	settings, err := LoadSettings(relayDir, project, db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	cm := &ConfigManager{settings: settings}
+	if err := cm.reload(); err != nil {
+		return nil, err
+	}
 	return cm, nil
 }
 
@@ -97,55 +140,20 @@ func getDefaultConfig() Config {
 				Options:   make(map[string]string),
 			},
 		},
+		Queue: QueueConfig{
+			MaxConcurrent: 1,
+		},
+		Runner: RunnerConfig{
+			BaseBranch:  "main",
+			FixAttempts: 1,
+			Approvals:   true,
+		},
+		Agents: AgentsConfig{
+			MaxConcurrent: 2,
+		},
 	}
 }
 
-// loadConfig loads configuration from file
-func (cm *ConfigManager) loadConfig() error {
-	data, err := os.ReadFile(cm.dataFile)
-	if err != nil {
-		return err
-	}
-
-	// Try to unmarshal with the new format first
-	if err := json.Unmarshal(data, &cm.config); err != nil {
-		// If that fails, try to load with the old format and migrate
-		var oldConfig struct {
-			IssueTracker IssueTrackerConfig `json:"issue_tracker"`
-			LLMs         struct {
-				Planning  string `json:"planning"`
-				Executing string `json:"executing"`
-			} `json:"llms"`
-		}
-
-		if err := json.Unmarshal(data, &oldConfig); err != nil {
-			return fmt.Errorf("failed to parse config (tried both new and old formats): %w", err)
-		}
-
-		// Migrate old format to new format
-		cm.config.IssueTracker = oldConfig.IssueTracker
-		cm.config.LLMs = LLMConfig{
-			Planning: LLMProviderConfig{
-				Type:      migrateLLMType(oldConfig.LLMs.Planning),
-				Model:     "claude-3-5-sonnet-20241022",
-				MaxTokens: 4096,
-				Options:   make(map[string]string),
-			},
-			Executing: LLMProviderConfig{
-				Type:      migrateLLMType(oldConfig.LLMs.Executing),
-				Model:     "claude-3-5-sonnet-20241022",
-				MaxTokens: 4096,
-				Options:   make(map[string]string),
-			},
-		}
-
-		// Save the migrated config
-		return cm.saveConfig()
-	}
-
-	return nil
-}
-
 // migrateLLMType converts old LLM type strings to new format
 func migrateLLMType(oldType string) string {
 	switch oldType {
@@ -160,14 +168,31 @@ func migrateLLMType(oldType string) string {
 	}
 }
 
-// saveConfig saves configuration to file
-func (cm *ConfigManager) saveConfig() error {
-	data, err := json.MarshalIndent(cm.config, "", "  ")
+// reload merges the settings layers again after a change
+func (cm *ConfigManager) reload() error {
+	config, err := cm.settings.Config()
+	if err != nil {
+		return err
+	}
+	cm.config = config
+	return nil
+}
+
+// update sets a key in one layer of the settings
+func (cm *ConfigManager) update(layer SettingsLayer, key string, value interface{}) error {
+	raw, err := json.Marshal(value)
 	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
+		return fmt.Errorf("failed to marshal %s: %w", key, err)
 	}
+	if err := cm.settings.set(layer, key, raw); err != nil {
+		return err
+	}
+	return cm.reload()
+}
 
-	return os.WriteFile(cm.dataFile, data, 0644)
+// Settings returns the layers the configuration is merged from
+func (cm *ConfigManager) Settings() *Settings {
+	return cm.settings
 }
 
 // GetConfig returns the current configuration
@@ -177,56 +202,48 @@ func (cm *ConfigManager) GetConfig() Config {
 
 // UpdateLLMPlanning updates the planning LLM setting
 func (cm *ConfigManager) UpdateLLMPlanning(config LLMProviderConfig) error {
-	cm.config.LLMs.Planning = config
-	return cm.saveConfig()
+	return cm.update(LayerProject, "llms.planning", config)
 }
 
 // UpdateLLMExecuting updates the executing LLM setting
 func (cm *ConfigManager) UpdateLLMExecuting(config LLMProviderConfig) error {
-	cm.config.LLMs.Executing = config
-	return cm.saveConfig()
+	return cm.update(LayerProject, "llms.executing", config)
 }
 
 // UpdateLLMPlanningType updates just the planning LLM type
 func (cm *ConfigManager) UpdateLLMPlanningType(providerType string) error {
-	cm.config.LLMs.Planning.Type = providerType
-	return cm.saveConfig()
+	return cm.update(LayerProject, "llms.planning.type", providerType)
 }
 
 // UpdateLLMExecutingType updates just the executing LLM type
 func (cm *ConfigManager) UpdateLLMExecutingType(providerType string) error {
-	cm.config.LLMs.Executing.Type = providerType
-	return cm.saveConfig()
+	return cm.update(LayerProject, "llms.executing.type", providerType)
 }
 
 // UpdateIssueTracker updates the issue tracker setting
 func (cm *ConfigManager) UpdateIssueTracker(provider string) error {
-	cm.config.IssueTracker.Provider = provider
-	return cm.saveConfig()
+	return cm.update(LayerProject, "issue_tracker.provider", provider)
 }
 
-// UpdateGitHubRepository updates the GitHub repository setting
+// UpdateGitHubRepository records the repository detected from the git
+// remote; it is machine-local, since the remote is
 func (cm *ConfigManager) UpdateGitHubRepository(repo string) error {
-	cm.config.IssueTracker.GitHub.Repository = repo
-	return cm.saveConfig()
+	return cm.update(LayerLocal, "issue_tracker.github.repository", repo)
 }
 
 // UpdateGitHubSyncDirection updates the GitHub sync direction
 func (cm *ConfigManager) UpdateGitHubSyncDirection(direction string) error {
-	cm.config.IssueTracker.GitHub.SyncDirection = direction
-	return cm.saveConfig()
+	return cm.update(LayerProject, "issue_tracker.github.sync_direction", direction)
 }
 
 // UpdateGitHubAutoSync updates the GitHub auto-sync setting
 func (cm *ConfigManager) UpdateGitHubAutoSync(enabled bool) error {
-	cm.config.IssueTracker.GitHub.AutoSync = enabled
-	return cm.saveConfig()
+	return cm.update(LayerProject, "issue_tracker.github.auto_sync", enabled)
 }
 
-// UpdateGitHubLastSyncedAt updates the last synced timestamp
+// UpdateGitHubLastSyncedAt records when this machine last synced
 func (cm *ConfigManager) UpdateGitHubLastSyncedAt(timestamp string) error {
-	cm.config.IssueTracker.GitHub.LastSyncedAt = timestamp
-	return cm.saveConfig()
+	return cm.update(LayerLocal, "issue_tracker.github.last_synced_at", timestamp)
 }
 
 // GetGitHubConfig returns the GitHub configuration
@@ -2,6 +2,7 @@ package main
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -30,6 +31,11 @@ func NewDatabase() (*Database, error) {
 	// Database file path
 	dbPath := filepath.Join(relayDir, "relay.db")
 
+	return openDatabase(dbPath)
+}
+
+// openDatabase opens (and migrates) the SQLite database at the given path
+func openDatabase(dbPath string) (*Database, error) {
 	conn, err := sql.Open("sqlite3", dbPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
@@ -89,6 +95,96 @@ func (db *Database) initSchema() error {
 		return fmt.Errorf("failed to create active_project table: %w", err)
 	}
 
+	// Create jobs table (persistent command queue)
+	jobsSchema := `
+	CREATE TABLE IF NOT EXISTS jobs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		project TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		action TEXT,
+		input TEXT,
+		issue_number INTEGER DEFAULT 0,
+		depends_on INTEGER DEFAULT 0,
+		condition TEXT,
+		status TEXT NOT NULL,
+		output TEXT,
+		error TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		started_at DATETIME,
+		finished_at DATETIME
+	);`
+
+	if _, err := db.conn.Exec(jobsSchema); err != nil {
+		return fmt.Errorf("failed to create jobs table: %w", err)
+	}
+
+	// Create job_summaries table (cached spoken summaries per verbosity)
+	summariesSchema := `
+	CREATE TABLE IF NOT EXISTS job_summaries (
+		job_id INTEGER NOT NULL,
+		verbosity TEXT NOT NULL,
+		spoken TEXT NOT NULL,
+		detail TEXT,
+		source TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (job_id, verbosity),
+		FOREIGN KEY (job_id) REFERENCES jobs(id)
+	);`
+
+	if _, err := db.conn.Exec(summariesSchema); err != nil {
+		return fmt.Errorf("failed to create job_summaries table: %w", err)
+	}
+
+	// Create issue_plans table (one step plan per issue)
+	plansSchema := `
+	CREATE TABLE IF NOT EXISTS issue_plans (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		project TEXT NOT NULL,
+		issue_number INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (project, issue_number)
+	);`
+
+	if _, err := db.conn.Exec(plansSchema); err != nil {
+		return fmt.Errorf("failed to create issue_plans table: %w", err)
+	}
+
+	// Create plan_steps table (ordered steps with their progress)
+	stepsSchema := `
+	CREATE TABLE IF NOT EXISTS plan_steps (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		plan_id INTEGER NOT NULL,
+		position INTEGER NOT NULL,
+		goal TEXT NOT NULL,
+		files TEXT,
+		acceptance TEXT,
+		status TEXT NOT NULL,
+		output TEXT,
+		error TEXT,
+		started_at DATETIME,
+		finished_at DATETIME,
+		FOREIGN KEY (plan_id) REFERENCES issue_plans(id)
+	);`
+
+	if _, err := db.conn.Exec(stepsSchema); err != nil {
+		return fmt.Errorf("failed to create plan_steps table: %w", err)
+	}
+
+	// Create repl_history table (inputs typed in the REPL, per project)
+	historySchema := `
+	CREATE TABLE IF NOT EXISTS repl_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		project TEXT NOT NULL,
+		input TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_repl_history_project ON repl_history (project, id);`
+
+	if _, err := db.conn.Exec(historySchema); err != nil {
+		return fmt.Errorf("failed to create repl_history table: %w", err)
+	}
+
 	return nil
 }
 
@@ -225,6 +321,74 @@ func (db *Database) RemoveProject(name string) error {
 	return nil
 }
 
+// GetProjectSetting returns a setting of a project, or "" if it is not set
+func (db *Database) GetProjectSetting(projectName, key string) (string, error) {
+	query := `
+	SELECT s.setting_value
+	FROM project_settings s JOIN projects p ON p.id = s.project_id
+	WHERE p.name = ? AND s.setting_key = ?`
+
+	var value sql.NullString
+	err := db.conn.QueryRow(query, projectName, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get project setting: %w", err)
+	}
+	return value.String, nil
+}
+
+// SetProjectSetting stores a setting of a project
+func (db *Database) SetProjectSetting(projectName, key, value string) error {
+	query := `
+	INSERT INTO project_settings (project_id, setting_key, setting_value)
+	SELECT id, ?, ? FROM projects WHERE name = ?
+	ON CONFLICT(project_id, setting_key) DO UPDATE SET setting_value = excluded.setting_value`
+
+	if _, err := db.conn.Exec(query, key, value, projectName); err != nil {
+		return fmt.Errorf("failed to set project setting: %w", err)
+	}
+	return nil
+}
+
+// ListProjectSettings returns the settings of a project whose keys start with prefix
+func (db *Database) ListProjectSettings(projectName, prefix string) (map[string]string, error) {
+	query := `
+	SELECT s.setting_key, s.setting_value
+	FROM project_settings s JOIN projects p ON p.id = s.project_id
+	WHERE p.name = ? AND substr(s.setting_key, 1, length(?)) = ?`
+
+	rows, err := db.conn.Query(query, projectName, prefix, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project settings: %w", err)
+	}
+	defer rows.Close()
+
+	settings := make(map[string]string)
+	for rows.Next() {
+		var key string
+		var value sql.NullString
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan project setting: %w", err)
+		}
+		settings[key] = value.String
+	}
+	return settings, rows.Err()
+}
+
+// DeleteProjectSetting removes a setting of a project
+func (db *Database) DeleteProjectSetting(projectName, key string) error {
+	query := `
+	DELETE FROM project_settings
+	WHERE setting_key = ? AND project_id IN (SELECT id FROM projects WHERE name = ?)`
+
+	if _, err := db.conn.Exec(query, key, projectName); err != nil {
+		return fmt.Errorf("failed to delete project setting: %w", err)
+	}
+	return nil
+}
+
 func (db *Database) SetActiveProject(projectID int) error {
 	// Use INSERT OR REPLACE to ensure only one active project
 	query := `INSERT OR REPLACE INTO active_project (id, project_id) VALUES (1, ?)`
@@ -273,6 +437,365 @@ func (db *Database) GetActiveProject() (*Project, error) {
 	return &project, nil
 }
 
+// InsertJob stores a new job and assigns its ID
+func (db *Database) InsertJob(job *Job) error {
+	query := `
+	INSERT INTO jobs (project, kind, action, input, issue_number, depends_on, condition, status, output, error, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := db.conn.Exec(query, job.Project, string(job.Kind), job.Action, job.Input, job.IssueNumber,
+		job.DependsOn, string(job.Condition), string(job.Status), job.Output, job.Error, job.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert job: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get job id: %w", err)
+	}
+	job.ID = id
+
+	return nil
+}
+
+// UpdateJob persists the mutable fields of a job
+func (db *Database) UpdateJob(job *Job) error {
+	query := `
+	UPDATE jobs SET status = ?, output = ?, error = ?, started_at = ?, finished_at = ?
+	WHERE id = ?`
+
+	_, err := db.conn.Exec(query, string(job.Status), job.Output, job.Error, job.StartedAt, job.FinishedAt, job.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update job %d: %w", job.ID, err)
+	}
+	return nil
+}
+
+// ListJobs returns the most recent jobs, optionally filtered by project
+func (db *Database) ListJobs(project string, limit int) ([]*Job, error) {
+	query := `
+	SELECT id, project, kind, action, input, issue_number, depends_on, condition, status, output, error, created_at, started_at, finished_at
+	FROM jobs
+	WHERE (? = '' OR project = ?)
+	ORDER BY id DESC
+	LIMIT ?`
+
+	if limit <= 0 {
+		limit = 100
+	}
+
+	rows, err := db.conn.Query(query, project, project, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// ListUnfinishedJobs returns queued and running jobs in creation order
+func (db *Database) ListUnfinishedJobs() ([]*Job, error) {
+	query := `
+	SELECT id, project, kind, action, input, issue_number, depends_on, condition, status, output, error, created_at, started_at, finished_at
+	FROM jobs
+	WHERE status IN (?, ?)
+	ORDER BY id ASC`
+
+	rows, err := db.conn.Query(query, string(JobQueued), string(JobRunning))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unfinished jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// GetJob retrieves a single job by ID
+func (db *Database) GetJob(id int64) (*Job, error) {
+	query := `
+	SELECT id, project, kind, action, input, issue_number, depends_on, condition, status, output, error, created_at, started_at, finished_at
+	FROM jobs
+	WHERE id = ?`
+
+	job, err := scanJob(db.conn.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("job %d not found", id)
+	}
+	return job, err
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanJob(row rowScanner) (*Job, error) {
+	var job Job
+	var kind, condition, status string
+	var action, input, output, errText sql.NullString
+	var startedAt, finishedAt sql.NullTime
+
+	err := row.Scan(
+		&job.ID,
+		&job.Project,
+		&kind,
+		&action,
+		&input,
+		&job.IssueNumber,
+		&job.DependsOn,
+		&condition,
+		&status,
+		&output,
+		&errText,
+		&job.CreatedAt,
+		&startedAt,
+		&finishedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to scan job: %w", err)
+	}
+
+	job.Kind = JobKind(kind)
+	job.Condition = DependencyCondition(condition)
+	job.Status = JobStatus(status)
+	job.Action = action.String
+	job.Input = input.String
+	job.Output = output.String
+	job.Error = errText.String
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+
+	return &job, nil
+}
+
+// SaveJobSummary stores (or replaces) a job's summary for its verbosity
+func (db *Database) SaveJobSummary(summary *Summary) error {
+	query := `
+	INSERT OR REPLACE INTO job_summaries (job_id, verbosity, spoken, detail, source, created_at)
+	VALUES (?, ?, ?, ?, ?, ?)`
+
+	_, err := db.conn.Exec(query, summary.JobID, string(summary.Verbosity), summary.Spoken, summary.Detail, summary.Source, summary.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save job summary: %w", err)
+	}
+
+	return nil
+}
+
+// GetJobSummary returns a cached summary, or nil if there is none
+func (db *Database) GetJobSummary(jobID int64, verbosity SummaryVerbosity) (*Summary, error) {
+	query := `
+	SELECT job_id, verbosity, spoken, detail, source, created_at
+	FROM job_summaries
+	WHERE job_id = ? AND verbosity = ?`
+
+	var summary Summary
+	var detail sql.NullString
+	err := db.conn.QueryRow(query, jobID, string(verbosity)).Scan(
+		&summary.JobID, &summary.Verbosity, &summary.Spoken, &detail, &summary.Source, &summary.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job summary: %w", err)
+	}
+
+	summary.Detail = detail.String
+	return &summary, nil
+}
+
+// SavePlan stores a plan and replaces its steps, assigning IDs
+func (db *Database) SavePlan(plan *Plan) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	plan.UpdatedAt = time.Now()
+	if plan.CreatedAt.IsZero() {
+		plan.CreatedAt = plan.UpdatedAt
+	}
+
+	query := `
+	INSERT INTO issue_plans (project, issue_number, created_at, updated_at)
+	VALUES (?, ?, ?, ?)
+	ON CONFLICT (project, issue_number) DO UPDATE SET updated_at = excluded.updated_at`
+
+	if _, err := tx.Exec(query, plan.Project, plan.IssueNumber, plan.CreatedAt, plan.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save plan: %w", err)
+	}
+	if err := tx.QueryRow(`SELECT id FROM issue_plans WHERE project = ? AND issue_number = ?`,
+		plan.Project, plan.IssueNumber).Scan(&plan.ID); err != nil {
+		return fmt.Errorf("failed to get plan id: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM plan_steps WHERE plan_id = ?`, plan.ID); err != nil {
+		return fmt.Errorf("failed to clear plan steps: %w", err)
+	}
+
+	stepQuery := `
+	INSERT INTO plan_steps (plan_id, position, goal, files, acceptance, status, output, error, started_at, finished_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	plan.Renumber()
+	for _, step := range plan.Steps {
+		files, _ := json.Marshal(step.Files)
+		result, err := tx.Exec(stepQuery, plan.ID, step.Position, step.Goal, string(files), step.Acceptance,
+			string(step.Status), step.Output, step.Error, step.StartedAt, step.FinishedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save plan step: %w", err)
+		}
+		if step.ID, err = result.LastInsertId(); err != nil {
+			return fmt.Errorf("failed to get plan step id: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit plan: %w", err)
+	}
+	return nil
+}
+
+// GetPlan returns an issue's plan with its steps in order, or nil if there is none
+func (db *Database) GetPlan(project string, issueNumber int) (*Plan, error) {
+	query := `
+	SELECT id, project, issue_number, created_at, updated_at
+	FROM issue_plans
+	WHERE project = ? AND issue_number = ?`
+
+	var plan Plan
+	err := db.conn.QueryRow(query, project, issueNumber).Scan(
+		&plan.ID, &plan.Project, &plan.IssueNumber, &plan.CreatedAt, &plan.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	stepQuery := `
+	SELECT id, position, goal, files, acceptance, status, output, error, started_at, finished_at
+	FROM plan_steps
+	WHERE plan_id = ?
+	ORDER BY position ASC`
+
+	rows, err := db.conn.Query(stepQuery, plan.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list plan steps: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		step, err := scanPlanStep(rows)
+		if err != nil {
+			return nil, err
+		}
+		plan.Steps = append(plan.Steps, step)
+	}
+
+	return &plan, nil
+}
+
+// UpdatePlanStep persists the progress of a step
+func (db *Database) UpdatePlanStep(step *PlanStep) error {
+	query := `
+	UPDATE plan_steps SET status = ?, output = ?, error = ?, started_at = ?, finished_at = ?
+	WHERE id = ?`
+
+	_, err := db.conn.Exec(query, string(step.Status), step.Output, step.Error, step.StartedAt, step.FinishedAt, step.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update plan step %d: %w", step.ID, err)
+	}
+	return nil
+}
+
+func scanPlanStep(row rowScanner) (*PlanStep, error) {
+	var step PlanStep
+	var status string
+	var files, acceptance, output, errText sql.NullString
+	var startedAt, finishedAt sql.NullTime
+
+	err := row.Scan(&step.ID, &step.Position, &step.Goal, &files, &acceptance, &status,
+		&output, &errText, &startedAt, &finishedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan plan step: %w", err)
+	}
+
+	step.Status = PlanStepStatus(status)
+	step.Acceptance = acceptance.String
+	step.Output = output.String
+	step.Error = errText.String
+	if files.String != "" {
+		json.Unmarshal([]byte(files.String), &step.Files)
+	}
+	if startedAt.Valid {
+		step.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		step.FinishedAt = &finishedAt.Time
+	}
+
+	return &step, nil
+}
+
+// AddHistoryEntry records an input typed in a project's REPL
+func (db *Database) AddHistoryEntry(project, input string) error {
+	query := `INSERT INTO repl_history (project, input, created_at) VALUES (?, ?, ?)`
+	if _, err := db.conn.Exec(query, project, input, time.Now()); err != nil {
+		return fmt.Errorf("failed to add history entry: %w", err)
+	}
+	return nil
+}
+
+// ListHistory returns the last limit inputs of a project's REPL, oldest first
+func (db *Database) ListHistory(project string, limit int) ([]string, error) {
+	query := `
+	SELECT input FROM (
+		SELECT id, input FROM repl_history WHERE project = ? ORDER BY id DESC LIMIT ?
+	) ORDER BY id ASC`
+
+	rows, err := db.conn.Query(query, project, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []string
+	for rows.Next() {
+		var input string
+		if err := rows.Scan(&input); err != nil {
+			return nil, fmt.Errorf("failed to scan history entry: %w", err)
+		}
+		history = append(history, input)
+	}
+	return history, rows.Err()
+}
+
 func (db *Database) Close() error {
 	if db.conn != nil {
 		return db.conn.Close()
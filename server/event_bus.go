@@ -0,0 +1,89 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types published on the EventBus
+const (
+	EventJobUpdated   = "job.updated"
+	EventLLMToken     = "llm.token"
+	EventLLMDone      = "llm.done"
+	EventIssueCreated = "issue.created"
+	EventIssueUpdated = "issue.updated"
+	EventIssueClosed  = "issue.closed"
+	EventRunUpdated   = "run.updated"
+	EventRunLog       = "run.log"
+)
+
+// Event is a single notification streamed to API clients
+type Event struct {
+	Type    string      `json:"type"`
+	Project string      `json:"project,omitempty"`
+	Time    time.Time   `json:"time"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// EventBus fans events out to subscribers. Slow subscribers drop events rather than block publishers.
+type EventBus struct {
+	mu          sync.RWMutex
+	subscribers map[int]chan Event
+	nextID      int
+}
+
+// NewEventBus creates an empty event bus
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subscribers: make(map[int]chan Event),
+	}
+}
+
+// Subscribe returns a channel of events and a function that unsubscribes it
+func (b *EventBus) Subscribe(buffer int) (<-chan Event, func()) {
+	if buffer <= 0 {
+		buffer = 64
+	}
+
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	ch := make(chan Event, buffer)
+	b.subscribers[id] = ch
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, id)
+			close(ch)
+			b.mu.Unlock()
+		})
+	}
+}
+
+// Publish sends an event to every subscriber
+func (b *EventBus) Publish(eventType, project string, data interface{}) {
+	if b == nil {
+		return
+	}
+
+	event := Event{
+		Type:    eventType,
+		Project: project,
+		Time:    time.Now(),
+		Data:    data,
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// Subscriber is not keeping up; drop rather than stall the publisher
+		}
+	}
+}
@@ -3,20 +3,23 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
+	"os/exec"
+	"strings"
 )
 
 type GitOperations struct {
+	projectName string
 	projectPath string
 	llmProvider LLMProvider
-	logger      *log.Logger
+	logger      *slog.Logger
 }
 
-func NewGitOperations(projectPath string, llmProvider LLMProvider) (*GitOperations, error) {
-	logger := log.New(os.Stdout, "[GitOps] ", log.LstdFlags)
+func NewGitOperations(projectName, projectPath string, llmProvider LLMProvider) (*GitOperations, error) {
+	logger := componentLogger("GitOps")
 
 	return &GitOperations{
+		projectName: projectName,
 		projectPath: projectPath,
 		llmProvider: llmProvider,
 		logger:      logger,
@@ -24,7 +27,20 @@ func NewGitOperations(projectPath string, llmProvider LLMProvider) (*GitOperatio
 }
 
 func (g *GitOperations) SmartCommit() error {
-	g.logger.Println("Starting smart commit process...")
+	response, err := g.RunSmartCommit(context.Background())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Smart commit result:\n%s\n", response)
+
+	return nil
+}
+
+// RunSmartCommit performs a smart commit and returns the LLM's summary
+func (g *GitOperations) RunSmartCommit(ctx context.Context) (string, error) {
+	g.logger.Info("Starting smart commit")
+	g.checkpoint(ctx, "smart commit")
 
 	// Use Claude to analyze changes and create a commit
 	command := `Analyze the current git changes in this repository and create an appropriate commit. 
@@ -39,19 +55,51 @@ func (g *GitOperations) SmartCommit() error {
 	
 	Do not ask for confirmation - proceed with the commit.`
 
-	response, err := g.llmProvider.SendMessage(context.Background(), command)
+	response, err := g.llmProvider.SendMessage(g.auditContext(ctx), command)
 	if err != nil {
-		return fmt.Errorf("failed to execute smart commit via Claude: %w", err)
+		return "", fmt.Errorf("failed to execute smart commit via Claude: %w", err)
 	}
 
-	g.logger.Printf("Smart commit response: %s", response)
-	fmt.Printf("Smart commit result:\n%s\n", response)
+	g.logger.Debug("Smart commit finished", "response", response)
 
-	return nil
+	return response, nil
+}
+
+// auditContext records the provider's git work against this project,
+// filling in only what the caller's scope leaves empty
+func (g *GitOperations) auditContext(ctx context.Context) context.Context {
+	scope := auditScopeFrom(ctx)
+	if scope.Project == "" {
+		scope.Project = g.projectName
+	}
+	if scope.Actor == "" {
+		scope.Actor = AuditActorUser
+	}
+	return WithAuditScope(ctx, scope)
+}
+
+// checkpoint snapshots the worktree before the LLM changes it; a failure is
+// logged rather than blocking the operation
+func (g *GitOperations) checkpoint(ctx context.Context, trigger string) {
+	if _, err := CreateCheckpoint(ctx, g.projectPath, trigger); err != nil {
+		g.logger.Warn("Could not create checkpoint", "error", err)
+	}
 }
 
 func (g *GitOperations) Push(branch string) error {
-	g.logger.Printf("Starting push to branch: %s", branch)
+	response, err := g.RunPush(context.Background(), branch)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Push result:\n%s\n", response)
+
+	return nil
+}
+
+// RunPush pushes the current branch and returns the LLM's summary
+func (g *GitOperations) RunPush(ctx context.Context, branch string) (string, error) {
+	g.logger.Info("Starting push", "branch", branch)
 
 	var command string
 	if branch == "" {
@@ -60,19 +108,31 @@ func (g *GitOperations) Push(branch string) error {
 		command = fmt.Sprintf("Push the current branch to the remote repository on branch '%s'.", branch)
 	}
 
-	response, err := g.llmProvider.SendMessage(context.Background(), command)
+	response, err := g.llmProvider.SendMessage(g.auditContext(ctx), command)
 	if err != nil {
-		return fmt.Errorf("failed to execute push via Claude: %w", err)
+		return "", fmt.Errorf("failed to execute push via Claude: %w", err)
 	}
 
-	g.logger.Printf("Push response: %s", response)
-	fmt.Printf("Push result:\n%s\n", response)
+	g.logger.Debug("Push finished", "response", response)
 
-	return nil
+	return response, nil
 }
 
 func (g *GitOperations) SmartCommitAndPush() error {
-	g.logger.Println("Starting smart commit and push process...")
+	response, err := g.RunSmartCommitAndPush(context.Background())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Smart commit and push result:\n%s\n", response)
+
+	return nil
+}
+
+// RunSmartCommitAndPush commits and pushes in one step and returns the LLM's summary
+func (g *GitOperations) RunSmartCommitAndPush(ctx context.Context) (string, error) {
+	g.logger.Info("Starting smart commit and push")
+	g.checkpoint(ctx, "smart commit and push")
 
 	// Use Claude to analyze, commit, and push in one operation
 	command := `Analyze the current git changes, create an appropriate commit, and push to the remote repository.
@@ -88,23 +148,22 @@ func (g *GitOperations) SmartCommitAndPush() error {
 	
 	Do not ask for confirmation - proceed with the commit and push.`
 
-	response, err := g.llmProvider.SendMessage(context.Background(), command)
+	response, err := g.llmProvider.SendMessage(g.auditContext(ctx), command)
 	if err != nil {
-		return fmt.Errorf("failed to execute smart commit and push via Claude: %w", err)
+		return "", fmt.Errorf("failed to execute smart commit and push via Claude: %w", err)
 	}
 
-	g.logger.Printf("Smart commit and push response: %s", response)
-	fmt.Printf("Smart commit and push result:\n%s\n", response)
+	g.logger.Debug("Smart commit and push finished", "response", response)
 
-	return nil
+	return response, nil
 }
 
 func (g *GitOperations) ListBranches() (string, error) {
-	g.logger.Println("Listing git branches...")
+	g.logger.Info("Listing git branches")
 
 	command := "List all git branches (local and remote) and show which one is currently active."
 
-	response, err := g.llmProvider.SendMessage(context.Background(), command)
+	response, err := g.llmProvider.SendMessage(g.auditContext(context.Background()), command)
 	if err != nil {
 		return "", fmt.Errorf("failed to list branches via Claude: %w", err)
 	}
@@ -113,7 +172,7 @@ func (g *GitOperations) ListBranches() (string, error) {
 }
 
 func (g *GitOperations) DeleteBranch(branchName string, force bool) error {
-	g.logger.Printf("Deleting local branch: %s (force: %v)", branchName, force)
+	g.logger.Info("Deleting local branch", "branch", branchName, "force", force)
 
 	var command string
 	if force {
@@ -122,26 +181,26 @@ func (g *GitOperations) DeleteBranch(branchName string, force bool) error {
 		command = fmt.Sprintf("Delete the local git branch '%s' using 'git branch -d %s'", branchName, branchName)
 	}
 
-	response, err := g.llmProvider.SendMessage(context.Background(), command)
+	response, err := g.llmProvider.SendMessage(g.auditContext(context.Background()), command)
 	if err != nil {
 		return fmt.Errorf("failed to delete branch via Claude: %w", err)
 	}
 
-	g.logger.Printf("Delete branch response: %s", response)
+	g.logger.Debug("Deleted local branch", "response", response)
 	return nil
 }
 
 func (g *GitOperations) DeleteRemoteBranch(branchName string) error {
-	g.logger.Printf("Deleting remote branch: %s", branchName)
+	g.logger.Info("Deleting remote branch", "branch", branchName)
 
 	command := fmt.Sprintf("Delete the remote git branch '%s' using 'git push origin --delete %s'", branchName, branchName)
 
-	response, err := g.llmProvider.SendMessage(context.Background(), command)
+	response, err := g.llmProvider.SendMessage(g.auditContext(context.Background()), command)
 	if err != nil {
 		return fmt.Errorf("failed to delete remote branch via Claude: %w", err)
 	}
 
-	g.logger.Printf("Delete remote branch response: %s", response)
+	g.logger.Debug("Deleted remote branch", "response", response)
 	return nil
 }
 
@@ -150,4 +209,74 @@ func (g *GitOperations) Close() error {
 		return g.llmProvider.Close()
 	}
 	return nil
-}
\ No newline at end of file
+}
+// GitStatus is a parsed summary of `git status --porcelain -b`
+type GitStatus struct {
+	Branch   string          `json:"branch"`
+	Upstream string          `json:"upstream,omitempty"`
+	Ahead    int             `json:"ahead"`
+	Behind   int             `json:"behind"`
+	Files    []GitStatusFile `json:"files"`
+	Clean    bool            `json:"clean"`
+}
+
+// GitStatusFile is a single changed path in the working tree
+type GitStatusFile struct {
+	Path     string `json:"path"`
+	Index    string `json:"index"`
+	Worktree string `json:"worktree"`
+}
+
+// WorkingTreeStatus reads the branch and changed files directly from git, without the LLM
+func (g *GitOperations) WorkingTreeStatus(ctx context.Context) (*GitStatus, error) {
+	cmd := exec.CommandContext(ctx, "git", "status", "--porcelain", "-b")
+	cmd.Dir = g.projectPath
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git status: %w", err)
+	}
+
+	return parseGitStatus(string(output)), nil
+}
+
+// parseGitStatus parses porcelain v1 output with the -b branch header
+func parseGitStatus(output string) *GitStatus {
+	status := &GitStatus{Files: []GitStatusFile{}}
+
+	for _, line := range strings.Split(output, "\n") {
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "## ") {
+			header := strings.TrimPrefix(line, "## ")
+			if idx := strings.Index(header, " ["); idx != -1 {
+				tracking := strings.Trim(header[idx+1:], "[]")
+				header = header[:idx]
+				for _, part := range strings.Split(tracking, ", ") {
+					fmt.Sscanf(part, "ahead %d", &status.Ahead)
+					fmt.Sscanf(part, "behind %d", &status.Behind)
+				}
+			}
+			if branch, upstream, ok := strings.Cut(header, "..."); ok {
+				status.Branch = branch
+				status.Upstream = upstream
+			} else {
+				status.Branch = strings.TrimPrefix(header, "No commits yet on ")
+			}
+			continue
+		}
+
+		if len(line) < 4 {
+			continue
+		}
+		status.Files = append(status.Files, GitStatusFile{
+			Path:     line[3:],
+			Index:    strings.TrimSpace(line[:1]),
+			Worktree: strings.TrimSpace(line[1:2]),
+		})
+	}
+
+	status.Clean = len(status.Files) == 0
+	return status
+}
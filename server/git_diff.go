@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The diff and log views read git's output directly: diffs are parsed into
+// files and hunks so a single hunk can be staged or unstaged with git apply.
+
+// DiffMode is what a diff view compares
+type DiffMode int
+
+const (
+	DiffUnstaged DiffMode = iota // Working tree against the index
+	DiffStaged                   // Index against HEAD
+	DiffBranch                   // HEAD against where it left the base branch
+	DiffCommit                   // One commit against its parent
+)
+
+func (m DiffMode) String() string {
+	switch m {
+	case DiffUnstaged:
+		return "unstaged"
+	case DiffStaged:
+		return "staged"
+	case DiffBranch:
+		return "branch"
+	case DiffCommit:
+		return "commit"
+	}
+	return "unknown"
+}
+
+// DiffHunk is one @@ section of a file's diff
+type DiffHunk struct {
+	Header   string   // The @@ line
+	Lines    []string // Context, added and removed lines with their prefix
+	OldStart int
+	NewStart int
+}
+
+// DiffFile is the diff of one file
+type DiffFile struct {
+	OldPath string
+	NewPath string
+	Header  []string // From "diff --git" up to the first hunk
+	Hunks   []DiffHunk
+	Binary  bool
+}
+
+// Path returns the file's path, or its old path when it was deleted
+func (f DiffFile) Path() string {
+	if f.NewPath == "" || f.NewPath == "/dev/null" {
+		return f.OldPath
+	}
+	return f.NewPath
+}
+
+// Stats counts the added and removed lines
+func (f DiffFile) Stats() (added, removed int) {
+	for _, hunk := range f.Hunks {
+		for _, line := range hunk.Lines {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				added++
+			case strings.HasPrefix(line, "-"):
+				removed++
+			}
+		}
+	}
+	return added, removed
+}
+
+// HunkPatch returns a patch with the file header and only hunk i, for git apply
+func (f DiffFile) HunkPatch(i int) string {
+	lines := append(append([]string{}, f.Header...), f.Hunks[i].Header)
+	lines = append(lines, f.Hunks[i].Lines...)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// parseUnifiedDiff splits git's unified diff output into files and hunks
+func parseUnifiedDiff(diff string) []DiffFile {
+	var files []DiffFile
+	var file *DiffFile
+	var hunk *DiffHunk
+
+	flush := func() {
+		if file == nil {
+			return
+		}
+		if hunk != nil {
+			file.Hunks = append(file.Hunks, *hunk)
+			hunk = nil
+		}
+		files = append(files, *file)
+		file = nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			file = &DiffFile{Header: []string{line}}
+			// Paths from the header, for files without --- and +++ lines
+			if a, b, ok := strings.Cut(strings.TrimPrefix(line, "diff --git "), " b/"); ok {
+				file.OldPath, file.NewPath = strings.TrimPrefix(a, "a/"), b
+			}
+		case file == nil:
+			// Text before the first file, such as a commit message
+		case hunkHeaderPattern.MatchString(line):
+			if hunk != nil {
+				file.Hunks = append(file.Hunks, *hunk)
+			}
+			match := hunkHeaderPattern.FindStringSubmatch(line)
+			oldStart, _ := strconv.Atoi(match[1])
+			newStart, _ := strconv.Atoi(match[2])
+			hunk = &DiffHunk{Header: line, OldStart: oldStart, NewStart: newStart}
+		case hunk != nil:
+			hunk.Lines = append(hunk.Lines, line)
+		default:
+			file.Header = append(file.Header, line)
+			switch {
+			case strings.HasPrefix(line, "--- "):
+				file.OldPath = strings.TrimPrefix(strings.TrimPrefix(line, "--- "), "a/")
+			case strings.HasPrefix(line, "+++ "):
+				file.NewPath = strings.TrimPrefix(strings.TrimPrefix(line, "+++ "), "b/")
+			case strings.HasPrefix(line, "Binary files "):
+				file.Binary = true
+			}
+		}
+	}
+	flush()
+	return files
+}
+
+// gitRead runs a read-only git command in dir
+func gitRead(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return string(output), nil
+}
+
+// LoadDiff returns the diff of a repository or worktree. base is the branch
+// DiffBranch compares against and commit the commit DiffCommit shows.
+func LoadDiff(ctx context.Context, dir string, mode DiffMode, base, commit string) ([]DiffFile, error) {
+	args := []string{"--no-color", "--no-ext-diff"}
+	switch mode {
+	case DiffUnstaged:
+		args = append([]string{"diff"}, args...)
+	case DiffStaged:
+		args = append([]string{"diff", "--cached"}, args...)
+	case DiffBranch:
+		args = append([]string{"diff"}, append(args, base+"...HEAD")...)
+	case DiffCommit:
+		args = append([]string{"show", "--format="}, append(args, commit)...)
+	}
+	output, err := gitRead(ctx, dir, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseUnifiedDiff(output), nil
+}
+
+// ApplyHunk stages hunk i of a file from the unstaged diff or, with
+// unstage, takes it out of the index using the staged diff
+func ApplyHunk(ctx context.Context, dir string, file DiffFile, i int, unstage bool) error {
+	args := []string{"apply", "--cached", "--whitespace=nowarn"}
+	if unstage {
+		args = append(args, "--reverse")
+	}
+	cmd := exec.CommandContext(ctx, "git", append(args, "-")...)
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(file.HunkPatch(i))
+	if output, err := auditedCombinedOutput(ctx, cmd); err != nil {
+		return fmt.Errorf("failed to apply hunk to the index: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// CommitEntry is a commit in the log view
+type CommitEntry struct {
+	Hash    string
+	Short   string
+	Author  string
+	Date    time.Time
+	Subject string
+}
+
+// LoadLog lists up to limit commits reachable from revisions, newest first;
+// no revisions means HEAD
+func LoadLog(ctx context.Context, dir string, limit int, revisions ...string) ([]CommitEntry, error) {
+	args := append([]string{"log", "--no-color", fmt.Sprintf("--max-count=%d", limit), "--format=%H%x1f%h%x1f%an%x1f%at%x1f%s"}, revisions...)
+	output, err := gitRead(ctx, dir, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []CommitEntry
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 5 {
+			continue
+		}
+		seconds, _ := strconv.ParseInt(fields[3], 10, 64)
+		commits = append(commits, CommitEntry{
+			Hash:    fields[0],
+			Short:   fields[1],
+			Author:  fields[2],
+			Date:    time.Unix(seconds, 0),
+			Subject: fields[4],
+		})
+	}
+	return commits, nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestParseUnifiedDiff(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@ package main
+ package main
+-var x = 1
++var x = 2
+ // end
+@@ -10,2 +10,3 @@ func f() {
+ 	a()
++	b()
+ }
+diff --git a/logo.png b/logo.png
+new file mode 100644
+index 0000000..3333333
+Binary files /dev/null and b/logo.png differ
+diff --git a/old.txt b/old.txt
+deleted file mode 100644
+index 4444444..0000000
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-gone
+`
+	files := parseUnifiedDiff(diff)
+	if len(files) != 3 {
+		t.Fatalf("Got %d files, want 3", len(files))
+	}
+
+	code := files[0]
+	if code.Path() != "main.go" || len(code.Hunks) != 2 || len(code.Header) != 4 {
+		t.Errorf("main.go: %+v", code)
+	}
+	if code.Hunks[1].OldStart != 10 || code.Hunks[1].NewStart != 10 || len(code.Hunks[1].Lines) != 3 {
+		t.Errorf("Second hunk: %+v", code.Hunks[1])
+	}
+	if added, removed := code.Stats(); added != 2 || removed != 1 {
+		t.Errorf("Stats: +%d -%d, want +2 -1", added, removed)
+	}
+	patch := code.HunkPatch(1)
+	if strings.Contains(patch, "var x") || !strings.HasPrefix(patch, "diff --git") || !strings.HasSuffix(patch, "+\tb()\n }\n") {
+		t.Errorf("Patch of the second hunk:\n%s", patch)
+	}
+
+	if !files[1].Binary || files[1].Path() != "logo.png" || len(files[1].Hunks) != 0 {
+		t.Errorf("logo.png: %+v", files[1])
+	}
+	if files[2].Path() != "old.txt" {
+		t.Errorf("Deleted file path: got %q", files[2].Path())
+	}
+}
+
+// TestApplyHunk stages one of two hunks and unstages it again
+func TestApplyHunk(t *testing.T) {
+	dir := newCheckpointTestRepo(t)
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, "line")
+	}
+	write := func(first, last string) {
+		t.Helper()
+		content := append(append([]string{first}, lines[1:19]...), last)
+		if err := os.WriteFile(filepath.Join(dir, "main.txt"), []byte(strings.Join(content, "\n")+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("line", "line")
+	commitAll(t, dir, "twenty lines")
+	write("first", "last")
+
+	ctx := context.Background()
+	files, err := LoadDiff(ctx, dir, DiffUnstaged, "", "")
+	if err != nil || len(files) != 1 || len(files[0].Hunks) != 2 {
+		t.Fatalf("Unstaged diff: %+v, %v", files, err)
+	}
+	if err := ApplyHunk(ctx, dir, files[0], 1, false); err != nil {
+		t.Fatal(err)
+	}
+
+	staged, err := LoadDiff(ctx, dir, DiffStaged, "", "")
+	if err != nil || len(staged) != 1 || len(staged[0].Hunks) != 1 || !strings.Contains(strings.Join(staged[0].Hunks[0].Lines, "\n"), "+last") {
+		t.Fatalf("Staged diff should hold the last hunk: %+v, %v", staged, err)
+	}
+	if unstaged, _ := LoadDiff(ctx, dir, DiffUnstaged, "", ""); len(unstaged) != 1 || len(unstaged[0].Hunks) != 1 {
+		t.Errorf("Unstaged diff should hold the first hunk: %+v", unstaged)
+	}
+
+	if err := ApplyHunk(ctx, dir, staged[0], 0, true); err != nil {
+		t.Fatal(err)
+	}
+	if staged, _ := LoadDiff(ctx, dir, DiffStaged, "", ""); len(staged) != 0 {
+		t.Errorf("Nothing should be staged: %+v", staged)
+	}
+}
+
+func TestLoadLogAndBranchDiff(t *testing.T) {
+	dir := newCheckpointTestRepo(t)
+	runTestGit(t, dir, "branch", "-M", "main")
+	runTestGit(t, dir, "checkout", "--quiet", "-b", "feature/issue-1")
+	if err := os.WriteFile(filepath.Join(dir, "feature.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	commitAll(t, dir, "add feature")
+
+	ctx := context.Background()
+	commits, err := LoadLog(ctx, dir, 10, "main..HEAD")
+	if err != nil || len(commits) != 1 || commits[0].Subject != "add feature" || commits[0].Author != "Test" {
+		t.Fatalf("Log: %+v, %v", commits, err)
+	}
+	if all, _ := LoadLog(ctx, dir, 10); len(all) != 2 {
+		t.Errorf("Full log: got %d commits, want 2", len(all))
+	}
+
+	for _, mode := range []DiffMode{DiffBranch, DiffCommit} {
+		files, err := LoadDiff(ctx, dir, mode, "main", commits[0].Hash)
+		if err != nil || len(files) != 1 || files[0].Path() != "feature.go" {
+			t.Errorf("%s diff: %+v, %v", mode, files, err)
+		}
+	}
+}
+
+func TestDiffModel(t *testing.T) {
+	files := parseUnifiedDiff(`diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1 +1 @@
+-func old() {}
++func new() {}
+@@ -9 +9 @@
+-x
++y
+diff --git a/b.py b/b.py
+--- a/b.py
++++ b/b.py
+@@ -1 +1 @@
+-# old
++# new
+`)
+	m := NewDiffModel(DiffData{Dir: "/repo", Title: "demo", Back: ViewREPL})
+	m.width, m.height = 100, 40
+	m, _ = m.Update(issueListActionMsg{label: "Loading diff"})
+	m, _ = m.Update(diffLoadedMsg{opID: m.op.id, files: files})
+
+	view := m.View()
+	if !strings.Contains(view, "File 1/2") || !strings.Contains(view, "a.go") || !strings.Contains(view, "func new() {}") {
+		t.Errorf("Diff view:\n%s", view)
+	}
+
+	m, _ = m.Update(runes("n"))
+	if m.hunk != 1 {
+		t.Errorf("Next hunk: got %d, want 1", m.hunk)
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if m.file != 1 || m.hunk != 0 || !strings.Contains(m.View(), "b.py") {
+		t.Errorf("Next file: file %d, hunk %d", m.file, m.hunk)
+	}
+
+	// Unstaging needs the staged diff
+	m, cmd := m.Update(runes("u"))
+	if cmd != nil || !strings.Contains(m.err, "staged diff") {
+		t.Errorf("Unstaging in the unstaged diff: %q", m.err)
+	}
+	m, cmd = m.Update(runes("m"))
+	if m.mode != DiffStaged || m.op == nil || cmd == nil {
+		t.Errorf("m should load the staged diff, got %s", m.mode)
+	}
+
+	_, cmd = m.Update(runes("x"))
+	if cmd != nil {
+		t.Errorf("Keys other than esc should wait for the load")
+	}
+}
+
+func TestHighlightCode(t *testing.T) {
+	for _, tc := range []struct{ code, ext string }{
+		{`if x := "a // b"; x != "" { return 42 } // done`, "go"},
+		{`def f(): return 'x'  # done`, "py"},
+		{`plain text, no language`, "txt"},
+	} {
+		// Highlighting only adds styling, never changes the text
+		if got := highlightCode(tc.code, tc.ext, ""); got != tc.code {
+			t.Errorf("highlightCode(%q) rendered %q", tc.code, got)
+		}
+	}
+}
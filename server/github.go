@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os/exec"
@@ -13,6 +14,7 @@ import (
 // GitHubService handles GitHub API operations via GitHub CLI
 type GitHubService struct {
 	configManager *ConfigManager
+	projectName   string
 	projectPath   string
 }
 
@@ -23,6 +25,7 @@ type GitHubIssue struct {
 	Body      string     `json:"body"`
 	State     string     `json:"state"` // "open" or "closed"
 	Labels    []string   `json:"labels"`
+	Assignees []string   `json:"assignees"`
 	URL       string     `json:"url"`
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at"`
@@ -30,13 +33,32 @@ type GitHubIssue struct {
 }
 
 // NewGitHubService creates a new GitHub service instance
-func NewGitHubService(configManager *ConfigManager, projectPath string) *GitHubService {
+func NewGitHubService(configManager *ConfigManager, projectName, projectPath string) *GitHubService {
 	return &GitHubService{
 		configManager: configManager,
+		projectName:   projectName,
 		projectPath:   projectPath,
 	}
 }
 
+// auditContext scopes the gh commands run for an issue to this project
+func (gs *GitHubService) auditContext(issueNumber int) context.Context {
+	return gs.scopeContext(context.Background(), issueNumber)
+}
+
+// scopeContext fills in the project and issue of ctx's audit scope where
+// the caller left them empty, keeping the caller's actor
+func (gs *GitHubService) scopeContext(ctx context.Context, issueNumber int) context.Context {
+	scope := auditScopeFrom(ctx)
+	if scope.Project == "" {
+		scope.Project = gs.projectName
+	}
+	if scope.IssueNumber == 0 {
+		scope.IssueNumber = issueNumber
+	}
+	return WithAuditScope(ctx, scope)
+}
+
 // IsAuthenticated checks if GitHub CLI is authenticated
 func (gs *GitHubService) IsAuthenticated() (bool, error) {
 	cmd := exec.Command("gh", "auth", "status")
@@ -100,6 +122,12 @@ func (gs *GitHubService) ValidateRepository(repo string) error {
 
 // ListIssues retrieves all issues from the GitHub repository
 func (gs *GitHubService) ListIssues() ([]Issue, error) {
+	return gs.ListIssuesContext(context.Background())
+}
+
+// ListIssuesContext is ListIssues with a context that stops the gh calls
+// when cancelled
+func (gs *GitHubService) ListIssuesContext(ctx context.Context) ([]Issue, error) {
 	config := gs.configManager.GetGitHubConfig()
 	if config.Repository == "" {
 		return nil, fmt.Errorf("GitHub repository not configured")
@@ -113,10 +141,10 @@ func (gs *GitHubService) ListIssues() ([]Issue, error) {
 	var allIssues []Issue
 	
 	// First, get all open issues
-	openCmd := exec.Command("gh", "issue", "list",
+	openCmd := exec.CommandContext(ctx, "gh", "issue", "list",
 		"--repo", config.Repository,
 		"--state", "open",
-		"--json", "number,title,body,state,labels,url,createdAt,updatedAt,closedAt",
+		"--json", "number,title,body,state,labels,assignees,url,createdAt,updatedAt,closedAt",
 		"--limit", "1000")
 	openCmd.Dir = gs.projectPath
 	
@@ -142,6 +170,7 @@ func (gs *GitHubService) ListIssues() ([]Issue, error) {
 			Body:      githubIssue.Body,
 			State:     githubIssue.State,
 			Labels:    githubIssue.Labels,
+			Assignees: githubIssue.Assignees,
 			CreatedAt: githubIssue.CreatedAt,
 			UpdatedAt: githubIssue.UpdatedAt,
 			ClosedAt:  githubIssue.ClosedAt,
@@ -151,11 +180,11 @@ func (gs *GitHubService) ListIssues() ([]Issue, error) {
 	}
 	
 	// Second, get closed issues from last 24 hours using search
-	closedCmd := exec.Command("gh", "issue", "list",
+	closedCmd := exec.CommandContext(ctx, "gh", "issue", "list",
 		"--repo", config.Repository,
 		"--state", "closed",
 		"--search", fmt.Sprintf("closed:>%s", oneDayAgo),
-		"--json", "number,title,body,state,labels,url,createdAt,updatedAt,closedAt",
+		"--json", "number,title,body,state,labels,assignees,url,createdAt,updatedAt,closedAt",
 		"--limit", "1000")
 	closedCmd.Dir = gs.projectPath
 
@@ -181,6 +210,7 @@ func (gs *GitHubService) ListIssues() ([]Issue, error) {
 			Body:      githubIssue.Body,
 			State:     githubIssue.State,
 			Labels:    githubIssue.Labels,
+			Assignees: githubIssue.Assignees,
 			CreatedAt: githubIssue.CreatedAt,
 			UpdatedAt: githubIssue.UpdatedAt,
 			ClosedAt:  githubIssue.ClosedAt,
@@ -214,7 +244,7 @@ func (gs *GitHubService) CreateIssue(title, body string, labels []string) (int,
 	cmd := exec.Command("gh", args...)
 	cmd.Dir = gs.projectPath
 
-	output, err := cmd.CombinedOutput()
+	output, err := auditedCombinedOutput(gs.auditContext(0), cmd)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create GitHub issue: %s", string(output))
 	}
@@ -245,6 +275,7 @@ func (gs *GitHubService) UpdateIssue(number int, title, body string, state strin
 	}
 
 	issueStr := strconv.Itoa(number)
+	ctx := gs.auditContext(number)
 
 	// Update title and body
 	if title != "" || body != "" {
@@ -259,7 +290,7 @@ func (gs *GitHubService) UpdateIssue(number int, title, body string, state strin
 		cmd := exec.Command("gh", args...)
 		cmd.Dir = gs.projectPath
 
-		if output, err := cmd.CombinedOutput(); err != nil {
+		if output, err := auditedCombinedOutput(ctx, cmd); err != nil {
 			return fmt.Errorf("failed to update issue %d: %s", number, string(output))
 		}
 	}
@@ -270,7 +301,7 @@ func (gs *GitHubService) UpdateIssue(number int, title, body string, state strin
 		args := []string{"issue", "edit", issueStr, "--repo", config.Repository, "--remove-label", "*"}
 		cmd := exec.Command("gh", args...)
 		cmd.Dir = gs.projectPath
-		if _, err := cmd.CombinedOutput(); err != nil {
+		if _, err := auditedCombinedOutput(ctx, cmd); err != nil {
 			// Ignore errors for removing labels as it might fail if no labels exist
 		}
 
@@ -278,7 +309,7 @@ func (gs *GitHubService) UpdateIssue(number int, title, body string, state strin
 		args = []string{"issue", "edit", issueStr, "--repo", config.Repository, "--add-label", strings.Join(labels, ",")}
 		cmd = exec.Command("gh", args...)
 		cmd.Dir = gs.projectPath
-		if output, err := cmd.CombinedOutput(); err != nil {
+		if output, err := auditedCombinedOutput(ctx, cmd); err != nil {
 			return fmt.Errorf("failed to update labels for issue %d: %s", number, string(output))
 		}
 	} else {
@@ -286,7 +317,7 @@ func (gs *GitHubService) UpdateIssue(number int, title, body string, state strin
 		args := []string{"issue", "edit", issueStr, "--repo", config.Repository, "--remove-label", "*"}
 		cmd := exec.Command("gh", args...)
 		cmd.Dir = gs.projectPath
-		if _, err := cmd.CombinedOutput(); err != nil {
+		if _, err := auditedCombinedOutput(ctx, cmd); err != nil {
 			// Ignore errors for removing labels as it might fail if no labels exist
 		}
 	}
@@ -295,13 +326,13 @@ func (gs *GitHubService) UpdateIssue(number int, title, body string, state strin
 	if state == "closed" {
 		cmd := exec.Command("gh", "issue", "close", issueStr, "--repo", config.Repository)
 		cmd.Dir = gs.projectPath
-		if output, err := cmd.CombinedOutput(); err != nil {
+		if output, err := auditedCombinedOutput(ctx, cmd); err != nil {
 			return fmt.Errorf("failed to close issue %d: %s", number, string(output))
 		}
 	} else if state == "open" {
 		cmd := exec.Command("gh", "issue", "reopen", issueStr, "--repo", config.Repository)
 		cmd.Dir = gs.projectPath
-		if output, err := cmd.CombinedOutput(); err != nil {
+		if output, err := auditedCombinedOutput(ctx, cmd); err != nil {
 			return fmt.Errorf("failed to reopen issue %d: %s", number, string(output))
 		}
 	}
@@ -318,7 +349,7 @@ func (gs *GitHubService) GetIssue(number int) (*Issue, error) {
 
 	cmd := exec.Command("gh", "issue", "view", strconv.Itoa(number),
 		"--repo", config.Repository,
-		"--json", "number,title,body,state,labels,url,createdAt,updatedAt,closedAt")
+		"--json", "number,title,body,state,labels,assignees,url,createdAt,updatedAt,closedAt")
 	cmd.Dir = gs.projectPath
 
 	output, err := cmd.Output()
@@ -343,6 +374,7 @@ func (gs *GitHubService) GetIssue(number int) (*Issue, error) {
 		Body:      githubIssue.Body,
 		State:     githubIssue.State,
 		Labels:    githubIssue.Labels,
+		Assignees: githubIssue.Assignees,
 		CreatedAt: githubIssue.CreatedAt,
 		UpdatedAt: githubIssue.UpdatedAt,
 		ClosedAt:  githubIssue.ClosedAt,
@@ -352,6 +384,288 @@ func (gs *GitHubService) GetIssue(number int) (*Issue, error) {
 	return issue, nil
 }
 
+// PullRequest is a pull request as listed by the GitHub CLI
+type PullRequest struct {
+	Number            int                `json:"number"`
+	State             string             `json:"state"` // "OPEN", "CLOSED" or "MERGED"
+	HeadRefName       string             `json:"headRefName"`
+	URL               string             `json:"url"`
+	StatusCheckRollup []PullRequestCheck `json:"statusCheckRollup"`
+}
+
+// PullRequestCheck is a check run (Conclusion, Status) or a commit status
+// (State) of a pull request's head commit
+type PullRequestCheck struct {
+	Name       string `json:"name,omitempty"`
+	Context    string `json:"context,omitempty"`
+	Status     string `json:"status,omitempty"`     // Check runs: "QUEUED", "IN_PROGRESS" or "COMPLETED"
+	Conclusion string `json:"conclusion,omitempty"` // Check runs: "SUCCESS", "FAILURE", "CANCELLED", ...
+	State      string `json:"state,omitempty"`      // Commit statuses: "SUCCESS", "FAILURE", "ERROR" or "PENDING"
+}
+
+// ChecksState sums up the checks: "FAILURE" if any failed, else "PENDING"
+// if any is still running, else "SUCCESS"; empty without checks
+func (pr PullRequest) ChecksState() string {
+	if len(pr.StatusCheckRollup) == 0 {
+		return ""
+	}
+	state := "SUCCESS"
+	for _, check := range pr.StatusCheckRollup {
+		result := check.Conclusion
+		if result == "" {
+			result = check.State
+		}
+		switch result {
+		case "FAILURE", "ERROR", "TIMED_OUT", "CANCELLED", "ACTION_REQUIRED", "STARTUP_FAILURE":
+			return "FAILURE"
+		case "SUCCESS", "NEUTRAL", "SKIPPED":
+		default:
+			// Running, queued or pending
+			state = "PENDING"
+		}
+	}
+	return state
+}
+
+// ListPullRequests retrieves the repository's recent pull requests in any state
+func (gs *GitHubService) ListPullRequests(ctx context.Context) ([]PullRequest, error) {
+	config := gs.configManager.GetGitHubConfig()
+	if config.Repository == "" {
+		return nil, fmt.Errorf("GitHub repository not configured")
+	}
+
+	cmd := exec.CommandContext(ctx, "gh", "pr", "list",
+		"--repo", config.Repository,
+		"--state", "all",
+		"--json", "number,state,headRefName,url,statusCheckRollup",
+		"--limit", "200")
+	cmd.Dir = gs.projectPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch GitHub pull requests: %w", err)
+	}
+
+	var pullRequests []PullRequest
+	if err := json.Unmarshal(output, &pullRequests); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub pull requests JSON: %w", err)
+	}
+	return pullRequests, nil
+}
+
+// OpenCounts returns how many issues and pull requests of the repository are
+// open. Without a configured repository it uses the one of the git remote.
+func (gs *GitHubService) OpenCounts(ctx context.Context) (issues, pullRequests int, err error) {
+	repo := gs.configManager.GetGitHubConfig().Repository
+	if repo == "" {
+		if repo, err = gs.DetectRepository(); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	count := func(kind string) (int, error) {
+		cmd := exec.CommandContext(ctx, "gh", kind, "list",
+			"--repo", repo,
+			"--state", "open",
+			"--json", "number",
+			"--limit", "1000")
+		cmd.Dir = gs.projectPath
+		output, err := cmd.Output()
+		if err != nil {
+			return 0, fmt.Errorf("failed to list open GitHub %ss: %w", kind, err)
+		}
+		var numbers []struct{}
+		if err := json.Unmarshal(output, &numbers); err != nil {
+			return 0, fmt.Errorf("failed to parse GitHub %s list JSON: %w", kind, err)
+		}
+		return len(numbers), nil
+	}
+
+	if issues, err = count("issue"); err != nil {
+		return 0, 0, err
+	}
+	if pullRequests, err = count("pr"); err != nil {
+		return 0, 0, err
+	}
+	return issues, pullRequests, nil
+}
+
+// EditLabels adds and removes labels of an issue, leaving its other labels alone
+func (gs *GitHubService) EditLabels(ctx context.Context, number int, add, remove []string) error {
+	config := gs.configManager.GetGitHubConfig()
+	if config.Repository == "" {
+		return fmt.Errorf("GitHub repository not configured")
+	}
+	if len(add) == 0 && len(remove) == 0 {
+		return nil
+	}
+
+	args := []string{"issue", "edit", strconv.Itoa(number), "--repo", config.Repository}
+	if len(add) > 0 {
+		args = append(args, "--add-label", strings.Join(add, ","))
+	}
+	if len(remove) > 0 {
+		args = append(args, "--remove-label", strings.Join(remove, ","))
+	}
+	cmd := exec.CommandContext(ctx, "gh", args...)
+	cmd.Dir = gs.projectPath
+
+	if output, err := auditedCombinedOutput(gs.scopeContext(ctx, number), cmd); err != nil {
+		return fmt.Errorf("failed to update labels for issue %d: %s", number, string(output))
+	}
+	return nil
+}
+
+// ProjectItem is an issue's card in a GitHub Projects v2 project
+type ProjectItem struct {
+	ID          string
+	IssueNumber int
+	Status      string // Value of the project's status field
+}
+
+// projectOwner returns owner, or the repository owner when it is empty
+func (gs *GitHubService) projectOwner(owner string) (string, error) {
+	if owner != "" {
+		return owner, nil
+	}
+	repository := gs.configManager.GetGitHubConfig().Repository
+	if repoOwner, _, ok := strings.Cut(repository, "/"); ok {
+		return repoOwner, nil
+	}
+	return "", fmt.Errorf("GitHub repository not configured")
+}
+
+// ListProjectItems retrieves the repository's issues in a GitHub Projects v2
+// project with the value of their status field
+func (gs *GitHubService) ListProjectItems(ctx context.Context, owner string, project int, statusField string) ([]ProjectItem, error) {
+	owner, err := gs.projectOwner(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, "gh", "project", "item-list", strconv.Itoa(project),
+		"--owner", owner,
+		"--format", "json",
+		"--limit", "1000")
+	cmd.Dir = gs.projectPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch GitHub project items: %w", err)
+	}
+
+	var result struct {
+		Items []map[string]interface{} `json:"items"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub project items JSON: %w", err)
+	}
+
+	// Field values are keyed by the lowercased field name
+	statusKey := strings.ToLower(statusField)
+	repository := gs.configManager.GetGitHubConfig().Repository
+	var items []ProjectItem
+	for _, raw := range result.Items {
+		content, _ := raw["content"].(map[string]interface{})
+		number, ok := content["number"].(float64)
+		if !ok || content["type"] != "Issue" {
+			continue
+		}
+		if repo, ok := content["repository"].(string); ok && repository != "" && !strings.EqualFold(repo, repository) {
+			continue
+		}
+		item := ProjectItem{IssueNumber: int(number)}
+		item.ID, _ = raw["id"].(string)
+		item.Status, _ = raw[statusKey].(string)
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// SetProjectStatus sets the status field of an issue's card in a GitHub
+// Projects v2 project, adding the issue to the project if needed
+func (gs *GitHubService) SetProjectStatus(ctx context.Context, owner string, project int, statusField string, issue Issue, status string) error {
+	owner, err := gs.projectOwner(owner)
+	if err != nil {
+		return err
+	}
+	projectStr := strconv.Itoa(project)
+	ghJSON := func(target interface{}, args ...string) error {
+		cmd := exec.CommandContext(ctx, "gh", args...)
+		cmd.Dir = gs.projectPath
+		output, err := cmd.Output()
+		if err != nil {
+			return fmt.Errorf("gh %s %s failed: %w", args[0], args[1], err)
+		}
+		return json.Unmarshal(output, target)
+	}
+
+	var view struct {
+		ID string `json:"id"`
+	}
+	if err := ghJSON(&view, "project", "view", projectStr, "--owner", owner, "--format", "json"); err != nil {
+		return fmt.Errorf("failed to find GitHub project %d: %w", project, err)
+	}
+
+	var fields struct {
+		Fields []struct {
+			ID      string `json:"id"`
+			Name    string `json:"name"`
+			Options []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"options"`
+		} `json:"fields"`
+	}
+	if err := ghJSON(&fields, "project", "field-list", projectStr, "--owner", owner, "--format", "json"); err != nil {
+		return fmt.Errorf("failed to list fields of GitHub project %d: %w", project, err)
+	}
+	var fieldID, optionID string
+	for _, field := range fields.Fields {
+		if strings.EqualFold(field.Name, statusField) {
+			fieldID = field.ID
+			for _, option := range field.Options {
+				if strings.EqualFold(option.Name, status) {
+					optionID = option.ID
+				}
+			}
+		}
+	}
+	if fieldID == "" {
+		return fmt.Errorf("GitHub project %d has no field %q", project, statusField)
+	}
+	if optionID == "" {
+		return fmt.Errorf("field %q of GitHub project %d has no option %q", statusField, project, status)
+	}
+
+	// item-add returns the existing item when the issue is already in the
+	// project. It changes the project, so unlike the lookups it is audited.
+	ctx = gs.scopeContext(ctx, issue.Number)
+	cmd := exec.CommandContext(ctx, "gh", "project", "item-add", projectStr, "--owner", owner, "--url", issue.URL, "--format", "json")
+	cmd.Dir = gs.projectPath
+	output, err := auditedOutput(ctx, cmd)
+	if err != nil {
+		return fmt.Errorf("failed to add issue #%d to GitHub project %d: %w", issue.Number, project, err)
+	}
+	var item struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(output, &item); err != nil {
+		return fmt.Errorf("failed to add issue #%d to GitHub project %d: %w", issue.Number, project, err)
+	}
+
+	cmd = exec.CommandContext(ctx, "gh", "project", "item-edit",
+		"--id", item.ID,
+		"--project-id", view.ID,
+		"--field-id", fieldID,
+		"--single-select-option-id", optionID)
+	cmd.Dir = gs.projectPath
+	if output, err := auditedCombinedOutput(ctx, cmd); err != nil {
+		return fmt.Errorf("failed to set %s of issue #%d: %s", statusField, issue.Number, string(output))
+	}
+	return nil
+}
+
 // AddComment adds a comment to a GitHub issue
 func (gs *GitHubService) AddComment(number int, comment string) error {
 	config := gs.configManager.GetGitHubConfig()
@@ -364,7 +678,7 @@ func (gs *GitHubService) AddComment(number int, comment string) error {
 		"--body", comment)
 	cmd.Dir = gs.projectPath
 
-	output, err := cmd.CombinedOutput()
+	output, err := auditedCombinedOutput(gs.auditContext(number), cmd)
 	if err != nil {
 		return fmt.Errorf("failed to add comment to GitHub issue #%d: %s", number, string(output))
 	}
@@ -414,6 +728,17 @@ func (gs *GitHubService) parseGitHubIssue(raw map[string]interface{}) (GitHubIss
 		}
 	}
 
+	// Parse assignees
+	if assigneesRaw, ok := raw["assignees"].([]interface{}); ok {
+		for _, assigneeRaw := range assigneesRaw {
+			if assigneeMap, ok := assigneeRaw.(map[string]interface{}); ok {
+				if login, ok := assigneeMap["login"].(string); ok {
+					issue.Assignees = append(issue.Assignees, login)
+				}
+			}
+		}
+	}
+
 	// Parse timestamps
 	if createdStr, ok := raw["createdAt"].(string); ok {
 		if created, err := time.Parse(time.RFC3339, createdStr); err == nil {
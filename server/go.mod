@@ -8,6 +8,8 @@ require (
 	github.com/charmbracelet/bubbletea v1.3.5
 	github.com/charmbracelet/lipgloss v1.1.0
 	github.com/mattn/go-sqlite3 v1.14.18
+	github.com/relay/mcp/shared v0.0.0
+	golang.org/x/sys v0.32.0
 )
 
 require (
@@ -27,6 +29,8 @@ require (
 	github.com/rivo/uniseg v0.4.7 // indirect
 	github.com/xo/terminfo v0.0.0-20220910002029-abceb7e1c41e // indirect
 	golang.org/x/sync v0.13.0 // indirect
-	golang.org/x/sys v0.32.0 // indirect
 	golang.org/x/text v0.3.8 // indirect
 )
+
+// Code shared with the MCP tools, such as the issue plan markers
+replace github.com/relay/mcp/shared => ../mcp/shared
@@ -6,20 +6,14 @@ import (
 	"os"
 	"os/exec"
 	"strings"
-)
 
-// ANSI color codes
-const (
-	ColorReset  = "\033[0m"
-	ColorBlue   = "\033[34m"
-	ColorGreen  = "\033[32m"
-	ColorYellow = "\033[33m"
-	ColorRed    = "\033[31m"
-	ColorBold   = "\033[1m"
-	ColorCyan   = "\033[36m"
-	ColorGray   = "\033[90m"
+	"github.com/charmbracelet/lipgloss"
 )
 
+// boldStyle is for headings in the plain terminal menus; their colors come
+// from the theme
+var boldStyle = lipgloss.NewStyle().Bold(true)
+
 // ANSI control codes
 const (
 	ClearScreen = "\033[2J"
@@ -87,17 +81,14 @@ func (m *InteractiveMenu) Display() {
 	fmt.Print(HideCursor)
 
 	// Title section with consistent formatting
-	fmt.Printf("%s%s%s\n", ColorBold, m.title, ColorReset)
+	fmt.Printf("%s\n", boldStyle.Render(m.title))
 	fmt.Printf("%s\n\n", strings.Repeat("=", len(m.title)))
 
 	// Menu items with consistent left alignment and selection indicator
 	for i, item := range m.items {
 		if i == m.selectedIdx {
 			// Selected item with clear visual indicator
-			fmt.Printf("%s> %s%s\n",
-				ColorBlue,
-				item.Content,
-				ColorReset)
+			fmt.Printf("%s\n", theme.Fg(theme.Selected).Render("> "+item.Content))
 		} else {
 			// Unselected item with consistent spacing
 			fmt.Printf("  %s\n", item.Content)
@@ -106,7 +97,7 @@ func (m *InteractiveMenu) Display() {
 
 	// Help section with aligned columns
 	if m.showHelp {
-		fmt.Printf("\n%sControls:%s\n", ColorBold, ColorReset)
+		fmt.Printf("\n%s\n", boldStyle.Render("Controls:"))
 		fmt.Printf("%s\n", strings.Repeat("-", 9))
 		fmt.Printf("%-8s %s\n", "↑/↓", "Navigate")
 		fmt.Printf("%-8s %s\n", "Enter", "Select item")
@@ -182,7 +173,7 @@ func ConfirmationDialog(message string) (bool, error) {
 	}
 	defer SetSttyCooked()
 
-	fmt.Printf("\n%s%s%s [Y/n]: ", ColorYellow, message, ColorReset)
+	fmt.Printf("\n%s [Y/n]: ", theme.Fg(theme.Warning).Render(message))
 
 	reader := bufio.NewReader(os.Stdin)
 	char, err := reader.ReadByte()
@@ -216,11 +207,11 @@ func NewIssueActionMenu(issue *Issue) *IssueActionMenu {
 func getStatusDisplay(state string) string {
 	switch strings.ToLower(state) {
 	case "open":
-		return ColorGreen + "[OPEN]" + ColorReset
+		return theme.Fg(theme.Success).Render("[OPEN]")
 	case "closed":
-		return ColorGray + "[CLOSED]" + ColorReset
+		return closedStyle.Render("[CLOSED]")
 	default:
-		return ColorCyan + "[" + strings.ToUpper(state) + "]" + ColorReset
+		return theme.Fg(theme.Info).Render("[" + strings.ToUpper(state) + "]")
 	}
 }
 
@@ -234,11 +225,11 @@ func getLabelsDisplay(labels []string) string {
 	for _, label := range labels {
 		switch strings.ToLower(label) {
 		case "enhancement":
-			displayLabels = append(displayLabels, ColorGreen+"[ENHANCEMENT]"+ColorReset)
+			displayLabels = append(displayLabels, theme.Fg(theme.Enhancement).Render("[ENHANCEMENT]"))
 		case "bug":
-			displayLabels = append(displayLabels, ColorRed+"[BUG]"+ColorReset)
+			displayLabels = append(displayLabels, theme.Fg(theme.Bug).Render("[BUG]"))
 		default:
-			displayLabels = append(displayLabels, ColorCyan+"["+strings.ToUpper(label)+"]"+ColorReset)
+			displayLabels = append(displayLabels, theme.Fg(theme.Info).Render("["+strings.ToUpper(label)+"]"))
 		}
 	}
 
@@ -251,7 +242,7 @@ func (m *IssueActionMenu) Display() {
 	fmt.Print(HideCursor)
 
 	// Header section
-	fmt.Printf("%sIssue #%d%s\n", ColorBold, m.issue.Number, ColorReset)
+	fmt.Printf("%s\n", boldStyle.Render(fmt.Sprintf("Issue #%d", m.issue.Number)))
 	fmt.Printf("%s\n\n", strings.Repeat("=", 15))
 
 	// Issue details with fixed-width left column for labels
@@ -266,7 +257,7 @@ func (m *IssueActionMenu) Display() {
 	fmt.Printf("%-*s %s\n", LabelWidth, "Created:", formatRelativeTime(m.issue.CreatedAt))
 
 	// Actions section with aligned columns
-	fmt.Printf("\n%sActions:%s\n", ColorBold, ColorReset)
+	fmt.Printf("\n%s\n", boldStyle.Render("Actions:"))
 	fmt.Printf("%s\n", strings.Repeat("-", 8))
 
 	// Action menu items with consistent key/description alignment
@@ -320,7 +311,7 @@ func TextInput(prompt string) (string, error) {
 	SetSttyCooked()
 	fmt.Print(ShowCursor)
 
-	fmt.Printf("%s%s%s: ", ColorBold, prompt, ColorReset)
+	fmt.Printf("%s: ", boldStyle.Render(prompt))
 
 	reader := bufio.NewReader(os.Stdin)
 	input, err := reader.ReadString('\n')
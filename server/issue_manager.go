@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"sort"
 	"strconv"
 	"strings"
@@ -15,6 +17,7 @@ type Issue struct {
 	Body      string     `json:"body"`       // GitHub issue body/description
 	State     string     `json:"state"`      // "open" or "closed"
 	Labels    []string   `json:"labels"`     // GitHub labels
+	Assignees []string   `json:"assignees"`  // GitHub logins of the assignees
 	CreatedAt time.Time  `json:"created_at"` // GitHub creation timestamp
 	UpdatedAt time.Time  `json:"updated_at"` // GitHub last update timestamp
 	ClosedAt  *time.Time `json:"closed_at"`  // GitHub closure timestamp (null for open issues)
@@ -27,11 +30,13 @@ type IssueManager struct {
 	configManager *ConfigManager
 	gitOperations *GitOperations
 	projectPath   string
+	events        *EventBus
+	projectName   string
 }
 
 // NewIssueManager creates a new IssueManager for the specified project
-func NewIssueManager(projectPath string, configManager *ConfigManager) (*IssueManager, error) {
-	githubService := NewGitHubService(configManager, projectPath)
+func NewIssueManager(projectName, projectPath string, configManager *ConfigManager) (*IssueManager, error) {
+	githubService := NewGitHubService(configManager, projectName, projectPath)
 	
 	// Verify GitHub authentication
 	authenticated, err := githubService.IsAuthenticated()
@@ -60,6 +65,7 @@ func NewIssueManager(projectPath string, configManager *ConfigManager) (*IssueMa
 		configManager: configManager,
 		gitOperations: nil, // Will be set via SetGitOperations
 		projectPath:   projectPath,
+		projectName:   projectName,
 	}, nil
 }
 
@@ -68,6 +74,19 @@ func (im *IssueManager) SetGitOperations(ops *GitOperations) {
 	im.gitOperations = ops
 }
 
+// SetEventBus makes the IssueManager publish issue changes for the given project
+func (im *IssueManager) SetEventBus(events *EventBus, projectName string) {
+	im.events = events
+	im.projectName = projectName
+}
+
+// publish sends an issue event if an event bus is attached
+func (im *IssueManager) publish(eventType string, data interface{}) {
+	if im.events != nil {
+		im.events.Publish(eventType, im.projectName, data)
+	}
+}
+
 // generateBranchName creates a feature branch name for an issue
 func (im *IssueManager) generateBranchName(issueID int) string {
 	return fmt.Sprintf("feature/issue-%d", issueID)
@@ -103,11 +122,21 @@ func (im *IssueManager) checkRemoteBranchExists(branchName string) bool {
 
 // ListIssues returns GitHub issues for the repository (open issues + closed issues from last 24 hours)
 func (im *IssueManager) ListIssues(filterStatus, filterLabel string) []Issue {
-	issues, err := im.githubService.ListIssues()
+	issues, err := im.ListIssuesContext(context.Background(), filterStatus, filterLabel)
 	if err != nil {
-		fmt.Printf("Error fetching issues from GitHub: %v\n", err)
+		slog.Error("Failed to fetch issues from GitHub", "error", err)
 		return []Issue{}
 	}
+	return issues
+}
+
+// ListIssuesContext is ListIssues with a context that cancels the GitHub
+// request, returning the error instead of logging it
+func (im *IssueManager) ListIssuesContext(ctx context.Context, filterStatus, filterLabel string) ([]Issue, error) {
+	issues, err := im.githubService.ListIssuesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	var filteredIssues []Issue
 
@@ -153,7 +182,7 @@ func (im *IssueManager) ListIssues(filterStatus, filterLabel string) []Issue {
 		return filteredIssues[i].Number > filteredIssues[j].Number
 	})
 
-	return filteredIssues
+	return filteredIssues, nil
 }
 
 // AddIssue creates a new GitHub issue
@@ -183,6 +212,8 @@ func (im *IssueManager) AddIssue(title string) (*Issue, error) {
 		return nil, fmt.Errorf("failed to fetch created issue: %w", err)
 	}
 
+	im.publish(EventIssueCreated, issue)
+
 	return issue, nil
 }
 
@@ -213,6 +244,8 @@ func (im *IssueManager) UpdateIssueTitle(number int, title string) error {
 		return fmt.Errorf("failed to update GitHub issue #%d: %w", number, err)
 	}
 
+	im.publish(EventIssueUpdated, map[string]interface{}{"number": number, "title": title})
+
 	return nil
 }
 
@@ -224,6 +257,8 @@ func (im *IssueManager) UpdateIssueBody(number int, body string) error {
 		return fmt.Errorf("failed to update GitHub issue #%d body: %w", number, err)
 	}
 
+	im.publish(EventIssueUpdated, map[string]interface{}{"number": number, "body": body})
+
 	return nil
 }
 
@@ -240,6 +275,8 @@ func (im *IssueManager) UpdateIssueStatus(number int, state string) error {
 		return fmt.Errorf("failed to update GitHub issue #%d state: %w", number, err)
 	}
 
+	im.publish(EventIssueUpdated, map[string]interface{}{"number": number, "state": state})
+
 	return nil
 }
 
@@ -251,6 +288,8 @@ func (im *IssueManager) UpdateIssueLabels(number int, labels []string) error {
 		return fmt.Errorf("failed to update GitHub issue #%d labels: %w", number, err)
 	}
 
+	im.publish(EventIssueUpdated, map[string]interface{}{"number": number, "labels": labels})
+
 	return nil
 }
 
@@ -265,7 +304,7 @@ func (im *IssueManager) DeleteIssue(number int) error {
 	// Add a comment indicating this was meant to be deleted
 	commentErr := im.githubService.AddComment(number, "This issue was marked for deletion and has been closed instead.")
 	if commentErr != nil {
-		fmt.Printf("Warning: Failed to add deletion comment to GitHub issue #%d: %v\n", number, commentErr)
+		slog.Warn(fmt.Sprintf("Failed to add deletion comment to GitHub issue #%d", number), "error", commentErr)
 	}
 	
 	return nil
@@ -298,28 +337,30 @@ func (im *IssueManager) CloseIssue(number int, closeReason string) error {
 	commentErr := im.githubService.AddComment(number, comment)
 	if commentErr != nil {
 		// Log error but don't fail the close operation
-		fmt.Printf("Warning: Failed to add close reason comment to GitHub issue #%d: %v\n", number, commentErr)
+		slog.Warn(fmt.Sprintf("Failed to add close reason comment to GitHub issue #%d", number), "error", commentErr)
 	}
 
+	im.publish(EventIssueClosed, map[string]interface{}{"number": number, "reason": closeReason})
+
 	// Delete feature branch if it exists (and gitOperations is available)
 	if im.gitOperations != nil {
 		branchName := im.generateBranchName(number)
 		
 		// Check if local branch exists and delete it
 		if im.checkBranchExists(branchName) {
-			fmt.Printf("Deleting local feature branch: %s\n", branchName)
+			slog.Info("Deleting local feature branch", "branch", branchName)
 			err := im.gitOperations.DeleteBranch(branchName, true) // Force delete to handle unmerged branches
 			if err != nil {
-				fmt.Printf("Warning: Failed to delete local branch %s: %v\n", branchName, err)
+				slog.Warn("Failed to delete local branch "+branchName, "error", err)
 			}
 		}
 		
 		// Check if remote branch exists and delete it
 		if im.checkRemoteBranchExists(branchName) {
-			fmt.Printf("Deleting remote feature branch: %s\n", branchName)
+			slog.Info("Deleting remote feature branch", "branch", branchName)
 			err := im.gitOperations.DeleteRemoteBranch(branchName)
 			if err != nil {
-				fmt.Printf("Warning: Failed to delete remote branch %s: %v\n", branchName, err)
+				slog.Warn("Failed to delete remote branch "+branchName, "error", err)
 			}
 		}
 	}
@@ -0,0 +1,466 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// progressPollInterval is how often the git directory is checked for
+	// changed refs and worktrees
+	progressPollInterval = 2 * time.Second
+
+	// progressFullRefresh is how often pull requests are refetched even
+	// without local changes, since they change on GitHub
+	progressFullRefresh = time.Minute
+)
+
+// EventIssueProgress is published when the progress of issues changes
+const EventIssueProgress = "issue.progress"
+
+// ProgressState is how far an issue has got, from no branch to a merged PR
+type ProgressState int
+
+const (
+	ProgressNone ProgressState = iota
+	ProgressBranch
+	ProgressWorktree
+	ProgressPushed
+	ProgressPROpen
+	ProgressPRMerged
+)
+
+func (s ProgressState) String() string {
+	switch s {
+	case ProgressBranch:
+		return "branch"
+	case ProgressWorktree:
+		return "worktree"
+	case ProgressPushed:
+		return "pushed"
+	case ProgressPROpen:
+		return "PR open"
+	case ProgressPRMerged:
+		return "PR merged"
+	default:
+		return "no branch"
+	}
+}
+
+// IssueProgress is the branch, worktree and pull request status of an issue
+type IssueProgress struct {
+	HasBranch   bool   `json:"has_branch"`
+	HasWorktree bool   `json:"has_worktree"`
+	Pushed      bool   `json:"pushed"`
+	PRNumber    int    `json:"pr_number,omitempty"`
+	PRState     string `json:"pr_state,omitempty"`  // "OPEN", "CLOSED" or "MERGED"
+	PRChecks    string `json:"pr_checks,omitempty"` // "SUCCESS", "FAILURE" or "PENDING"; empty without checks
+}
+
+// State returns the furthest state the issue has reached
+func (p IssueProgress) State() ProgressState {
+	switch {
+	case p.PRState == "MERGED":
+		return ProgressPRMerged
+	case p.PRState == "OPEN":
+		return ProgressPROpen
+	case p.Pushed:
+		return ProgressPushed
+	case p.HasWorktree:
+		return ProgressWorktree
+	case p.HasBranch:
+		return ProgressBranch
+	default:
+		return ProgressNone
+	}
+}
+
+// InProgress reports whether the issue is being worked on locally: it has a
+// feature branch checked out in a worktree
+func (p IssueProgress) InProgress() bool {
+	return p.HasBranch && p.HasWorktree
+}
+
+// Label describes the state in brackets, with the PR number once there is a
+// pull request; no branch is the empty string
+func (p IssueProgress) Label() string {
+	state := p.State()
+	switch {
+	case state == ProgressNone:
+		return ""
+	case state == ProgressPROpen && p.PRNumber > 0:
+		return fmt.Sprintf("[PR #%d open]", p.PRNumber)
+	case state == ProgressPRMerged && p.PRNumber > 0:
+		return fmt.Sprintf("[PR #%d merged]", p.PRNumber)
+	}
+	return "[" + state.String() + "]"
+}
+
+// Badge renders the label in the state's color for the issue list
+func (p IssueProgress) Badge() string {
+	styles := map[ProgressState]lipgloss.Style{
+		ProgressBranch:   theme.Fg(theme.Help),
+		ProgressWorktree: inProgressStyle,
+		ProgressPushed:   theme.Fg(theme.Info),
+		ProgressPROpen:   theme.Fg(theme.Success),
+		ProgressPRMerged: theme.Fg(theme.Special),
+	}
+	label := p.Label()
+	if label == "" {
+		return ""
+	}
+	return styles[p.State()].Render(label)
+}
+
+var issueBranchPattern = regexp.MustCompile(`(?:^|/)feature/issue-(\d+)$`)
+
+// issueOfRef returns the issue number of a feature branch ref, or 0
+func issueOfRef(ref string) int {
+	match := issueBranchPattern.FindStringSubmatch(ref)
+	if match == nil {
+		return 0
+	}
+	number, _ := strconv.Atoi(match[1])
+	return number
+}
+
+// IssueProgressService computes the progress of every issue in one pass over
+// the project's refs, worktrees and pull requests, and refreshes it in the
+// background when the git directory changes
+type IssueProgressService struct {
+	projectPath  string
+	listPRs      func(ctx context.Context) ([]PullRequest, error) // Nil skips pull requests
+	pollInterval time.Duration
+	events       *EventBus
+	projectName  string
+	notifier     *Notifier
+	logger       *slog.Logger
+
+	mu        sync.RWMutex
+	progress  map[int]IssueProgress
+	updated   time.Time
+	prFetched time.Time
+
+	changes chan struct{}
+	refresh chan struct{}
+	cancel  context.CancelFunc
+	done    chan struct{}
+	closed  chan struct{}
+}
+
+// NewIssueProgressService creates the service for a project; listPRs may be nil
+func NewIssueProgressService(projectPath string, listPRs func(ctx context.Context) ([]PullRequest, error)) *IssueProgressService {
+	return &IssueProgressService{
+		projectPath:  projectPath,
+		listPRs:      listPRs,
+		pollInterval: progressPollInterval,
+		logger:       componentLogger("Progress"),
+		progress:     make(map[int]IssueProgress),
+		changes:      make(chan struct{}, 1),
+		refresh:      make(chan struct{}, 1),
+		closed:       make(chan struct{}),
+	}
+}
+
+// SetEventBus publishes progress changes to API clients
+func (s *IssueProgressService) SetEventBus(events *EventBus, projectName string) {
+	s.events, s.projectName = events, projectName
+}
+
+// SetNotifier sends a notification when the checks of an open pull request fail
+func (s *IssueProgressService) SetNotifier(notifier *Notifier) {
+	s.notifier = notifier
+}
+
+// Get returns the cached progress of an issue
+func (s *IssueProgressService) Get(issueNumber int) IssueProgress {
+	if s == nil {
+		return IssueProgress{}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.progress[issueNumber]
+}
+
+// InProgress returns the issues with a branch and a worktree, in order
+func (s *IssueProgressService) InProgress() []int {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var numbers []int
+	for number, p := range s.progress {
+		if p.InProgress() {
+			numbers = append(numbers, number)
+		}
+	}
+	sort.Ints(numbers)
+	return numbers
+}
+
+// Changes receives a value whenever the cached progress changes
+func (s *IssueProgressService) Changes() <-chan struct{} {
+	return s.changes
+}
+
+// RequestRefresh asks the background loop to refresh soon, including pull requests
+func (s *IssueProgressService) RequestRefresh() {
+	if s == nil {
+		return
+	}
+	select {
+	case s.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// Start refreshes now and then whenever the git directory changes
+func (s *IssueProgressService) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	go s.watch(ctx)
+}
+
+// Close stops the background refresh
+func (s *IssueProgressService) Close() error {
+	if s.cancel != nil {
+		s.cancel()
+		<-s.done
+		s.cancel = nil
+		close(s.closed)
+	}
+	return nil
+}
+
+func (s *IssueProgressService) watch(ctx context.Context) {
+	defer close(s.done)
+
+	gitDir := s.gitDir(ctx)
+	signature := gitDirSignature(gitDir)
+	s.refreshLogged(ctx, true)
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.refresh:
+			signature = gitDirSignature(gitDir)
+			s.refreshLogged(ctx, true)
+		case <-ticker.C:
+			s.mu.RLock()
+			stalePRs := time.Since(s.prFetched) > progressFullRefresh
+			s.mu.RUnlock()
+			if current := gitDirSignature(gitDir); current != signature || stalePRs {
+				signature = current
+				s.refreshLogged(ctx, stalePRs)
+			}
+		}
+	}
+}
+
+func (s *IssueProgressService) refreshLogged(ctx context.Context, withPRs bool) {
+	if err := s.Refresh(ctx, withPRs); err != nil && ctx.Err() == nil {
+		s.logger.Debug("Progress refresh failed", "error", err)
+	}
+}
+
+// gitDir returns the repository's common git directory, shared by its worktrees
+func (s *IssueProgressService) gitDir(ctx context.Context) string {
+	output, err := s.git(ctx, "rev-parse", "--git-common-dir")
+	if err != nil {
+		return ""
+	}
+	dir := strings.TrimSpace(output)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(s.projectPath, dir)
+	}
+	return dir
+}
+
+// gitDirSignature summarises the modification times of the refs, packed refs
+// and worktree metadata, so a change to any of them can be noticed cheaply
+func gitDirSignature(gitDir string) string {
+	if gitDir == "" {
+		return ""
+	}
+	var latest time.Time
+	count := 0
+	note := func(info fs.FileInfo) {
+		count++
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	if info, err := os.Stat(filepath.Join(gitDir, "packed-refs")); err == nil {
+		note(info)
+	}
+	if entries, err := os.ReadDir(filepath.Join(gitDir, "worktrees")); err == nil {
+		for _, entry := range entries {
+			if info, err := entry.Info(); err == nil {
+				note(info)
+			}
+		}
+	}
+	for _, refs := range []string{"refs/heads", "refs/remotes"} {
+		filepath.WalkDir(filepath.Join(gitDir, refs), func(path string, entry fs.DirEntry, err error) error {
+			if err == nil {
+				if info, err := entry.Info(); err == nil {
+					note(info)
+				}
+			}
+			return nil
+		})
+	}
+	return fmt.Sprintf("%d:%d", count, latest.UnixNano())
+}
+
+func (s *IssueProgressService) git(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = s.projectPath
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return string(output), nil
+}
+
+// Refresh recomputes the progress of every issue: one git call for the
+// branches, one for the worktrees and, with withPRs, one gh call for the
+// pull requests
+func (s *IssueProgressService) Refresh(ctx context.Context, withPRs bool) error {
+	progress := make(map[int]IssueProgress)
+	update := func(number int, change func(*IssueProgress)) {
+		if number > 0 {
+			p := progress[number]
+			change(&p)
+			progress[number] = p
+		}
+	}
+
+	refs, err := s.git(ctx, "for-each-ref", "--format=%(refname)", "refs/heads/", "refs/remotes/")
+	if err != nil {
+		return err
+	}
+	for _, ref := range strings.Fields(refs) {
+		if strings.HasPrefix(ref, "refs/heads/") {
+			update(issueOfRef(ref), func(p *IssueProgress) { p.HasBranch = true })
+		} else {
+			update(issueOfRef(ref), func(p *IssueProgress) { p.Pushed = true })
+		}
+	}
+
+	worktrees, err := s.git(ctx, "worktree", "list", "--porcelain")
+	if err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(strings.NewReader(worktrees))
+	for scanner.Scan() {
+		if ref, ok := strings.CutPrefix(scanner.Text(), "branch "); ok {
+			update(issueOfRef(ref), func(p *IssueProgress) { p.HasWorktree = true })
+		}
+	}
+
+	s.mu.RLock()
+	previous := s.progress
+	s.mu.RUnlock()
+
+	fetchedPRs := false
+	prURLs := make(map[int]string)
+	if withPRs && s.listPRs != nil {
+		pullRequests, err := s.listPRs(ctx)
+		if err != nil {
+			s.logger.Debug("Could not list pull requests", "error", err)
+		} else {
+			fetchedPRs = true
+			// Listed newest first, so the latest PR of a branch wins
+			for i := len(pullRequests) - 1; i >= 0; i-- {
+				pr := pullRequests[i]
+				number := issueOfRef(pr.HeadRefName)
+				update(number, func(p *IssueProgress) {
+					p.PRNumber, p.PRState, p.PRChecks = pr.Number, pr.State, pr.ChecksState()
+				})
+				prURLs[number] = pr.URL
+			}
+		}
+	}
+	if !fetchedPRs {
+		// Keep the pull requests from the last fetch
+		for number, p := range previous {
+			if p.PRNumber > 0 {
+				update(number, func(current *IssueProgress) {
+					current.PRNumber, current.PRState, current.PRChecks = p.PRNumber, p.PRState, p.PRChecks
+				})
+			}
+		}
+	}
+
+	changed := len(progress) != len(previous)
+	for number, p := range progress {
+		if previous[number] != p {
+			changed = true
+		}
+	}
+
+	s.mu.Lock()
+	s.progress = progress
+	s.updated = time.Now()
+	firstFetch := s.prFetched.IsZero()
+	if fetchedPRs {
+		s.prFetched = s.updated
+	}
+	s.mu.Unlock()
+
+	// Checks that were already red when relay started are not news
+	if fetchedPRs && !firstFetch {
+		s.notifyFailedChecks(previous, progress, prURLs)
+	}
+
+	if changed {
+		select {
+		case s.changes <- struct{}{}:
+		default:
+		}
+		s.events.Publish(EventIssueProgress, s.projectName, progress)
+	}
+	return nil
+}
+
+// notifyFailedChecks announces the open pull requests whose checks failed
+// since the last fetch
+func (s *IssueProgressService) notifyFailedChecks(previous, current map[int]IssueProgress, urls map[int]string) {
+	for number, p := range current {
+		if p.PRState != "OPEN" || p.PRChecks != "FAILURE" {
+			continue
+		}
+		if before := previous[number]; before.PRNumber == p.PRNumber && before.PRChecks == "FAILURE" {
+			continue
+		}
+		s.notifier.Notify(Notification{
+			Event:   NotifyChecksFailed,
+			Issue:   number,
+			URL:     urls[number],
+			Urgent:  true,
+			Title:   "Checks failed",
+			Message: fmt.Sprintf("Checks of pull request #%d failed", p.PRNumber),
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIssueProgressService(t *testing.T) {
+	dir := newCheckpointTestRepo(t)
+	runTestGit(t, dir, "branch", "feature/issue-3")
+	runTestGit(t, dir, "worktree", "add", "--quiet", filepath.Join(t.TempDir(), "wt"), "-b", "feature/issue-5")
+	runTestGit(t, dir, "branch", "feature/issue-7")
+	runTestGit(t, dir, "update-ref", "refs/remotes/origin/feature/issue-7", "HEAD")
+	runTestGit(t, dir, "branch", "feature/issue-9")
+
+	listPRs := func(ctx context.Context) ([]PullRequest, error) {
+		return []PullRequest{
+			{Number: 12, State: "OPEN", HeadRefName: "feature/issue-9"},
+			{Number: 11, State: "MERGED", HeadRefName: "feature/issue-10"},
+			{Number: 4, State: "CLOSED", HeadRefName: "feature/issue-10"},
+		}, nil
+	}
+	service := NewIssueProgressService(dir, listPRs)
+	if err := service.Refresh(context.Background(), true); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+
+	tests := []struct {
+		issue      int
+		state      ProgressState
+		inProgress bool
+		label      string
+	}{
+		{1, ProgressNone, false, ""},
+		{3, ProgressBranch, false, "[branch]"},
+		{5, ProgressWorktree, true, "[worktree]"},
+		{7, ProgressPushed, false, "[pushed]"},
+		{9, ProgressPROpen, false, "[PR #12 open]"},
+		{10, ProgressPRMerged, false, "[PR #11 merged]"},
+	}
+	for _, tt := range tests {
+		progress := service.Get(tt.issue)
+		if progress.State() != tt.state || progress.InProgress() != tt.inProgress || progress.Label() != tt.label {
+			t.Errorf("Issue #%d: got %v (in progress %v, label %q), want %v (%v, %q)",
+				tt.issue, progress.State(), progress.InProgress(), progress.Label(), tt.state, tt.inProgress, tt.label)
+		}
+	}
+
+	// A refresh without pull requests keeps the last ones fetched
+	if err := service.Refresh(context.Background(), false); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if got := service.Get(9).State(); got != ProgressPROpen {
+		t.Errorf("Issue #9 after refresh: got %v, want %v", got, ProgressPROpen)
+	}
+}
+
+func TestIssueProgressWatch(t *testing.T) {
+	dir := newCheckpointTestRepo(t)
+	service := NewIssueProgressService(dir, nil)
+	service.pollInterval = 20 * time.Millisecond
+	service.Start()
+	defer service.Close()
+
+	runTestGit(t, dir, "branch", "feature/issue-2")
+	deadline := time.After(5 * time.Second)
+	for service.Get(2).State() != ProgressBranch {
+		select {
+		case <-service.Changes():
+		case <-deadline:
+			t.Fatal("New branch was not noticed")
+		}
+	}
+}
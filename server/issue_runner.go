@@ -0,0 +1,836 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/relay/mcp/shared"
+)
+
+// RunStage is a step of an issue run
+type RunStage string
+
+const (
+	RunStageWorktree RunStage = "worktree"
+	RunStagePlan     RunStage = "plan"
+	RunStageExecute  RunStage = "execute"
+	RunStageChecks   RunStage = "checks"
+	RunStageCommit   RunStage = "commit"
+	RunStagePR       RunStage = "pr"
+	RunStageDone     RunStage = "done"
+)
+
+// runStages lists the stages in the order they run
+var runStages = []RunStage{RunStageWorktree, RunStagePlan, RunStageExecute, RunStageChecks, RunStageCommit, RunStagePR}
+
+// RunState is the lifecycle state of an issue run
+type RunState string
+
+const (
+	RunPending   RunState = "pending"
+	RunRunning   RunState = "running"
+	RunPaused    RunState = "paused"
+	RunAwaiting  RunState = "awaiting_approval"
+	RunSucceeded RunState = "succeeded"
+	RunFailed    RunState = "failed"
+	RunCancelled RunState = "cancelled"
+)
+
+// RunLogEntry is a line of an issue run's live log
+type RunLogEntry struct {
+	IssueNumber int       `json:"issue_number"`
+	Time        time.Time `json:"time"`
+	Stage       RunStage  `json:"stage"`
+	Message     string    `json:"message"`
+}
+
+// ApprovalRequest is a checkpoint waiting for a human decision
+type ApprovalRequest struct {
+	Stage   RunStage `json:"stage"`
+	Summary string   `json:"summary"`
+}
+
+// IssueRunStatus is a snapshot of an issue run for display
+type IssueRunStatus struct {
+	IssueNumber      int              `json:"issue_number"`
+	Title            string           `json:"title"`
+	Stage            RunStage         `json:"stage"`
+	State            RunState         `json:"state"`
+	Worktree         string           `json:"worktree,omitempty"`
+	Branch           string           `json:"branch,omitempty"`
+	Plan             string           `json:"plan,omitempty"`
+	StepsDone        int              `json:"steps_done"`
+	StepsTotal       int              `json:"steps_total"`
+	PRURL            string           `json:"pr_url,omitempty"`
+	Error            string           `json:"error,omitempty"`
+	ApprovalsEnabled bool             `json:"approvals_enabled"`
+	Pending          *ApprovalRequest `json:"pending,omitempty"`
+	Logs             []RunLogEntry    `json:"logs"`
+	StartedAt        time.Time        `json:"started_at"`
+	FinishedAt       *time.Time       `json:"finished_at,omitempty"`
+}
+
+// runnerIssueStore is the subset of IssueManager an issue run needs
+type runnerIssueStore interface {
+	GetIssue(number int) (*Issue, error)
+	UpdateIssueBody(number int, body string) error
+}
+
+// maxRunLogEntries bounds the log kept in memory for the TUI
+const maxRunLogEntries = 500
+
+// IssueRunner takes an issue from worktree to pull request without a terminal:
+// plan with the planning provider, save the plan to the issue, execute it with
+// the executing provider in the worktree, run checks, commit and open a PR.
+type IssueRunner struct {
+	issueNumber int
+	projectName string
+	projectPath string
+	config      RunnerConfig
+	replan      bool
+
+	issues      runnerIssueStore
+	plans       PlanStore
+	planner     LLMProvider
+	newExecutor func(dir string) (LLMProvider, error)
+	push        func(ctx context.Context, dir, branch string) error
+	openPR      func(ctx context.Context, dir, base, branch, title, body string) (string, error)
+	events      *EventBus
+	notifier    *Notifier
+
+	// OnLog and OnApproval are called as the run logs and reaches checkpoints
+	OnLog      func(entry RunLogEntry)
+	OnApproval func(request ApprovalRequest)
+
+	mu       sync.Mutex
+	issue    *Issue
+	plan     *Plan
+	executor LLMProvider
+	status   IssueRunStatus
+	resume   chan struct{} // Non-nil while paused
+	answer   chan bool     // Non-nil while an approval is pending
+	cancel   context.CancelFunc
+	stopped  bool // Cancel was called, possibly before the run started
+	done     chan struct{}
+}
+
+// NewIssueRunner creates a run for an issue in the session's project, using the
+// session's providers, MCP tools and runner settings
+func NewIssueRunner(session *REPLSession, issueNumber int) *IssueRunner {
+	config := session.configManager.GetConfig()
+	executingConfig := config.LLMs.Executing
+	mcpManager := session.mcpManager
+
+	runnerConfig := config.Runner
+	if runnerConfig.BaseBranch == "" {
+		runnerConfig.BaseBranch = "main"
+	}
+
+	r := &IssueRunner{
+		issueNumber: issueNumber,
+		projectName: session.currentProject.Name,
+		projectPath: session.currentProject.Path,
+		config:      runnerConfig,
+		issues:      session.issueManager,
+		plans:       session.projectManager.db,
+		planner:     session.llmManager.GetPlanningProvider(),
+		newExecutor: func(dir string) (LLMProvider, error) {
+			// The executor works inside the worktree, so it gets its own provider rooted there
+			provider, err := NewProviderFactory(dir).CreateProvider(executingConfig)
+			if err != nil {
+				return nil, err
+			}
+			if user, ok := provider.(ToolUser); ok && mcpManager != nil {
+				user.SetToolSet(mcpManager)
+			}
+			return provider, nil
+		},
+		push:     pushBranch,
+		openPR:   openPullRequest,
+		events:   session.events,
+		notifier: session.notifier,
+	}
+	r.status = IssueRunStatus{
+		IssueNumber:      issueNumber,
+		Stage:            RunStageWorktree,
+		State:            RunPending,
+		ApprovalsEnabled: r.config.Approvals,
+		Logs:             []RunLogEntry{},
+	}
+	return r
+}
+
+// SetReplan makes the run write a fresh plan even if the issue already has one
+func (r *IssueRunner) SetReplan(replan bool) {
+	r.replan = replan
+}
+
+// Status returns a snapshot of the run
+func (r *IssueRunner) Status() IssueRunStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := r.status
+	status.Logs = append([]RunLogEntry(nil), r.status.Logs...)
+	if r.status.Pending != nil {
+		pending := *r.status.Pending
+		status.Pending = &pending
+	}
+	return status
+}
+
+// IsFinished reports whether the run has ended
+func (r *IssueRunner) IsFinished() bool {
+	state := r.Status().State
+	return state == RunSucceeded || state == RunFailed || state == RunCancelled
+}
+
+func (r *IssueRunner) logf(format string, args ...interface{}) {
+	r.mu.Lock()
+	entry := RunLogEntry{IssueNumber: r.issueNumber, Time: time.Now(), Stage: r.status.Stage, Message: fmt.Sprintf(format, args...)}
+	r.status.Logs = append(r.status.Logs, entry)
+	if len(r.status.Logs) > maxRunLogEntries {
+		r.status.Logs = r.status.Logs[len(r.status.Logs)-maxRunLogEntries:]
+	}
+	onLog := r.OnLog
+	r.mu.Unlock()
+
+	if onLog != nil {
+		onLog(entry)
+	}
+	r.events.Publish(EventRunLog, r.projectName, entry)
+}
+
+// update changes the run status under the lock and announces it
+func (r *IssueRunner) update(change func(status *IssueRunStatus)) {
+	r.mu.Lock()
+	change(&r.status)
+	status := r.status
+	status.Logs = nil
+	r.mu.Unlock()
+
+	r.events.Publish(EventRunUpdated, r.projectName, status)
+}
+
+// Pause holds the run at the next checkpoint. A provider call already in
+// flight finishes first.
+func (r *IssueRunner) Pause() {
+	r.mu.Lock()
+	if r.resume != nil || r.status.State != RunRunning && r.status.State != RunAwaiting {
+		r.mu.Unlock()
+		return
+	}
+	r.resume = make(chan struct{})
+	r.mu.Unlock()
+
+	r.logf("Pause requested; the run will hold at the next checkpoint")
+}
+
+// Resume continues a paused run
+func (r *IssueRunner) Resume() {
+	r.mu.Lock()
+	resume := r.resume
+	r.resume = nil
+	r.mu.Unlock()
+
+	if resume != nil {
+		close(resume)
+		r.logf("Resumed")
+	}
+}
+
+// TogglePause pauses a running run or resumes a paused one
+func (r *IssueRunner) TogglePause() {
+	r.mu.Lock()
+	paused := r.resume != nil
+	r.mu.Unlock()
+
+	if paused {
+		r.Resume()
+	} else {
+		r.Pause()
+	}
+}
+
+// SetApprovalsEnabled turns human checkpoints on or off. Turning them off
+// approves a checkpoint that is currently waiting.
+func (r *IssueRunner) SetApprovalsEnabled(enabled bool) {
+	r.update(func(status *IssueRunStatus) {
+		status.ApprovalsEnabled = enabled
+	})
+	if enabled {
+		r.logf("Approvals enabled")
+	} else {
+		r.logf("Approvals disabled")
+		r.Answer(true)
+	}
+}
+
+// Answer approves or rejects the pending checkpoint, if any
+func (r *IssueRunner) Answer(approved bool) {
+	r.mu.Lock()
+	answer := r.answer
+	r.answer = nil
+	r.mu.Unlock()
+
+	if answer != nil {
+		answer <- approved
+	}
+}
+
+// Cancel stops the run; a run that hasn't started yet stops as soon as it does
+func (r *IssueRunner) Cancel() {
+	r.mu.Lock()
+	r.stopped = true
+	cancel := r.cancel
+	r.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// Wait blocks until a started run finishes
+func (r *IssueRunner) Wait() {
+	r.mu.Lock()
+	done := r.done
+	r.mu.Unlock()
+
+	if done != nil {
+		<-done
+	}
+}
+
+// Start runs the issue in the background
+func (r *IssueRunner) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	r.mu.Lock()
+	r.done = make(chan struct{})
+	r.cancel = cancel
+	r.mu.Unlock()
+
+	go func() {
+		defer close(r.done)
+		// A panicking run fails on its own without taking the process down
+		defer func() {
+			if p := recover(); p != nil {
+				r.finish(fmt.Errorf("run panicked: %v", p))
+			}
+		}()
+		r.Run(ctx)
+	}()
+}
+
+// Run executes every stage in order and returns when the run ends
+func (r *IssueRunner) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ctx = WithAuditScope(ctx, AuditScope{Project: r.projectName, IssueNumber: r.issueNumber, Actor: AuditActorAgent})
+
+	r.mu.Lock()
+	r.cancel = cancel
+	stopped := r.stopped
+	r.mu.Unlock()
+	if stopped {
+		cancel()
+	}
+
+	r.update(func(status *IssueRunStatus) {
+		status.State = RunRunning
+		status.StartedAt = time.Now()
+	})
+
+	defer func() {
+		if r.executor != nil {
+			r.executor.Close()
+		}
+	}()
+
+	steps := map[RunStage]func(context.Context) error{
+		RunStageWorktree: r.prepareWorktree,
+		RunStagePlan:     r.writePlan,
+		RunStageExecute:  r.executePlan,
+		RunStageChecks:   r.runChecks,
+		RunStageCommit:   r.commitChanges,
+		RunStagePR:       r.submitPullRequest,
+	}
+
+	for _, stage := range runStages {
+		if err := r.checkpoint(ctx); err != nil {
+			return r.finish(err)
+		}
+
+		r.update(func(status *IssueRunStatus) { status.Stage = stage })
+		if err := steps[stage](ctx); err != nil {
+			return r.finish(err)
+		}
+	}
+
+	r.update(func(status *IssueRunStatus) { status.Stage = RunStageDone })
+	return r.finish(nil)
+}
+
+// finish records the outcome of the run
+func (r *IssueRunner) finish(err error) error {
+	now := time.Now()
+	state := RunSucceeded
+	switch {
+	case errors.Is(err, context.Canceled):
+		state = RunCancelled
+	case err != nil:
+		state = RunFailed
+	}
+
+	if err != nil {
+		r.logf("Run %s: %v", state, err)
+	} else {
+		r.logf("Run finished: %s", r.Status().PRURL)
+	}
+
+	r.update(func(status *IssueRunStatus) {
+		status.State = state
+		status.FinishedAt = &now
+		status.Pending = nil
+		if err != nil {
+			status.Error = err.Error()
+		}
+	})
+	r.notifyFinished(state, err)
+	return err
+}
+
+// notifyFinished tells the project's sinks that the run succeeded or
+// failed; a cancelled run was stopped by someone who already knows
+func (r *IssueRunner) notifyFinished(state RunState, err error) {
+	status := r.Status()
+	switch state {
+	case RunSucceeded:
+		r.notifier.Notify(Notification{
+			Event:   NotifyRunSucceeded,
+			Issue:   r.issueNumber,
+			URL:     status.PRURL,
+			Title:   "Run succeeded",
+			Message: fmt.Sprintf("%s: pull request %s", status.Title, status.PRURL),
+		})
+	case RunFailed:
+		r.notifier.Notify(Notification{
+			Event:   NotifyRunFailed,
+			Issue:   r.issueNumber,
+			Urgent:  true,
+			Title:   "Run failed",
+			Message: fmt.Sprintf("%s: failed at %s: %v", status.Title, status.Stage, err),
+		})
+	}
+}
+
+// checkpoint holds the run while it is paused
+func (r *IssueRunner) checkpoint(ctx context.Context) error {
+	r.mu.Lock()
+	resume := r.resume
+	r.mu.Unlock()
+
+	if resume == nil {
+		return ctx.Err()
+	}
+
+	r.logf("Paused")
+	r.update(func(status *IssueRunStatus) { status.State = RunPaused })
+	select {
+	case <-resume:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	r.update(func(status *IssueRunStatus) { status.State = RunRunning })
+	return nil
+}
+
+// approve waits for a human decision when the stage is configured for approval
+func (r *IssueRunner) approve(ctx context.Context, stage RunStage, summary string) error {
+	if !r.Status().ApprovalsEnabled || !r.config.requiresApproval(stage) {
+		return nil
+	}
+
+	request := ApprovalRequest{Stage: stage, Summary: summary}
+	answer := make(chan bool, 1)
+
+	r.mu.Lock()
+	r.answer = answer
+	onApproval := r.OnApproval
+	r.mu.Unlock()
+
+	r.update(func(status *IssueRunStatus) {
+		status.State = RunAwaiting
+		status.Pending = &request
+	})
+	r.logf("Waiting for approval of %s", stage)
+	if onApproval != nil {
+		onApproval(request)
+	}
+	r.notifier.Notify(Notification{
+		Event:   NotifyRunAwaiting,
+		Issue:   r.issueNumber,
+		Title:   "Waiting for approval",
+		Message: fmt.Sprintf("%s: approve the %s to continue", r.Status().Title, stage),
+	})
+
+	var approved bool
+	select {
+	case approved = <-answer:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	r.update(func(status *IssueRunStatus) {
+		status.State = RunRunning
+		status.Pending = nil
+	})
+	if !approved {
+		return fmt.Errorf("%s was not approved", stage)
+	}
+	r.logf("Approved %s", stage)
+
+	// A pause requested while waiting takes effect before continuing
+	return r.checkpoint(ctx)
+}
+
+// prepareWorktree creates the issue's worktree and feature branch, or reuses them
+func (r *IssueRunner) prepareWorktree(ctx context.Context) error {
+	issue, err := r.issues.GetIssue(r.issueNumber)
+	if err != nil {
+		return err
+	}
+	r.issue = issue
+
+	branch := generateBranchName(r.issueNumber)
+	worktree := filepath.Join(r.projectPath, generateWorktreeName(r.projectName, r.issueNumber))
+	r.update(func(status *IssueRunStatus) {
+		status.Title = issue.Title
+		status.Branch = branch
+		status.Worktree = worktree
+	})
+
+	if _, err := os.Stat(filepath.Join(worktree, ".git")); err == nil {
+		r.logf("Reusing worktree %s", worktree)
+		return nil
+	}
+
+	if _, err := runGitCommand(ctx, r.projectPath, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+		r.logf("Checking out existing branch %s in %s", branch, worktree)
+		_, err := runGitCommand(ctx, r.projectPath, "worktree", "add", worktree, branch)
+		return err
+	}
+
+	// Branch from the latest base when the remote is reachable
+	start := r.config.BaseBranch
+	if _, err := runGitCommand(ctx, r.projectPath, "fetch", "origin", r.config.BaseBranch); err == nil {
+		start = "origin/" + r.config.BaseBranch
+	} else {
+		r.logf("Could not fetch origin/%s, branching from local %s", r.config.BaseBranch, r.config.BaseBranch)
+	}
+
+	r.logf("Creating worktree %s on %s from %s", worktree, branch, start)
+	_, err = runGitCommand(ctx, r.projectPath, "worktree", "add", "-b", branch, worktree, start)
+	return err
+}
+
+// writePlan loads the issue's saved plan, or has the planning provider write
+// one, and mirrors it to the issue body
+func (r *IssueRunner) writePlan(ctx context.Context) error {
+	plan, err := r.plans.GetPlan(r.projectName, r.issueNumber)
+	if err != nil {
+		return err
+	}
+
+	if plan != nil && len(plan.Steps) > 0 && !r.replan {
+		if plan.RecoverInterrupted() {
+			r.logf("A step was interrupted by an earlier run and will run again")
+			if err := r.plans.SavePlan(plan); err != nil {
+				return err
+			}
+		}
+		done, total := plan.Progress()
+		r.logf("Using the saved plan for issue #%d (%d/%d steps done)", r.issueNumber, done, total)
+	} else {
+		plan = nil
+		if existing, ok := shared.ExtractPlanSection(r.issue.Body); ok && !r.replan {
+			// A plan written on the issue by hand or by the issue-planner tool
+			if steps := parsePlanMarkdown(existing); len(steps) > 0 {
+				r.logf("Using the plan already written on issue #%d", r.issueNumber)
+				plan = NewPlan(r.projectName, r.issueNumber, steps)
+			}
+		}
+		if plan == nil {
+			r.logf("Planning with %s", r.planner.GetProviderName())
+			if plan, err = GeneratePlan(ctx, r.planner, r.projectName, r.issue); err != nil {
+				return err
+			}
+		}
+		if err := r.plans.SavePlan(plan); err != nil {
+			return err
+		}
+	}
+
+	r.plan = plan
+	if err := r.mirrorPlan(); err != nil {
+		return fmt.Errorf("failed to save plan to issue: %w", err)
+	}
+	return r.approve(ctx, RunStagePlan, plan.Markdown())
+}
+
+// mirrorPlan writes the plan, with step progress, into the issue body
+func (r *IssueRunner) mirrorPlan() error {
+	markdown := r.plan.Markdown()
+	done, total := r.plan.Progress()
+	r.update(func(status *IssueRunStatus) {
+		status.Plan = markdown
+		status.StepsDone = done
+		status.StepsTotal = total
+	})
+
+	body := shared.MergePlanSection(r.issue.Body, markdown)
+	if body == r.issue.Body {
+		return nil
+	}
+	if err := r.issues.UpdateIssueBody(r.issueNumber, body); err != nil {
+		return err
+	}
+	r.issue.Body = body
+	return nil
+}
+
+// executePlan has the executing provider carry out the plan one step at a time
+// in the worktree. Finished steps are skipped, so a rerun picks up where the
+// last one stopped.
+func (r *IssueRunner) executePlan(ctx context.Context) error {
+	worktree := r.Status().Worktree
+
+	executor, err := r.newExecutor(worktree)
+	if err != nil {
+		return fmt.Errorf("failed to create executing provider: %w", err)
+	}
+	r.executor = executor
+
+	done, total := r.plan.Progress()
+	if done == total {
+		r.logf("All %d plan steps are already done", total)
+		return nil
+	}
+	r.logf("Executing plan with %s", executor.GetProviderName())
+	if done > 0 {
+		r.logf("Resuming at step %d of %d", done+1, total)
+	}
+
+	for step := r.plan.NextStep(); step != nil; step = r.plan.NextStep() {
+		if err := r.checkpoint(ctx); err != nil {
+			return err
+		}
+
+		r.logf("Step %d/%d: %s", step.Position, total, step.Goal)
+		r.snapshot(ctx, worktree, fmt.Sprintf("issue #%d step %d: %s", r.issueNumber, step.Position, step.Goal))
+		err := ExecutePlanStep(ctx, executor, r.plans, r.issue, r.plan, step, worktree)
+		if mirrorErr := r.mirrorPlan(); mirrorErr != nil {
+			r.logf("Could not update the plan on the issue: %v", mirrorErr)
+		}
+		if err != nil {
+			return err
+		}
+		r.logOutput(step.Output)
+	}
+	return nil
+}
+
+// runChecks runs the configured checks, giving the executor a chance to fix failures
+func (r *IssueRunner) runChecks(ctx context.Context) error {
+	if len(r.config.Checks) == 0 {
+		r.logf("No checks configured")
+		return nil
+	}
+
+	worktree := r.Status().Worktree
+	for attempt := 0; ; attempt++ {
+		var failures []string
+		for _, check := range r.config.Checks {
+			if err := r.checkpoint(ctx); err != nil {
+				return err
+			}
+
+			r.logf("$ %s", check)
+			output, err := runCheckCommand(ctx, worktree, check)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err != nil {
+				r.logOutput(output)
+				failures = append(failures, fmt.Sprintf("$ %s\n%s", check, strings.TrimSpace(output)))
+				continue
+			}
+			r.logf("✓ %s", check)
+		}
+
+		if len(failures) == 0 {
+			return nil
+		}
+		if attempt >= r.config.FixAttempts {
+			return fmt.Errorf("%d check(s) failed", len(failures))
+		}
+
+		r.logf("Asking %s to fix %d failing check(s)", r.executor.GetProviderName(), len(failures))
+		r.snapshot(ctx, worktree, fmt.Sprintf("issue #%d fix attempt %d", r.issueNumber, attempt+1))
+		response, err := r.executor.SendMessage(ctx, buildRunFixPrompt(failures))
+		if err != nil {
+			return fmt.Errorf("failed to fix checks: %w", err)
+		}
+		r.logOutput(response)
+	}
+}
+
+// commitChanges commits everything the executor changed in the worktree
+func (r *IssueRunner) commitChanges(ctx context.Context) error {
+	worktree := r.Status().Worktree
+
+	if _, err := runGitCommand(ctx, worktree, "add", "-A"); err != nil {
+		return err
+	}
+	diffstat, err := runGitCommand(ctx, worktree, "diff", "--cached", "--stat")
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(diffstat) == "" {
+		// A rerun may find the work already committed
+		ahead, err := runGitCommand(ctx, worktree, "rev-list", "--count", r.config.BaseBranch+"..HEAD")
+		if err == nil && strings.TrimSpace(ahead) != "0" {
+			r.logf("Nothing new to commit; branch already has %s commit(s)", strings.TrimSpace(ahead))
+			return nil
+		}
+		return fmt.Errorf("the executor made no changes")
+	}
+
+	r.logOutput(diffstat)
+	if err := r.approve(ctx, RunStageCommit, diffstat); err != nil {
+		return err
+	}
+
+	message := runCommitTitle(r.issue)
+	if _, err := runGitCommand(ctx, worktree, "commit", "-m", message); err != nil {
+		return err
+	}
+	r.logf("Committed: %s", message)
+	return nil
+}
+
+// submitPullRequest pushes the branch and opens (or finds) its pull request
+func (r *IssueRunner) submitPullRequest(ctx context.Context) error {
+	status := r.Status()
+	title := runCommitTitle(r.issue)
+	body := fmt.Sprintf("Closes #%d\n\n## Plan\n\n%s\n", r.issueNumber, status.Plan)
+
+	if err := r.approve(ctx, RunStagePR, title+"\n\n"+body); err != nil {
+		return err
+	}
+
+	r.logf("Pushing %s", status.Branch)
+	if err := r.push(ctx, status.Worktree, status.Branch); err != nil {
+		if isPushRejected(err) {
+			r.notifier.Notify(Notification{
+				Event:   NotifySyncConflict,
+				Issue:   r.issueNumber,
+				Urgent:  true,
+				Title:   "Push rejected",
+				Message: fmt.Sprintf("%s has commits on origin that the worktree lacks; pull or rebase, then push again", status.Branch),
+			})
+		}
+		return err
+	}
+
+	url, err := r.openPR(ctx, status.Worktree, r.config.BaseBranch, status.Branch, title, body)
+	if err != nil {
+		return err
+	}
+
+	r.update(func(status *IssueRunStatus) { status.PRURL = url })
+	r.logf("Pull request: %s", url)
+	return nil
+}
+
+// snapshot takes a rollback checkpoint of the worktree before the executor changes it
+func (r *IssueRunner) snapshot(ctx context.Context, worktree, trigger string) {
+	if _, err := CreateCheckpoint(ctx, worktree, truncateText(trigger, 120)); err != nil {
+		r.logf("Could not create checkpoint: %v", err)
+	}
+}
+
+// logOutput logs command or provider output, trimmed to keep the log readable
+func (r *IssueRunner) logOutput(output string) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) > 40 {
+		lines = append(lines[:40], fmt.Sprintf("... (%d more lines)", len(lines)-40))
+	}
+	for _, line := range lines {
+		if line != "" {
+			r.logf("  %s", line)
+		}
+	}
+}
+
+// runCommitTitle is used for both the commit and the pull request
+func runCommitTitle(issue *Issue) string {
+	return fmt.Sprintf("feat: resolve issue #%d - %s", issue.Number, issue.Title)
+}
+
+func buildRunFixPrompt(failures []string) string {
+	return "These checks failed after your changes:\n\n" + strings.Join(failures, "\n\n") +
+		"\n\nFix the problems in the worktree. Do not commit. Finish with a short summary of the fix."
+}
+
+// runGitCommand runs git in dir and returns its output
+func runGitCommand(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	output, err := auditedCombinedOutput(ctx, cmd)
+	if err != nil {
+		return string(output), fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
+
+// pushBranch pushes a branch to origin and sets its upstream
+func pushBranch(ctx context.Context, dir, branch string) error {
+	_, err := runGitCommand(ctx, dir, "push", "-u", "origin", branch)
+	return err
+}
+
+// isPushRejected reports whether a push failed because the remote branch
+// has commits the local one lacks
+func isPushRejected(err error) bool {
+	message := err.Error()
+	return strings.Contains(message, "[rejected]") || strings.Contains(message, "non-fast-forward") || strings.Contains(message, "fetch first")
+}
+
+// openPullRequest returns the branch's open pull request, creating it with gh if there is none
+func openPullRequest(ctx context.Context, dir, base, branch, title, body string) (string, error) {
+	view := exec.CommandContext(ctx, "gh", "pr", "view", branch, "--json", "url", "--jq", ".url")
+	view.Dir = dir
+	if output, err := view.Output(); err == nil && strings.TrimSpace(string(output)) != "" {
+		return strings.TrimSpace(string(output)), nil
+	}
+
+	create := exec.CommandContext(ctx, "gh", "pr", "create", "--base", base, "--head", branch, "--title", title, "--body", body)
+	create.Dir = dir
+	output, err := auditedCombinedOutput(ctx, create)
+	if err != nil {
+		return "", fmt.Errorf("failed to create pull request: %s", strings.TrimSpace(string(output)))
+	}
+
+	// gh prints the URL as the last line
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	return lines[len(lines)-1], nil
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/relay/mcp/shared"
+)
+
+// funcProvider answers messages with a function
+type funcProvider struct {
+	send func(ctx context.Context, message string) (string, error)
+}
+
+func (p *funcProvider) SendMessage(ctx context.Context, message string) (string, error) {
+	return p.send(ctx, message)
+}
+
+func (p *funcProvider) SendMessageWithSession(ctx context.Context, message string, sessionID string) (string, error) {
+	return p.send(ctx, message)
+}
+
+func (p *funcProvider) GetProviderName() string { return "func" }
+
+func (p *funcProvider) Close() error { return nil }
+
+// fakeIssueStore keeps issues in memory
+type fakeIssueStore struct {
+	mu     sync.Mutex
+	issues map[int]*Issue
+}
+
+func (s *fakeIssueStore) GetIssue(number int) (*Issue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	issue, ok := s.issues[number]
+	if !ok {
+		return nil, fmt.Errorf("issue #%d not found", number)
+	}
+	found := *issue
+	return &found, nil
+}
+
+func (s *fakeIssueStore) UpdateIssueBody(number int, body string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.issues[number].Body = body
+	return nil
+}
+
+func runTestGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	output, err := runGitCommand(context.Background(), dir, args...)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return strings.TrimSpace(output)
+}
+
+// newTestIssueRunner builds a runner against a real repository with a bare
+// origin; the executor writes files and pull requests are recorded
+func newTestIssueRunner(t *testing.T) (*IssueRunner, *fakeIssueStore, *[]string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin.git")
+	project := filepath.Join(root, "demo")
+	runTestGit(t, root, "init", "--bare", "-b", "main", origin)
+	runTestGit(t, root, "init", "-b", "main", project)
+	runTestGit(t, project, "config", "user.email", "relay@example.com")
+	runTestGit(t, project, "config", "user.name", "Relay")
+	if err := os.WriteFile(filepath.Join(project, "README.md"), []byte("demo\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	runTestGit(t, project, "add", "-A")
+	runTestGit(t, project, "commit", "-m", "Initial commit")
+	runTestGit(t, project, "remote", "add", "origin", origin)
+	runTestGit(t, project, "push", "-u", "origin", "main")
+
+	store := &fakeIssueStore{issues: map[int]*Issue{
+		7: {Number: 7, Title: "Add feature", Body: "We need a feature file.", State: "open"},
+	}}
+
+	db, err := openDatabase(filepath.Join(root, "relay.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	var prs []string
+	runner := &IssueRunner{
+		issueNumber: 7,
+		projectName: "demo",
+		projectPath: project,
+		config:      RunnerConfig{BaseBranch: "main", Checks: []string{"test -f fixed.txt"}, FixAttempts: 1, Approvals: true},
+		issues:      store,
+		plans:       db,
+		planner: &funcProvider{send: func(ctx context.Context, message string) (string, error) {
+			return "1. Add feature.txt\n2. Make checks pass", nil
+		}},
+		newExecutor: func(dir string) (LLMProvider, error) {
+			return &funcProvider{send: func(ctx context.Context, message string) (string, error) {
+				name := "feature.txt"
+				if strings.Contains(message, "checks failed") {
+					name = "fixed.txt"
+				}
+				return "Wrote " + name, os.WriteFile(filepath.Join(dir, name), []byte("done\n"), 0644)
+			}}, nil
+		},
+		push: pushBranch,
+		openPR: func(ctx context.Context, dir, base, branch, title, body string) (string, error) {
+			prs = append(prs, strings.Join([]string{base, branch, title, body}, "|"))
+			return "https://example.com/pr/1", nil
+		},
+	}
+	runner.status = IssueRunStatus{IssueNumber: 7, Stage: RunStageWorktree, State: RunPending, ApprovalsEnabled: true}
+	return runner, store, &prs
+}
+
+// TestIssueRunnerEndToEnd tests plan, execute, check-fix, commit, push and PR with approvals
+func TestIssueRunnerEndToEnd(t *testing.T) {
+	runner, store, prs := newTestIssueRunner(t)
+
+	var approved []RunStage
+	runner.OnApproval = func(request ApprovalRequest) {
+		approved = append(approved, request.Stage)
+		go runner.Answer(true)
+	}
+
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v\n%+v", err, runner.Status().Logs)
+	}
+
+	status := runner.Status()
+	if status.State != RunSucceeded || status.Stage != RunStageDone || status.PRURL != "https://example.com/pr/1" {
+		t.Errorf("Unexpected final status: %+v", status)
+	}
+	if strings.Join(stagesToStrings(approved), ",") != "plan,commit,pr" {
+		t.Errorf("Expected plan, commit and PR approvals, got %v", approved)
+	}
+
+	issue, _ := store.GetIssue(7)
+	if plan, ok := shared.ExtractPlanSection(issue.Body); !ok || !strings.Contains(plan, "Add feature.txt") || !strings.HasPrefix(issue.Body, "We need a feature file.") {
+		t.Errorf("Expected plan saved to the issue, got %q", issue.Body)
+	}
+
+	// Both the first change and the check fix are committed and pushed
+	files := runTestGit(t, status.Worktree, "show", "--name-only", "--format=%s", "HEAD")
+	if !strings.Contains(files, "feat: resolve issue #7 - Add feature") || !strings.Contains(files, "feature.txt") || !strings.Contains(files, "fixed.txt") {
+		t.Errorf("Unexpected commit: %s", files)
+	}
+	if remote := runTestGit(t, status.Worktree, "ls-remote", "origin", "feature/issue-7"); remote == "" {
+		t.Error("Expected the branch to be pushed")
+	}
+
+	if len(*prs) != 1 || !strings.HasPrefix((*prs)[0], "main|feature/issue-7|") || !strings.Contains((*prs)[0], "Closes #7") {
+		t.Errorf("Unexpected pull requests: %v", *prs)
+	}
+}
+
+func stagesToStrings(stages []RunStage) []string {
+	var names []string
+	for _, stage := range stages {
+		names = append(names, string(stage))
+	}
+	return names
+}
+
+// TestIssueRunnerRejectAndPause tests that a rejected checkpoint fails the run and
+// that a paused run holds at the next checkpoint until resumed
+func TestIssueRunnerRejectAndPause(t *testing.T) {
+	runner, _, _ := newTestIssueRunner(t)
+	runner.OnApproval = func(request ApprovalRequest) { go runner.Answer(false) }
+
+	err := runner.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "plan was not approved") || runner.Status().State != RunFailed {
+		t.Fatalf("Expected rejected plan to fail the run, got %v (%s)", err, runner.Status().State)
+	}
+
+	runner, store, _ := newTestIssueRunner(t)
+	runner.status.ApprovalsEnabled = false
+	release := make(chan struct{})
+	runner.planner = &funcProvider{send: func(ctx context.Context, message string) (string, error) {
+		<-release
+		return "1. Do it", nil
+	}}
+	store.issues[7].Body = ""
+
+	runner.Start(context.Background())
+	waitForRunStage(t, runner, RunStagePlan, RunRunning)
+	runner.Pause()
+	close(release)
+
+	waitForRunStage(t, runner, RunStagePlan, RunPaused)
+	runner.Resume()
+	runner.Wait()
+
+	if status := runner.Status(); status.State != RunSucceeded {
+		t.Errorf("Expected resumed run to succeed, got %s: %s", status.State, status.Error)
+	}
+}
+
+func waitForRunStage(t *testing.T, runner *IssueRunner, stage RunStage, state RunState) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		status := runner.Status()
+		if status.Stage == stage && status.State == state {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	status := runner.Status()
+	t.Fatalf("Run did not reach %s/%s (at %s/%s)", stage, state, status.Stage, status.State)
+}
+
+// TestPlanSection tests merging a plan into an issue body and reading it back
+func TestPlanSection(t *testing.T) {
+	body := shared.MergePlanSection("Description", "1. First")
+	body = shared.MergePlanSection(body+"\n\nFooter", "1. Second")
+
+	plan, ok := shared.ExtractPlanSection(body)
+	if !ok || plan != "1. Second" {
+		t.Errorf("Expected replaced plan, got %q", plan)
+	}
+	if !strings.HasPrefix(body, "Description\n\n") || !strings.HasSuffix(body, "Footer") || strings.Count(body, shared.PlanSectionStart) != 1 {
+		t.Errorf("Expected surrounding content preserved, got %q", body)
+	}
+
+	// A start marker whose end marker was deleted keeps the text after it
+	body = shared.MergePlanSection("Intro\n"+shared.PlanSectionStart+"\nOld plan\n\nUser notes", "1. New")
+	if !strings.HasPrefix(body, "Intro\n") || !strings.HasSuffix(body, "\nOld plan\n\nUser notes") {
+		t.Errorf("Unterminated section clobbered the body: %q", body)
+	}
+	if plan, ok := shared.ExtractPlanSection(body); !ok || plan != "1. New" {
+		t.Errorf("Expected the new plan, got %q", plan)
+	}
+	if _, ok := shared.ExtractPlanSection("Notes\n" + shared.PlanSectionStart + "\nno end"); ok {
+		t.Error("An unterminated section should not be extracted")
+	}
+}
@@ -0,0 +1,592 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JobKind identifies what a queued job does
+type JobKind string
+
+const (
+	JobKindLLM   JobKind = "llm"   // Send a prompt to the executing provider
+	JobKindGit   JobKind = "git"   // commit, push or commit-push
+	JobKindCheck JobKind = "check" // Run a shell command (tests, linters, builds)
+	JobKindIssue JobKind = "issue" // create, close or comment on an issue
+)
+
+// JobStatus is the lifecycle state of a job
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+	JobCancelled JobStatus = "cancelled"
+	JobSkipped   JobStatus = "skipped" // Dependency finished in a way that did not satisfy the condition
+)
+
+// DependencyCondition controls when a dependent job may start
+type DependencyCondition string
+
+const (
+	DependAfter     DependencyCondition = "after"      // Run once the dependency finishes, whatever the outcome
+	DependOnSuccess DependencyCondition = "on-success" // Run only if the dependency succeeded
+	DependOnFailure DependencyCondition = "on-failure" // Run only if the dependency failed
+)
+
+// Job is a single unit of queued work
+type Job struct {
+	ID          int64               `json:"id"`
+	Project     string              `json:"project"`
+	Kind        JobKind             `json:"kind"`
+	Action      string              `json:"action,omitempty"` // e.g. "commit", "close"
+	Input       string              `json:"input,omitempty"`  // prompt, shell command or issue text
+	IssueNumber int                 `json:"issue_number,omitempty"`
+	DependsOn   int64               `json:"depends_on,omitempty"`
+	Condition   DependencyCondition `json:"condition,omitempty"`
+	Status      JobStatus           `json:"status"`
+	Output      string              `json:"output,omitempty"`
+	Error       string              `json:"error,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+	StartedAt   *time.Time          `json:"started_at,omitempty"`
+	FinishedAt  *time.Time          `json:"finished_at,omitempty"`
+}
+
+// IsFinished reports whether the job reached a terminal state
+func (j *Job) IsFinished() bool {
+	switch j.Status {
+	case JobSucceeded, JobFailed, JobCancelled, JobSkipped:
+		return true
+	}
+	return false
+}
+
+// Describe returns a short one-line description of the job
+func (j *Job) Describe() string {
+	switch j.Kind {
+	case JobKindLLM:
+		return fmt.Sprintf("llm: %s", truncateText(j.Input, 50))
+	case JobKindGit:
+		return fmt.Sprintf("git %s", j.Action)
+	case JobKindCheck:
+		return fmt.Sprintf("check: %s", truncateText(j.Input, 50))
+	case JobKindIssue:
+		if j.IssueNumber > 0 {
+			return fmt.Sprintf("issue %s #%d", j.Action, j.IssueNumber)
+		}
+		return fmt.Sprintf("issue %s: %s", j.Action, truncateText(j.Input, 40))
+	}
+	return string(j.Kind)
+}
+
+// JobExecutor runs jobs for a single project
+type JobExecutor interface {
+	ExecuteJob(ctx context.Context, job *Job) (string, error)
+}
+
+// JobQueue is a persistent, dependency-aware job queue with per-project concurrency limits
+type JobQueue struct {
+	db           *Database
+	mu           sync.Mutex
+	jobs         map[int64]*Job // Unfinished jobs plus recently finished ones referenced by dependencies
+	executors    map[string]JobExecutor
+	running      map[string]int // Running job count per project
+	cancels      map[int64]context.CancelFunc
+	limits       map[string]int
+	defaultLimit int
+	listeners    []func(Job)
+	closed       bool
+	wg           sync.WaitGroup
+}
+
+// NewJobQueue creates a queue backed by the given database.
+// Jobs that were running when Relay last exited are marked as failed;
+// queued jobs are picked up again once an executor for their project registers.
+func NewJobQueue(db *Database) (*JobQueue, error) {
+	q := &JobQueue{
+		db:           db,
+		jobs:         make(map[int64]*Job),
+		executors:    make(map[string]JobExecutor),
+		running:      make(map[string]int),
+		cancels:      make(map[int64]context.CancelFunc),
+		limits:       make(map[string]int),
+		defaultLimit: 1,
+	}
+
+	unfinished, err := db.ListUnfinishedJobs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load unfinished jobs: %w", err)
+	}
+
+	for _, job := range unfinished {
+		if job.Status == JobRunning {
+			now := time.Now()
+			job.Status = JobFailed
+			job.Error = "interrupted: relay exited while the job was running"
+			job.FinishedAt = &now
+			if err := db.UpdateJob(job); err != nil {
+				return nil, err
+			}
+		}
+		q.jobs[job.ID] = job
+	}
+
+	return q, nil
+}
+
+// SetExecutor registers the executor that runs jobs for a project
+func (q *JobQueue) SetExecutor(project string, executor JobExecutor) {
+	q.mu.Lock()
+	q.executors[project] = executor
+	q.mu.Unlock()
+	q.dispatch()
+}
+
+// RemoveExecutor unregisters a project's executor; its queued jobs wait
+// until one is set again
+func (q *JobQueue) RemoveExecutor(project string) {
+	q.mu.Lock()
+	delete(q.executors, project)
+	q.mu.Unlock()
+}
+
+// SetConcurrencyLimit sets how many jobs may run at once for a project
+func (q *JobQueue) SetConcurrencyLimit(project string, limit int) {
+	if limit < 1 {
+		limit = 1
+	}
+	q.mu.Lock()
+	q.limits[project] = limit
+	q.mu.Unlock()
+	q.dispatch()
+}
+
+// Subscribe registers a callback invoked (outside the queue lock) whenever a job changes
+func (q *JobQueue) Subscribe(listener func(Job)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.listeners = append(q.listeners, listener)
+}
+
+// Enqueue validates, persists and schedules a new job
+func (q *JobQueue) Enqueue(job *Job) (*Job, error) {
+	if job.Project == "" {
+		return nil, fmt.Errorf("job project is required")
+	}
+
+	switch job.Kind {
+	case JobKindLLM, JobKindCheck:
+		if strings.TrimSpace(job.Input) == "" {
+			return nil, fmt.Errorf("%s job requires input", job.Kind)
+		}
+	case JobKindGit:
+		if job.Action != "commit" && job.Action != "push" && job.Action != "commit-push" {
+			return nil, fmt.Errorf("invalid git action '%s'. Valid actions: commit, push, commit-push", job.Action)
+		}
+	case JobKindIssue:
+		if job.Action != "create" && job.Action != "close" && job.Action != "comment" {
+			return nil, fmt.Errorf("invalid issue action '%s'. Valid actions: create, close, comment", job.Action)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported job kind: %s", job.Kind)
+	}
+
+	if job.DependsOn != 0 {
+		if job.Condition == "" {
+			job.Condition = DependAfter
+		}
+		if job.Condition != DependAfter && job.Condition != DependOnSuccess && job.Condition != DependOnFailure {
+			return nil, fmt.Errorf("invalid dependency condition '%s'", job.Condition)
+		}
+		if _, err := q.lookup(job.DependsOn); err != nil {
+			return nil, fmt.Errorf("dependency: %w", err)
+		}
+	} else {
+		job.Condition = ""
+	}
+
+	job.Status = JobQueued
+	job.CreatedAt = time.Now()
+
+	if err := q.db.InsertJob(job); err != nil {
+		return nil, err
+	}
+
+	snapshot := *job
+
+	q.mu.Lock()
+	q.jobs[job.ID] = job
+	q.mu.Unlock()
+
+	q.notify(snapshot)
+	q.dispatch()
+
+	return &snapshot, nil
+}
+
+// Cancel stops a queued or running job
+func (q *JobQueue) Cancel(id int64) error {
+	q.mu.Lock()
+	job, ok := q.jobs[id]
+	if !ok {
+		q.mu.Unlock()
+		return fmt.Errorf("job %d is not active", id)
+	}
+
+	switch job.Status {
+	case JobQueued:
+		q.finishLocked(job, JobCancelled, "", "cancelled before start")
+		snapshot := *job
+		q.mu.Unlock()
+		q.notify(snapshot)
+		q.dispatch()
+		return nil
+	case JobRunning:
+		cancel := q.cancels[id]
+		q.mu.Unlock()
+		if cancel != nil {
+			cancel()
+		}
+		return nil
+	default:
+		q.mu.Unlock()
+		return fmt.Errorf("job %d already %s", id, job.Status)
+	}
+}
+
+// Get returns a copy of a job, preferring the in-memory state
+func (q *JobQueue) Get(id int64) (*Job, error) {
+	return q.lookup(id)
+}
+
+// List returns the most recent jobs for a project, newest first
+func (q *JobQueue) List(project string, limit int) ([]*Job, error) {
+	jobs, err := q.db.ListJobs(project, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Overlay in-memory state, which may be newer than what was last persisted
+	q.mu.Lock()
+	for i, job := range jobs {
+		if live, ok := q.jobs[job.ID]; ok {
+			copied := *live
+			jobs[i] = &copied
+		}
+	}
+	q.mu.Unlock()
+
+	return jobs, nil
+}
+
+// Close cancels running jobs and waits for them to stop
+func (q *JobQueue) Close() error {
+	q.mu.Lock()
+	q.closed = true
+	for _, cancel := range q.cancels {
+		cancel()
+	}
+	q.mu.Unlock()
+
+	q.wg.Wait()
+	return nil
+}
+
+func (q *JobQueue) lookup(id int64) (*Job, error) {
+	q.mu.Lock()
+	if job, ok := q.jobs[id]; ok {
+		copied := *job
+		q.mu.Unlock()
+		return &copied, nil
+	}
+	q.mu.Unlock()
+
+	return q.db.GetJob(id)
+}
+
+// dispatch starts every queued job whose dependency and concurrency constraints allow it
+func (q *JobQueue) dispatch() {
+	var changed []Job
+
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+
+	// Resolve in creation order so chains settle in a single pass
+	ids := make([]int64, 0, len(q.jobs))
+	for id := range q.jobs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		job := q.jobs[id]
+		if job.Status != JobQueued {
+			continue
+		}
+
+		ready, skip := q.dependencyStateLocked(job)
+		if skip != "" {
+			q.finishLocked(job, JobSkipped, "", skip)
+			changed = append(changed, *job)
+			continue
+		}
+		if !ready {
+			continue
+		}
+
+		executor, ok := q.executors[job.Project]
+		if !ok {
+			continue
+		}
+
+		limit := q.defaultLimit
+		if l, ok := q.limits[job.Project]; ok {
+			limit = l
+		}
+		if q.running[job.Project] >= limit {
+			continue
+		}
+
+		now := time.Now()
+		job.Status = JobRunning
+		job.StartedAt = &now
+		q.running[job.Project]++
+		q.db.UpdateJob(job)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		q.cancels[job.ID] = cancel
+		changed = append(changed, *job)
+
+		q.wg.Add(1)
+		go q.run(ctx, executor, job)
+	}
+
+	// Drop finished jobs nobody is waiting on any more
+	q.pruneLocked()
+	q.mu.Unlock()
+
+	for _, job := range changed {
+		q.notify(job)
+	}
+}
+
+// dependencyStateLocked reports whether a job may run, or why it must be skipped
+func (q *JobQueue) dependencyStateLocked(job *Job) (ready bool, skipReason string) {
+	if job.DependsOn == 0 {
+		return true, ""
+	}
+
+	dep, ok := q.jobs[job.DependsOn]
+	if !ok {
+		loaded, err := q.db.GetJob(job.DependsOn)
+		if err != nil {
+			return false, fmt.Sprintf("dependency job %d not found", job.DependsOn)
+		}
+		dep = loaded
+		q.jobs[dep.ID] = dep
+	}
+
+	if !dep.IsFinished() {
+		return false, ""
+	}
+
+	switch job.Condition {
+	case DependOnSuccess:
+		if dep.Status != JobSucceeded {
+			return false, fmt.Sprintf("job %d %s", dep.ID, dep.Status)
+		}
+	case DependOnFailure:
+		if dep.Status != JobFailed {
+			return false, fmt.Sprintf("job %d %s", dep.ID, dep.Status)
+		}
+	}
+
+	return true, ""
+}
+
+func (q *JobQueue) run(ctx context.Context, executor JobExecutor, job *Job) {
+	defer q.wg.Done()
+
+	output, err := q.execute(ctx, executor, job)
+
+	q.mu.Lock()
+	delete(q.cancels, job.ID)
+	q.running[job.Project]--
+	switch {
+	case ctx.Err() != nil:
+		q.finishLocked(job, JobCancelled, output, "cancelled")
+	case err != nil:
+		q.finishLocked(job, JobFailed, output, err.Error())
+	default:
+		q.finishLocked(job, JobSucceeded, output, "")
+	}
+	snapshot := *job
+	q.mu.Unlock()
+
+	q.notify(snapshot)
+	q.dispatch()
+}
+
+// execute runs the job, turning executor panics into job failures
+func (q *JobQueue) execute(ctx context.Context, executor JobExecutor, job *Job) (output string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	copied := *job
+	return executor.ExecuteJob(ctx, &copied)
+}
+
+func (q *JobQueue) finishLocked(job *Job, status JobStatus, output, errText string) {
+	now := time.Now()
+	job.Status = status
+	job.Output = output
+	job.Error = errText
+	job.FinishedAt = &now
+	q.db.UpdateJob(job)
+}
+
+func (q *JobQueue) pruneLocked() {
+	needed := make(map[int64]bool)
+	for _, job := range q.jobs {
+		if !job.IsFinished() && job.DependsOn != 0 {
+			needed[job.DependsOn] = true
+		}
+	}
+	for id, job := range q.jobs {
+		if job.IsFinished() && !needed[id] {
+			delete(q.jobs, id)
+		}
+	}
+}
+
+func (q *JobQueue) notify(job Job) {
+	q.mu.Lock()
+	listeners := append([]func(Job){}, q.listeners...)
+	q.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener(job)
+	}
+}
+
+// parseQueueCommand parses the arguments of a /queue REPL command into a job.
+//
+//	/queue llm <prompt>
+//	/queue commit | push | commit-push
+//	/queue check <shell command>
+//	/queue close <issue> [completed|not planned|duplicate]
+//	/queue comment <issue> <text>
+//	/queue create <issue title>
+//
+// Any of them may end with "--after N", "--on-success N" or "--on-failure N".
+func parseQueueCommand(project string, args []string) (*Job, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("usage: /queue <llm|commit|push|commit-push|check|close|comment|create> [args] [--after|--on-success|--on-failure <job>]")
+	}
+
+	job := &Job{Project: project}
+
+	// Strip a trailing dependency modifier; other flags belong to the job,
+	// as in "/queue check pytest --maxfail 1"
+	if len(args) >= 2 {
+		condition := DependencyCondition(strings.TrimPrefix(args[len(args)-2], "--"))
+		if strings.HasPrefix(args[len(args)-2], "--") && (condition == DependAfter || condition == DependOnSuccess || condition == DependOnFailure) {
+			id, err := strconv.ParseInt(strings.TrimPrefix(args[len(args)-1], "#"), 10, 64)
+			if err != nil || id <= 0 {
+				return nil, fmt.Errorf("invalid job id '%s'", args[len(args)-1])
+			}
+			job.DependsOn = id
+			job.Condition = condition
+			args = args[:len(args)-2]
+		}
+	}
+
+	if len(args) == 0 {
+		return nil, fmt.Errorf("missing job type")
+	}
+
+	rest := args[1:]
+	switch args[0] {
+	case "llm", "ask":
+		job.Kind = JobKindLLM
+		job.Input = strings.Join(rest, " ")
+	case "commit", "push", "commit-push":
+		if len(rest) > 0 {
+			return nil, fmt.Errorf("/queue %s takes no arguments, got '%s'", args[0], strings.Join(rest, " "))
+		}
+		job.Kind = JobKindGit
+		job.Action = args[0]
+	case "check", "run":
+		job.Kind = JobKindCheck
+		job.Input = strings.Join(rest, " ")
+	case "close", "comment":
+		if len(rest) == 0 {
+			return nil, fmt.Errorf("usage: /queue %s <issue> ...", args[0])
+		}
+		number, err := parseIssueID(strings.TrimPrefix(rest[0], "#"))
+		if err != nil {
+			return nil, err
+		}
+		job.Kind = JobKindIssue
+		job.Action = args[0]
+		job.IssueNumber = number
+		job.Input = strings.Join(rest[1:], " ")
+		if args[0] == "comment" && strings.TrimSpace(job.Input) == "" {
+			return nil, fmt.Errorf("usage: /queue comment <issue> <text>")
+		}
+	case "create":
+		job.Kind = JobKindIssue
+		job.Action = "create"
+		job.Input = strings.Join(rest, " ")
+	default:
+		return nil, fmt.Errorf("unknown job type '%s'", args[0])
+	}
+
+	return job, nil
+}
+
+// truncateText shortens text to max characters (runes, not bytes), adding an ellipsis when cut
+func truncateText(text string, max int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	if max <= 3 {
+		return string(runes[:max])
+	}
+	return string(runes[:max-3]) + "..."
+}
+
+// runCheckCommand runs a shell command in dir and returns its combined output
+func runCheckCommand(ctx context.Context, dir, command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/c", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Dir = dir
+
+	output, err := auditedCombinedOutput(ctx, cmd)
+	if err != nil {
+		return string(output), fmt.Errorf("command failed: %w", err)
+	}
+
+	return string(output), nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeExecutor records executed jobs and fails checks whose input is "fail"
+type fakeExecutor struct {
+	mu       sync.Mutex
+	executed []string
+	block    chan struct{}
+}
+
+func (e *fakeExecutor) ExecuteJob(ctx context.Context, job *Job) (string, error) {
+	if e.block != nil && job.Input == "block" {
+		select {
+		case <-e.block:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	e.mu.Lock()
+	e.executed = append(e.executed, job.Input)
+	e.mu.Unlock()
+
+	if job.Input == "fail" {
+		return "boom", fmt.Errorf("check failed")
+	}
+	return "ok: " + job.Input, nil
+}
+
+func newTestQueue(t *testing.T) (*JobQueue, *Database) {
+	t.Helper()
+	db, err := openDatabase(filepath.Join(t.TempDir(), "relay.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	queue, err := NewJobQueue(db)
+	if err != nil {
+		t.Fatalf("Failed to create job queue: %v", err)
+	}
+	t.Cleanup(func() { queue.Close() })
+
+	return queue, db
+}
+
+func waitForJob(t *testing.T, queue *JobQueue, id int64) *Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := queue.Get(id)
+		if err != nil {
+			t.Fatalf("Failed to get job %d: %v", id, err)
+		}
+		if job.IsFinished() {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Job %d did not finish in time", id)
+	return nil
+}
+
+// TestJobQueueDependencies tests after/on-success/on-failure chaining
+func TestJobQueueDependencies(t *testing.T) {
+	queue, _ := newTestQueue(t)
+	executor := &fakeExecutor{}
+	queue.SetExecutor("demo", executor)
+
+	tests, err := queue.Enqueue(&Job{Project: "demo", Kind: JobKindCheck, Input: "fail"})
+	if err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	commit, _ := queue.Enqueue(&Job{Project: "demo", Kind: JobKindCheck, Input: "commit", DependsOn: tests.ID, Condition: DependOnSuccess})
+	report, _ := queue.Enqueue(&Job{Project: "demo", Kind: JobKindCheck, Input: "report", DependsOn: tests.ID, Condition: DependOnFailure})
+	cleanup, _ := queue.Enqueue(&Job{Project: "demo", Kind: JobKindCheck, Input: "cleanup", DependsOn: commit.ID, Condition: DependAfter})
+
+	if got := waitForJob(t, queue, tests.ID); got.Status != JobFailed || got.Output != "boom" {
+		t.Errorf("Expected failed job with output, got %s %q", got.Status, got.Output)
+	}
+	if got := waitForJob(t, queue, commit.ID); got.Status != JobSkipped {
+		t.Errorf("Expected on-success job to be skipped, got %s", got.Status)
+	}
+	if got := waitForJob(t, queue, report.ID); got.Status != JobSucceeded {
+		t.Errorf("Expected on-failure job to run, got %s", got.Status)
+	}
+	if got := waitForJob(t, queue, cleanup.ID); got.Status != JobSucceeded {
+		t.Errorf("Expected after job to run once its dependency settled, got %s", got.Status)
+	}
+}
+
+// TestJobQueueCancelAndLimit tests cancellation and the per-project concurrency limit
+func TestJobQueueCancelAndLimit(t *testing.T) {
+	queue, _ := newTestQueue(t)
+	executor := &fakeExecutor{block: make(chan struct{})}
+	queue.SetExecutor("demo", executor)
+
+	blocked, _ := queue.Enqueue(&Job{Project: "demo", Kind: JobKindCheck, Input: "block"})
+	waiting, _ := queue.Enqueue(&Job{Project: "demo", Kind: JobKindCheck, Input: "second"})
+
+	time.Sleep(50 * time.Millisecond)
+	if got, _ := queue.Get(waiting.ID); got.Status != JobQueued {
+		t.Errorf("Expected second job to wait for the concurrency slot, got %s", got.Status)
+	}
+
+	if err := queue.Cancel(blocked.ID); err != nil {
+		t.Fatalf("Failed to cancel: %v", err)
+	}
+	if got := waitForJob(t, queue, blocked.ID); got.Status != JobCancelled {
+		t.Errorf("Expected cancelled job, got %s", got.Status)
+	}
+	if got := waitForJob(t, queue, waiting.ID); got.Status != JobSucceeded {
+		t.Errorf("Expected second job to run after cancellation, got %s", got.Status)
+	}
+}
+
+// TestJobQueuePersistence tests that queued jobs survive a restart and running ones are failed
+func TestJobQueuePersistence(t *testing.T) {
+	queue, db := newTestQueue(t)
+
+	queued, _ := queue.Enqueue(&Job{Project: "later", Kind: JobKindCheck, Input: "resume me"})
+	interrupted := &Job{Project: "later", Kind: JobKindCheck, Input: "was running", Status: JobRunning, CreatedAt: time.Now()}
+	if err := db.InsertJob(interrupted); err != nil {
+		t.Fatalf("Failed to insert job: %v", err)
+	}
+
+	restarted, err := NewJobQueue(db)
+	if err != nil {
+		t.Fatalf("Failed to reload queue: %v", err)
+	}
+	defer restarted.Close()
+
+	if got, _ := restarted.Get(interrupted.ID); got.Status != JobFailed {
+		t.Errorf("Expected interrupted job to be failed, got %s", got.Status)
+	}
+
+	restarted.SetExecutor("later", &fakeExecutor{})
+	if got := waitForJob(t, restarted, queued.ID); got.Status != JobSucceeded {
+		t.Errorf("Expected persisted job to run after restart, got %s", got.Status)
+	}
+}
+
+// TestParseQueueCommand tests the /queue argument parser
+func TestParseQueueCommand(t *testing.T) {
+	job, err := parseQueueCommand("demo", []string{"check", "go", "test", "./...", "--on-success", "#4"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if job.Kind != JobKindCheck || job.Input != "go test ./..." || job.DependsOn != 4 || job.Condition != DependOnSuccess {
+		t.Errorf("Unexpected job: %+v", job)
+	}
+
+	job, err = parseQueueCommand("demo", []string{"close", "#12", "not", "planned"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if job.Kind != JobKindIssue || job.IssueNumber != 12 || job.Input != "not planned" {
+		t.Errorf("Unexpected job: %+v", job)
+	}
+
+	if _, err := parseQueueCommand("demo", []string{"deploy"}); err == nil {
+		t.Error("Expected error for unknown job type")
+	}
+	if _, err := parseQueueCommand("demo", []string{"commit", "--before", "3"}); err == nil {
+		t.Error("Expected error for arguments to commit")
+	}
+
+	// Flags that aren't dependency modifiers are part of the command
+	job, err = parseQueueCommand("demo", []string{"check", "pytest", "--maxfail", "1"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if job.Input != "pytest --maxfail 1" || job.DependsOn != 0 {
+		t.Errorf("Unexpected job: %+v", job)
+	}
+	job, err = parseQueueCommand("demo", []string{"check", "pytest", "--maxfail", "1", "--after", "2"})
+	if err != nil || job.Input != "pytest --maxfail 1" || job.DependsOn != 2 || job.Condition != DependAfter {
+		t.Errorf("Unexpected job: %+v, %v", job, err)
+	}
+}
+
+// TestTruncateText tests that truncation never splits a multi-byte rune
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		text string
+		max  int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"  spaced \n out ", 10, "spaced out"},
+		{"Fix the 🐛 in the 🚀 launcher", 12, "Fix the 🐛..."},
+		{"🐛🐛🐛🐛", 3, "🐛🐛🐛"},
+	}
+	for _, tt := range tests {
+		if got := truncateText(tt.text, tt.max); got != tt.want {
+			t.Errorf("truncateText(%q, %d) = %q, want %q", tt.text, tt.max, got, tt.want)
+		}
+	}
+}
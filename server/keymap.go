@@ -0,0 +1,467 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Every key the TUI acts on is an action in keyActions. Views still handle
+// their default keys in Update; the keymap turns a rebound key into the
+// default key of its action before the view sees it, so ~/.relay/keymap.json
+// can move any action without the views knowing.
+
+// KeyAction is something a key does in one view, or anywhere for the global scope
+type KeyAction struct {
+	ID    string   // "<scope>.<name>", e.g. "issue_list.close"
+	Title string   // Shown in the command palette and the key overlay
+	Keys  []string // Default keys; the view handles all of them
+
+	// navigation actions such as moving the selection are left out of the palette
+	navigation bool
+	// available reports whether the action applies right now; nil means always
+	available func(m TUIModel) bool
+	// run performs global actions. View actions reach the view as their key;
+	// the palette and the key overlay are opened by TUIModel.runAction.
+	run func(m TUIModel) (TUIModel, tea.Cmd)
+}
+
+const globalScope = "global"
+
+// scope returns the view scope of the action, or globalScope
+func (a KeyAction) scope() string {
+	scope, _, _ := strings.Cut(a.ID, ".")
+	return scope
+}
+
+// viewScopes maps the views with key actions to their scope
+var viewScopes = map[ViewType]string{
+	ViewIssueList:   "issue_list",
+	ViewIssueDetail: "issue_detail",
+	ViewBoard:       "board",
+	ViewJobs:        "jobs",
+	ViewAgents:      "agents",
+	ViewMCP:         "mcp",
+	ViewCheckpoints: "checkpoints",
+	ViewPlan:        "plan",
+	ViewIssueRun:    "issue_run",
+	ViewProjects:    "projects",
+	ViewDiff:        "diff",
+	ViewLog:         "log",
+}
+
+// scopeTitles names the scopes in the palette and the key overlay
+var scopeTitles = map[string]string{
+	globalScope:    "Global",
+	"issue_list":   "Issues",
+	"issue_detail": "Issue",
+	"board":        "Board",
+	"jobs":         "Jobs",
+	"agents":       "Agents",
+	"mcp":          "MCP servers",
+	"checkpoints":  "Checkpoints",
+	"plan":         "Plan",
+	"issue_run":    "Agent run",
+	"projects":     "Projects",
+	"diff":         "Diff",
+	"log":          "Log",
+}
+
+// switchTo returns a global action's run function that switches views
+func switchTo(view ViewType, data func(m TUIModel) interface{}) func(m TUIModel) (TUIModel, tea.Cmd) {
+	return func(m TUIModel) (TUIModel, tea.Cmd) {
+		var value interface{}
+		if data != nil {
+			value = data(m)
+		}
+		return m, SwitchToView(view, value)
+	}
+}
+
+// hasSelectedIssue reports whether the issue list has an issue to act on
+func hasSelectedIssue(m TUIModel) bool {
+	return len(m.issueListModel.issues) > 0
+}
+
+// hasIssueWorktree reports whether the issue shown in detail has a worktree
+func hasIssueWorktree(m TUIModel) bool {
+	_, err := issueWorktreeDir(m.replSession, m.issueDetailModel.issue.Number)
+	return err == nil
+}
+
+var keyActions = []KeyAction{
+	{ID: "global.palette", Title: "Command palette", Keys: []string{"ctrl+p"}},
+	{ID: "global.help", Title: "Key bindings", Keys: []string{"?"}},
+	{ID: "global.repl", Title: "Go to the REPL", run: switchTo(ViewREPL, nil)},
+	{ID: "global.projects", Title: "Go to projects", run: switchTo(ViewProjects, nil)},
+	{ID: "global.issues", Title: "Go to issues", run: switchTo(ViewIssueList, nil)},
+	{ID: "global.board", Title: "Go to the board", run: switchTo(ViewBoard, nil)},
+	{ID: "global.jobs", Title: "Go to jobs", run: switchTo(ViewJobs, nil)},
+	{ID: "global.agents", Title: "Go to agents", run: switchTo(ViewAgents, nil)},
+	{ID: "global.terminal", Title: "Go to agent sessions", run: switchTo(ViewAgentTerminal, func(m TUIModel) interface{} {
+		return AgentTerminalData{Back: ViewREPL}
+	})},
+	{ID: "global.mcp", Title: "Go to MCP servers", run: switchTo(ViewMCP, nil)},
+	{ID: "global.checkpoints", Title: "Go to checkpoints", run: switchTo(ViewCheckpoints, func(m TUIModel) interface{} {
+		return CheckpointData{Dir: m.replSession.currentProject.Path, Back: ViewREPL}
+	})},
+	{ID: "global.diff", Title: "Go to diff", run: switchTo(ViewDiff, func(m TUIModel) interface{} {
+		return DiffData{Dir: m.replSession.currentProject.Path, Title: m.replSession.currentProject.Name, Base: sessionBaseBranch(m.replSession), Back: ViewREPL}
+	})},
+	{ID: "global.log", Title: "Go to log", run: switchTo(ViewLog, func(m TUIModel) interface{} {
+		return LogData{Dir: m.replSession.currentProject.Path, Title: m.replSession.currentProject.Name, Back: ViewREPL}
+	})},
+	{ID: "global.config", Title: "Open settings", run: switchTo(ViewConfig, nil)},
+	{ID: "global.quit", Title: "Quit", run: func(m TUIModel) (TUIModel, tea.Cmd) { return m, tea.Quit }},
+
+	{ID: "issue_list.back", Title: "Back", Keys: []string{"q", "esc"}, navigation: true},
+	{ID: "issue_list.up", Title: "Previous issue", Keys: []string{"up", "k"}, navigation: true},
+	{ID: "issue_list.down", Title: "Next issue", Keys: []string{"down", "j"}, navigation: true},
+	{ID: "issue_list.open", Title: "Open issue", Keys: []string{"enter", " "}, available: hasSelectedIssue},
+	{ID: "issue_list.close", Title: "Close issue", Keys: []string{"c"}, available: hasSelectedIssue},
+	{ID: "issue_list.finish", Title: "Finish issue", Keys: []string{"f"}, available: func(m TUIModel) bool {
+		if !hasSelectedIssue(m) {
+			return false
+		}
+		issue := m.issueListModel.issues[m.issueListModel.selected]
+		return issue.State != "closed" && m.replSession.progress.Get(issue.Number).InProgress()
+	}},
+	{ID: "issue_list.new", Title: "New issue", Keys: []string{"n"}},
+	{ID: "issue_list.chat", Title: "Chat about the issues", Keys: []string{"o"}},
+	{ID: "issue_list.search", Title: "Search", Keys: []string{"/"}},
+	{ID: "issue_list.filter", Title: "Filter", Keys: []string{"F"}},
+	{ID: "issue_list.sort", Title: "Cycle sort order", Keys: []string{"s"}},
+	{ID: "issue_list.board", Title: "Board", Keys: []string{"b"}},
+	{ID: "issue_list.refresh", Title: "Refresh", Keys: []string{"r"}},
+
+	{ID: "issue_detail.back", Title: "Back", Keys: []string{"q", "esc"}, navigation: true},
+	{ID: "issue_detail.up", Title: "Previous field", Keys: []string{"up", "k"}, navigation: true},
+	{ID: "issue_detail.down", Title: "Next field", Keys: []string{"down", "j"}, navigation: true},
+	{ID: "issue_detail.edit", Title: "Edit field", Keys: []string{"enter", " "}},
+	{ID: "issue_detail.close", Title: "Close issue", Keys: []string{"c", "o"}},
+	{ID: "issue_detail.start", Title: "Start or continue agent session", Keys: []string{"s"}},
+	{ID: "issue_detail.external", Title: "Open in external terminal", Keys: []string{"S"}},
+	{ID: "issue_detail.attach", Title: "Attach to agent session", Keys: []string{"t"}, available: func(m TUIModel) bool {
+		return m.replSession.terminals.ForIssue(m.replSession.currentProject.Name, m.issueDetailModel.issue.Number) != nil
+	}},
+	{ID: "issue_detail.run", Title: "Agent run", Keys: []string{"a"}, available: func(m TUIModel) bool {
+		return m.issueDetailModel.issue.State != "closed"
+	}},
+	{ID: "issue_detail.plan", Title: "Plan", Keys: []string{"p"}},
+	{ID: "issue_detail.finish", Title: "Finish issue", Keys: []string{"f"}, available: func(m TUIModel) bool {
+		issue := m.issueDetailModel.issue
+		return issue.State != "closed" && m.replSession.progress.Get(issue.Number).InProgress()
+	}},
+	{ID: "issue_detail.chat", Title: "Chat about the issue", Keys: []string{"d"}},
+	{ID: "issue_detail.diff", Title: "Diff of the worktree", Keys: []string{"D"}, available: hasIssueWorktree},
+	{ID: "issue_detail.log", Title: "Log of the issue branch", Keys: []string{"L"}, available: hasIssueWorktree},
+
+	{ID: "board.back", Title: "Back", Keys: []string{"q", "esc"}, navigation: true},
+	{ID: "board.left", Title: "Previous column", Keys: []string{"left", "h"}, navigation: true},
+	{ID: "board.right", Title: "Next column", Keys: []string{"right", "l"}, navigation: true},
+	{ID: "board.up", Title: "Previous card", Keys: []string{"up", "k"}, navigation: true},
+	{ID: "board.down", Title: "Next card", Keys: []string{"down", "j"}, navigation: true},
+	{ID: "board.move_left", Title: "Move card left", Keys: []string{"shift+left", "H"}},
+	{ID: "board.move_right", Title: "Move card right", Keys: []string{"shift+right", "L"}},
+	{ID: "board.open", Title: "Open issue", Keys: []string{"enter", " "}},
+	{ID: "board.refresh", Title: "Refresh", Keys: []string{"r"}},
+
+	{ID: "jobs.back", Title: "Back", Keys: []string{"q", "esc"}, navigation: true},
+	{ID: "jobs.up", Title: "Previous job", Keys: []string{"up", "k"}, navigation: true},
+	{ID: "jobs.down", Title: "Next job", Keys: []string{"down", "j"}, navigation: true},
+	{ID: "jobs.cancel", Title: "Cancel job", Keys: []string{"x"}},
+	{ID: "jobs.summarize", Title: "Summarize job", Keys: []string{"s"}},
+	{ID: "jobs.verbosity", Title: "Cycle summary verbosity", Keys: []string{"v"}},
+	{ID: "jobs.detail", Title: "Toggle detail", Keys: []string{"d"}},
+	{ID: "jobs.refresh", Title: "Refresh", Keys: []string{"r"}},
+
+	{ID: "agents.back", Title: "Back", Keys: []string{"q", "esc"}, navigation: true},
+	{ID: "agents.up", Title: "Previous agent", Keys: []string{"up", "k"}, navigation: true},
+	{ID: "agents.down", Title: "Next agent", Keys: []string{"down", "j"}, navigation: true},
+	{ID: "agents.follow", Title: "Follow agent", Keys: []string{"enter"}},
+	{ID: "agents.promote", Title: "Promote agent", Keys: []string{"p"}},
+	{ID: "agents.cancel", Title: "Cancel agent", Keys: []string{"x"}},
+	{ID: "agents.clear", Title: "Clear finished agents", Keys: []string{"c"}},
+
+	{ID: "mcp.back", Title: "Back", Keys: []string{"q", "esc"}, navigation: true},
+	{ID: "mcp.up", Title: "Previous server", Keys: []string{"up", "k"}, navigation: true},
+	{ID: "mcp.down", Title: "Next server", Keys: []string{"down", "j"}, navigation: true},
+	{ID: "mcp.refresh", Title: "Check health", Keys: []string{"r"}},
+
+	{ID: "checkpoints.back", Title: "Back", Keys: []string{"q", "esc"}, navigation: true},
+	{ID: "checkpoints.up", Title: "Previous checkpoint", Keys: []string{"up", "k"}, navigation: true},
+	{ID: "checkpoints.down", Title: "Next checkpoint", Keys: []string{"down", "j"}, navigation: true},
+	{ID: "checkpoints.restore", Title: "Restore checkpoint", Keys: []string{"enter"}},
+	{ID: "checkpoints.refresh", Title: "Refresh", Keys: []string{"r"}},
+
+	{ID: "plan.back", Title: "Back", Keys: []string{"q", "esc"}, navigation: true},
+	{ID: "plan.up", Title: "Previous step", Keys: []string{"up", "k"}, navigation: true},
+	{ID: "plan.down", Title: "Next step", Keys: []string{"down", "j"}, navigation: true},
+	{ID: "plan.run", Title: "Run plan", Keys: []string{"r"}},
+	{ID: "plan.generate", Title: "Generate plan", Keys: []string{"g"}},
+	{ID: "plan.new", Title: "New step", Keys: []string{"n"}},
+	{ID: "plan.goal", Title: "Edit goal", Keys: []string{"enter", "e"}},
+	{ID: "plan.files", Title: "Edit files", Keys: []string{"f"}},
+	{ID: "plan.acceptance", Title: "Edit acceptance criteria", Keys: []string{"a"}},
+	{ID: "plan.delete", Title: "Delete step", Keys: []string{"d"}},
+	{ID: "plan.move_up", Title: "Move step up", Keys: []string{"K", "shift+up"}},
+	{ID: "plan.move_down", Title: "Move step down", Keys: []string{"J", "shift+down"}},
+	{ID: "plan.skip", Title: "Skip step", Keys: []string{"s"}},
+	{ID: "plan.rerun", Title: "Rerun step", Keys: []string{"u"}},
+
+	{ID: "projects.back", Title: "Back", Keys: []string{"q", "esc"}, navigation: true},
+	{ID: "projects.up", Title: "Previous project", Keys: []string{"up", "k"}, navigation: true},
+	{ID: "projects.down", Title: "Next project", Keys: []string{"down", "j"}, navigation: true},
+	{ID: "projects.issues", Title: "Switch to project and show its issues", Keys: []string{"enter", " "}},
+	{ID: "projects.board", Title: "Switch to project and show its board", Keys: []string{"b"}},
+	{ID: "projects.switch", Title: "Switch to project", Keys: []string{"s"}},
+	{ID: "projects.refresh", Title: "Refresh", Keys: []string{"r"}},
+
+	{ID: "diff.back", Title: "Back", Keys: []string{"q", "esc"}, navigation: true},
+	{ID: "diff.up", Title: "Scroll up", Keys: []string{"up", "k"}, navigation: true},
+	{ID: "diff.down", Title: "Scroll down", Keys: []string{"down", "j"}, navigation: true},
+	{ID: "diff.page_up", Title: "Page up", Keys: []string{"pgup", "ctrl+u"}, navigation: true},
+	{ID: "diff.page_down", Title: "Page down", Keys: []string{"pgdown", "ctrl+d"}, navigation: true},
+	{ID: "diff.next_file", Title: "Next file", Keys: []string{"tab", "]"}},
+	{ID: "diff.previous_file", Title: "Previous file", Keys: []string{"shift+tab", "["}},
+	{ID: "diff.next_hunk", Title: "Next hunk", Keys: []string{"n", "J"}},
+	{ID: "diff.previous_hunk", Title: "Previous hunk", Keys: []string{"p", "K"}},
+	{ID: "diff.stage", Title: "Stage hunk", Keys: []string{"s"}, available: func(m TUIModel) bool {
+		return m.diffModel.mode == DiffUnstaged
+	}},
+	{ID: "diff.unstage", Title: "Unstage hunk", Keys: []string{"u"}, available: func(m TUIModel) bool {
+		return m.diffModel.mode == DiffStaged
+	}},
+	{ID: "diff.mode", Title: "Switch between unstaged, staged and branch diffs", Keys: []string{"m"}, available: func(m TUIModel) bool {
+		return m.diffModel.mode != DiffCommit
+	}},
+	{ID: "diff.refresh", Title: "Refresh", Keys: []string{"r"}},
+
+	{ID: "log.back", Title: "Back", Keys: []string{"q", "esc"}, navigation: true},
+	{ID: "log.up", Title: "Previous commit", Keys: []string{"up", "k"}, navigation: true},
+	{ID: "log.down", Title: "Next commit", Keys: []string{"down", "j"}, navigation: true},
+	{ID: "log.open", Title: "Show commit diff", Keys: []string{"enter", " "}},
+	{ID: "log.refresh", Title: "Refresh", Keys: []string{"r"}},
+
+	{ID: "issue_run.back", Title: "Back", Keys: []string{"q", "esc"}, navigation: true},
+	{ID: "issue_run.pause", Title: "Pause or resume", Keys: []string{"p"}},
+	{ID: "issue_run.approve", Title: "Approve", Keys: []string{"y"}},
+	{ID: "issue_run.reject", Title: "Reject", Keys: []string{"n"}},
+	{ID: "issue_run.approvals", Title: "Toggle approvals", Keys: []string{"t"}},
+	{ID: "issue_run.checkpoints", Title: "Checkpoints", Keys: []string{"u"}},
+	{ID: "issue_run.cancel", Title: "Cancel run", Keys: []string{"x"}},
+}
+
+// keyActionsByID indexes keyActions
+var keyActionsByID = func() map[string]KeyAction {
+	actions := make(map[string]KeyAction, len(keyActions))
+	for _, action := range keyActions {
+		actions[action.ID] = action
+	}
+	return actions
+}()
+
+// keyTypes maps the names of special keys, as KeyMsg.String gives them, to
+// their key types
+var keyTypes = func() map[string]tea.KeyType {
+	types := make(map[string]tea.KeyType)
+	for t := tea.KeyType(-128); t <= tea.KeyBackspace; t++ {
+		if t == tea.KeyRunes {
+			continue
+		}
+		if name := (tea.Key{Type: t}).String(); name != "" {
+			if _, ok := types[name]; !ok {
+				types[name] = t
+			}
+		}
+	}
+	return types
+}()
+
+// keyMsg builds the key press a key name stands for
+func keyMsg(key string) tea.KeyMsg {
+	alt := false
+	if rest, ok := strings.CutPrefix(key, "alt+"); ok && rest != "" {
+		alt, key = true, rest
+	}
+	if t, ok := keyTypes[key]; ok {
+		return tea.KeyMsg{Type: t, Alt: alt}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key), Alt: alt}
+}
+
+// validKey reports whether a key name is one a key press can produce
+func validKey(key string) bool {
+	key = strings.TrimPrefix(key, "alt+")
+	if _, ok := keyTypes[key]; ok {
+		return true
+	}
+	return utf8.RuneCountInString(key) == 1
+}
+
+// keyList is a keymap.json entry: one key or a list of them
+type keyList []string
+
+func (k *keyList) UnmarshalJSON(data []byte) error {
+	var key string
+	if err := json.Unmarshal(data, &key); err == nil {
+		*k = keyList{key}
+		return nil
+	}
+	var keys []string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("expected a key or a list of keys: %w", err)
+	}
+	*k = keys
+	return nil
+}
+
+// Keymap holds the keys bound to every action
+type Keymap struct {
+	keys  map[string][]string          // Action ID to its keys
+	byKey map[string]map[string]string // Scope to key to action ID
+}
+
+// DefaultKeymap returns the keymap with every action on its default keys
+func DefaultKeymap() *Keymap {
+	keymap, err := newKeymap(nil)
+	if err != nil {
+		panic(fmt.Sprintf("default keymap: %v", err))
+	}
+	return keymap
+}
+
+// newKeymap applies overrides to the default keys. It fails on unknown
+// actions or keys, and on a key bound twice in one view or bound both in a
+// view and globally.
+func newKeymap(overrides map[string]keyList) (*Keymap, error) {
+	keymap := &Keymap{keys: make(map[string][]string), byKey: make(map[string]map[string]string)}
+	for _, action := range keyActions {
+		keymap.keys[action.ID] = action.Keys
+	}
+
+	ids := make([]string, 0, len(overrides))
+	for id := range overrides {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if _, ok := keyActionsByID[id]; !ok {
+			return nil, fmt.Errorf("unknown action %q", id)
+		}
+		keys := make([]string, len(overrides[id]))
+		for i, key := range overrides[id] {
+			if key == "space" {
+				key = " "
+			}
+			if !validKey(key) {
+				return nil, fmt.Errorf("action %q: unknown key %q", id, key)
+			}
+			keys[i] = key
+		}
+		keymap.keys[id] = keys
+	}
+
+	for _, action := range keyActions {
+		scope := action.scope()
+		if keymap.byKey[scope] == nil {
+			keymap.byKey[scope] = make(map[string]string)
+		}
+		for _, key := range keymap.keys[action.ID] {
+			if other, ok := keymap.byKey[scope][key]; ok && other != action.ID {
+				return nil, fmt.Errorf("key %q is bound to both %s and %s", key, other, action.ID)
+			}
+			keymap.byKey[scope][key] = action.ID
+		}
+	}
+	for scope, keys := range keymap.byKey {
+		if scope == globalScope {
+			continue
+		}
+		for key, id := range keys {
+			if global, ok := keymap.byKey[globalScope][key]; ok {
+				return nil, fmt.Errorf("key %q is bound to both %s and %s", key, global, id)
+			}
+		}
+	}
+	return keymap, nil
+}
+
+// LoadKeymap reads ~/.relay/keymap.json, an object from action IDs to keys;
+// a missing file means the defaults
+func LoadKeymap(relayDir string) (*Keymap, error) {
+	path := filepath.Join(relayDir, "keymap.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return DefaultKeymap(), nil
+		}
+		return nil, fmt.Errorf("failed to read keymap: %w", err)
+	}
+	var overrides map[string]keyList
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse keymap %s: %w", path, err)
+	}
+	keymap, err := newKeymap(overrides)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keymap %s: %w", path, err)
+	}
+	return keymap, nil
+}
+
+// Keys returns the keys bound to an action
+func (k *Keymap) Keys(id string) []string {
+	return k.keys[id]
+}
+
+// Lookup returns the action a key is bound to in a scope
+func (k *Keymap) Lookup(scope, key string) (KeyAction, bool) {
+	id, ok := k.byKey[scope][key]
+	if !ok {
+		return KeyAction{}, false
+	}
+	return keyActionsByID[id], true
+}
+
+// Unbound reports whether key is a default key of an action in scope that
+// the keymap moved elsewhere. The view would still act on it, so it is dropped.
+func (k *Keymap) Unbound(scope, key string) bool {
+	if _, ok := k.byKey[scope][key]; ok {
+		return false
+	}
+	for _, action := range keyActions {
+		if action.scope() == scope && containsString(action.Keys, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Actions returns the actions of a scope in their order
+func (k *Keymap) Actions(scope string) []KeyAction {
+	var actions []KeyAction
+	for _, action := range keyActions {
+		if action.scope() == scope {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+// keyLabel formats keys for display, e.g. "q/esc"
+func keyLabel(keys []string) string {
+	labels := make([]string, len(keys))
+	for i, key := range keys {
+		if key == " " {
+			key = "space"
+		}
+		labels[i] = key
+	}
+	return strings.Join(labels, "/")
+}
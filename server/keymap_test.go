@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestDefaultKeymap(t *testing.T) {
+	keymap := DefaultKeymap()
+	for _, action := range keyActions {
+		if _, ok := scopeTitles[action.scope()]; !ok {
+			t.Errorf("Action %s has no scope title", action.ID)
+		}
+		for _, key := range action.Keys {
+			if got := keyMsg(key).String(); got != key {
+				t.Errorf("Key %q of %s round-trips as %q", key, action.ID, got)
+			}
+			if bound, ok := keymap.Lookup(action.scope(), key); !ok || bound.ID != action.ID {
+				t.Errorf("Key %q is not bound to %s", key, action.ID)
+			}
+		}
+	}
+}
+
+func TestLoadKeymap(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr string
+	}{
+		{"missing file", "", ""},
+		{"rebind", `{"jobs.cancel": "z", "issue_list.close": ["x", "ctrl+x"], "issue_list.open": ["enter"], "issue_list.new": "space"}`, ""},
+		{"unknown action", `{"jobs.explode": "z"}`, "unknown action"},
+		{"unknown key", `{"jobs.cancel": "ctrl+banana"}`, "unknown key"},
+		{"conflict", `{"jobs.cancel": "s"}`, "jobs.cancel and jobs.summarize"},
+		{"global conflict", `{"global.quit": "r"}`, "global.quit"},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		if tt.json != "" {
+			if err := os.WriteFile(filepath.Join(dir, "keymap.json"), []byte(tt.json), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		_, err := LoadKeymap(dir)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: got %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestApplyKeymap(t *testing.T) {
+	keymap, err := newKeymap(map[string]keyList{"jobs.cancel": {"z"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := TUIModel{keymap: keymap, currentView: ViewJobs}
+
+	// A rebound key reaches the view as the action's default key
+	_, msg, _, handled := m.applyKeymap(runes("z"))
+	if handled || msg.(tea.KeyMsg).String() != "x" {
+		t.Errorf("z gave %v, handled %v", msg, handled)
+	}
+	// The old key no longer cancels
+	if _, _, _, handled := m.applyKeymap(runes("x")); !handled {
+		t.Error("Expected x to be dropped")
+	}
+	// Other keys are left alone
+	if _, msg, _, handled := m.applyKeymap(runes("s")); handled || msg.(tea.KeyMsg).String() != "s" {
+		t.Errorf("s gave %v, handled %v", msg, handled)
+	}
+
+	next, _, _, _ := m.applyKeymap(runes("?"))
+	if !next.showKeys || !strings.Contains(next.keyHelpView(), "Cancel job") {
+		t.Error("Expected ? to show the key overlay")
+	}
+
+	// In the REPL ? is typed, while ctrl+p still opens the palette
+	m.currentView = ViewREPL
+	if _, _, _, handled := m.applyKeymap(runes("?")); handled {
+		t.Error("Expected ? to reach the REPL")
+	}
+	m.currentView = ViewJobs
+	m, _, _, _ = m.applyKeymap(tea.KeyMsg{Type: tea.KeyCtrlP})
+	if m.palette == nil {
+		t.Fatal("Expected ctrl+p to open the palette")
+	}
+	for _, key := range "cncl job" {
+		m, _, _, _ = m.applyKeymap(runes(string(key)))
+	}
+	if len(m.palette.matches) == 0 || m.palette.matches[0].action.ID != "jobs.cancel" {
+		t.Fatalf("Palette matched %+v", m.palette.matches)
+	}
+	if !strings.Contains(m.palette.View(80, 24), "z") {
+		t.Error("Expected the palette to show the rebound key")
+	}
+
+	// Running it sends the default key, which the keymap passes on
+	m, _, cmd, _ := m.applyKeymap(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.palette != nil || cmd == nil {
+		t.Fatal("Expected enter to close the palette and run the action")
+	}
+	if action, ok := cmd().(keyActionMsg); !ok || action.key.String() != "x" {
+		t.Errorf("Palette ran %v", cmd())
+	}
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"strings"
 )
 
 // LLMProvider defines the interface for all LLM providers
@@ -20,6 +24,70 @@ type LLMProvider interface {
 	Close() error
 }
 
+// StreamingProvider is implemented by providers that can deliver a response
+// incrementally as tokens are generated
+type StreamingProvider interface {
+	// StreamMessage sends a message and calls onToken for each chunk of the response.
+	// The full response is returned once the stream ends.
+	StreamMessage(ctx context.Context, message string, onToken func(string)) (string, error)
+}
+
+// maxToolRounds bounds how many times a provider runs tools before it settles
+// for the model's latest text
+const maxToolRounds = 10
+
+// ToolSet supplies tools that API providers offer to the model
+type ToolSet interface {
+	// Tools returns the currently available tools
+	Tools() []MCPTool
+
+	// CallTool runs a tool by its qualified name and returns its text output
+	CallTool(ctx context.Context, name string, arguments json.RawMessage) (string, error)
+}
+
+// ToolUser is implemented by providers that can call tools during a conversation
+type ToolUser interface {
+	SetToolSet(tools ToolSet)
+}
+
+// StreamOrSend streams the response when the provider supports it, and otherwise
+// delivers the whole response as a single chunk
+func StreamOrSend(ctx context.Context, provider LLMProvider, message string, onToken func(string)) (string, error) {
+	if streamer, ok := provider.(StreamingProvider); ok {
+		return streamer.StreamMessage(ctx, message, onToken)
+	}
+
+	response, err := provider.SendMessage(ctx, message)
+	if err != nil {
+		return "", err
+	}
+	if onToken != nil {
+		onToken(response)
+	}
+	return response, nil
+}
+
+// readSSEData calls handle with the payload of every "data:" line in a
+// server-sent event stream until handle reports done or the stream ends
+func readSSEData(r io.Reader, handle func(data string) (done bool, err error)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		done, err := handle(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		if err != nil || done {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
 // LLMProviderConfig holds configuration for LLM providers
 type LLMProviderConfig struct {
 	Type      string            `json:"type"`       // "claude", "openai", "local", etc.
@@ -42,19 +110,28 @@ func NewProviderFactory(workingDir string) *ProviderFactory {
 	}
 }
 
-// CreateProvider creates an LLM provider based on the configuration
+// CreateProvider creates an LLM provider based on the configuration. Its
+// requests are recorded in the audit log.
 func (f *ProviderFactory) CreateProvider(config LLMProviderConfig) (LLMProvider, error) {
+	var provider LLMProvider
+	var err error
+
 	switch config.Type {
 	case "claude":
-		return NewClaudeProvider(config, f.workingDir)
+		provider, err = NewClaudeProvider(config, f.workingDir)
 	case "claude-cli":
 		// Backwards compatibility with existing CLI implementation
-		return NewClaudeCLIProvider(f.workingDir)
+		provider, err = NewClaudeCLIProvider(f.workingDir)
 	case "openai":
-		return NewOpenAIProvider(config)
+		provider, err = NewOpenAIProvider(config)
 	default:
 		return nil, fmt.Errorf("unsupported LLM provider type: %s", config.Type)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newAuditedProvider(provider), nil
 }
 
 // LLMManager manages multiple LLM providers for different use cases
@@ -95,6 +172,15 @@ func (m *LLMManager) GetExecutingProvider() LLMProvider {
 	return m.executingProvider
 }
 
+// SetToolSet offers tools to every provider that supports them
+func (m *LLMManager) SetToolSet(tools ToolSet) {
+	for _, provider := range []LLMProvider{m.planningProvider, m.executingProvider} {
+		if user, ok := provider.(ToolUser); ok {
+			user.SetToolSet(tools)
+		}
+	}
+}
+
 // Close closes all providers
 func (m *LLMManager) Close() error {
 	var errs []error
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Logs go to a rotating file under ~/.relay/logs. Warnings and errors are
+// also shown: on stderr for CLI commands, and as toasts while the TUI owns
+// the terminal, so nothing is written over the alt screen.
+const (
+	defaultLogMaxSize  = 5 * 1024 * 1024
+	defaultLogMaxFiles = 5
+	logFileName        = "relay.log"
+
+	// toastKey marks a record that should be shown to the user even below
+	// warning level, e.g. the outcome of a background action
+	toastKey = "toast"
+)
+
+// LogConfig configures the central logger
+type LogConfig struct {
+	Level    slog.Level
+	JSON     bool   // JSON lines instead of key=value text
+	Dir      string // Directory of the log files
+	MaxSize  int64  // Bytes before the file is rotated
+	MaxFiles int    // Rotated files kept besides the current one
+}
+
+// LogConfigFromEnv reads RELAY_LOG_LEVEL (debug, info, warn, error),
+// RELAY_LOG_FORMAT (text or json) and RELAY_LOG_DIR
+func LogConfigFromEnv() (LogConfig, error) {
+	config := LogConfig{Level: slog.LevelInfo, MaxSize: defaultLogMaxSize, MaxFiles: defaultLogMaxFiles}
+
+	if level := os.Getenv("RELAY_LOG_LEVEL"); level != "" {
+		if err := config.Level.UnmarshalText([]byte(level)); err != nil {
+			return config, fmt.Errorf("invalid RELAY_LOG_LEVEL %q: %w", level, err)
+		}
+	}
+
+	switch format := strings.ToLower(os.Getenv("RELAY_LOG_FORMAT")); format {
+	case "", "text":
+	case "json":
+		config.JSON = true
+	default:
+		return config, fmt.Errorf("invalid RELAY_LOG_FORMAT %q: use text or json", format)
+	}
+
+	config.Dir = os.Getenv("RELAY_LOG_DIR")
+	if config.Dir == "" {
+		relayDir, err := relayHomeDir()
+		if err != nil {
+			return config, err
+		}
+		config.Dir = filepath.Join(relayDir, "logs")
+	}
+
+	return config, nil
+}
+
+// rotatingFile is a log file that is renamed to relay.log.1, relay.log.2, ...
+// once it grows past maxSize
+type rotatingFile struct {
+	mu       sync.Mutex
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+func openRotatingFile(dir string, maxSize int64, maxFiles int) (*rotatingFile, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	r := &rotatingFile{path: filepath.Join(dir, logFileName), maxSize: maxSize, maxFiles: maxFiles}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	r.file, r.size = file, info.Size()
+	return nil
+}
+
+func (r *rotatingFile) rotate() error {
+	r.file.Close()
+	os.Remove(r.path + "." + strconv.Itoa(r.maxFiles))
+	for i := r.maxFiles - 1; i >= 1; i-- {
+		os.Rename(r.path+"."+strconv.Itoa(i), r.path+"."+strconv.Itoa(i+1))
+	}
+	if r.maxFiles > 0 {
+		os.Rename(r.path, r.path+".1")
+	} else {
+		os.Remove(r.path)
+	}
+	return r.open()
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// Toast is a log message surfaced in the TUI
+type Toast struct {
+	Level   slog.Level
+	Message string
+	Time    time.Time
+}
+
+// logSinks routes the records that reach the user: to stderr, or to the
+// TUI while it owns the terminal
+type logSinks struct {
+	mu      sync.Mutex
+	console io.Writer
+	toasts  chan Toast
+}
+
+var userLogSinks = &logSinks{console: os.Stderr}
+
+// SubscribeToasts sends warnings, errors and toast records to the returned
+// channel instead of stderr until the returned function is called
+func SubscribeToasts() (<-chan Toast, func()) {
+	ch := make(chan Toast, 32)
+	userLogSinks.mu.Lock()
+	userLogSinks.toasts = ch
+	userLogSinks.mu.Unlock()
+
+	return ch, func() {
+		userLogSinks.mu.Lock()
+		if userLogSinks.toasts == ch {
+			userLogSinks.toasts = nil
+		}
+		userLogSinks.mu.Unlock()
+	}
+}
+
+func (s *logSinks) deliver(record slog.Record, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.toasts != nil {
+		// A full channel means the TUI is behind; the file still has the record
+		select {
+		case s.toasts <- Toast{Level: record.Level, Message: message, Time: record.Time}:
+		default:
+		}
+		return
+	}
+	if s.console != nil {
+		fmt.Fprintln(s.console, message)
+	}
+}
+
+// userHandler passes records to the file handler and shows warnings, errors
+// and toast records to the user
+type userHandler struct {
+	next  slog.Handler
+	sinks *logSinks
+	attrs []slog.Attr
+}
+
+func (h *userHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	// Toast records are shown even when the file skips their level
+	return true
+}
+
+func (h *userHandler) Handle(ctx context.Context, record slog.Record) error {
+	toast := record.Level >= slog.LevelWarn
+	var component string
+	var details []string
+	collect := func(attr slog.Attr) bool {
+		switch attr.Key {
+		case toastKey:
+			toast = toast || attr.Value.Bool()
+		case "component":
+			component = attr.Value.String()
+		case "error":
+			details = append(details, attr.Value.String())
+		}
+		return true
+	}
+	for _, attr := range h.attrs {
+		collect(attr)
+	}
+	record.Attrs(collect)
+
+	if toast {
+		message := record.Message
+		if len(details) > 0 {
+			message += ": " + strings.Join(details, "; ")
+		}
+		if component != "" {
+			message = "[" + component + "] " + message
+		}
+		h.sinks.deliver(record, message)
+	}
+
+	if h.next.Enabled(ctx, record.Level) {
+		return h.next.Handle(ctx, record)
+	}
+	return nil
+}
+
+func (h *userHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &userHandler{next: h.next.WithAttrs(attrs), sinks: h.sinks, attrs: append(append([]slog.Attr(nil), h.attrs...), attrs...)}
+}
+
+func (h *userHandler) WithGroup(name string) slog.Handler {
+	return &userHandler{next: h.next.WithGroup(name), sinks: h.sinks, attrs: h.attrs}
+}
+
+// newLogHandler builds the handler that writes to w in the configured format
+func newLogHandler(w io.Writer, config LogConfig, sinks *logSinks) slog.Handler {
+	options := &slog.HandlerOptions{Level: config.Level}
+	var next slog.Handler
+	if config.JSON {
+		next = slog.NewJSONHandler(w, options)
+	} else {
+		next = slog.NewTextHandler(w, options)
+	}
+	return &userHandler{next: next, sinks: sinks}
+}
+
+// InitLogging makes the central logger the slog and log default. The
+// returned closer flushes the log file.
+func InitLogging(config LogConfig) (io.Closer, error) {
+	if config.MaxSize <= 0 {
+		config.MaxSize = defaultLogMaxSize
+	}
+	file, err := openRotatingFile(config.Dir, config.MaxSize, config.MaxFiles)
+	if err != nil {
+		return nil, err
+	}
+	slog.SetDefault(slog.New(newLogHandler(file, config, userLogSinks)))
+	return file, nil
+}
+
+// componentLogger returns the central logger tagged with a component name
+func componentLogger(component string) *slog.Logger {
+	return slog.Default().With("component", component)
+}
+
+// notifyUser logs an info message that is also shown to the user, e.g. the
+// outcome of an action that ran in the background
+func notifyUser(message string, args ...any) {
+	slog.Info(message, append(args, toastKey, true)...)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestLogHandler tests level filtering, the JSON format and which records
+// are shown to the user
+func TestLogHandler(t *testing.T) {
+	var file, console bytes.Buffer
+	sinks := &logSinks{console: &console}
+	logger := slog.New(newLogHandler(&file, LogConfig{Level: slog.LevelInfo, JSON: true}, sinks)).With("component", "Test")
+
+	logger.Debug("too detailed")
+	logger.Info("routine")
+	logger.Warn("disk almost full", "error", "93% used")
+	logger.Info("PR created", toastKey, true)
+
+	lines := strings.Split(strings.TrimSpace(file.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 records in the file, got %d:\n%s", len(lines), file.String())
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &record); err != nil {
+		t.Fatalf("Expected JSON records: %v", err)
+	}
+	if record["msg"] != "disk almost full" || record["component"] != "Test" || record["level"] != "WARN" {
+		t.Errorf("Unexpected record: %v", record)
+	}
+
+	expected := "[Test] disk almost full: 93% used\n[Test] PR created\n"
+	if console.String() != expected {
+		t.Errorf("Expected only the warning and the toast on the console, got %q", console.String())
+	}
+
+	// While the TUI runs, the same records become toasts
+	toasts := make(chan Toast, 4)
+	console.Reset()
+	sinks.toasts = toasts
+	logger.Error("push failed")
+	if console.Len() != 0 {
+		t.Errorf("Expected nothing on the console while the TUI runs, got %q", console.String())
+	}
+	select {
+	case toast := <-toasts:
+		if toast.Level != slog.LevelError || toast.Message != "[Test] push failed" {
+			t.Errorf("Unexpected toast: %+v", toast)
+		}
+	default:
+		t.Error("Expected a toast")
+	}
+}
+
+// TestRotatingFile tests that the log rotates and keeps a bounded number of files
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	file, err := openRotatingFile(dir, 100, 2)
+	if err != nil {
+		t.Fatalf("openRotatingFile failed: %v", err)
+	}
+	defer file.Close()
+
+	line := []byte(strings.Repeat("x", 59) + "\n")
+	for i := 0; i < 5; i++ {
+		if _, err := file.Write(line); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	for _, name := range []string{"relay.log", "relay.log.1", "relay.log.2"} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Expected %s: %v", name, err)
+		}
+		if info.Size() > 100 {
+			t.Errorf("Expected %s within the size limit, got %d bytes", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "relay.log.3")); !os.IsNotExist(err) {
+		t.Error("Expected only two rotated files to be kept")
+	}
+}
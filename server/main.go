@@ -1,14 +1,42 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
 )
 
 func main() {
+	// Log to ~/.relay/logs; warnings and errors also reach stderr or the TUI
+	if logConfig, err := LogConfigFromEnv(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	} else if logFile, err := InitLogging(logConfig); err != nil {
+		fmt.Printf("Warning: logging to a file is unavailable: %v\n", err)
+	} else {
+		defer logFile.Close()
+	}
+
+	// Record prompts and the commands Relay runs; Relay still works without it
+	if auditLog, err := NewAuditLog(); err != nil {
+		slog.Warn("Audit log unavailable", "error", err)
+	} else if auditLog != nil {
+		SetAuditLog(auditLog)
+		defer auditLog.Close()
+	}
+
 	// If no arguments provided, start with default project's TUI
 	if len(os.Args) < 2 {
 		handleStartTUI("")
@@ -40,6 +68,16 @@ func main() {
 		handleProjectStatus()
 	case "sync":
 		handleGitHubSync()
+	case "serve":
+		handleServe()
+	case "run-issue":
+		handleRunIssue()
+	case "undo":
+		handleUndo()
+	case "audit":
+		handleAudit()
+	case "config":
+		handleConfig()
 	default:
 		// If it's not a known command, treat it as a project name
 		handleStartTUI(command)
@@ -62,6 +100,11 @@ func printUsage() {
 	fmt.Println("  relay push              Push to current branch")
 	fmt.Println("  relay commit-push       Smart commit and push")
 	fmt.Println("  relay status            Show current project status")
+	fmt.Println("  relay serve             Start the HTTP/WebSocket API server")
+	fmt.Println("  relay run-issue <n>     Plan, implement and open a PR for an issue headlessly")
+	fmt.Println("  relay undo [n]          List checkpoints, or restore one")
+	fmt.Println("  relay audit [show <id>|export]  Query or export the audit log")
+	fmt.Println("  relay config get|set|unset|list  Read or change settings (--show-origin)")
 }
 
 func handleAddProject() {
@@ -92,7 +135,7 @@ func handleAddProject() {
 
 	pm, err := NewProjectManager()
 	if err != nil {
-		log.Printf("Failed to initialize project manager: %v", err)
+		slog.Error("Failed to initialize project manager", "error", err)
 		os.Exit(1)
 	}
 	defer pm.Close()
@@ -116,7 +159,7 @@ func handleOpenProject() {
 
 	pm, err := NewProjectManager()
 	if err != nil {
-		log.Printf("Failed to initialize project manager: %v", err)
+		slog.Error("Failed to initialize project manager", "error", err)
 		os.Exit(1)
 	}
 	defer pm.Close()
@@ -133,7 +176,7 @@ func handleOpenProject() {
 func handleListProjects() {
 	pm, err := NewProjectManager()
 	if err != nil {
-		log.Printf("Failed to initialize project manager: %v", err)
+		slog.Error("Failed to initialize project manager", "error", err)
 		os.Exit(1)
 	}
 	defer pm.Close()
@@ -171,7 +214,7 @@ func handleRemoveProject() {
 
 	pm, err := NewProjectManager()
 	if err != nil {
-		log.Printf("Failed to initialize project manager: %v", err)
+		slog.Error("Failed to initialize project manager", "error", err)
 		os.Exit(1)
 	}
 	defer pm.Close()
@@ -188,7 +231,7 @@ func handleRemoveProject() {
 func handleSmartCommit() {
 	pm, err := NewProjectManager()
 	if err != nil {
-		log.Printf("Failed to initialize project manager: %v", err)
+		slog.Error("Failed to initialize project manager", "error", err)
 		os.Exit(1)
 	}
 	defer pm.Close()
@@ -208,7 +251,7 @@ func handleSmartCommit() {
 	}
 	defer llmProvider.Close()
 
-	gitOps, err := NewGitOperations(project.Path, llmProvider)
+	gitOps, err := NewGitOperations(project.Name, project.Path, llmProvider)
 	if err != nil {
 		fmt.Printf("Error initializing git operations: %v\n", err)
 		os.Exit(1)
@@ -226,7 +269,7 @@ func handleSmartCommit() {
 func handleSmartPush() {
 	pm, err := NewProjectManager()
 	if err != nil {
-		log.Printf("Failed to initialize project manager: %v", err)
+		slog.Error("Failed to initialize project manager", "error", err)
 		os.Exit(1)
 	}
 	defer pm.Close()
@@ -246,7 +289,7 @@ func handleSmartPush() {
 	}
 	defer llmProvider.Close()
 
-	gitOps, err := NewGitOperations(project.Path, llmProvider)
+	gitOps, err := NewGitOperations(project.Name, project.Path, llmProvider)
 	if err != nil {
 		fmt.Printf("Error initializing git operations: %v\n", err)
 		os.Exit(1)
@@ -264,7 +307,7 @@ func handleSmartPush() {
 func handleSmartCommitPush() {
 	pm, err := NewProjectManager()
 	if err != nil {
-		log.Printf("Failed to initialize project manager: %v", err)
+		slog.Error("Failed to initialize project manager", "error", err)
 		os.Exit(1)
 	}
 	defer pm.Close()
@@ -284,7 +327,7 @@ func handleSmartCommitPush() {
 	}
 	defer llmProvider.Close()
 
-	gitOps, err := NewGitOperations(project.Path, llmProvider)
+	gitOps, err := NewGitOperations(project.Name, project.Path, llmProvider)
 	if err != nil {
 		fmt.Printf("Error initializing git operations: %v\n", err)
 		os.Exit(1)
@@ -302,7 +345,7 @@ func handleSmartCommitPush() {
 func handleProjectStatus() {
 	pm, err := NewProjectManager()
 	if err != nil {
-		log.Printf("Failed to initialize project manager: %v", err)
+		slog.Error("Failed to initialize project manager", "error", err)
 		os.Exit(1)
 	}
 	defer pm.Close()
@@ -384,3 +427,549 @@ func handleGitHubSync() {
 	fmt.Println("Note: Sync functionality has been replaced with direct GitHub integration.")
 	fmt.Println("Issues are now managed directly on GitHub. Use the TUI or CLI to interact with GitHub issues.")
 }
+
+func handleServe() {
+	serveCmd := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := serveCmd.String("addr", ":8787", "Address to listen on")
+	token := serveCmd.String("token", os.Getenv("RELAY_API_TOKEN"), "API token (defaults to $RELAY_API_TOKEN, or a generated one)")
+	tlsCert := serveCmd.String("tls-cert", "", "TLS certificate file")
+	tlsKey := serveCmd.String("tls-key", "", "TLS private key file")
+	var origins stringList
+	serveCmd.Var(&origins, "allow-origin", "Origin allowed to open WebSockets besides the server's own, e.g. http://localhost:3000 (repeatable)")
+	serveCmd.Parse(os.Args[2:])
+
+	if (*tlsCert == "") != (*tlsKey == "") {
+		fmt.Println("Error: --tls-cert and --tls-key must be used together")
+		os.Exit(1)
+	}
+
+	if *token == "" {
+		generated, err := generateAPIToken()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		*token = generated
+		fmt.Printf("Generated API token: %s\n", generated)
+	}
+
+	pm, err := NewProjectManager()
+	if err != nil {
+		slog.Error("Failed to initialize project manager", "error", err)
+		os.Exit(1)
+	}
+	defer pm.Close()
+
+	jobQueue, err := NewJobQueue(pm.db)
+	if err != nil {
+		slog.Error("Failed to initialize job queue", "error", err)
+		os.Exit(1)
+	}
+	defer jobQueue.Close()
+
+	server := NewAPIServer(pm, jobQueue, NewEventBus(), *token)
+	defer server.Close()
+	server.SetAllowedOrigins(origins)
+
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           server.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	scheme := "http"
+	if *tlsCert != "" {
+		scheme = "https"
+	}
+	fmt.Printf("Relay API listening on %s://%s%s\n", scheme, *addr, apiVersionPrefix)
+
+	if *tlsCert != "" {
+		err = httpServer.ListenAndServeTLS(*tlsCert, *tlsKey)
+	} else {
+		err = httpServer.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Printf("Server error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// stringList is a repeatable string flag
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ", ") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func handleRunIssue() {
+	runCmd := flag.NewFlagSet("run-issue", flag.ExitOnError)
+	projectName := runCmd.String("project", "", "Project to run in (defaults to the active project)")
+	baseBranch := runCmd.String("base", "", "Base branch for the worktree and PR (defaults to the runner config)")
+	noApprovals := runCmd.Bool("no-approvals", false, "Don't stop for approval at checkpoints")
+	approvals := runCmd.Bool("approvals", false, "Stop for approval at checkpoints even if the config turns them off")
+	replan := runCmd.Bool("replan", false, "Write a new plan even if the issue already has one")
+	var checks stringList
+	runCmd.Var(&checks, "check", "Check command to run before committing (repeatable, replaces configured checks)")
+
+	// Accept the issue number before or after the flags
+	args := os.Args[2:]
+	var numberArg string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		numberArg, args = args[0], args[1:]
+	}
+	runCmd.Parse(args)
+	if numberArg == "" {
+		numberArg = runCmd.Arg(0)
+	}
+
+	issueNumber, err := strconv.Atoi(strings.TrimPrefix(numberArg, "#"))
+	if err != nil || issueNumber <= 0 {
+		fmt.Println("Error: Issue number is required")
+		fmt.Println("Usage: relay run-issue <n> [--project name] [--base main] [--check cmd]... [--no-approvals] [--replan]")
+		os.Exit(1)
+	}
+
+	if *projectName == "" {
+		pm, err := NewProjectManager()
+		if err != nil {
+			slog.Error("Failed to initialize project manager", "error", err)
+			os.Exit(1)
+		}
+		project, err := pm.GetActiveProject()
+		pm.Close()
+		if err != nil {
+			fmt.Println("No active project. Use --project or 'relay open <project>' first.")
+			os.Exit(1)
+		}
+		*projectName = project.Name
+	}
+
+	session, err := NewRunnerSession(*projectName)
+	if err != nil {
+		fmt.Printf("Error creating session: %v\n", err)
+		os.Exit(1)
+	}
+	defer session.Close()
+
+	runner := NewIssueRunner(session, issueNumber)
+	runner.SetReplan(*replan)
+	if *baseBranch != "" {
+		runner.config.BaseBranch = *baseBranch
+	}
+	if len(checks) > 0 {
+		runner.config.Checks = checks
+	}
+
+	runner.OnLog = func(entry RunLogEntry) {
+		fmt.Printf("%s [%s] %s\n", entry.Time.Format("15:04:05"), entry.Stage, entry.Message)
+	}
+
+	switch {
+	case *noApprovals:
+		runner.SetApprovalsEnabled(false)
+	case *approvals:
+		runner.SetApprovalsEnabled(true)
+	}
+
+	// Without a terminal nobody can answer, so checkpoints are rejected rather than waited on forever
+	var stdinClosed atomic.Bool
+	runner.OnApproval = func(request ApprovalRequest) {
+		if stdinClosed.Load() {
+			fmt.Println("stdin is closed; rejecting. Use --no-approvals for unattended runs.")
+			go runner.Answer(false)
+			return
+		}
+		fmt.Printf("\n---- %s ----\n%s\n----\nApprove %s? [y/n] ", request.Stage, strings.TrimSpace(request.Summary), request.Stage)
+	}
+
+	fmt.Printf("Running issue #%d in %s. Type p to pause, r to resume, a to toggle approvals, Ctrl+C to cancel.\n",
+		issueNumber, *projectName)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+			case "y", "yes":
+				runner.Answer(true)
+			case "n", "no":
+				runner.Answer(false)
+			case "p", "pause":
+				runner.Pause()
+			case "r", "resume":
+				runner.Resume()
+			case "a", "approvals":
+				runner.SetApprovalsEnabled(!runner.Status().ApprovalsEnabled)
+			}
+		}
+		stdinClosed.Store(true)
+		if runner.Status().Pending != nil {
+			runner.Answer(false)
+		}
+	}()
+
+	if err := runner.Run(ctx); err != nil {
+		fmt.Printf("Issue #%d run %s: %v\n", issueNumber, runner.Status().State, err)
+		// os.Exit skips the deferred Close, which waits for the run's notifications
+		session.Close()
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Issue #%d done: %s\n", issueNumber, runner.Status().PRURL)
+}
+
+func handleUndo() {
+	undoCmd := flag.NewFlagSet("undo", flag.ExitOnError)
+	projectName := undoCmd.String("project", "", "Project whose checkpoints to use (defaults to the active project)")
+	dir := undoCmd.String("dir", "", "Worktree whose checkpoints to use, e.g. an issue worktree")
+	yes := undoCmd.Bool("yes", false, "Restore without asking for confirmation")
+
+	// Accept the checkpoint before or after the flags
+	args := os.Args[2:]
+	var key string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		key, args = args[0], args[1:]
+	}
+	undoCmd.Parse(args)
+	if key == "" {
+		key = undoCmd.Arg(0)
+	}
+
+	if *dir == "" {
+		pm, err := NewProjectManager()
+		if err != nil {
+			slog.Error("Failed to initialize project manager", "error", err)
+			os.Exit(1)
+		}
+		var project *Project
+		if *projectName != "" {
+			project, err = pm.GetProject(*projectName)
+		} else {
+			project, err = pm.GetActiveProject()
+		}
+		pm.Close()
+
+		if err == nil {
+			*dir = project.Path
+		} else if *projectName != "" {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		} else {
+			*dir = "."
+		}
+	}
+
+	ctx := context.Background()
+	checkpoints, err := ListCheckpoints(ctx, *dir)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if key == "" {
+		if len(checkpoints) == 0 {
+			fmt.Println("No checkpoints yet. Relay takes one before smart commits, prompts and agent steps.")
+			return
+		}
+		fmt.Printf("Checkpoints for %s (newest first):\n\n", checkpoints[0].Worktree)
+		for i, checkpoint := range checkpoints {
+			fmt.Printf("  %3d  %s  %s\n", i+1, checkpoint.CreatedAt.Format("2006-01-02 15:04:05"), checkpoint.Trigger)
+			fmt.Printf("       %s\n", CheckpointDiffstat(ctx, checkpoint))
+		}
+		fmt.Println("\nRestore one with: relay undo <n>")
+		return
+	}
+
+	checkpoint, err := FindCheckpoint(checkpoints, key)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Restore %s to the checkpoint from %s (%s)?\n", checkpoint.Worktree, checkpoint.CreatedAt.Format("2006-01-02 15:04:05"), checkpoint.Trigger)
+	if !*yes {
+		fmt.Print("The current state is checkpointed first. Continue? [y/N] ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Println("Cancelled")
+			return
+		}
+	}
+
+	safety, err := RestoreCheckpoint(ctx, *dir, checkpoint)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("✅ Restored")
+	fmt.Printf("The previous state is checkpoint %s; run 'relay undo' to see it\n", safety.ID)
+}
+
+func handleAudit() {
+	args := os.Args[2:]
+	subcommand := "list"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		subcommand, args = args[0], args[1:]
+	}
+
+	auditCmd := flag.NewFlagSet("audit "+subcommand, flag.ExitOnError)
+	projectName := auditCmd.String("project", "", "Only events for this project")
+	issueNumber := auditCmd.Int("issue", 0, "Only events for this issue")
+	actor := auditCmd.String("actor", "", "Only events by this actor (user or agent)")
+	kind := auditCmd.String("kind", "", "Only events of this kind (llm, tool or command)")
+	since := auditCmd.String("since", "", "Only events since a duration ago (e.g. 24h) or a date (2006-01-02)")
+	search := auditCmd.String("grep", "", "Only events whose prompt, response or command contains this text")
+	limit := auditCmd.Int("limit", 50, "Maximum number of events (0 for all)")
+	format := auditCmd.String("format", "jsonl", "Export format: jsonl or csv")
+	output := auditCmd.String("output", "", "Export to this file instead of stdout")
+
+	// Accept the event ID before or after the flags
+	var idArg string
+	if subcommand == "show" && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		idArg, args = args[0], args[1:]
+	}
+	auditCmd.Parse(args)
+
+	auditLog := defaultAuditLog.Load()
+	if auditLog == nil {
+		fmt.Println("The audit log is disabled in ~/.relay/audit.json")
+		os.Exit(1)
+	}
+
+	filter := AuditFilter{
+		Project:     *projectName,
+		IssueNumber: *issueNumber,
+		Actor:       *actor,
+		Kind:        AuditKind(*kind),
+		Search:      *search,
+		Limit:       *limit,
+	}
+	if *since != "" {
+		t, err := parseAuditSince(*since, time.Now())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		filter.Since = t
+	}
+
+	switch subcommand {
+	case "list":
+		events, err := auditLog.Query(filter)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(events) == 0 {
+			fmt.Println("No audit events match")
+			return
+		}
+		for _, event := range events {
+			fmt.Println(formatAuditLine(event))
+		}
+		fmt.Println("\nShow an event in full with: relay audit show <id>")
+
+	case "show":
+		if idArg == "" {
+			idArg = auditCmd.Arg(0)
+		}
+		id, err := strconv.ParseInt(idArg, 10, 64)
+		if err != nil {
+			fmt.Println("Usage: relay audit show <id>")
+			os.Exit(1)
+		}
+		event, err := auditLog.Get(id)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if event == nil {
+			fmt.Printf("No audit event %d\n", id)
+			os.Exit(1)
+		}
+		fmt.Print(formatAuditEvent(event))
+
+	case "export":
+		// Exports cover everything that matches unless a limit is given
+		if !flagWasSet(auditCmd, "limit") {
+			filter.Limit = 0
+		}
+		events, err := auditLog.Query(filter)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		out := os.Stdout
+		if *output != "" {
+			file, err := os.Create(*output)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			defer file.Close()
+			out = file
+		}
+		if err := ExportAuditEvents(out, events, *format); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if *output != "" {
+			fmt.Printf("Exported %d events to %s\n", len(events), *output)
+		}
+
+	default:
+		fmt.Println("Usage: relay audit [list|show <id>|export] [--project p] [--issue n] [--actor user|agent] [--kind llm|tool|command] [--since 24h] [--grep text] [--limit n]")
+		os.Exit(1)
+	}
+}
+
+func handleConfig() {
+	args := os.Args[2:]
+	subcommand := "list"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		subcommand, args = args[0], args[1:]
+	}
+
+	configCmd := flag.NewFlagSet("config "+subcommand, flag.ExitOnError)
+	projectName := configCmd.String("project", "", "Project whose settings to use (defaults to the active project)")
+	global := configCmd.Bool("global", false, "Change ~/.relay/config.json, for every project")
+	shared := configCmd.Bool("shared", false, "Change the project's .relay/config.json, shared through the repository")
+	showOrigin := configCmd.Bool("show-origin", false, "Show the layer and file or variable each value comes from")
+
+	// Accept the key and value before or after the flags
+	var positional []string
+	for len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		positional, args = append(positional, args[0]), args[1:]
+	}
+	configCmd.Parse(args)
+	positional = append(positional, configCmd.Args()...)
+
+	usage := "Usage: relay config [get <key>|set <key> <value>|unset <key>|list [prefix]] [--project p] [--global|--shared] [--show-origin]"
+	if *global && *shared {
+		fmt.Println("Error: --global and --shared cannot be combined")
+		os.Exit(1)
+	}
+	// Changes stay on this machine unless asked otherwise
+	layer := LayerLocal
+	if *global {
+		layer = LayerGlobal
+	} else if *shared {
+		layer = LayerProject
+	}
+
+	pm, err := NewProjectManager()
+	if err != nil {
+		slog.Error("Failed to initialize project manager", "error", err)
+		os.Exit(1)
+	}
+	defer pm.Close()
+
+	// Without a project only the global settings apply
+	var project *Project
+	if *projectName != "" {
+		project, err = pm.GetProject(*projectName)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	} else if active, err := pm.GetActiveProject(); err == nil {
+		project = active
+	} else if (subcommand == "set" || subcommand == "unset") && layer != LayerGlobal {
+		fmt.Println("Error: no active project; pass --project or --global")
+		os.Exit(1)
+	}
+
+	relayDir, err := relayHomeDir()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	settings, err := LoadSettings(relayDir, project, pm.db)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	printValue := func(value SettingValue) {
+		if *showOrigin {
+			fmt.Printf("%-8s %s\t%s=%s\n", value.Layer, value.Source, value.Key, value)
+		} else {
+			fmt.Printf("%s=%s\n", value.Key, value)
+		}
+	}
+
+	switch {
+	case subcommand == "list" && len(positional) <= 1:
+		prefix := ""
+		if len(positional) == 1 {
+			prefix = positional[0]
+		}
+		for _, value := range settings.Values(prefix) {
+			printValue(value)
+		}
+
+	case subcommand == "get" && len(positional) == 1:
+		values := settings.Values(positional[0])
+		if len(values) == 0 {
+			fmt.Printf("%s is not set\n", positional[0])
+			os.Exit(1)
+		}
+		for _, value := range values {
+			if !*showOrigin && len(values) == 1 && value.Key == positional[0] {
+				fmt.Println(value)
+				continue
+			}
+			printValue(value)
+		}
+
+	case subcommand == "set" && len(positional) == 2:
+		if err := settings.Set(layer, positional[0], positional[1]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Set %s in the %s settings\n", positional[0], layer)
+		for _, value := range settings.Values(positional[0]) {
+			if value.Key == positional[0] && value.Layer != layer {
+				fmt.Printf("Note: the %s value from %s takes precedence\n", value.Layer, value.Source)
+			}
+		}
+
+	case subcommand == "unset" && len(positional) == 1:
+		if err := settings.Unset(layer, positional[0]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Unset %s in the %s settings\n", positional[0], layer)
+
+	default:
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+}
+
+// flagWasSet reports whether a flag was given on the command line
+func flagWasSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
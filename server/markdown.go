@@ -0,0 +1,169 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Issue bodies and LLM responses are markdown. renderMarkdown draws the
+// common subset for the terminal: headings, lists with task checkboxes,
+// block quotes, fenced code, rules and inline emphasis, code and links.
+
+var (
+	// Built from the theme by applyTheme
+	mdHeadingStyle   lipgloss.Style
+	mdTitleStyle     lipgloss.Style
+	mdCodeBlockStyle lipgloss.Style
+	mdCodeFenceStyle lipgloss.Style
+	mdInlineCode     lipgloss.Style
+	mdBoldStyle      = lipgloss.NewStyle().Bold(true)
+	mdItalicStyle    = lipgloss.NewStyle().Italic(true)
+	mdLinkStyle      lipgloss.Style
+	mdQuoteStyle     lipgloss.Style
+	mdBulletStyle    lipgloss.Style
+	mdDoneStyle      lipgloss.Style
+
+	mdHeadingPattern = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdListPattern    = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	mdTaskPattern    = regexp.MustCompile(`^\[([ xX])\]\s+(.*)$`)
+	mdRulePattern    = regexp.MustCompile(`^\s*([-*_])(\s*([-*_])){2,}\s*$`)
+
+	mdCodeSpan   = regexp.MustCompile("`([^`]+)`")
+	mdBoldSpan   = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	mdItalicSpan = regexp.MustCompile(`(^|[^\w*])[*_]([^*_\s][^*_]*)[*_]`)
+	mdLinkSpan   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+)
+
+// renderMarkdown renders markdown for a terminal of the given width
+func renderMarkdown(source string, width int) string {
+	if width < 20 {
+		width = 20
+	}
+
+	var out []string
+	lines := strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n")
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			out = append(out, wrapStyled(renderInline(strings.Join(paragraph, " ")), width, ""))
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			flush()
+			fence := trimmed[:3]
+			language := strings.TrimSpace(trimmed[3:])
+			out = append(out, mdCodeFenceStyle.Render("┌─ "+language))
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code := strings.ReplaceAll(lines[i], "\t", "    ")
+				out = append(out, mdCodeFenceStyle.Render("│ ")+mdCodeBlockStyle.Render(code))
+			}
+			out = append(out, mdCodeFenceStyle.Render("└─"))
+
+		case trimmed == "":
+			flush()
+			if len(out) > 0 && out[len(out)-1] != "" {
+				out = append(out, "")
+			}
+
+		case mdHeadingPattern.MatchString(trimmed):
+			flush()
+			match := mdHeadingPattern.FindStringSubmatch(trimmed)
+			style := mdHeadingStyle
+			if len(match[1]) == 1 {
+				style = mdTitleStyle
+			}
+			out = append(out, style.Render(match[2]))
+
+		case mdRulePattern.MatchString(trimmed):
+			flush()
+			out = append(out, mdCodeFenceStyle.Render(strings.Repeat("─", min(width, 40))))
+
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			quote := strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+			out = append(out, wrapStyled(mdQuoteStyle.Render(renderInline(quote)), width, mdCodeFenceStyle.Render("│ ")))
+
+		case mdListPattern.MatchString(line):
+			flush()
+			match := mdListPattern.FindStringSubmatch(line)
+			indent := strings.Repeat("  ", len(strings.ReplaceAll(match[1], "\t", "  "))/2)
+			marker, text := mdBulletStyle.Render("•"), match[3]
+			if match[2][0] >= '0' && match[2][0] <= '9' {
+				marker = mdBulletStyle.Render(match[2])
+			}
+			if task := mdTaskPattern.FindStringSubmatch(text); task != nil {
+				marker, text = "☐", task[2]
+				if task[1] != " " {
+					marker, text = mdDoneStyle.Render("☑"), mdDoneStyle.Render(task[2])
+				}
+			}
+			out = append(out, wrapStyled(renderInline(text), width, indent+marker+" "))
+
+		default:
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	flush()
+
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	return strings.Join(out, "\n")
+}
+
+// renderInline styles code spans, links, bold and italic text
+func renderInline(text string) string {
+	// Code spans are styled last so their contents stay literal
+	var spans []string
+	text = mdCodeSpan.ReplaceAllStringFunc(text, func(span string) string {
+		spans = append(spans, mdInlineCode.Render(span[1:len(span)-1]))
+		return "\x00" + string(rune('0'+len(spans)-1)) + "\x00"
+	})
+
+	text = mdLinkSpan.ReplaceAllStringFunc(text, func(link string) string {
+		match := mdLinkSpan.FindStringSubmatch(link)
+		if match[1] == match[2] {
+			return mdLinkStyle.Render(match[2])
+		}
+		return match[1] + " (" + mdLinkStyle.Render(match[2]) + ")"
+	})
+	text = mdBoldSpan.ReplaceAllStringFunc(text, func(bold string) string {
+		return mdBoldStyle.Render(bold[2 : len(bold)-2])
+	})
+	text = mdItalicSpan.ReplaceAllStringFunc(text, func(italic string) string {
+		match := mdItalicSpan.FindStringSubmatch(italic)
+		return match[1] + mdItalicStyle.Render(match[2])
+	})
+
+	for i, span := range spans {
+		text = strings.Replace(text, "\x00"+string(rune('0'+i))+"\x00", span, 1)
+	}
+	return text
+}
+
+// wrapStyled soft-wraps styled text to width, putting prefix before the
+// first line and indenting the rest to match
+func wrapStyled(text string, width int, prefix string) string {
+	prefixWidth := lipgloss.Width(prefix)
+	wrapped := lipgloss.NewStyle().Width(width - prefixWidth).Render(text)
+
+	lines := strings.Split(wrapped, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " ")
+		if i == 0 {
+			lines[i] = prefix + lines[i]
+		} else {
+			lines[i] = strings.Repeat(" ", prefixWidth) + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestRenderMarkdown tests headings, lists, task boxes, code blocks and
+// inline markup
+func TestRenderMarkdown(t *testing.T) {
+	source := "# Login fails\n\n" +
+		"Users see **500** on `POST /login`, see [the log](https://example.com/log).\n\n" +
+		"## Steps\n" +
+		"- [ ] reproduce\n" +
+		"- [x] add test\n" +
+		"1. first\n\n" +
+		"```go\n" +
+		"func **notBold**() {}\n" +
+		"```\n" +
+		"> quoted\n"
+
+	rendered := renderMarkdown(source, 80)
+
+	for _, expected := range []string{
+		"Login fails",
+		"Users see 500 on POST /login, see the log (https://example.com/log).",
+		"☐ reproduce",
+		"☑ add test",
+		"1. first",
+		"┌─ go",
+		"│ func **notBold**() {}",
+		"│ quoted",
+	} {
+		if !strings.Contains(rendered, expected) {
+			t.Errorf("Expected %q in:\n%s", expected, rendered)
+		}
+	}
+	if strings.Contains(rendered, "# Login") || strings.Contains(rendered, "- [ ]") {
+		t.Errorf("Expected markdown syntax to be rendered:\n%s", rendered)
+	}
+}
+
+// TestRenderMarkdownWrap tests that paragraphs and list items wrap to the width
+func TestRenderMarkdownWrap(t *testing.T) {
+	rendered := renderMarkdown("- "+strings.Repeat("word ", 20), 30)
+	lines := strings.Split(rendered, "\n")
+	if len(lines) < 3 {
+		t.Fatalf("Expected the item to wrap, got:\n%s", rendered)
+	}
+	for _, line := range lines {
+		if len([]rune(line)) > 30 {
+			t.Errorf("Line wider than 30: %q", line)
+		}
+	}
+	if !strings.HasPrefix(lines[1], "  word") {
+		t.Errorf("Expected wrapped lines to be indented under the bullet, got %q", lines[1])
+	}
+}
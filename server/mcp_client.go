@@ -0,0 +1,566 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mcpProtocolVersion is the MCP revision Relay speaks as a client
+const mcpProtocolVersion = "2024-11-05"
+
+// mcpToolSeparator joins server and tool names into the name offered to the model
+const mcpToolSeparator = "__"
+
+// MCPServerConfig describes a stdio MCP server in .relay/config.json, in the
+// same shape other MCP hosts use for their "mcpServers" section
+type MCPServerConfig struct {
+	Command  string            `json:"command"`
+	Args     []string          `json:"args,omitempty"`
+	Env      map[string]string `json:"env,omitempty"`
+	Cwd      string            `json:"cwd,omitempty"`
+	Disabled bool              `json:"disabled,omitempty"`
+}
+
+// MCPServerState is the connection state of an MCP server
+type MCPServerState string
+
+const (
+	MCPStarting  MCPServerState = "starting"
+	MCPConnected MCPServerState = "connected"
+	MCPUnhealthy MCPServerState = "unhealthy"
+	MCPFailed    MCPServerState = "failed"
+	MCPStopped   MCPServerState = "stopped"
+	MCPDisabled  MCPServerState = "disabled"
+)
+
+// MCPTool is a tool discovered on an MCP server
+type MCPTool struct {
+	Server      string          `json:"server"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+}
+
+// QualifiedName is the tool name offered to the model: server__tool
+func (t MCPTool) QualifiedName() string {
+	return sanitizeToolName(t.Server + mcpToolSeparator + t.Name)
+}
+
+var toolNameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// sanitizeToolName makes a name acceptable to the Claude and OpenAI tool APIs
+func sanitizeToolName(name string) string {
+	name = toolNameInvalidChars.ReplaceAllString(name, "_")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// MCPServerStatus is a snapshot of a server for display
+type MCPServerStatus struct {
+	Name        string         `json:"name"`
+	State       MCPServerState `json:"state"`
+	ServerName  string         `json:"server_name,omitempty"`
+	Version     string         `json:"version,omitempty"`
+	Tools       []MCPTool      `json:"tools"`
+	Error       string         `json:"error,omitempty"`
+	LastChecked time.Time      `json:"last_checked"`
+	Latency     time.Duration  `json:"latency"`
+	Stderr      []string       `json:"stderr,omitempty"`
+}
+
+// mcpRPCResponse is a JSON-RPC response from a server
+type mcpRPCResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// MCPClient is a connection to one stdio MCP server
+type MCPClient struct {
+	name   string
+	config MCPServerConfig
+	dir    string
+
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan mcpRPCResponse
+	status  MCPServerStatus
+	stderr  []string
+	done    chan struct{}
+}
+
+// NewMCPClient creates a client for a configured server; relative cwd values resolve against projectPath
+func NewMCPClient(name string, config MCPServerConfig, projectPath string) *MCPClient {
+	dir := config.Cwd
+	if dir == "" {
+		dir = projectPath
+	} else if !filepath.IsAbs(dir) {
+		dir = filepath.Join(projectPath, dir)
+	}
+
+	return &MCPClient{
+		name:    name,
+		config:  config,
+		dir:     dir,
+		pending: make(map[int64]chan mcpRPCResponse),
+		status:  MCPServerStatus{Name: name, State: MCPStarting, Tools: []MCPTool{}},
+		done:    make(chan struct{}),
+	}
+}
+
+// Start spawns the server, performs the initialize handshake and discovers its tools
+func (c *MCPClient) Start(ctx context.Context) error {
+	if c.config.Command == "" {
+		return c.fail(fmt.Errorf("no command configured"))
+	}
+
+	cmd := exec.Command(c.config.Command, c.config.Args...)
+	cmd.Dir = c.dir
+	cmd.Env = os.Environ()
+	for key, value := range c.config.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return c.fail(fmt.Errorf("failed to open stdin: %w", err))
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return c.fail(fmt.Errorf("failed to open stdout: %w", err))
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return c.fail(fmt.Errorf("failed to open stderr: %w", err))
+	}
+
+	if err := cmd.Start(); err != nil {
+		return c.fail(fmt.Errorf("failed to start %s: %w", c.config.Command, err))
+	}
+
+	c.mu.Lock()
+	c.cmd = cmd
+	c.stdin = stdin
+	c.mu.Unlock()
+
+	go c.readLoop(stdout)
+	go c.stderrLoop(stderr)
+
+	var initResult struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		ServerInfo      struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"serverInfo"`
+	}
+	err = c.call(ctx, "initialize", map[string]interface{}{
+		"protocolVersion": mcpProtocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]string{"name": "relay", "version": "1.0.0"},
+	}, &initResult)
+	if err != nil {
+		c.Close()
+		return c.fail(fmt.Errorf("initialize failed: %w", err))
+	}
+
+	if err := c.notify("notifications/initialized", nil); err != nil {
+		c.Close()
+		return c.fail(err)
+	}
+
+	c.mu.Lock()
+	c.status.ServerName = initResult.ServerInfo.Name
+	c.status.Version = initResult.ServerInfo.Version
+	c.mu.Unlock()
+
+	if err := c.RefreshTools(ctx); err != nil {
+		c.Close()
+		return c.fail(err)
+	}
+
+	c.mu.Lock()
+	c.status.State = MCPConnected
+	c.status.Error = ""
+	c.status.LastChecked = time.Now()
+	c.mu.Unlock()
+
+	return nil
+}
+
+func (c *MCPClient) fail(err error) error {
+	c.mu.Lock()
+	c.status.State = MCPFailed
+	c.status.Error = err.Error()
+	c.status.LastChecked = time.Now()
+	c.mu.Unlock()
+	return fmt.Errorf("MCP server %s: %w", c.name, err)
+}
+
+// readLoop routes responses to waiting callers until the server exits
+func (c *MCPClient) readLoop(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+
+	for scanner.Scan() {
+		var response mcpRPCResponse
+		if err := json.Unmarshal(scanner.Bytes(), &response); err != nil || response.ID == nil {
+			continue // Ignore server notifications and noise
+		}
+
+		var id int64
+		if err := json.Unmarshal(response.ID, &id); err != nil {
+			continue
+		}
+
+		c.mu.Lock()
+		ch, ok := c.pending[id]
+		delete(c.pending, id)
+		c.mu.Unlock()
+
+		if ok {
+			ch <- response
+		}
+	}
+
+	c.mu.Lock()
+	if c.status.State != MCPFailed {
+		c.status.State = MCPStopped
+	}
+	if c.status.Error == "" {
+		c.status.Error = "server exited"
+	}
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+	c.mu.Unlock()
+
+	close(c.done)
+}
+
+// stderrLoop keeps the last lines the server logged, since stdout is reserved for the protocol
+func (c *MCPClient) stderrLoop(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		c.mu.Lock()
+		c.stderr = append(c.stderr, scanner.Text())
+		if len(c.stderr) > 20 {
+			c.stderr = c.stderr[len(c.stderr)-20:]
+		}
+		c.mu.Unlock()
+	}
+}
+
+func (c *MCPClient) write(message interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if _, err := c.stdin.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write to server: %w", err)
+	}
+	return nil
+}
+
+func (c *MCPClient) notify(method string, params interface{}) error {
+	message := map[string]interface{}{"jsonrpc": "2.0", "method": method}
+	if params != nil {
+		message["params"] = params
+	}
+	return c.write(message)
+}
+
+// call sends a request and decodes the result into result (if non-nil)
+func (c *MCPClient) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	c.mu.Lock()
+	c.nextID++
+	id := c.nextID
+	ch := make(chan mcpRPCResponse, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	message := map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method}
+	if params != nil {
+		message["params"] = params
+	}
+	if err := c.write(message); err != nil {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return err
+	}
+
+	select {
+	case response, ok := <-ch:
+		if !ok {
+			return fmt.Errorf("server exited before responding to %s", method)
+		}
+		if response.Error != nil {
+			return fmt.Errorf("%s failed (%d): %s", method, response.Error.Code, response.Error.Message)
+		}
+		if result != nil {
+			if err := json.Unmarshal(response.Result, result); err != nil {
+				return fmt.Errorf("failed to parse %s result: %w", method, err)
+			}
+		}
+		return nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// RefreshTools re-reads the server's tool list
+func (c *MCPClient) RefreshTools(ctx context.Context) error {
+	var result struct {
+		Tools []MCPTool `json:"tools"`
+	}
+	if err := c.call(ctx, "tools/list", map[string]interface{}{}, &result); err != nil {
+		return fmt.Errorf("tools/list failed: %w", err)
+	}
+
+	for i := range result.Tools {
+		result.Tools[i].Server = c.name
+		if len(result.Tools[i].InputSchema) == 0 {
+			result.Tools[i].InputSchema = json.RawMessage(`{"type":"object","properties":{}}`)
+		}
+	}
+
+	c.mu.Lock()
+	c.status.Tools = result.Tools
+	c.mu.Unlock()
+	return nil
+}
+
+// CallTool runs a tool and returns its text content. Tool-level failures
+// (isError) are returned as errors carrying the tool's message.
+func (c *MCPClient) CallTool(ctx context.Context, name string, arguments json.RawMessage) (string, error) {
+	if len(arguments) == 0 {
+		arguments = json.RawMessage("{}")
+	}
+
+	var result struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		IsError bool `json:"isError"`
+	}
+	params := map[string]interface{}{"name": name, "arguments": arguments}
+	if err := c.call(ctx, "tools/call", params, &result); err != nil {
+		return "", err
+	}
+
+	var text []string
+	for _, content := range result.Content {
+		if content.Type == "text" {
+			text = append(text, content.Text)
+		} else {
+			text = append(text, fmt.Sprintf("[%s content]", content.Type))
+		}
+	}
+
+	output := strings.Join(text, "\n")
+	if result.IsError {
+		return output, fmt.Errorf("tool %s failed: %s", name, output)
+	}
+	return output, nil
+}
+
+// Ping checks the server responds, updating its health
+func (c *MCPClient) Ping(ctx context.Context) error {
+	c.mu.Lock()
+	state := c.status.State
+	c.mu.Unlock()
+	if state != MCPConnected && state != MCPUnhealthy {
+		return fmt.Errorf("server is %s", state)
+	}
+
+	start := time.Now()
+	err := c.call(ctx, "ping", nil, nil)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status.LastChecked = time.Now()
+	c.status.Latency = time.Since(start)
+	if c.status.State == MCPStopped || c.status.State == MCPFailed {
+		return err
+	}
+	if err != nil {
+		c.status.State = MCPUnhealthy
+		c.status.Error = err.Error()
+		return err
+	}
+	c.status.State = MCPConnected
+	c.status.Error = ""
+	return nil
+}
+
+// Status returns a snapshot of the server's state and tools
+func (c *MCPClient) Status() MCPServerStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := c.status
+	status.Tools = append([]MCPTool(nil), c.status.Tools...)
+	status.Stderr = append([]string(nil), c.stderr...)
+	return status
+}
+
+// Close stops the server process
+func (c *MCPClient) Close() error {
+	c.mu.Lock()
+	cmd, stdin := c.cmd, c.stdin
+	c.mu.Unlock()
+
+	if cmd == nil {
+		return nil
+	}
+
+	// Closing stdin asks a well-behaved server to exit; kill it if it doesn't
+	stdin.Close()
+	select {
+	case <-c.done:
+	case <-time.After(2 * time.Second):
+		cmd.Process.Kill()
+		<-c.done
+	}
+	cmd.Wait()
+
+	c.mu.Lock()
+	c.status.State = MCPStopped
+	c.mu.Unlock()
+	return nil
+}
+
+// MCPManager owns the project's MCP server connections and exposes their tools
+type MCPManager struct {
+	clients []*MCPClient
+	byName  map[string]*MCPClient
+	wg      sync.WaitGroup
+}
+
+// NewMCPManager creates clients for the configured servers without starting them
+func NewMCPManager(configs map[string]MCPServerConfig, projectPath string) *MCPManager {
+	m := &MCPManager{byName: make(map[string]*MCPClient)}
+
+	names := make([]string, 0, len(configs))
+	for name := range configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		client := NewMCPClient(name, configs[name], projectPath)
+		if configs[name].Disabled {
+			client.status.State = MCPDisabled
+		}
+		m.clients = append(m.clients, client)
+		m.byName[name] = client
+	}
+
+	return m
+}
+
+// Start connects to every enabled server concurrently. Failures are recorded
+// in each server's status rather than returned, so one bad server doesn't block the rest.
+func (m *MCPManager) Start(ctx context.Context) {
+	for _, client := range m.clients {
+		if client.config.Disabled {
+			continue
+		}
+		m.wg.Add(1)
+		go func(client *MCPClient) {
+			defer m.wg.Done()
+			startCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			defer cancel()
+			client.Start(startCtx)
+		}(client)
+	}
+}
+
+// Wait blocks until every server has finished starting
+func (m *MCPManager) Wait() {
+	m.wg.Wait()
+}
+
+// Tools returns the tools of all connected servers
+func (m *MCPManager) Tools() []MCPTool {
+	var tools []MCPTool
+	for _, client := range m.clients {
+		status := client.Status()
+		if status.State == MCPConnected || status.State == MCPUnhealthy {
+			tools = append(tools, status.Tools...)
+		}
+	}
+	return tools
+}
+
+// CallTool routes a qualified tool name (server__tool) to its server
+func (m *MCPManager) CallTool(ctx context.Context, qualifiedName string, arguments json.RawMessage) (string, error) {
+	for _, tool := range m.Tools() {
+		if tool.QualifiedName() == qualifiedName {
+			return m.byName[tool.Server].CallTool(ctx, tool.Name, arguments)
+		}
+	}
+	return "", fmt.Errorf("unknown tool: %s", qualifiedName)
+}
+
+// Statuses returns a snapshot of every configured server
+func (m *MCPManager) Statuses() []MCPServerStatus {
+	statuses := make([]MCPServerStatus, 0, len(m.clients))
+	for _, client := range m.clients {
+		statuses = append(statuses, client.Status())
+	}
+	return statuses
+}
+
+// CheckHealth pings every connected server
+func (m *MCPManager) CheckHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, client := range m.clients {
+		wg.Add(1)
+		go func(client *MCPClient) {
+			defer wg.Done()
+			pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			client.Ping(pingCtx)
+		}(client)
+	}
+	wg.Wait()
+}
+
+// Close stops every server
+func (m *MCPManager) Close() error {
+	m.wg.Wait()
+	for _, client := range m.clients {
+		client.Close()
+	}
+	return nil
+}
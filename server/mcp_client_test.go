@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestMain lets the test binary double as a tiny MCP server: tests spawn
+// os.Args[0] with RELAY_FAKE_MCP=1 to get a real stdio server process.
+func TestMain(m *testing.M) {
+	if os.Getenv("RELAY_FAKE_MCP") == "1" {
+		runFakeMCPServer()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runFakeMCPServer answers initialize, ping, tools/list and tools/call with an echo and a failing tool
+func runFakeMCPServer() {
+	fmt.Fprintln(os.Stderr, "fake server starting")
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var request struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params struct {
+				Name      string `json:"name"`
+				Arguments struct {
+					Text string `json:"text"`
+				} `json:"arguments"`
+			} `json:"params"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil || request.ID == nil {
+			continue
+		}
+
+		var result interface{}
+		switch request.Method {
+		case "initialize":
+			result = map[string]interface{}{
+				"protocolVersion": mcpProtocolVersion,
+				"serverInfo":      map[string]string{"name": "fake", "version": "0.1.0"},
+			}
+		case "ping":
+			result = map[string]interface{}{}
+		case "tools/list":
+			result = map[string]interface{}{"tools": []map[string]interface{}{
+				{"name": "echo", "description": "Echo text back", "inputSchema": map[string]interface{}{
+					"type": "object", "properties": map[string]interface{}{"text": map[string]string{"type": "string"}},
+				}},
+				{"name": "fail", "description": "Always fails"},
+			}}
+		case "tools/call":
+			if request.Params.Name == "fail" {
+				result = map[string]interface{}{"content": []map[string]string{{"type": "text", "text": "boom"}}, "isError": true}
+			} else {
+				result = map[string]interface{}{"content": []map[string]string{{"type": "text", "text": request.Params.Arguments.Text}}}
+			}
+		}
+
+		response := map[string]interface{}{"jsonrpc": "2.0", "id": request.ID, "result": result}
+		if result == nil {
+			response = map[string]interface{}{"jsonrpc": "2.0", "id": request.ID,
+				"error": map[string]interface{}{"code": -32601, "message": "method not found"}}
+		}
+		data, _ := json.Marshal(response)
+		fmt.Println(string(data))
+	}
+}
+
+func newFakeMCPManager(t *testing.T, extra map[string]MCPServerConfig) *MCPManager {
+	t.Helper()
+	configs := map[string]MCPServerConfig{
+		"fake": {Command: os.Args[0], Env: map[string]string{"RELAY_FAKE_MCP": "1"}},
+	}
+	for name, config := range extra {
+		configs[name] = config
+	}
+
+	manager := NewMCPManager(configs, t.TempDir())
+	manager.Start(context.Background())
+	manager.Wait()
+	t.Cleanup(func() { manager.Close() })
+	return manager
+}
+
+// TestMCPManager tests discovery, routing, tool errors and health across servers
+func TestMCPManager(t *testing.T) {
+	manager := newFakeMCPManager(t, map[string]MCPServerConfig{
+		"broken": {Command: "/nonexistent/mcp-server"},
+		"off":    {Command: os.Args[0], Disabled: true},
+	})
+
+	states := make(map[string]MCPServerState)
+	for _, status := range manager.Statuses() {
+		states[status.Name] = status.State
+	}
+	if states["fake"] != MCPConnected || states["broken"] != MCPFailed || states["off"] != MCPDisabled {
+		t.Fatalf("Unexpected server states: %v", states)
+	}
+
+	tools := manager.Tools()
+	if len(tools) != 2 || tools[0].QualifiedName() != "fake__echo" {
+		t.Fatalf("Expected fake's two tools, got %+v", tools)
+	}
+
+	output, err := manager.CallTool(context.Background(), "fake__echo", json.RawMessage(`{"text":"hello"}`))
+	if err != nil || output != "hello" {
+		t.Errorf("Expected echo, got %q (%v)", output, err)
+	}
+
+	if _, err := manager.CallTool(context.Background(), "fake__fail", nil); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Expected tool failure, got %v", err)
+	}
+
+	if _, err := manager.CallTool(context.Background(), "broken__anything", nil); err == nil {
+		t.Error("Expected unknown tool error")
+	}
+
+	manager.CheckHealth(context.Background())
+	status := manager.Statuses()[1]
+	if status.Name != "fake" || status.State != MCPConnected || status.LastChecked.IsZero() {
+		t.Errorf("Expected healthy fake server, got %+v", status)
+	}
+	if len(status.Stderr) == 0 || status.Stderr[0] != "fake server starting" {
+		t.Errorf("Expected captured stderr, got %v", status.Stderr)
+	}
+
+	manager.Close()
+	if len(manager.Tools()) != 0 {
+		t.Error("Expected no tools after close")
+	}
+}
+
+// TestProviderToolLoops tests that API providers offer MCP tools and feed results back
+func TestProviderToolLoops(t *testing.T) {
+	manager := newFakeMCPManager(t, nil)
+
+	t.Run("claude", func(t *testing.T) {
+		var requests []ClaudeRequest
+		api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var request ClaudeRequest
+			json.NewDecoder(r.Body).Decode(&request)
+			requests = append(requests, request)
+
+			if len(requests) == 1 {
+				fmt.Fprint(w, `{"stop_reason":"tool_use","content":[{"type":"tool_use","id":"call_1","name":"fake__echo","input":{"text":"from tool"}}]}`)
+				return
+			}
+			data, _ := json.Marshal(request.Messages[len(request.Messages)-1].Content)
+			fmt.Fprintf(w, `{"stop_reason":"end_turn","content":[{"type":"text","text":%q}]}`, string(data))
+		}))
+		defer api.Close()
+
+		provider, err := NewClaudeProvider(LLMProviderConfig{APIKey: "test", BaseURL: api.URL}, t.TempDir())
+		if err != nil {
+			t.Fatalf("Failed to create provider: %v", err)
+		}
+		provider.SetToolSet(manager)
+
+		response, err := provider.SendMessageWithSession(context.Background(), "use the tool", "s1")
+		if err != nil {
+			t.Fatalf("SendMessage failed: %v", err)
+		}
+		if !strings.Contains(response, `"tool_use_id":"call_1"`) || !strings.Contains(response, `"content":"from tool"`) {
+			t.Errorf("Expected tool result in follow-up request, got %s", response)
+		}
+		if len(requests) != 2 || len(requests[0].Tools) != 2 || requests[0].Tools[0].Name != "fake__echo" {
+			t.Errorf("Expected tools offered on both rounds, got %+v", requests)
+		}
+		if len(provider.sessions["s1"].Messages) != 2 {
+			t.Errorf("Expected session to keep only the question and answer, got %d messages", len(provider.sessions["s1"].Messages))
+		}
+	})
+
+	t.Run("openai", func(t *testing.T) {
+		var requests []OpenAIRequest
+		api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var request OpenAIRequest
+			json.NewDecoder(r.Body).Decode(&request)
+			requests = append(requests, request)
+
+			if len(requests) == 1 {
+				fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"","tool_calls":[`+
+					`{"id":"call_1","type":"function","function":{"name":"fake__fail","arguments":"{}"}}]}}]}`)
+				return
+			}
+			last := request.Messages[len(request.Messages)-1]
+			fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%q}}]}`, last.Role+"/"+last.ToolCallID+": "+last.Content)
+		}))
+		defer api.Close()
+
+		provider, err := NewOpenAIProvider(LLMProviderConfig{APIKey: "test", BaseURL: api.URL})
+		if err != nil {
+			t.Fatalf("Failed to create provider: %v", err)
+		}
+		provider.SetToolSet(manager)
+
+		response, err := provider.SendMessage(context.Background(), "use the tool")
+		if err != nil {
+			t.Fatalf("SendMessage failed: %v", err)
+		}
+		if !strings.HasPrefix(response, "tool/call_1: Error:") || !strings.Contains(response, "boom") {
+			t.Errorf("Expected tool error fed back to the model, got %q", response)
+		}
+		if len(requests[0].Tools) != 2 || requests[0].Tools[1].Function.Name != "fake__fail" {
+			t.Errorf("Expected function tools, got %+v", requests[0].Tools)
+		}
+	})
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Events that can be routed to notification sinks
+const (
+	NotifyRunSucceeded = "run.succeeded"
+	NotifyRunFailed    = "run.failed"
+	NotifyRunAwaiting  = "run.awaiting_approval"
+	NotifyChecksFailed = "checks.failed" // A pull request's CI went red
+	NotifySyncConflict = "sync.conflict" // A push was rejected because the remote branch moved
+)
+
+var notifyEvents = []string{NotifyRunSucceeded, NotifyRunFailed, NotifyRunAwaiting, NotifyChecksFailed, NotifySyncConflict}
+
+// Sink types
+const (
+	SinkDesktop = "desktop" // notify-send
+	SinkWebhook = "webhook"
+	SinkNtfy    = "ntfy"
+	SinkGotify  = "gotify"
+)
+
+// notifyTimeout bounds a single delivery, so a dead webhook never piles up goroutines
+const notifyTimeout = 10 * time.Second
+
+// NotificationsConfig routes events to sinks. For example:
+//
+//	"notifications": {
+//	  "sinks": {
+//	    "desk": {"type": "desktop"},
+//	    "phone": {"type": "ntfy", "url": "https://ntfy.example.com/relay"}
+//	  },
+//	  "rules": [
+//	    {"events": ["*"], "sinks": ["desk"]},
+//	    {"events": ["run.failed", "checks.failed"], "sinks": ["phone"]}
+//	  ]
+//	}
+type NotificationsConfig struct {
+	Sinks map[string]NotifySinkConfig `json:"sinks,omitempty"`
+	Rules []NotifyRule                `json:"rules,omitempty"`
+}
+
+// NotifySinkConfig is where notifications go
+type NotifySinkConfig struct {
+	Type     string            `json:"type"`               // "desktop", "webhook", "ntfy" or "gotify"
+	URL      string            `json:"url,omitempty"`      // Webhook URL, ntfy topic URL or Gotify server URL
+	Token    string            `json:"token,omitempty"`    // Gotify application token or ntfy access token
+	Headers  map[string]string `json:"headers,omitempty"`  // Extra webhook headers
+	Template string            `json:"template,omitempty"` // Webhook body as a Go template of JSON; the notification as JSON when empty
+}
+
+// NotifyRule sends the events matching any of its patterns ("run.*", "*") to its sinks
+type NotifyRule struct {
+	Events []string `json:"events"`
+	Sinks  []string `json:"sinks"`
+}
+
+// Notification is one event worth telling someone about
+type Notification struct {
+	Event   string    `json:"event"`
+	Project string    `json:"project"`
+	Issue   int       `json:"issue,omitempty"`
+	Title   string    `json:"title"`
+	Message string    `json:"message"`
+	URL     string    `json:"url,omitempty"`
+	Urgent  bool      `json:"urgent"` // Failures and conflicts
+	Time    time.Time `json:"time"`
+}
+
+// NotificationSink delivers notifications
+type NotificationSink interface {
+	Send(ctx context.Context, n Notification) error
+}
+
+// Notifier sends a project's events to the sinks its rules pick
+type Notifier struct {
+	project string
+	sinks   map[string]NotificationSink
+	rules   []NotifyRule
+	logger  *slog.Logger
+	sending sync.WaitGroup // Notify calls still delivering
+}
+
+// NewNotifier builds the sinks of a project's notification settings and
+// checks the rules against them. A project without rules gets a notifier
+// that sends nothing.
+func NewNotifier(project string, config NotificationsConfig) (*Notifier, error) {
+	n := &Notifier{
+		project: project,
+		sinks:   make(map[string]NotificationSink),
+		rules:   config.Rules,
+		logger:  componentLogger("Notify"),
+	}
+
+	client := &http.Client{Timeout: notifyTimeout}
+	for name, sink := range config.Sinks {
+		built, err := newNotificationSink(sink, client)
+		if err != nil {
+			return nil, fmt.Errorf("notification sink %q: %w", name, err)
+		}
+		n.sinks[name] = built
+	}
+
+	for i, rule := range config.Rules {
+		if len(rule.Events) == 0 || len(rule.Sinks) == 0 {
+			return nil, fmt.Errorf("notification rule %d: needs events and sinks", i+1)
+		}
+		for _, pattern := range rule.Events {
+			if !matchesAnyEvent(pattern) {
+				return nil, fmt.Errorf("notification rule %d: %q matches no event (events: %s)", i+1, pattern, strings.Join(notifyEvents, ", "))
+			}
+		}
+		for _, name := range rule.Sinks {
+			if _, ok := n.sinks[name]; !ok {
+				return nil, fmt.Errorf("notification rule %d: unknown sink %q", i+1, name)
+			}
+		}
+	}
+	return n, nil
+}
+
+func newNotificationSink(config NotifySinkConfig, client *http.Client) (NotificationSink, error) {
+	if config.Type != SinkDesktop && config.URL == "" {
+		return nil, fmt.Errorf("%s sink needs a url", config.Type)
+	}
+
+	switch config.Type {
+	case SinkDesktop:
+		return desktopSink{}, nil
+	case SinkWebhook:
+		sink := webhookSink{url: config.URL, headers: config.Headers, client: client}
+		if config.Template != "" {
+			body, err := template.New("webhook").Funcs(template.FuncMap{"json": jsonValue}).Parse(config.Template)
+			if err != nil {
+				return nil, fmt.Errorf("invalid template: %w", err)
+			}
+			sink.body = body
+		}
+		return sink, nil
+	case SinkNtfy:
+		return ntfySink{url: config.URL, token: config.Token, client: client}, nil
+	case SinkGotify:
+		if config.Token == "" {
+			return nil, fmt.Errorf("gotify sink needs an application token")
+		}
+		return gotifySink{url: strings.TrimSuffix(config.URL, "/"), token: config.Token, client: client}, nil
+	}
+	return nil, fmt.Errorf("unknown type %q (want desktop, webhook, ntfy or gotify)", config.Type)
+}
+
+// matchesAnyEvent reports whether a rule pattern can ever fire, to catch typos
+func matchesAnyEvent(pattern string) bool {
+	for _, event := range notifyEvents {
+		if ok, _ := path.Match(pattern, event); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Notify sends a notification in the background to every sink a rule picks
+// for its event. Failures are logged, never returned: a notification must
+// not fail the work it reports on.
+func (n *Notifier) Notify(notification Notification) {
+	if n == nil {
+		return
+	}
+	n.sending.Add(1)
+	go func() {
+		defer n.sending.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		defer cancel()
+		if err := n.Send(ctx, notification); err != nil {
+			n.logger.Warn("Notification failed", "event", notification.Event, "error", err)
+		}
+	}()
+}
+
+// Wait blocks until the notifications in flight are delivered or the timeout
+// passes, and reports whether they all finished. Call it before exiting so
+// the last notifications of a run are not lost.
+func (n *Notifier) Wait(timeout time.Duration) bool {
+	if n == nil {
+		return true
+	}
+	done := make(chan struct{})
+	go func() {
+		n.sending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		n.logger.Warn("Gave up waiting for notifications", "timeout", timeout)
+		return false
+	}
+}
+
+// Send delivers a notification to the sinks of its event and waits for them
+func (n *Notifier) Send(ctx context.Context, notification Notification) error {
+	if n == nil {
+		return nil
+	}
+	notification.Project = n.project
+	if notification.Time.IsZero() {
+		notification.Time = time.Now()
+	}
+
+	var errs []error
+	for _, name := range n.sinksFor(notification.Event) {
+		if err := n.sinks[name].Send(ctx, notification); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sinksFor returns the sinks of the rules matching an event, each once
+func (n *Notifier) sinksFor(event string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, rule := range n.rules {
+		for _, pattern := range rule.Events {
+			if ok, _ := path.Match(pattern, event); !ok {
+				continue
+			}
+			for _, name := range rule.Sinks {
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+			break
+		}
+	}
+	return names
+}
+
+// heading is the notification's title with the project and issue in front
+func (n Notification) heading() string {
+	if n.Issue > 0 {
+		return fmt.Sprintf("%s #%d: %s", n.Project, n.Issue, n.Title)
+	}
+	return n.Project + ": " + n.Title
+}
+
+// desktopSink shows notifications with notify-send (libnotify)
+type desktopSink struct{}
+
+func (desktopSink) Send(ctx context.Context, n Notification) error {
+	urgency := "normal"
+	if n.Urgent {
+		urgency = "critical"
+	}
+	cmd := exec.CommandContext(ctx, "notify-send", "--app-name=Relay", "--urgency="+urgency, n.heading(), n.Message)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return fmt.Errorf("notify-send not found; desktop notifications need libnotify")
+		}
+		return fmt.Errorf("notify-send failed: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// webhookSink posts the notification, or the body its template renders, as JSON
+type webhookSink struct {
+	url     string
+	headers map[string]string
+	body    *template.Template
+	client  *http.Client
+}
+
+func (s webhookSink) Send(ctx context.Context, n Notification) error {
+	var body []byte
+	if s.body == nil {
+		data, err := json.Marshal(n)
+		if err != nil {
+			return fmt.Errorf("failed to marshal notification: %w", err)
+		}
+		body = data
+	} else {
+		var rendered bytes.Buffer
+		if err := s.body.Execute(&rendered, n); err != nil {
+			return fmt.Errorf("failed to render template: %w", err)
+		}
+		if !json.Valid(rendered.Bytes()) {
+			return fmt.Errorf("template did not render JSON: %s", truncateText(rendered.String(), 200))
+		}
+		body = rendered.Bytes()
+	}
+
+	headers := map[string]string{"Content-Type": "application/json"}
+	for key, value := range s.headers {
+		headers[key] = value
+	}
+	return postNotification(ctx, s.client, s.url, body, headers)
+}
+
+// jsonValue quotes a value for a webhook template: {"text": {{json .Title}}}
+func jsonValue(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	return string(data), err
+}
+
+// ntfySink publishes to an ntfy topic; the URL includes the topic
+type ntfySink struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (s ntfySink) Send(ctx context.Context, n Notification) error {
+	headers := map[string]string{"Title": n.heading(), "Tags": "relay," + n.Event}
+	if n.Urgent {
+		headers["Priority"] = "high"
+	}
+	if n.URL != "" {
+		headers["Click"] = n.URL
+	}
+	if s.token != "" {
+		headers["Authorization"] = "Bearer " + s.token
+	}
+	return postNotification(ctx, s.client, s.url, []byte(n.Message), headers)
+}
+
+// gotifySink posts messages to a Gotify server with an application token
+type gotifySink struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (s gotifySink) Send(ctx context.Context, n Notification) error {
+	priority := 5
+	if n.Urgent {
+		priority = 8
+	}
+	message := map[string]interface{}{"title": n.heading(), "message": n.Message, "priority": priority}
+	if n.URL != "" {
+		message["extras"] = map[string]interface{}{
+			"client::notification": map[string]interface{}{"click": map[string]string{"url": n.URL}},
+		}
+	}
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	return postNotification(ctx, s.client, s.url+"/message", body, map[string]string{
+		"Content-Type": "application/json",
+		"X-Gotify-Key": s.token,
+	})
+}
+
+// postNotification POSTs a body and treats any non-2xx status as a failure
+func postNotification(ctx context.Context, client *http.Client, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		reply, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s returned status %d: %s", url, resp.StatusCode, strings.TrimSpace(string(reply)))
+	}
+	return nil
+}
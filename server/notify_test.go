@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// notifyRequest is a request received by the stand-in notification server
+type notifyRequest struct {
+	path   string
+	header http.Header
+	body   string
+}
+
+// newNotifyServer stands in for webhooks, ntfy and Gotify; paths under
+// /fail answer 500
+func newNotifyServer(t *testing.T) (*httptest.Server, <-chan notifyRequest) {
+	t.Helper()
+	requests := make(chan notifyRequest, 16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- notifyRequest{path: r.URL.Path, header: r.Header, body: string(body)}
+		if strings.HasPrefix(r.URL.Path, "/fail") {
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+// nextNotifyRequest waits for the server to receive a request
+func nextNotifyRequest(t *testing.T, requests <-chan notifyRequest) notifyRequest {
+	t.Helper()
+	select {
+	case request := <-requests:
+		return request
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a notification")
+		return notifyRequest{}
+	}
+}
+
+func TestNotifierSinks(t *testing.T) {
+	server, requests := newNotifyServer(t)
+	notifier, err := NewNotifier("demo", NotificationsConfig{
+		Sinks: map[string]NotifySinkConfig{
+			"hook":  {Type: SinkWebhook, URL: server.URL + "/hook"},
+			"chat":  {Type: SinkWebhook, URL: server.URL + "/chat", Headers: map[string]string{"X-Team": "core"}, Template: `{"text": {{json .Title}}, "issue": {{.Issue}}}`},
+			"phone": {Type: SinkNtfy, URL: server.URL + "/relay", Token: "tk_secret"},
+			"home":  {Type: SinkGotify, URL: server.URL + "/gotify/", Token: "app"},
+		},
+		Rules: []NotifyRule{
+			{Events: []string{"run.*"}, Sinks: []string{"hook"}},
+			{Events: []string{NotifyChecksFailed}, Sinks: []string{"chat"}},
+			{Events: []string{NotifyChecksFailed}, Sinks: []string{"phone", "home"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// The default webhook body is the notification
+	if err := notifier.Send(ctx, Notification{Event: NotifyRunFailed, Issue: 7, Title: "Run failed", Message: "boom", Urgent: true}); err != nil {
+		t.Fatal(err)
+	}
+	hook := nextNotifyRequest(t, requests)
+	var sent Notification
+	if err := json.Unmarshal([]byte(hook.body), &sent); err != nil || hook.path != "/hook" || sent.Project != "demo" || sent.Issue != 7 || !sent.Urgent || sent.Time.IsZero() {
+		t.Errorf("Webhook got %s %s (%v)", hook.path, hook.body, err)
+	}
+	if len(requests) != 0 {
+		t.Errorf("Only the run rule should match run.failed")
+	}
+
+	failed := Notification{Event: NotifyChecksFailed, Issue: 9, Title: "Checks failed", Message: "Checks of pull request #12 failed", URL: "https://example.com/pr/12", Urgent: true}
+	if err := notifier.Send(ctx, failed); err != nil {
+		t.Fatal(err)
+	}
+	received := make(map[string]notifyRequest)
+	for i := 0; i < 3; i++ {
+		request := nextNotifyRequest(t, requests)
+		received[request.path] = request
+	}
+
+	if chat := received["/chat"]; chat.body != `{"text": "Checks failed", "issue": 9}` || chat.header.Get("X-Team") != "core" {
+		t.Errorf("Templated webhook got %q, headers %v", chat.body, chat.header)
+	}
+
+	ntfy := received["/relay"]
+	if ntfy.body != failed.Message || ntfy.header.Get("Title") != "demo #9: Checks failed" || ntfy.header.Get("Priority") != "high" ||
+		ntfy.header.Get("Click") != failed.URL || ntfy.header.Get("Authorization") != "Bearer tk_secret" {
+		t.Errorf("ntfy got %q, headers %v", ntfy.body, ntfy.header)
+	}
+
+	gotify := received["/gotify/message"]
+	var message struct {
+		Title    string `json:"title"`
+		Priority int    `json:"priority"`
+		Extras   map[string]struct {
+			Click struct {
+				URL string `json:"url"`
+			} `json:"click"`
+		} `json:"extras"`
+	}
+	if err := json.Unmarshal([]byte(gotify.body), &message); err != nil || gotify.header.Get("X-Gotify-Key") != "app" ||
+		message.Priority != 8 || message.Extras["client::notification"].Click.URL != failed.URL {
+		t.Errorf("Gotify got %s, headers %v (%v)", gotify.body, gotify.header, err)
+	}
+
+	// Events without a rule go nowhere
+	if err := notifier.Send(ctx, Notification{Event: NotifySyncConflict}); err != nil || len(requests) != 0 {
+		t.Errorf("sync.conflict has no rule: %v", err)
+	}
+}
+
+func TestNotifierErrors(t *testing.T) {
+	server, _ := newNotifyServer(t)
+	notifier, err := NewNotifier("demo", NotificationsConfig{
+		Sinks: map[string]NotifySinkConfig{"hook": {Type: SinkWebhook, URL: server.URL + "/fail", Template: `{"text": {{.Title}}}`}},
+		Rules: []NotifyRule{{Events: []string{"*"}, Sinks: []string{"hook"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Titles need quoting with json to make JSON
+	if err := notifier.Send(context.Background(), Notification{Event: NotifyRunFailed, Title: "Run failed"}); err == nil || !strings.Contains(err.Error(), "did not render JSON") {
+		t.Errorf("Unquoted title: %v", err)
+	}
+	if err := notifier.Send(context.Background(), Notification{Event: NotifyRunFailed, Title: "7"}); err == nil || !strings.Contains(err.Error(), "status 500") {
+		t.Errorf("Failing server: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		config  NotificationsConfig
+		wantErr string
+	}{
+		{"unknown type", NotificationsConfig{Sinks: map[string]NotifySinkConfig{"x": {Type: "pager", URL: "http://x"}}}, "unknown type"},
+		{"no url", NotificationsConfig{Sinks: map[string]NotifySinkConfig{"x": {Type: SinkNtfy}}}, "needs a url"},
+		{"no token", NotificationsConfig{Sinks: map[string]NotifySinkConfig{"x": {Type: SinkGotify, URL: "http://x"}}}, "token"},
+		{"bad template", NotificationsConfig{Sinks: map[string]NotifySinkConfig{"x": {Type: SinkWebhook, URL: "http://x", Template: "{{"}}}, "invalid template"},
+		{"unknown sink", NotificationsConfig{Rules: []NotifyRule{{Events: []string{"*"}, Sinks: []string{"x"}}}}, `unknown sink "x"`},
+		{"typo", NotificationsConfig{Sinks: map[string]NotifySinkConfig{"x": {Type: SinkDesktop}}, Rules: []NotifyRule{{Events: []string{"runs.*"}, Sinks: []string{"x"}}}}, "matches no event"},
+		{"empty rule", NotificationsConfig{Rules: []NotifyRule{{Events: []string{"*"}}}}, "needs events and sinks"},
+	}
+	for _, tt := range tests {
+		if _, err := NewNotifier("demo", tt.config); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: got %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+
+	// A project without notification settings notifies nobody
+	if notifier, err := NewNotifier("demo", NotificationsConfig{}); err != nil || notifier.Send(context.Background(), Notification{Event: NotifyRunFailed}) != nil {
+		t.Errorf("Empty settings: %v", err)
+	}
+}
+
+// TestNotifierWait tests that Wait holds on until background sends are done
+func TestNotifierWait(t *testing.T) {
+	release := make(chan struct{})
+	var delivered atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		delivered.Store(true)
+	}))
+	t.Cleanup(server.Close)
+	notifier, err := NewNotifier("demo", NotificationsConfig{
+		Sinks: map[string]NotifySinkConfig{"hook": {Type: SinkWebhook, URL: server.URL}},
+		Rules: []NotifyRule{{Events: []string{"*"}, Sinks: []string{"hook"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notifier.Notify(Notification{Event: NotifyRunSucceeded, Title: "Done"})
+	if notifier.Wait(50 * time.Millisecond) {
+		t.Error("Wait should time out while the webhook hangs")
+	}
+	close(release)
+	if !notifier.Wait(5*time.Second) || !delivered.Load() {
+		t.Error("Wait returned before the notification was delivered")
+	}
+
+	var none *Notifier
+	if !none.Wait(time.Millisecond) {
+		t.Error("A nil notifier has nothing to wait for")
+	}
+}
+
+// TestDesktopSink runs a stand-in notify-send from PATH
+func TestDesktopSink(t *testing.T) {
+	dir := t.TempDir()
+	args := filepath.Join(dir, "args")
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > " + args + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "notify-send"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	n := Notification{Project: "demo", Issue: 7, Title: "Run failed", Message: "failed at checks", Urgent: true}
+	if err := (desktopSink{}).Send(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(args)
+	if want := "--app-name=Relay\n--urgency=critical\ndemo #7: Run failed\nfailed at checks\n"; string(got) != want {
+		t.Errorf("notify-send got %q, want %q", got, want)
+	}
+}
+
+// TestIssueRunnerSyncConflict tests that a push rejected by a moved remote
+// branch notifies a sync conflict and then the failed run
+func TestIssueRunnerSyncConflict(t *testing.T) {
+	runner, _, _ := newTestIssueRunner(t)
+	server, requests := newNotifyServer(t)
+	notifier, err := NewNotifier("demo", NotificationsConfig{
+		Sinks: map[string]NotifySinkConfig{"hook": {Type: SinkWebhook, URL: server.URL}},
+		Rules: []NotifyRule{{Events: []string{NotifySyncConflict, NotifyRunFailed}, Sinks: []string{"hook"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.notifier = notifier
+	runner.config.Approvals = false
+	runner.status.ApprovalsEnabled = false
+	runner.config.Checks = nil
+
+	// Someone else pushed the issue branch
+	runTestGit(t, runner.projectPath, "checkout", "-b", "elsewhere")
+	if err := os.WriteFile(filepath.Join(runner.projectPath, "other.txt"), []byte("other\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runTestGit(t, runner.projectPath, "add", "-A")
+	runTestGit(t, runner.projectPath, "commit", "-m", "Other work")
+	runTestGit(t, runner.projectPath, "push", "origin", "elsewhere:feature/issue-7")
+	runTestGit(t, runner.projectPath, "checkout", "main")
+
+	if err := runner.Run(context.Background()); err == nil {
+		t.Fatal("Expected the push to be rejected")
+	}
+
+	var events []string
+	for i := 0; i < 2; i++ {
+		var sent Notification
+		request := nextNotifyRequest(t, requests)
+		if err := json.Unmarshal([]byte(request.body), &sent); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, sent.Event)
+	}
+	if strings.Join(events, ",") != "sync.conflict,run.failed" && strings.Join(events, ",") != "run.failed,sync.conflict" {
+		t.Errorf("Notified %v", events)
+	}
+}
+
+func TestChecksFailedNotification(t *testing.T) {
+	dir := newCheckpointTestRepo(t)
+	server, requests := newNotifyServer(t)
+	notifier, err := NewNotifier("demo", NotificationsConfig{
+		Sinks: map[string]NotifySinkConfig{"hook": {Type: SinkWebhook, URL: server.URL}},
+		Rules: []NotifyRule{{Events: []string{NotifyChecksFailed}, Sinks: []string{"hook"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lint := PullRequestCheck{Name: "lint", Status: "IN_PROGRESS"}
+	listPRs := func(ctx context.Context) ([]PullRequest, error) {
+		return []PullRequest{
+			{Number: 12, State: "OPEN", HeadRefName: "feature/issue-9", URL: "https://example.com/pr/12",
+				StatusCheckRollup: []PullRequestCheck{{Name: "test", Status: "COMPLETED", Conclusion: "SUCCESS"}, lint}},
+			{Number: 13, State: "OPEN", HeadRefName: "feature/issue-10",
+				StatusCheckRollup: []PullRequestCheck{{Context: "ci/legacy", State: "FAILURE"}}},
+		}, nil
+	}
+	service := NewIssueProgressService(dir, listPRs)
+	service.SetNotifier(notifier)
+
+	// Already red at the first fetch: not news
+	if err := service.Refresh(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	if got := service.Get(9).PRChecks; got != "PENDING" {
+		t.Errorf("Checks of #9: got %q, want PENDING", got)
+	}
+
+	lint.Status, lint.Conclusion = "COMPLETED", "FAILURE"
+	if err := service.Refresh(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	var sent Notification
+	if err := json.Unmarshal([]byte(nextNotifyRequest(t, requests).body), &sent); err != nil || sent.Issue != 9 || sent.URL != "https://example.com/pr/12" {
+		t.Errorf("Checks failed notification: %+v, %v", sent, err)
+	}
+
+	// Still red: no second notification
+	if err := service.Refresh(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case request := <-requests:
+		t.Errorf("Unexpected notification: %s", request.body)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
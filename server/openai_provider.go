@@ -6,9 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"strings"
 	"sync"
 	"time"
 )
@@ -22,9 +23,12 @@ const (
 type OpenAIProvider struct {
 	config     LLMProviderConfig
 	httpClient *http.Client
-	logger     *log.Logger
+	logger     *slog.Logger
 	sessions   map[string]*OpenAISession
 	sessionMu  sync.RWMutex
+	apiURL     string
+	toolMu     sync.RWMutex // Guards toolSet: MCP servers set it once they start, mid-session
+	toolSet    ToolSet
 }
 
 // OpenAISession represents a conversation session
@@ -36,16 +40,40 @@ type OpenAISession struct {
 
 // OpenAIMessage represents a message in the conversation
 type OpenAIMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []OpenAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+// OpenAIToolCall is a function call requested by the model
+type OpenAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// OpenAITool describes a function the model may call
+type OpenAITool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description,omitempty"`
+		Parameters  json.RawMessage `json:"parameters"`
+	} `json:"function"`
 }
 
 // OpenAIRequest represents the request structure for OpenAI API
 type OpenAIRequest struct {
 	Model       string          `json:"model"`
 	Messages    []OpenAIMessage `json:"messages"`
+	Tools       []OpenAITool    `json:"tools,omitempty"`
 	MaxTokens   int             `json:"max_tokens,omitempty"`
 	Temperature float64         `json:"temperature,omitempty"`
+	Stream      bool            `json:"stream,omitempty"`
 }
 
 // OpenAIResponse represents the response structure from OpenAI API
@@ -55,12 +83,9 @@ type OpenAIResponse struct {
 	Created int64  `json:"created"`
 	Model   string `json:"model"`
 	Choices []struct {
-		Index   int `json:"index"`
-		Message struct {
-			Role    string `json:"role"`
-			Content string `json:"content"`
-		} `json:"message"`
-		FinishReason string `json:"finish_reason"`
+		Index        int           `json:"index"`
+		Message      OpenAIMessage `json:"message"`
+		FinishReason string        `json:"finish_reason"`
 	} `json:"choices"`
 	Usage struct {
 		PromptTokens     int `json:"prompt_tokens"`
@@ -71,7 +96,7 @@ type OpenAIResponse struct {
 
 // NewOpenAIProvider creates a new OpenAI API provider
 func NewOpenAIProvider(config LLMProviderConfig) (*OpenAIProvider, error) {
-	logger := log.New(os.Stdout, "[OpenAIProvider] ", log.LstdFlags)
+	logger := componentLogger("OpenAIProvider")
 
 	// Use API key from config or environment
 	apiKey := config.APIKey
@@ -98,16 +123,55 @@ func NewOpenAIProvider(config LLMProviderConfig) (*OpenAIProvider, error) {
 		Timeout: 60 * time.Second,
 	}
 
-	logger.Printf("OpenAI API provider initialized (model: %s, maxTokens: %d)", config.Model, config.MaxTokens)
+	logger.Debug("OpenAI API provider initialized", "model", config.Model, "maxTokens", config.MaxTokens)
+
+	apiURL := openaiAPIURL
+	if config.BaseURL != "" {
+		apiURL = strings.TrimRight(config.BaseURL, "/") + "/chat/completions"
+	}
 
 	return &OpenAIProvider{
 		config:     config,
 		httpClient: httpClient,
 		logger:     logger,
 		sessions:   make(map[string]*OpenAISession),
+		apiURL:     apiURL,
 	}, nil
 }
 
+// SetToolSet makes tools available to the model in subsequent requests
+func (p *OpenAIProvider) SetToolSet(tools ToolSet) {
+	p.toolMu.Lock()
+	defer p.toolMu.Unlock()
+	p.toolSet = tools
+}
+
+// currentToolSet returns the tools set so far; a request keeps the one it
+// started with
+func (p *OpenAIProvider) currentToolSet() ToolSet {
+	p.toolMu.RLock()
+	defer p.toolMu.RUnlock()
+	return p.toolSet
+}
+
+// tools returns the function definitions to offer the model
+func (p *OpenAIProvider) tools(toolSet ToolSet) []OpenAITool {
+	if toolSet == nil {
+		return nil
+	}
+
+	var tools []OpenAITool
+	for _, tool := range toolSet.Tools() {
+		var openaiTool OpenAITool
+		openaiTool.Type = "function"
+		openaiTool.Function.Name = tool.QualifiedName()
+		openaiTool.Function.Description = tool.Description
+		openaiTool.Function.Parameters = tool.InputSchema
+		tools = append(tools, openaiTool)
+	}
+	return tools
+}
+
 // SendMessage sends a message to OpenAI API
 func (p *OpenAIProvider) SendMessage(ctx context.Context, message string) (string, error) {
 	messages := []OpenAIMessage{
@@ -154,25 +218,63 @@ func (p *OpenAIProvider) SendMessageWithSession(ctx context.Context, message str
 	return response, nil
 }
 
-// sendRequest sends a request to OpenAI API
+// sendRequest sends a request to OpenAI API, running any tools the model
+// calls and returning its final text response
 func (p *OpenAIProvider) sendRequest(ctx context.Context, messages []OpenAIMessage) (string, error) {
-	request := OpenAIRequest{
-		Model:       p.config.Model,
-		Messages:    messages,
-		MaxTokens:   p.config.MaxTokens,
-		Temperature: 0.7,
+	toolSet := p.currentToolSet()
+	tools := p.tools(toolSet)
+
+	// Tool rounds extend a copy so callers' histories only keep the final answer
+	messages = append([]OpenAIMessage(nil), messages...)
+
+	for round := 0; ; round++ {
+		openaiResp, err := p.post(ctx, OpenAIRequest{
+			Model:       p.config.Model,
+			Messages:    messages,
+			Tools:       tools,
+			MaxTokens:   p.config.MaxTokens,
+			Temperature: 0.7,
+		})
+		if err != nil {
+			return "", err
+		}
+
+		if len(openaiResp.Choices) == 0 {
+			return "", fmt.Errorf("empty response choices from OpenAI API")
+		}
+
+		message := openaiResp.Choices[0].Message
+		if len(message.ToolCalls) == 0 || round >= maxToolRounds {
+			p.logger.Debug("Received response from OpenAI API",
+				"chars", len(message.Content), "totalTokens", openaiResp.Usage.TotalTokens)
+			return message.Content, nil
+		}
+
+		messages = append(messages, message)
+		for _, call := range message.ToolCalls {
+			p.logger.Debug("Calling tool", "tool", call.Function.Name)
+			output, err := toolSet.CallTool(ctx, call.Function.Name, json.RawMessage(call.Function.Arguments))
+			if err != nil {
+				output = "Error: " + err.Error()
+			}
+			messages = append(messages, OpenAIMessage{Role: "tool", Content: output, ToolCallID: call.ID})
+		}
 	}
+}
 
+// post sends a single request to OpenAI API
+func (p *OpenAIProvider) post(ctx context.Context, request OpenAIRequest) (*OpenAIResponse, error) {
 	jsonData, err := json.Marshal(request)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	p.logger.Printf("Sending request to OpenAI API (model: %s, messages: %d)", p.config.Model, len(messages))
+	p.logger.Debug("Sending request to OpenAI API",
+		"model", p.config.Model, "messages", len(request.Messages), "tools", len(request.Tools))
 
-	req, err := http.NewRequestWithContext(ctx, "POST", openaiAPIURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", p.apiURL, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -180,33 +282,108 @@ func (p *OpenAIProvider) sendRequest(ctx context.Context, messages []OpenAIMessa
 
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("OpenAI API error (status %d): %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("OpenAI API error (status %d): %s", resp.StatusCode, string(body))
 	}
 
 	var openaiResp OpenAIResponse
 	if err := json.Unmarshal(body, &openaiResp); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	if len(openaiResp.Choices) == 0 {
-		return "", fmt.Errorf("empty response choices from OpenAI API")
+	return &openaiResp, nil
+}
+
+// StreamMessage sends a message to OpenAI API and streams the response tokens
+func (p *OpenAIProvider) StreamMessage(ctx context.Context, message string, onToken func(string)) (string, error) {
+	// Tool rounds need whole responses, so with tools the answer arrives as one chunk
+	if len(p.tools(p.currentToolSet())) > 0 {
+		response, err := p.SendMessage(ctx, message)
+		if err == nil && onToken != nil {
+			onToken(response)
+		}
+		return response, err
 	}
 
-	response := openaiResp.Choices[0].Message.Content
-	p.logger.Printf("Received response from OpenAI API (%d chars, %d total tokens)",
-		len(response), openaiResp.Usage.TotalTokens)
+	request := OpenAIRequest{
+		Model:       p.config.Model,
+		Messages:    []OpenAIMessage{{Role: "user", Content: message}},
+		MaxTokens:   p.config.MaxTokens,
+		Temperature: 0.7,
+		Stream:      true,
+	}
 
-	return response, nil
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	p.logger.Debug("Streaming request to OpenAI API", "model", p.config.Model)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.apiURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+
+	// Streams can outlive the client timeout; rely on ctx for cancellation instead
+	client := &http.Client{Transport: p.httpClient.Transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("OpenAI API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var response strings.Builder
+	err = readSSEData(resp.Body, func(data string) (bool, error) {
+		if data == "[DONE]" {
+			return true, nil
+		}
+
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return false, nil // Ignore payloads we don't understand
+		}
+
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				response.WriteString(choice.Delta.Content)
+				if onToken != nil {
+					onToken(choice.Delta.Content)
+				}
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return response.String(), err
+	}
+
+	p.logger.Debug("Received streamed response from OpenAI API", "chars", response.Len())
+
+	return response.String(), nil
 }
 
 // GetProviderName returns the provider name
@@ -222,6 +399,6 @@ func (p *OpenAIProvider) Close() error {
 	// Clear all sessions
 	p.sessions = make(map[string]*OpenAISession)
 
-	p.logger.Println("OpenAI API provider closed")
+	p.logger.Debug("OpenAI API provider closed")
 	return nil
 }
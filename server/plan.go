@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// PlanStepStatus is the progress of a single plan step
+type PlanStepStatus string
+
+const (
+	PlanStepPending PlanStepStatus = "pending"
+	PlanStepRunning PlanStepStatus = "running"
+	PlanStepDone    PlanStepStatus = "done"
+	PlanStepFailed  PlanStepStatus = "failed"
+	PlanStepSkipped PlanStepStatus = "skipped"
+)
+
+// PlanStep is one ordered unit of work in an issue's plan
+type PlanStep struct {
+	ID         int64          `json:"id"`
+	Position   int            `json:"position"`
+	Goal       string         `json:"goal"`
+	Files      []string       `json:"files,omitempty"`
+	Acceptance string         `json:"acceptance,omitempty"`
+	Status     PlanStepStatus `json:"status"`
+	Output     string         `json:"output,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	StartedAt  *time.Time     `json:"started_at,omitempty"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+}
+
+// Plan is the step list for an issue, written by the planning provider and
+// carried out one step at a time by the executing provider
+type Plan struct {
+	ID          int64       `json:"id"`
+	Project     string      `json:"project"`
+	IssueNumber int         `json:"issue_number"`
+	Steps       []*PlanStep `json:"steps"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// PlanStore persists plans and step progress
+type PlanStore interface {
+	SavePlan(plan *Plan) error
+	GetPlan(project string, issueNumber int) (*Plan, error)
+	UpdatePlanStep(step *PlanStep) error
+}
+
+// NewPlan creates a plan for an issue from its steps
+func NewPlan(project string, issueNumber int, steps []*PlanStep) *Plan {
+	now := time.Now()
+	plan := &Plan{Project: project, IssueNumber: issueNumber, Steps: steps, CreatedAt: now, UpdatedAt: now}
+	plan.Renumber()
+	return plan
+}
+
+// Renumber sets step positions after steps are added, removed or moved
+func (p *Plan) Renumber() {
+	for i, step := range p.Steps {
+		step.Position = i + 1
+		if step.Status == "" {
+			step.Status = PlanStepPending
+		}
+	}
+}
+
+// NextStep returns the first step that still has to run, or nil when the plan is complete
+func (p *Plan) NextStep() *PlanStep {
+	for _, step := range p.Steps {
+		if step.Status != PlanStepDone && step.Status != PlanStepSkipped {
+			return step
+		}
+	}
+	return nil
+}
+
+// Progress returns how many steps are finished (done or skipped) out of the total
+func (p *Plan) Progress() (int, int) {
+	finished := 0
+	for _, step := range p.Steps {
+		if step.Status == PlanStepDone || step.Status == PlanStepSkipped {
+			finished++
+		}
+	}
+	return finished, len(p.Steps)
+}
+
+// RecoverInterrupted resets steps left running by a crash so they run again.
+// It reports whether any step was reset.
+func (p *Plan) RecoverInterrupted() bool {
+	recovered := false
+	for _, step := range p.Steps {
+		if step.Status == PlanStepRunning {
+			step.Status = PlanStepPending
+			step.StartedAt = nil
+			recovered = true
+		}
+	}
+	return recovered
+}
+
+// Markdown renders the plan as a task list for the issue body
+func (p *Plan) Markdown() string {
+	var md strings.Builder
+	for _, step := range p.Steps {
+		check := " "
+		if step.Status == PlanStepDone {
+			check = "x"
+		}
+		md.WriteString(fmt.Sprintf("- [%s] %d. %s", check, step.Position, step.Goal))
+		switch step.Status {
+		case PlanStepRunning:
+			md.WriteString(" _(in progress)_")
+		case PlanStepFailed:
+			md.WriteString(" _(failed)_")
+		case PlanStepSkipped:
+			md.WriteString(" _(skipped)_")
+		}
+		md.WriteString("\n")
+
+		if len(step.Files) > 0 {
+			md.WriteString("  - Files: " + strings.Join(step.Files, ", ") + "\n")
+		}
+		if step.Acceptance != "" {
+			md.WriteString("  - Done when: " + step.Acceptance + "\n")
+		}
+	}
+	return strings.TrimRight(md.String(), "\n")
+}
+
+var (
+	// planStepLine matches "1. Goal", "- [ ] 1. Goal" and "- [x] **1. Goal**"
+	planStepLine = regexp.MustCompile(`^(?:[-*]\s+\[([ xX])\]\s+)?(?:\*\*)?(\d+)[.)]\s+(.+)$`)
+	// planStepSuffix matches the status note Markdown appends to a step
+	planStepSuffix = regexp.MustCompile(`\s+_\((in progress|failed|skipped)\)_$`)
+)
+
+// parsePlanMarkdown reads steps from a numbered markdown list, including the
+// task list written by Plan.Markdown and plans written by hand or other tools
+func parsePlanMarkdown(text string) []*PlanStep {
+	var steps []*PlanStep
+	var current *PlanStep
+
+	for _, raw := range strings.Split(text, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+
+		indented := raw != strings.TrimLeft(raw, " \t")
+		if match := planStepLine.FindStringSubmatch(line); match != nil && !indented {
+			goal := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(match[3]), "**"))
+			status := PlanStepPending
+			if suffix := planStepSuffix.FindStringSubmatch(goal); suffix != nil {
+				goal = strings.TrimSpace(strings.TrimSuffix(goal, suffix[0]))
+				switch suffix[1] {
+				case "failed":
+					status = PlanStepFailed
+				case "skipped":
+					status = PlanStepSkipped
+				}
+			}
+			if strings.EqualFold(match[1], "x") {
+				status = PlanStepDone
+			}
+			current = &PlanStep{Goal: strings.Trim(goal, "*"), Status: status}
+			steps = append(steps, current)
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		// Detail bullets under a step
+		detail := strings.TrimSpace(strings.TrimLeft(line, "-*"))
+		label, value, found := strings.Cut(detail, ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.Trim(strings.TrimSpace(label), "*_")) {
+		case "files", "file":
+			current.Files = splitPlanFiles(value)
+		case "done when", "acceptance", "acceptance criteria":
+			current.Acceptance = value
+		}
+	}
+	return steps
+}
+
+// splitPlanFiles splits a comma separated file list, dropping code backticks
+func splitPlanFiles(value string) []string {
+	var files []string
+	for _, file := range strings.Split(value, ",") {
+		file = strings.Trim(strings.TrimSpace(file), "`")
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// parsePlanResponse reads steps from a planning provider reply: JSON as
+// requested, or a numbered list when the model ignores the format
+func parsePlanResponse(response string) ([]*PlanStep, error) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start != -1 && end > start {
+		var reply struct {
+			Steps []struct {
+				Goal       string   `json:"goal"`
+				Files      []string `json:"files"`
+				Acceptance string   `json:"acceptance"`
+			} `json:"steps"`
+		}
+		if err := json.Unmarshal([]byte(response[start:end+1]), &reply); err == nil && len(reply.Steps) > 0 {
+			var steps []*PlanStep
+			for _, s := range reply.Steps {
+				if strings.TrimSpace(s.Goal) == "" {
+					continue
+				}
+				steps = append(steps, &PlanStep{
+					Goal:       strings.TrimSpace(s.Goal),
+					Files:      s.Files,
+					Acceptance: strings.TrimSpace(s.Acceptance),
+					Status:     PlanStepPending,
+				})
+			}
+			if len(steps) > 0 {
+				return steps, nil
+			}
+		}
+	}
+
+	if steps := parsePlanMarkdown(response); len(steps) > 0 {
+		return steps, nil
+	}
+	return nil, fmt.Errorf("planning provider returned no plan steps")
+}
+
+// GeneratePlan asks the planning provider for an issue's plan
+func GeneratePlan(ctx context.Context, planner LLMProvider, project string, issue *Issue) (*Plan, error) {
+	response, err := planner.SendMessage(ctx, buildPlanPrompt(issue))
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan: %w", err)
+	}
+
+	steps, err := parsePlanResponse(response)
+	if err != nil {
+		return nil, err
+	}
+	return NewPlan(project, issue.Number, steps), nil
+}
+
+// ExecutePlanStep has the executing provider carry out one step, persisting
+// its status before and after so an interrupted step is picked up again
+func ExecutePlanStep(ctx context.Context, executor LLMProvider, store PlanStore, issue *Issue, plan *Plan, step *PlanStep, worktree string) error {
+	now := time.Now()
+	step.Status = PlanStepRunning
+	step.StartedAt = &now
+	step.FinishedAt = nil
+	step.Error = ""
+	if err := store.UpdatePlanStep(step); err != nil {
+		return err
+	}
+
+	response, err := executor.SendMessage(ctx, buildPlanStepPrompt(issue, plan, step, worktree))
+	finished := time.Now()
+	step.FinishedAt = &finished
+	if err != nil {
+		// A cancelled step is left to run again rather than marked failed
+		if ctx.Err() != nil {
+			step.Status = PlanStepPending
+			step.StartedAt = nil
+			step.FinishedAt = nil
+		} else {
+			step.Status = PlanStepFailed
+			step.Error = err.Error()
+		}
+		if saveErr := store.UpdatePlanStep(step); saveErr != nil {
+			return saveErr
+		}
+		return fmt.Errorf("step %d failed: %w", step.Position, err)
+	}
+
+	step.Status = PlanStepDone
+	step.Output = strings.TrimSpace(response)
+	return store.UpdatePlanStep(step)
+}
+
+func buildPlanPrompt(issue *Issue) string {
+	var prompt strings.Builder
+	prompt.WriteString(fmt.Sprintf("Issue #%d: %s\n", issue.Number, issue.Title))
+	if len(issue.Labels) > 0 {
+		prompt.WriteString(fmt.Sprintf("Labels: %s\n", strings.Join(issue.Labels, ", ")))
+	}
+	if issue.Body != "" {
+		prompt.WriteString(fmt.Sprintf("\nDescription:\n%s\n", issue.Body))
+	}
+	prompt.WriteString("\nWrite an implementation plan for this issue as a short list of ordered steps. " +
+		"Each step should be small enough to implement and verify on its own. " +
+		"Reply with JSON only, in this form:\n" +
+		`{"steps": [{"goal": "what to change", "files": ["path/likely/touched.go"], "acceptance": "how to tell it is done"}]}` +
+		"\nDo not make any changes.")
+	return prompt.String()
+}
+
+func buildPlanStepPrompt(issue *Issue, plan *Plan, step *PlanStep, worktree string) string {
+	var prompt strings.Builder
+	prompt.WriteString(fmt.Sprintf("You are implementing issue #%d: %s\n", issue.Number, issue.Title))
+	if worktree != "" {
+		prompt.WriteString(fmt.Sprintf("\nYou are working in the git worktree %s on its own feature branch.\n", worktree))
+	}
+	prompt.WriteString(fmt.Sprintf("\nFull plan:\n%s\n", plan.Markdown()))
+
+	prompt.WriteString(fmt.Sprintf("\nCarry out step %d only: %s\n", step.Position, step.Goal))
+	if len(step.Files) > 0 {
+		prompt.WriteString(fmt.Sprintf("Files likely involved: %s\n", strings.Join(step.Files, ", ")))
+	}
+	if step.Acceptance != "" {
+		prompt.WriteString(fmt.Sprintf("Done when: %s\n", step.Acceptance))
+	}
+	prompt.WriteString("\nEarlier steps are already done; later steps come after this one. " +
+		"Do not commit, push or open a pull request. " +
+		"Finish with a short summary of what you changed.")
+	return prompt.String()
+}
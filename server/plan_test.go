@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/relay/mcp/shared"
+)
+
+// TestParsePlanResponse tests JSON replies, numbered-list fallbacks and the issue task list round trip
+func TestParsePlanResponse(t *testing.T) {
+	reply := "Here is the plan:\n```json\n" +
+		`{"steps": [{"goal": "Add the field", "files": ["config.go"], "acceptance": "config loads"}, {"goal": "Use it", "files": []}]}` +
+		"\n```"
+	steps, err := parsePlanResponse(reply)
+	if err != nil || len(steps) != 2 {
+		t.Fatalf("Expected two JSON steps, got %v (%v)", steps, err)
+	}
+	if steps[0].Goal != "Add the field" || steps[0].Files[0] != "config.go" || steps[0].Acceptance != "config loads" {
+		t.Errorf("Unexpected first step: %+v", steps[0])
+	}
+
+	steps, err = parsePlanResponse("1. First thing\n   - Files: `a.go`, `b.go`\n   - Acceptance: tests pass\n2) Second thing")
+	if err != nil || len(steps) != 2 || len(steps[0].Files) != 2 || steps[0].Acceptance != "tests pass" || steps[1].Goal != "Second thing" {
+		t.Errorf("Unexpected markdown steps: %+v (%v)", steps, err)
+	}
+
+	if _, err := parsePlanResponse("I can't plan this."); err == nil {
+		t.Error("Expected an error for a reply without steps")
+	}
+
+	// The task list mirrored to the issue reads back with its progress
+	plan := NewPlan("demo", 1, []*PlanStep{
+		{Goal: "Done step", Files: []string{"a.go"}, Acceptance: "it builds", Status: PlanStepDone},
+		{Goal: "Failed step", Status: PlanStepFailed},
+		{Goal: "Skipped step", Status: PlanStepSkipped},
+		{Goal: "Next step"},
+	})
+	parsed := parsePlanMarkdown(plan.Markdown())
+	if len(parsed) != 4 {
+		t.Fatalf("Expected four steps back, got %d from %q", len(parsed), plan.Markdown())
+	}
+	for i, step := range parsed {
+		want := plan.Steps[i]
+		if step.Goal != want.Goal || step.Status != want.Status || step.Acceptance != want.Acceptance || len(step.Files) != len(want.Files) {
+			t.Errorf("Step %d: expected %+v, got %+v", i+1, want, step)
+		}
+	}
+}
+
+// TestPlanDatabase tests saving, loading and updating plans and their steps
+func TestPlanDatabase(t *testing.T) {
+	db, err := openDatabase(filepath.Join(t.TempDir(), "relay.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if plan, err := db.GetPlan("demo", 3); err != nil || plan != nil {
+		t.Fatalf("Expected no plan, got %v (%v)", plan, err)
+	}
+
+	plan := NewPlan("demo", 3, []*PlanStep{
+		{Goal: "One", Files: []string{"one.go"}},
+		{Goal: "Two", Acceptance: "two works"},
+	})
+	if err := db.SavePlan(plan); err != nil {
+		t.Fatalf("SavePlan failed: %v", err)
+	}
+
+	now := time.Now()
+	plan.Steps[0].Status = PlanStepDone
+	plan.Steps[0].Output = "did one"
+	plan.Steps[0].FinishedAt = &now
+	if err := db.UpdatePlanStep(plan.Steps[0]); err != nil {
+		t.Fatalf("UpdatePlanStep failed: %v", err)
+	}
+
+	loaded, err := db.GetPlan("demo", 3)
+	if err != nil || loaded == nil || len(loaded.Steps) != 2 {
+		t.Fatalf("Expected saved plan, got %+v (%v)", loaded, err)
+	}
+	first := loaded.Steps[0]
+	if first.Status != PlanStepDone || first.Output != "did one" || first.FinishedAt == nil || first.Files[0] != "one.go" {
+		t.Errorf("Unexpected first step: %+v", first)
+	}
+	if next := loaded.NextStep(); next == nil || next.Goal != "Two" || next.Acceptance != "two works" {
+		t.Errorf("Expected step two next, got %+v", next)
+	}
+
+	// Editing replaces the steps in their new order
+	loaded.Steps = []*PlanStep{loaded.Steps[1], {Goal: "Three"}}
+	if err := db.SavePlan(loaded); err != nil {
+		t.Fatalf("SavePlan failed: %v", err)
+	}
+	edited, _ := db.GetPlan("demo", 3)
+	if len(edited.Steps) != 2 || edited.Steps[0].Goal != "Two" || edited.Steps[1].Position != 2 || edited.ID != plan.ID {
+		t.Errorf("Unexpected edited plan: %+v", edited)
+	}
+}
+
+// TestIssueRunnerResumesPlan tests that a rerun after a crash skips finished
+// steps and reruns the one that was interrupted
+func TestIssueRunnerResumesPlan(t *testing.T) {
+	runner, store, _ := newTestIssueRunner(t)
+	runner.status.ApprovalsEnabled = false
+	runner.planner = &funcProvider{send: func(ctx context.Context, message string) (string, error) {
+		t.Error("Expected the saved plan to be used")
+		return "", nil
+	}}
+
+	plan := NewPlan("demo", 7, []*PlanStep{
+		{Goal: "Write feature.txt", Status: PlanStepDone},
+		{Goal: "Write fixed.txt", Status: PlanStepRunning},
+		{Goal: "Tidy up"},
+	})
+	if err := runner.plans.SavePlan(plan); err != nil {
+		t.Fatalf("SavePlan failed: %v", err)
+	}
+
+	var executed []string
+	runner.newExecutor = func(dir string) (LLMProvider, error) {
+		return &funcProvider{send: func(ctx context.Context, message string) (string, error) {
+			executed = append(executed, message)
+			return "ok", os.WriteFile(filepath.Join(dir, "fixed.txt"), []byte("done\n"), 0644)
+		}}, nil
+	}
+
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v\n%+v", err, runner.Status().Logs)
+	}
+
+	if len(executed) != 2 || !strings.Contains(executed[0], "Carry out step 2 only: Write fixed.txt") || !strings.Contains(executed[1], "step 3 only") {
+		t.Errorf("Expected steps 2 and 3 to run, got %d prompts", len(executed))
+	}
+
+	saved, _ := runner.plans.GetPlan("demo", 7)
+	if done, total := saved.Progress(); done != 3 || total != 3 {
+		t.Errorf("Expected all steps done, got %d/%d", done, total)
+	}
+	issue, _ := store.GetIssue(7)
+	if section, _ := shared.ExtractPlanSection(issue.Body); !strings.Contains(section, "- [x] 3. Tidy up") {
+		t.Errorf("Expected step progress mirrored to the issue, got %q", section)
+	}
+}
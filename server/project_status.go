@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProjectStatus is a project's row on the dashboard. The git columns are
+// read from the working copy and load for every project at once; the GitHub
+// counts need two gh calls, so they load per project when asked for.
+type ProjectStatus struct {
+	Project      *Project
+	Branch       string
+	Dirty        bool
+	InProgress   []int     // Issues with a branch and a worktree
+	LastActivity time.Time // Last commit or last opened, whichever is later
+	Err          error     // Why the git columns could not be read
+
+	CountsLoaded bool
+	OpenIssues   int
+	OpenPRs      int
+	CountsErr    error
+}
+
+// projectGit runs a read-only git command in a project
+func projectGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// LoadProjectStatus reads the git columns of a project
+func LoadProjectStatus(ctx context.Context, project *Project) ProjectStatus {
+	status := ProjectStatus{Project: project, LastActivity: project.LastOpened}
+
+	branch, err := projectGit(ctx, project.Path, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		status.Err = err
+		return status
+	}
+	status.Branch = branch
+
+	changes, err := projectGit(ctx, project.Path, "status", "--porcelain")
+	if err != nil {
+		status.Err = err
+		return status
+	}
+	status.Dirty = changes != ""
+
+	// An empty repository has no commits yet
+	if committed, err := projectGit(ctx, project.Path, "log", "-1", "--format=%ct"); err == nil && committed != "" {
+		if seconds, err := strconv.ParseInt(committed, 10, 64); err == nil {
+			if at := time.Unix(seconds, 0); at.After(status.LastActivity) {
+				status.LastActivity = at
+			}
+		}
+	}
+
+	progress := NewIssueProgressService(project.Path, nil)
+	if err := progress.Refresh(ctx, false); err != nil {
+		status.Err = err
+		return status
+	}
+	status.InProgress = progress.InProgress()
+	return status
+}
+
+// LoadProjectStatuses reads the git columns of every project concurrently
+func LoadProjectStatuses(ctx context.Context, projects []*Project) []ProjectStatus {
+	statuses := make([]ProjectStatus, len(projects))
+	var wg sync.WaitGroup
+	for i, project := range projects {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = LoadProjectStatus(ctx, project)
+		}()
+	}
+	wg.Wait()
+	return statuses
+}
+
+// LoadProjectCounts fetches the open issue and pull request counts of a project
+func LoadProjectCounts(ctx context.Context, project *Project, db *Database) (issues, pullRequests int, err error) {
+	configManager, err := NewConfigManager(project, db)
+	if err != nil {
+		return 0, 0, err
+	}
+	return NewGitHubService(configManager, project.Name, project.Path).OpenCounts(ctx)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestLoadProjectStatuses(t *testing.T) {
+	dir := newCheckpointTestRepo(t)
+	runTestGit(t, dir, "worktree", "add", "--quiet", filepath.Join(t.TempDir(), "wt"), "-b", "feature/issue-4")
+	if err := os.WriteFile(filepath.Join(dir, "main.txt"), []byte("v2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opened := time.Now().Add(-48 * time.Hour)
+	projects := []*Project{
+		{Name: "demo", Path: dir, LastOpened: opened},
+		{Name: "gone", Path: filepath.Join(t.TempDir(), "missing"), LastOpened: opened},
+	}
+	statuses := LoadProjectStatuses(context.Background(), projects)
+
+	demo := statuses[0]
+	if demo.Err != nil || !demo.Dirty || demo.Branch == "" {
+		t.Errorf("demo: %+v", demo)
+	}
+	if len(demo.InProgress) != 1 || demo.InProgress[0] != 4 {
+		t.Errorf("In progress: got %v, want [4]", demo.InProgress)
+	}
+	if !demo.LastActivity.After(opened) {
+		t.Errorf("Last activity %v should be the commit, not %v", demo.LastActivity, opened)
+	}
+
+	if gone := statuses[1]; gone.Err == nil || !gone.LastActivity.Equal(opened) {
+		t.Errorf("Missing project: %+v", gone)
+	}
+}
+
+func TestProjectDashboardModel(t *testing.T) {
+	session := &REPLSession{currentProject: &Project{Name: "beta"}}
+	m := NewProjectDashboardModel(session)
+	m.width = 120
+
+	m, _ = m.Update(issueListActionMsg{label: "Loading projects"})
+	m, cmd := m.Update(projectsLoadedMsg{opID: m.op.id, statuses: []ProjectStatus{
+		{Project: &Project{Name: "alpha", Path: "/src/alpha"}, Branch: "main"},
+		{Project: &Project{Name: "beta", Path: "/src/beta"}, Branch: "feature/issue-2", Dirty: true, InProgress: []int{2}},
+	}})
+
+	// The current project is selected and its counts load lazily
+	if m.selected != 1 || cmd == nil || !m.loading["beta"] || m.loading["alpha"] {
+		t.Fatalf("Selected %d, loading %v", m.selected, m.loading)
+	}
+	if view := m.View(); !strings.Contains(view, "…") || !strings.Contains(view, "#2") || !strings.Contains(view, "dirty") {
+		t.Errorf("Dashboard view:\n%s", view)
+	}
+
+	m, _ = m.Update(projectCountsMsg{project: "beta", issues: 7, prs: 3})
+	if view := m.View(); !strings.Contains(view, "7") || m.loading["beta"] {
+		t.Errorf("Counts were not shown:\n%s", view)
+	}
+
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	if m.selected != 0 || cmd == nil || !m.loading["alpha"] {
+		t.Errorf("Selecting alpha should load its counts")
+	}
+	m, _ = m.Update(projectCountsMsg{project: "alpha", err: errors.New("gh: not logged in")})
+	if view := m.View(); !strings.Contains(view, "not logged in") {
+		t.Errorf("Count error was not shown:\n%s", view)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPTY opens a pseudo-terminal master and returns it with the slave's path
+func openPTY() (*os.File, string, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open /dev/ptmx: %w", err)
+	}
+	fd := uintptr(master.Fd())
+
+	for _, request := range []uintptr{unix.TIOCPTYGRANT, unix.TIOCPTYUNLK} {
+		if _, _, errno := unix.Syscall(unix.SYS_IOCTL, fd, request, 0); errno != 0 {
+			master.Close()
+			return nil, "", fmt.Errorf("failed to unlock terminal: %w", errno)
+		}
+	}
+
+	name := make([]byte, 128)
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, fd, unix.TIOCPTYGNAME, uintptr(unsafe.Pointer(&name[0]))); errno != 0 {
+		master.Close()
+		return nil, "", fmt.Errorf("failed to get terminal name: %w", errno)
+	}
+	if end := bytes.IndexByte(name, 0); end >= 0 {
+		name = name[:end]
+	}
+	return master, string(name), nil
+}
@@ -0,0 +1,19 @@
+//go:build !linux && !darwin
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+var errPTYUnsupported = errors.New("embedded terminals are not supported on this platform")
+
+func startPTY(cmd *exec.Cmd, rows, cols int) (*os.File, error) {
+	return nil, errPTYUnsupported
+}
+
+func resizePTY(master *os.File, rows, cols int) error {
+	return errPTYUnsupported
+}
@@ -0,0 +1,49 @@
+//go:build linux || darwin
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// startPTY starts cmd with a new pseudo-terminal as its controlling terminal
+// and returns the master side
+func startPTY(cmd *exec.Cmd, rows, cols int) (*os.File, error) {
+	master, slaveName, err := openPTY()
+	if err != nil {
+		return nil, err
+	}
+	slave, err := os.OpenFile(slaveName, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, fmt.Errorf("failed to open %s: %w", slaveName, err)
+	}
+	defer slave.Close()
+
+	if err := resizePTY(master, rows, cols); err != nil {
+		master.Close()
+		return nil, err
+	}
+
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	if err := cmd.Start(); err != nil {
+		master.Close()
+		return nil, fmt.Errorf("failed to start %s: %w", cmd.Path, err)
+	}
+	return master, nil
+}
+
+// resizePTY tells the program in the terminal about a new window size
+func resizePTY(master *os.File, rows, cols int) error {
+	size := &unix.Winsize{Row: uint16(rows), Col: uint16(cols)}
+	if err := unix.IoctlSetWinsize(int(master.Fd()), unix.TIOCSWINSZ, size); err != nil {
+		return fmt.Errorf("failed to resize terminal: %w", err)
+	}
+	return nil
+}
@@ -4,7 +4,7 @@ import (
 	"bufio"
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"strings"
 
@@ -18,13 +18,20 @@ type REPLSession struct {
 	gitOps         *GitOperations
 	issueManager   *IssueManager
 	configManager  *ConfigManager
-	logger         *log.Logger
+	jobQueue       *JobQueue
+	agents         *AgentScheduler
+	events         *EventBus
+	summarizer     *Summarizer
+	mcpManager     *MCPManager
+	runners        map[int]*IssueRunner  // Headless issue runs started this session
+	terminals      *AgentTerminalManager // Interactive agent sessions in the terminal pane
+	progress       *IssueProgressService // Cached branch, worktree and PR state of issues
+	notifier       *Notifier             // Sends run, CI and sync events to the project's sinks
+	logger         *slog.Logger
 }
 
 // NewREPLSession creates a new REPL session for the specified project
 func NewREPLSession(projectName string) (*REPLSession, error) {
-	logger := log.New(os.Stdout, "[REPL] ", log.LstdFlags)
-
 	// Initialize project manager
 	pm, err := NewProjectManager()
 	if err != nil {
@@ -45,63 +52,274 @@ func NewREPLSession(projectName string) (*REPLSession, error) {
 		return nil, fmt.Errorf("failed to open project '%s': %w", projectName, err)
 	}
 
-	// Initialize Config Manager first to get LLM settings
-	configManager, err := NewConfigManager(project.Path)
+	// Initialize the job queue
+	jobQueue, err := NewJobQueue(pm.db)
+	if err != nil {
+		pm.Close()
+		return nil, fmt.Errorf("failed to initialize job queue: %w", err)
+	}
+
+	agents := NewAgentSchedulerFromEnv()
+
+	session, err := newProjectSession(pm, project, jobQueue, agents, nil)
+	if err != nil {
+		agents.Close()
+		jobQueue.Close()
+		pm.Close()
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// newProjectSession wires up the managers for a project without changing the
+// process working directory, so several sessions can share one process (relay serve).
+// The project manager, job queue and agent scheduler are shared; the session registers
+// itself as the queue's executor for the project.
+func newProjectSession(pm *ProjectManager, project *Project, jobQueue *JobQueue, agents *AgentScheduler, events *EventBus) (*REPLSession, error) {
+	session, config, err := newProjectServices(pm, project, events)
+	if err != nil {
+		return nil, err
+	}
+	session.jobQueue = jobQueue
+	session.agents = agents
+	session.summarizer = NewSummarizer(session.llmManager.GetPlanningProvider())
+	session.terminals = NewAgentTerminalManagerFromEnv()
+	session.progress = NewIssueProgressService(project.Path, session.issueManager.githubService.ListPullRequests)
+	session.progress.SetEventBus(events, project.Name)
+	session.progress.SetNotifier(session.notifier)
+	session.progress.Start()
+
+	session.startMCPServers(config.MCPServers)
+
+	// Let the queue run this project's jobs
+	jobQueue.SetConcurrencyLimit(project.Name, config.Queue.MaxConcurrent)
+	jobQueue.SetExecutor(project.Name, session)
+	agents.SetConcurrencyLimit(project.Name, config.Agents.MaxConcurrent)
+
+	return session, nil
+}
+
+// NewRunnerSession opens a project with just what a headless issue run needs:
+// no job queue executor, progress polling or MCP servers, so relay run-issue
+// neither runs the project's queued jobs nor starts its tools
+func NewRunnerSession(projectName string) (*REPLSession, error) {
+	pm, err := NewProjectManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize project manager: %w", err)
+	}
+	project, err := pm.GetProject(projectName)
+	if err != nil {
+		pm.Close()
+		return nil, fmt.Errorf("failed to get project '%s': %w", projectName, err)
+	}
+
+	session, _, err := newProjectServices(pm, project, nil)
 	if err != nil {
 		pm.Close()
-		return nil, fmt.Errorf("failed to initialize config manager: %w", err)
+		return nil, err
+	}
+	return session, nil
+}
+
+// newProjectServices creates the configuration, providers, git and issue
+// managers of a project session
+func newProjectServices(pm *ProjectManager, project *Project, events *EventBus) (*REPLSession, Config, error) {
+	// Initialize Config Manager first to get LLM settings
+	configManager, err := NewConfigManager(project, pm.db)
+	if err != nil {
+		return nil, Config{}, fmt.Errorf("failed to initialize config manager: %w", err)
 	}
 
 	// Initialize LLM Manager with current configuration
 	config := configManager.GetConfig()
+	notifier, err := NewNotifier(project.Name, config.Notifications)
+	if err != nil {
+		return nil, Config{}, fmt.Errorf("invalid notification settings: %w", err)
+	}
 	llmManager, err := NewLLMManager(config.LLMs.Planning, config.LLMs.Executing, project.Path)
 	if err != nil {
-		pm.Close()
-		return nil, fmt.Errorf("failed to initialize LLM manager: %w", err)
+		return nil, Config{}, fmt.Errorf("failed to initialize LLM manager: %w", err)
 	}
 
 	// Initialize Git operations
-	gitOps, err := NewGitOperations(project.Path, llmManager.GetExecutingProvider())
+	gitOps, err := NewGitOperations(project.Name, project.Path, llmManager.GetExecutingProvider())
 	if err != nil {
 		llmManager.Close()
-		pm.Close()
-		return nil, fmt.Errorf("failed to initialize git operations: %w", err)
+		return nil, Config{}, fmt.Errorf("failed to initialize git operations: %w", err)
 	}
 
 	// Initialize Issue Manager
-	issueManager, err := NewIssueManager(project.Path, configManager)
+	issueManager, err := NewIssueManager(project.Name, project.Path, configManager)
 	if err != nil {
 		gitOps.Close()
 		llmManager.Close()
-		pm.Close()
-		return nil, fmt.Errorf("failed to initialize issue manager: %w", err)
+		return nil, Config{}, fmt.Errorf("failed to initialize issue manager: %w", err)
 	}
 
 	// Set GitOperations for the IssueManager
 	issueManager.SetGitOperations(gitOps)
+	issueManager.SetEventBus(events, project.Name)
 
-	return &REPLSession{
+	session := &REPLSession{
 		currentProject: project,
 		projectManager: pm,
 		llmManager:     llmManager,
 		gitOps:         gitOps,
 		issueManager:   issueManager,
 		configManager:  configManager,
-		logger:         logger,
-	}, nil
+		events:         events,
+		notifier:       notifier,
+		logger:         componentLogger("REPL"),
+	}
+	return session, config, nil
+}
+
+// ExecuteJob runs a queued job against the session's project
+func (r *REPLSession) ExecuteJob(ctx context.Context, job *Job) (string, error) {
+	ctx = r.auditContext(ctx)
+	switch job.Kind {
+	case JobKindLLM:
+		r.checkpoint(ctx, "queued prompt: "+job.Input)
+		return r.llmManager.GetExecutingProvider().SendMessage(ctx, job.Input)
+
+	case JobKindGit:
+		switch job.Action {
+		case "commit":
+			return r.gitOps.RunSmartCommit(ctx)
+		case "push":
+			return r.gitOps.RunPush(ctx, "")
+		case "commit-push":
+			return r.gitOps.RunSmartCommitAndPush(ctx)
+		}
+		return "", fmt.Errorf("unknown git action: %s", job.Action)
+
+	case JobKindCheck:
+		return runCheckCommand(ctx, r.currentProject.Path, job.Input)
+
+	case JobKindIssue:
+		switch job.Action {
+		case "create":
+			issue, err := r.issueManager.AddIssue(job.Input)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Created issue #%d: %s", issue.Number, issue.Title), nil
+		case "close":
+			reason := job.Input
+			if reason == "" {
+				reason = "completed"
+			}
+			if err := r.issueManager.CloseIssue(job.IssueNumber, reason); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Closed issue #%d as %s", job.IssueNumber, reason), nil
+		case "comment":
+			if err := r.issueManager.githubService.AddComment(job.IssueNumber, job.Input); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Commented on issue #%d", job.IssueNumber), nil
+		}
+		return "", fmt.Errorf("unknown issue action: %s", job.Action)
+	}
+
+	return "", fmt.Errorf("unsupported job kind: %s", job.Kind)
+}
+
+// SummarizeJob returns a spoken summary of a job's result. Summaries of finished
+// jobs produced by the planning provider are cached; fallbacks are not, so a
+// later request can still get the better summary.
+func (r *REPLSession) SummarizeJob(ctx context.Context, job *Job, verbosity SummaryVerbosity) (*Summary, error) {
+	db := r.projectManager.db
+
+	if job.IsFinished() {
+		cached, err := db.GetJobSummary(job.ID, verbosity)
+		if err != nil {
+			return nil, err
+		}
+		if cached != nil {
+			return cached, nil
+		}
+	}
+
+	summary := r.summarizer.Summarize(ctx, jobSummaryInput(job), verbosity)
+	summary.JobID = job.ID
+
+	if job.IsFinished() && summary.Source == SummarySourceLLM {
+		if err := db.SaveJobSummary(summary); err != nil {
+			return nil, err
+		}
+	}
+
+	return summary, nil
+}
+
+// auditContext records what happens under ctx as the user's work on the current project
+func (r *REPLSession) auditContext(ctx context.Context) context.Context {
+	return WithAuditScope(ctx, AuditScope{Project: r.currentProject.Name, Actor: AuditActorUser})
+}
+
+// checkpoint snapshots the project before an executing-provider prompt; a
+// failure is logged rather than blocking the prompt
+func (r *REPLSession) checkpoint(ctx context.Context, trigger string) {
+	if _, err := CreateCheckpoint(ctx, r.currentProject.Path, truncateText(trigger, 120)); err != nil {
+		r.logger.Warn("Could not create checkpoint", "error", err)
+	}
+}
+
+// StartIssueRun starts a headless run for an issue in the background, or
+// returns the run already in progress for it
+func (r *REPLSession) StartIssueRun(issueNumber int) *IssueRunner {
+	if runner, ok := r.runners[issueNumber]; ok && !runner.IsFinished() {
+		return runner
+	}
+	if r.runners == nil {
+		r.runners = make(map[int]*IssueRunner)
+	}
+
+	runner := NewIssueRunner(r, issueNumber)
+	r.runners[issueNumber] = runner
+	r.agents.Submit(runner)
+	return runner
+}
+
+// IssueRun returns the latest run of an issue in this session, if any
+func (r *REPLSession) IssueRun(issueNumber int) *IssueRunner {
+	return r.runners[issueNumber]
 }
 
 // Start begins the REPL loop using Bubble Tea TUI
 func (r *REPLSession) Start() error {
 	defer r.Close()
 
+	// Warnings and errors become toasts instead of being written over the TUI
+	toasts, unsubscribe := SubscribeToasts()
+	defer unsubscribe()
+
+	// Rebound keys and the theme are checked before the TUI starts
+	relayDir, err := relayHomeDir()
+	if err != nil {
+		return err
+	}
+	keymap, err := LoadKeymap(relayDir)
+	if err != nil {
+		return err
+	}
+	userTheme, err := LoadTheme(relayDir)
+	if err != nil {
+		return err
+	}
+	applyTheme(userTheme)
+
 	// Initialize Bubble Tea TUI
 	model := InitTUI(r)
+	model.toastCh = toasts
+	model.keymap = keymap
 
 	// Start the Bubble Tea program
 	program := tea.NewProgram(model, tea.WithAltScreen())
 
-	_, err := program.Run()
+	_, err = program.Run()
 	return err
 }
 
@@ -116,7 +334,7 @@ func (r *REPLSession) handleStatus() error {
 	fmt.Printf("Last Opened: %s\n", r.currentProject.LastOpened.Format("2006-01-02 15:04:05"))
 
 	// Get git status through Claude
-	response, err := r.llmManager.GetExecutingProvider().SendMessage(context.Background(), "Show me the current git status and a brief summary of any changes.")
+	response, err := r.llmManager.GetExecutingProvider().SendMessage(r.auditContext(context.Background()), "Show me the current git status and a brief summary of any changes.")
 	if err != nil {
 		return fmt.Errorf("failed to get git status: %w", err)
 	}
@@ -164,43 +382,67 @@ func (r *REPLSession) handleListProjects() error {
 }
 
 func (r *REPLSession) handleSwitchProject(projectName string) error {
-	// Get the new project
-	newProject, err := r.projectManager.GetProject(projectName)
-	if err != nil {
-		return fmt.Errorf("failed to get project '%s': %w", projectName, err)
+	if err := r.SwitchProject(projectName); err != nil {
+		return err
 	}
+	fmt.Printf("Switched to project '%s' at %s\n", r.currentProject.Name, r.currentProject.Path)
+	return nil
+}
 
-	// Open the new project
-	err = r.projectManager.OpenProject(projectName)
+// SwitchProject moves the session to another project without restarting.
+// The per-project managers are rebuilt for it; the project manager, job
+// queue, agent scheduler and agent sessions are kept. The switch is refused
+// while the old project has running jobs or active agents; its queued jobs
+// wait until it is opened again.
+func (r *REPLSession) SwitchProject(projectName string) error {
+	if projectName == r.currentProject.Name {
+		return nil
+	}
+	project, err := r.projectManager.GetProject(projectName)
 	if err != nil {
-		return fmt.Errorf("failed to open project '%s': %w", projectName, err)
+		return fmt.Errorf("failed to get project '%s': %w", projectName, err)
 	}
 
-	// Update current project reference
-	r.currentProject = newProject
-
-	// Reinitialize LLM Manager with new working directory
-	r.llmManager.Close()
-	config := r.configManager.GetConfig()
-	r.llmManager, err = NewLLMManager(config.LLMs.Planning, config.LLMs.Executing, newProject.Path)
+	// Running jobs use this session's managers, which are about to close
+	jobs, err := r.jobQueue.List(r.currentProject.Name, 0)
 	if err != nil {
-		return fmt.Errorf("failed to initialize LLM manager for new project: %w", err)
+		return fmt.Errorf("failed to list jobs: %w", err)
+	}
+	for _, job := range jobs {
+		if job.Status == JobRunning {
+			return fmt.Errorf("project '%s' has running jobs; wait for them or cancel them first", r.currentProject.Name)
+		}
+	}
+	// Closing the services would cancel the project's agents mid-run
+	if r.agents != nil {
+		for _, agent := range r.agents.Statuses() {
+			if agent.Project == r.currentProject.Name && agent.Run.FinishedAt == nil {
+				return fmt.Errorf("project '%s' has active agents; wait for them or cancel them first", r.currentProject.Name)
+			}
+		}
 	}
 
-	// Update Git operations
-	r.gitOps.Close()
-	r.gitOps, err = NewGitOperations(newProject.Path, r.llmManager.GetExecutingProvider())
+	next, err := newProjectSession(r.projectManager, project, r.jobQueue, r.agents, r.events)
 	if err != nil {
-		return fmt.Errorf("failed to initialize git operations for new project: %w", err)
+		return fmt.Errorf("failed to switch to project '%s': %w", projectName, err)
+	}
+	if err := r.projectManager.OpenProject(projectName); err != nil {
+		next.closeProjectServices()
+		return fmt.Errorf("failed to open project '%s': %w", projectName, err)
 	}
 
-	// Update Issue Manager for new project
-	r.issueManager, err = NewIssueManager(newProject.Path, r.configManager)
-	if err != nil {
-		return fmt.Errorf("failed to initialize issue manager for new project: %w", err)
+	// Agent sessions are kept per project, so one manager serves all of them
+	next.terminals.Close()
+	next.terminals, r.terminals = r.terminals, nil
+
+	previous := r.currentProject.Name
+	if err := r.closeProjectServices(); err != nil {
+		r.logger.Warn("Failed to close project services", "project", previous, "error", err)
 	}
+	r.jobQueue.RemoveExecutor(previous)
 
-	fmt.Printf("Switched to project '%s' at %s\n", newProject.Name, newProject.Path)
+	*r = *next
+	r.jobQueue.SetExecutor(project.Name, r)
 	return nil
 }
 
@@ -541,7 +783,7 @@ func (r *REPLSession) handleIssueChatWithClaude(issue *Issue) error {
 	fmt.Print(ShowCursor)
 	clearScreen()
 
-	fmt.Printf("%sChat about Issue #%d: %s%s\n", ColorBold, issue.Number, issue.Title, ColorReset)
+	fmt.Printf("%s\n", boldStyle.Render(fmt.Sprintf("Chat about Issue #%d: %s", issue.Number, issue.Title)))
 	displayStatus := issue.State
 
 	if len(issue.Labels) > 0 {
@@ -563,17 +805,17 @@ func (r *REPLSession) handleIssueChatWithClaude(issue *Issue) error {
 	}
 
 	// Send initial context to Claude
-	response, err := r.llmManager.GetPlanningProvider().SendMessage(context.Background(), contextPrompt)
+	response, err := r.llmManager.GetPlanningProvider().SendMessage(r.auditContext(context.Background()), contextPrompt)
 	if err != nil {
 		return fmt.Errorf("failed to start chat with Claude: %w", err)
 	}
 
-	fmt.Printf("%sClaude:%s %s\n\n", ColorGreen, ColorReset, response)
+	fmt.Printf("%s %s\n\n", theme.Fg(theme.Success).Render("Claude:"), response)
 
 	// Interactive chat loop
 	reader := bufio.NewReader(os.Stdin)
 	for {
-		fmt.Printf("%sYou:%s ", ColorBlue, ColorReset)
+		fmt.Printf("%s ", theme.Fg(theme.Accent).Render("You:"))
 		input, err := reader.ReadString('\n')
 		if err != nil {
 			return fmt.Errorf("error reading input: %w", err)
@@ -590,13 +832,14 @@ func (r *REPLSession) handleIssueChatWithClaude(issue *Issue) error {
 		}
 
 		// Send to Claude
-		response, err := r.llmManager.GetExecutingProvider().SendMessage(context.Background(), input)
+		r.checkpoint(context.Background(), "prompt: "+input)
+		response, err := r.llmManager.GetExecutingProvider().SendMessage(r.auditContext(context.Background()), input)
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			continue
 		}
 
-		fmt.Printf("\n%sClaude:%s %s\n\n", ColorGreen, ColorReset, response)
+		fmt.Printf("\n%s %s\n\n", theme.Fg(theme.Success).Render("Claude:"), response)
 	}
 
 	fmt.Println("Chat ended. Returning to issue menu...")
@@ -642,7 +885,7 @@ func (r *REPLSession) handleIssuePushToGitHub(issue *Issue) error {
 	SetSttyCooked()
 	fmt.Print(ShowCursor)
 
-	fmt.Printf("%sPushing Issue #%d to GitHub...%s\n", ColorYellow, issue.Number, ColorReset)
+	fmt.Printf("%s\n", theme.Fg(theme.Warning).Render(fmt.Sprintf("Pushing Issue #%d to GitHub...", issue.Number)))
 
 	// Create GitHub issue via Claude
 	var githubPrompt string
@@ -656,12 +899,12 @@ func (r *REPLSession) handleIssuePushToGitHub(issue *Issue) error {
 			issue.Title, displayStatus)
 	}
 
-	response, err := r.llmManager.GetExecutingProvider().SendMessage(context.Background(), githubPrompt)
+	response, err := r.llmManager.GetExecutingProvider().SendMessage(r.auditContext(context.Background()), githubPrompt)
 	if err != nil {
 		return fmt.Errorf("failed to push to GitHub: %w", err)
 	}
 
-	fmt.Printf("\n%sGitHub Push Result:%s\n%s\n", ColorGreen, ColorReset, response)
+	fmt.Printf("\n%s\n%s\n", theme.Fg(theme.Success).Render("GitHub Push Result:"), response)
 	fmt.Println("\nPress any key to continue...")
 	SetSttyRaw()
 	reader := bufio.NewReader(os.Stdin)
@@ -744,6 +987,65 @@ func (r *REPLSession) handleIssueDelete(parts []string) error {
 func (r *REPLSession) Close() error {
 	var errors []error
 
+	if r.jobQueue != nil {
+		if err := r.jobQueue.Close(); err != nil {
+			errors = append(errors, fmt.Errorf("job queue close error: %w", err))
+		}
+	}
+
+	if r.agents != nil {
+		if err := r.agents.Close(); err != nil {
+			errors = append(errors, fmt.Errorf("agent scheduler close error: %w", err))
+		}
+	}
+
+	// Notifications go out in the background; let the last ones arrive before exiting
+	r.notifier.Wait(notifyTimeout)
+
+	if err := r.closeProjectServices(); err != nil {
+		errors = append(errors, err)
+	}
+
+	if r.projectManager != nil {
+		if err := r.projectManager.Close(); err != nil {
+			errors = append(errors, fmt.Errorf("project manager close error: %w", err))
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("cleanup errors: %v", errors)
+	}
+
+	return nil
+}
+
+// startMCPServers connects to the project's MCP servers in the background and
+// offers their tools to the API providers as they come up
+func (r *REPLSession) startMCPServers(configs map[string]MCPServerConfig) {
+	r.mcpManager = NewMCPManager(configs, r.currentProject.Path)
+	r.mcpManager.Start(context.Background())
+	r.llmManager.SetToolSet(r.mcpManager)
+}
+
+// closeProjectServices closes the per-project managers but not the shared
+// project manager or job queue
+func (r *REPLSession) closeProjectServices() error {
+	var errors []error
+
+	// Runs use the project's providers, so stop them first
+	if r.agents != nil {
+		r.agents.CancelProject(r.currentProject.Name)
+	}
+	r.runners = nil
+
+	if r.terminals != nil {
+		r.terminals.Close()
+	}
+
+	if r.progress != nil {
+		r.progress.Close()
+	}
+
 	if r.gitOps != nil {
 		if err := r.gitOps.Close(); err != nil {
 			errors = append(errors, fmt.Errorf("git operations close error: %w", err))
@@ -756,14 +1058,14 @@ func (r *REPLSession) Close() error {
 		}
 	}
 
-	if r.projectManager != nil {
-		if err := r.projectManager.Close(); err != nil {
-			errors = append(errors, fmt.Errorf("project manager close error: %w", err))
+	if r.mcpManager != nil {
+		if err := r.mcpManager.Close(); err != nil {
+			errors = append(errors, fmt.Errorf("MCP manager close error: %w", err))
 		}
 	}
 
 	if len(errors) > 0 {
-		return fmt.Errorf("cleanup errors: %v", errors)
+		return fmt.Errorf("project service errors: %v", errors)
 	}
 
 	return nil
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestSwitchProjectWithActiveAgents tests that switching projects refuses
+// rather than cancelling the current project's runs
+func TestSwitchProjectWithActiveAgents(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	scheduler := NewAgentScheduler(2, NewLLMRateLimiter(0, 0))
+	defer scheduler.Close()
+
+	queue, db := newTestQueue(t)
+	if err := db.AddProject("beta", t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	session := &REPLSession{
+		projectManager: &ProjectManager{db: db},
+		currentProject: &Project{Name: "alpha"},
+		jobQueue:       queue,
+		agents:         scheduler,
+	}
+
+	scheduler.Submit(newBlockingAgentRunner(t, "alpha", 7, release))
+	waitForAgents(t, scheduler, "the agent to start", func(s map[string]AgentStatus) bool {
+		return s["alpha#7"].Run.State == RunRunning
+	})
+	if err := session.SwitchProject("beta"); err == nil || !strings.Contains(err.Error(), "active agents") {
+		t.Fatalf("Expected the switch to be refused, got %v", err)
+	}
+	if state := scheduler.Agent("alpha", 7).Runner.Status().State; state != RunRunning {
+		t.Errorf("Expected the agent to keep running, got %s", state)
+	}
+}
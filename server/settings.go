@@ -0,0 +1,524 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SettingsLayer is one source of settings; later layers override earlier ones
+type SettingsLayer string
+
+const (
+	LayerDefault SettingsLayer = "default" // Built into relay
+	LayerGlobal  SettingsLayer = "global"  // ~/.relay/config.json, for every project
+	LayerLocal   SettingsLayer = "local"   // The project_settings table: this machine only, never committed
+	LayerProject SettingsLayer = "project" // <project>/.relay/config.json, shared through the repository
+	LayerEnv     SettingsLayer = "env"     // RELAY_* environment variables
+)
+
+// settingsLayers are the layers from lowest to highest precedence
+var settingsLayers = []SettingsLayer{LayerDefault, LayerGlobal, LayerLocal, LayerProject, LayerEnv}
+
+// localSettingPrefix keeps config keys apart from the other project settings
+// in the project_settings table
+const localSettingPrefix = "config."
+
+// machineLocalSettings describe this checkout rather than the project, so
+// they live in the local layer even when an old config.json holds them
+var machineLocalSettings = []string{"issue_tracker.github.repository", "issue_tracker.github.last_synced_at"}
+
+// SettingValue is the effective value of a key and where it came from
+type SettingValue struct {
+	Key    string
+	Value  json.RawMessage
+	Layer  SettingsLayer
+	Source string // File, table or environment variable
+}
+
+// String renders the value the way it is set: strings bare, the rest as JSON
+func (v SettingValue) String() string {
+	var s string
+	if json.Unmarshal(v.Value, &s) == nil {
+		return s
+	}
+	return string(v.Value)
+}
+
+// Settings are the layers of a project's configuration. Each layer is kept
+// flat, keyed by dotted paths such as "runner.base_branch", so objects merge
+// key by key across layers while lists and values replace each other.
+type Settings struct {
+	project     string
+	projectFile string // Empty without a project
+	globalFile  string
+	db          *Database // Nil leaves out the local layer
+	layers      map[SettingsLayer]map[string]json.RawMessage
+}
+
+// LoadSettings reads every layer of a project's settings; project may be nil
+// for the global settings alone
+func LoadSettings(relayDir string, project *Project, db *Database) (*Settings, error) {
+	s := &Settings{
+		globalFile: filepath.Join(relayDir, "config.json"),
+		layers:     make(map[SettingsLayer]map[string]json.RawMessage),
+	}
+	if project != nil {
+		s.project = project.Name
+		s.projectFile = filepath.Join(project.Path, ".relay", "config.json")
+		s.db = db
+	}
+
+	defaults, err := json.Marshal(getDefaultConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal default settings: %w", err)
+	}
+	s.layers[LayerDefault] = make(map[string]json.RawMessage)
+	if err := flattenSettings("", defaults, s.layers[LayerDefault]); err != nil {
+		return nil, err
+	}
+
+	if s.layers[LayerGlobal], err = readSettingsFile(s.globalFile); err != nil {
+		return nil, err
+	}
+	if s.layers[LayerLocal], err = s.readLocal(); err != nil {
+		return nil, err
+	}
+	if s.layers[LayerProject], err = readSettingsFile(s.projectFile); err != nil {
+		return nil, err
+	}
+	migratedLLMs := migrateLLMSettings(s.layers[LayerProject])
+	migratedLocal, err := s.migrateProjectFile()
+	if err != nil {
+		return nil, err
+	}
+	if migratedLLMs || migratedLocal {
+		if err := s.save(LayerProject); err != nil {
+			return nil, fmt.Errorf("failed to save migrated settings: %w", err)
+		}
+	}
+	if s.layers[LayerEnv], err = readEnvSettings(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// readSettingsFile reads a config.json; a missing file sets nothing
+func readSettingsFile(path string) (map[string]json.RawMessage, error) {
+	flat := make(map[string]json.RawMessage)
+	if path == "" {
+		return flat, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return flat, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settings: %w", err)
+	}
+	if err := flattenSettings("", data, flat); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return flat, nil
+}
+
+func (s *Settings) readLocal() (map[string]json.RawMessage, error) {
+	flat := make(map[string]json.RawMessage)
+	if s.db == nil {
+		return flat, nil
+	}
+	rows, err := s.db.ListProjectSettings(s.project, localSettingPrefix)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range rows {
+		flat[strings.TrimPrefix(key, localSettingPrefix)] = json.RawMessage(value)
+	}
+	return flat, nil
+}
+
+// readEnvSettings picks up RELAY_RUNNER_BASE_BRANCH and the like for every
+// setting that holds a single value
+func readEnvSettings() (map[string]json.RawMessage, error) {
+	flat := make(map[string]json.RawMessage)
+	for _, key := range envSettingKeys(reflect.TypeOf(Config{}), "") {
+		value, ok := os.LookupEnv(settingEnvName(key))
+		if !ok {
+			continue
+		}
+		raw, err := parseSettingValue(key, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", settingEnvName(key), err)
+		}
+		flat[key] = raw
+	}
+	return flat, nil
+}
+
+// migrateLLMSettings turns the old "llms": {"planning": "claude"} format
+// into provider types, reporting whether it changed anything
+func migrateLLMSettings(flat map[string]json.RawMessage) bool {
+	migrated := false
+	for _, role := range []string{"planning", "executing"} {
+		var old string
+		if json.Unmarshal(flat["llms."+role], &old) != nil {
+			continue
+		}
+		delete(flat, "llms."+role)
+		flat["llms."+role+".type"], _ = json.Marshal(migrateLLMType(old))
+		migrated = true
+	}
+	return migrated
+}
+
+// migrateProjectFile cleans up a config.json written in full by older
+// versions: empty strings are dropped so they don't shadow the layers below,
+// and machine-local settings move to the local layer. It reports whether
+// the project layer changed.
+func (s *Settings) migrateProjectFile() (bool, error) {
+	flat := s.layers[LayerProject]
+	changed := false
+	for key, value := range flat {
+		if string(value) == `""` {
+			delete(flat, key)
+			changed = true
+		}
+	}
+	if s.db == nil {
+		return changed, nil
+	}
+
+	for _, key := range machineLocalSettings {
+		value, ok := flat[key]
+		if !ok {
+			continue
+		}
+		// A value already set on this machine wins over the stale shared one
+		if _, ok := s.layers[LayerLocal][key]; !ok {
+			s.layers[LayerLocal][key] = value
+			if err := s.persist(LayerLocal, nil, map[string]json.RawMessage{key: value}); err != nil {
+				return false, fmt.Errorf("failed to migrate %s: %w", key, err)
+			}
+		}
+		delete(flat, key)
+		changed = true
+	}
+	return changed, nil
+}
+
+// Config merges the layers into a Config
+func (s *Settings) Config() (Config, error) {
+	merged := make(map[string]json.RawMessage)
+	for _, layer := range settingsLayers {
+		for key, value := range s.layers[layer] {
+			merged[key] = value
+		}
+	}
+
+	data, err := json.Marshal(unflattenSettings(merged))
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to merge settings: %w", err)
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, fmt.Errorf("invalid settings: %w", err)
+	}
+	return config, nil
+}
+
+// Values returns the effective values of key and the keys under it, or of
+// every key when key is empty, sorted by key
+func (s *Settings) Values(key string) []SettingValue {
+	effective := make(map[string]SettingValue)
+	for _, layer := range settingsLayers {
+		for k, value := range s.layers[layer] {
+			if key == "" || k == key || strings.HasPrefix(k, key+".") {
+				effective[k] = SettingValue{Key: k, Value: value, Layer: layer, Source: s.source(layer, k)}
+			}
+		}
+	}
+
+	values := make([]SettingValue, 0, len(effective))
+	for _, value := range effective {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].Key < values[j].Key })
+	return values
+}
+
+// source names where a layer keeps a key
+func (s *Settings) source(layer SettingsLayer, key string) string {
+	switch layer {
+	case LayerGlobal:
+		return s.globalFile
+	case LayerLocal:
+		return "project_settings (this machine)"
+	case LayerProject:
+		return s.projectFile
+	case LayerEnv:
+		return settingEnvName(key)
+	}
+	return "built-in"
+}
+
+// Set parses a value given on the command line and stores it in a layer
+func (s *Settings) Set(layer SettingsLayer, key, value string) error {
+	raw, err := parseSettingValue(key, value)
+	if err != nil {
+		return err
+	}
+	return s.set(layer, key, raw)
+}
+
+// set replaces key, and everything under it, in a layer
+func (s *Settings) set(layer SettingsLayer, key string, raw json.RawMessage) error {
+	if err := s.writable(layer); err != nil {
+		return err
+	}
+	if _, err := settingType(key); err != nil {
+		return err
+	}
+
+	leaves := make(map[string]json.RawMessage)
+	if err := flattenSettings(key, raw, leaves); err != nil {
+		return err
+	}
+	flat := s.layers[layer]
+	removed := removeSetting(flat, key)
+	for k, value := range leaves {
+		flat[k] = value
+	}
+	return s.persist(layer, removed, leaves)
+}
+
+// Unset removes key, and everything under it, from a layer
+func (s *Settings) Unset(layer SettingsLayer, key string) error {
+	if err := s.writable(layer); err != nil {
+		return err
+	}
+	removed := removeSetting(s.layers[layer], key)
+	if len(removed) == 0 {
+		return fmt.Errorf("%s is not set in the %s settings", key, layer)
+	}
+	return s.persist(layer, removed, nil)
+}
+
+func (s *Settings) writable(layer SettingsLayer) error {
+	switch layer {
+	case LayerGlobal:
+		return nil
+	case LayerLocal:
+		if s.db == nil {
+			return fmt.Errorf("local settings need a project")
+		}
+		return nil
+	case LayerProject:
+		if s.projectFile == "" {
+			return fmt.Errorf("project settings need a project")
+		}
+		return nil
+	case LayerEnv:
+		return fmt.Errorf("environment settings come from RELAY_* variables")
+	}
+	return fmt.Errorf("%s settings cannot be changed", layer)
+}
+
+// removeSetting deletes key and the keys under it, returning them
+func removeSetting(flat map[string]json.RawMessage, key string) []string {
+	var removed []string
+	for k := range flat {
+		if k == key || strings.HasPrefix(k, key+".") {
+			removed = append(removed, k)
+			delete(flat, k)
+		}
+	}
+	return removed
+}
+
+// persist writes a changed layer: the whole file, or the changed rows
+func (s *Settings) persist(layer SettingsLayer, removed []string, added map[string]json.RawMessage) error {
+	if layer != LayerLocal {
+		return s.save(layer)
+	}
+	for _, key := range removed {
+		if _, ok := added[key]; !ok {
+			if err := s.db.DeleteProjectSetting(s.project, localSettingPrefix+key); err != nil {
+				return err
+			}
+		}
+	}
+	for key, value := range added {
+		if err := s.db.SetProjectSetting(s.project, localSettingPrefix+key, string(value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// save writes a file layer as indented JSON
+func (s *Settings) save(layer SettingsLayer) error {
+	path := s.globalFile
+	if layer == LayerProject {
+		path = s.projectFile
+	}
+	data, err := json.MarshalIndent(unflattenSettings(s.layers[layer]), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal settings: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// flattenSettings adds the values of a JSON document to flat under dotted
+// keys; objects are walked, everything else is a value. Nulls set nothing.
+func flattenSettings(prefix string, raw json.RawMessage, flat map[string]json.RawMessage) error {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(trimmed, &object); err != nil {
+			return err
+		}
+		for key, value := range object {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			if err := flattenSettings(key, value, flat); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if prefix == "" {
+		return fmt.Errorf("settings must be a JSON object")
+	}
+	if string(trimmed) == "null" {
+		return nil
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, trimmed); err != nil {
+		return err
+	}
+	flat[prefix] = json.RawMessage(compact.Bytes())
+	return nil
+}
+
+// unflattenSettings nests dotted keys back into JSON objects
+func unflattenSettings(flat map[string]json.RawMessage) map[string]interface{} {
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	root := make(map[string]interface{})
+	for _, key := range keys {
+		parts := strings.Split(key, ".")
+		object := root
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := object[part].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				object[part] = child
+			}
+			object = child
+		}
+		object[parts[len(parts)-1]] = flat[key]
+	}
+	return root
+}
+
+// settingType resolves a dotted key to the Go type it sets in Config. Keys
+// under a map, such as mcpServers.<name>, take any name.
+func settingType(key string) (reflect.Type, error) {
+	t := reflect.TypeOf(Config{})
+	for _, part := range strings.Split(key, ".") {
+		switch t.Kind() {
+		case reflect.Struct:
+			field, ok := jsonField(t, part)
+			if !ok {
+				return nil, fmt.Errorf("unknown setting %q", key)
+			}
+			t = field.Type
+		case reflect.Map:
+			t = t.Elem()
+		default:
+			return nil, fmt.Errorf("unknown setting %q", key)
+		}
+	}
+	return t, nil
+}
+
+// jsonField finds a struct field by its JSON name
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if field := t.Field(i); jsonName(field) == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// parseSettingValue turns a command-line or environment value into JSON:
+// text for string settings, JSON for everything else
+func parseSettingValue(key, value string) (json.RawMessage, error) {
+	t, err := settingType(key)
+	if err != nil {
+		return nil, err
+	}
+	if t.Kind() == reflect.String {
+		raw, err := json.Marshal(value)
+		return raw, err
+	}
+
+	raw := json.RawMessage(value)
+	if err := json.Unmarshal(raw, reflect.New(t).Interface()); err != nil {
+		return nil, fmt.Errorf("%s takes %s, got %q", key, settingKind(t), value)
+	}
+	return raw, nil
+}
+
+// settingKind describes the values a type takes for error messages
+func settingKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int64, reflect.Float64:
+		return "a number"
+	case reflect.Slice:
+		return "a JSON list"
+	}
+	return "a JSON object"
+}
+
+// envSettingKeys lists the keys of the single-value settings of a struct
+func envSettingKeys(t reflect.Type, prefix string) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := jsonName(field)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch field.Type.Kind() {
+		case reflect.Struct:
+			keys = append(keys, envSettingKeys(field.Type, key)...)
+		case reflect.String, reflect.Bool, reflect.Int:
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// settingEnvName is the environment variable of a key: runner.base_branch
+// is RELAY_RUNNER_BASE_BRANCH
+func settingEnvName(key string) string {
+	return "RELAY_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
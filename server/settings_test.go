@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newSettingsTest returns a relay dir, a project with a .relay dir and a
+// database that knows the project
+func newSettingsTest(t *testing.T) (string, *Project, *Database) {
+	t.Helper()
+	relayDir := t.TempDir()
+	project := &Project{Name: "demo", Path: t.TempDir()}
+	if err := os.MkdirAll(filepath.Join(project.Path, ".relay"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	db, err := openDatabase(filepath.Join(relayDir, "relay.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.AddProject(project.Name, project.Path); err != nil {
+		t.Fatal(err)
+	}
+	return relayDir, project, db
+}
+
+// TestSettingsLayers tests that each layer overrides the ones below it and
+// that Values reports where the winning value came from
+func TestSettingsLayers(t *testing.T) {
+	relayDir, project, db := newSettingsTest(t)
+	writeFile := func(path, content string) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(filepath.Join(relayDir, "config.json"), `{"runner": {"base_branch": "global", "approvals": true}, "agents": {"max_concurrent": 5}}`)
+	writeFile(filepath.Join(project.Path, ".relay", "config.json"), `{"runner": {"base_branch": "shared"}}`)
+	if err := db.SetProjectSetting(project.Name, localSettingPrefix+"agents.max_concurrent", "3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetProjectSetting(project.Name, "issue_list.filter", "is:open"); err != nil {
+		t.Fatal(err)
+	}
+
+	settings, err := LoadSettings(relayDir, project, db)
+	if err != nil {
+		t.Fatalf("LoadSettings failed: %v", err)
+	}
+	config, err := settings.Config()
+	if err != nil {
+		t.Fatalf("Config failed: %v", err)
+	}
+	if config.Runner.BaseBranch != "shared" || config.Agents.MaxConcurrent != 3 || config.Queue.MaxConcurrent != 1 {
+		t.Errorf("Unexpected merge: runner %+v, agents %+v, queue %+v", config.Runner, config.Agents, config.Queue)
+	}
+
+	origins := make(map[string]SettingsLayer)
+	for _, value := range settings.Values("") {
+		origins[value.Key] = value.Layer
+	}
+	want := map[string]SettingsLayer{
+		"runner.base_branch":    LayerProject,
+		"runner.approvals":      LayerGlobal,
+		"agents.max_concurrent": LayerLocal,
+		"queue.max_concurrent":  LayerDefault,
+	}
+	for key, layer := range want {
+		if origins[key] != layer {
+			t.Errorf("%s: got layer %q, want %q", key, origins[key], layer)
+		}
+	}
+	if _, ok := origins["issue_list.filter"]; ok {
+		t.Error("Other project settings should not show up as config")
+	}
+
+	t.Setenv("RELAY_RUNNER_BASE_BRANCH", "env")
+	settings, err = LoadSettings(relayDir, project, db)
+	if err != nil {
+		t.Fatalf("LoadSettings failed: %v", err)
+	}
+	values := settings.Values("runner.base_branch")
+	if len(values) != 1 || values[0].String() != "env" || values[0].Source != "RELAY_RUNNER_BASE_BRANCH" {
+		t.Errorf("Environment should win: %+v", values)
+	}
+}
+
+// TestSettingsSetUnset tests changing each writable layer and that the
+// changes survive a reload
+func TestSettingsSetUnset(t *testing.T) {
+	relayDir, project, db := newSettingsTest(t)
+	settings, err := LoadSettings(relayDir, project, db)
+	if err != nil {
+		t.Fatalf("LoadSettings failed: %v", err)
+	}
+
+	for _, layer := range []SettingsLayer{LayerGlobal, LayerLocal, LayerProject} {
+		if err := settings.Set(layer, "runner.base_branch", string(layer)); err != nil {
+			t.Fatalf("Set %s failed: %v", layer, err)
+		}
+	}
+	if err := settings.Set(LayerLocal, "runner.checks", `["go vet ./...", "go test ./..."]`); err != nil {
+		t.Fatalf("Set list failed: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(project.Path, ".relay", "config.json")); err != nil || strings.Contains(string(data), "checks") {
+		t.Errorf("Local settings leaked into the repository: %s, %v", data, err)
+	}
+
+	settings, err = LoadSettings(relayDir, project, db)
+	if err != nil {
+		t.Fatalf("LoadSettings failed: %v", err)
+	}
+	config, _ := settings.Config()
+	if config.Runner.BaseBranch != "project" || len(config.Runner.Checks) != 2 {
+		t.Errorf("Unexpected runner settings: %+v", config.Runner)
+	}
+
+	if err := settings.Unset(LayerProject, "runner.base_branch"); err != nil {
+		t.Fatalf("Unset failed: %v", err)
+	}
+	config, _ = settings.Config()
+	if config.Runner.BaseBranch != "local" {
+		t.Errorf("Unset should reveal the local value, got %q", config.Runner.BaseBranch)
+	}
+	if err := settings.Unset(LayerProject, "runner.base_branch"); err == nil {
+		t.Error("Unsetting a missing key should fail")
+	}
+
+	errorTests := []struct {
+		layer   SettingsLayer
+		key     string
+		value   string
+		wantErr string
+	}{
+		{LayerLocal, "runner.branch", "x", "unknown setting"},
+		{LayerLocal, "agents.max_concurrent", "lots", "takes a number"},
+		{LayerEnv, "runner.base_branch", "x", "environment"},
+		{LayerDefault, "runner.base_branch", "x", "cannot be changed"},
+	}
+	for _, tt := range errorTests {
+		err := settings.Set(tt.layer, tt.key, tt.value)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Set %s %s=%s: got %v, want %q", tt.layer, tt.key, tt.value, err, tt.wantErr)
+		}
+	}
+}
+
+// TestSettingsLegacyLLMs tests that the old string LLM format is migrated
+// in the project file
+func TestSettingsLegacyLLMs(t *testing.T) {
+	relayDir, project, db := newSettingsTest(t)
+	path := filepath.Join(project.Path, ".relay", "config.json")
+	if err := os.WriteFile(path, []byte(`{"llms": {"planning": "claude", "executing": "openai"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	settings, err := LoadSettings(relayDir, project, db)
+	if err != nil {
+		t.Fatalf("LoadSettings failed: %v", err)
+	}
+	config, err := settings.Config()
+	if err != nil {
+		t.Fatalf("Config failed: %v", err)
+	}
+	if config.LLMs.Planning.Type != migrateLLMType("claude") || config.LLMs.Executing.Type != migrateLLMType("openai") {
+		t.Errorf("Unexpected LLMs: %+v", config.LLMs)
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), `"planning": "claude"`) {
+		t.Errorf("Migrated settings were not saved: %s", data)
+	}
+}
+
+// TestSettingsBaselineProjectFile tests a config.json written in full by
+// older versions: its empty repository must not shadow the detected one
+func TestSettingsBaselineProjectFile(t *testing.T) {
+	_, project, db := newSettingsTest(t)
+	t.Setenv("HOME", t.TempDir())
+	baseline := getDefaultConfig()
+	baseline.IssueTracker.GitHub.SyncDirection = "pull"
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(project.Path, ".relay", "config.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cm, err := NewConfigManager(project, db)
+	if err != nil {
+		t.Fatalf("NewConfigManager failed: %v", err)
+	}
+	if err := cm.UpdateGitHubRepository("owner/repo"); err != nil {
+		t.Fatalf("UpdateGitHubRepository failed: %v", err)
+	}
+	github := cm.GetGitHubConfig()
+	if github.Repository != "owner/repo" || github.SyncDirection != "pull" {
+		t.Errorf("Unexpected GitHub settings: %+v", github)
+	}
+
+	shared, _ := os.ReadFile(path)
+	if strings.Contains(string(shared), "repository") || strings.Contains(string(shared), "last_synced_at") {
+		t.Errorf("Machine-local settings left in the repository: %s", shared)
+	}
+
+	// A repository an older version detected moves to this machine
+	if err := os.WriteFile(path, []byte(`{"issue_tracker": {"github": {"repository": "old/repo"}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DeleteProjectSetting(project.Name, localSettingPrefix+"issue_tracker.github.repository"); err != nil {
+		t.Fatal(err)
+	}
+	cm, err = NewConfigManager(project, db)
+	if err != nil {
+		t.Fatalf("NewConfigManager failed: %v", err)
+	}
+	values := cm.Settings().Values("issue_tracker.github.repository")
+	if len(values) != 1 || values[0].String() != "old/repo" || values[0].Layer != LayerLocal {
+		t.Errorf("Repository was not moved to the local layer: %+v", values)
+	}
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SummaryVerbosity controls how much a spoken summary says
+type SummaryVerbosity string
+
+const (
+	VerbosityBrief    SummaryVerbosity = "brief"
+	VerbosityNormal   SummaryVerbosity = "normal"
+	VerbosityDetailed SummaryVerbosity = "detailed"
+)
+
+// Summary sources
+const (
+	SummarySourceLLM      = "llm"
+	SummarySourceFallback = "fallback"
+)
+
+// Summary is a plain-language result summary. Spoken is short and safe for
+// text-to-speech; Detail is a longer version meant for screens.
+type Summary struct {
+	JobID     int64            `json:"job_id,omitempty"`
+	Verbosity SummaryVerbosity `json:"verbosity"`
+	Spoken    string           `json:"spoken"`
+	Detail    string           `json:"detail"`
+	Source    string           `json:"source"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// SummaryInput is the text to summarize, with an optional headline describing the outcome
+type SummaryInput struct {
+	Headline string
+	Text     string
+}
+
+// summaryLimits bounds the spoken summary and the detail version for a verbosity level
+type summaryLimits struct {
+	sentences   int
+	words       int
+	detailLines int
+}
+
+// ParseVerbosity parses a verbosity name; empty means normal
+func ParseVerbosity(value string) (SummaryVerbosity, error) {
+	switch SummaryVerbosity(strings.ToLower(strings.TrimSpace(value))) {
+	case "":
+		return VerbosityNormal, nil
+	case VerbosityBrief:
+		return VerbosityBrief, nil
+	case VerbosityNormal:
+		return VerbosityNormal, nil
+	case VerbosityDetailed:
+		return VerbosityDetailed, nil
+	}
+	return "", fmt.Errorf("invalid verbosity '%s'. Valid levels: brief, normal, detailed", value)
+}
+
+// Next cycles brief → normal → detailed → brief
+func (v SummaryVerbosity) Next() SummaryVerbosity {
+	switch v {
+	case VerbosityBrief:
+		return VerbosityNormal
+	case VerbosityNormal:
+		return VerbosityDetailed
+	}
+	return VerbosityBrief
+}
+
+func (v SummaryVerbosity) limits() summaryLimits {
+	switch v {
+	case VerbosityBrief:
+		return summaryLimits{sentences: 1, words: 25, detailLines: 10}
+	case VerbosityDetailed:
+		return summaryLimits{sentences: 6, words: 120, detailLines: 60}
+	}
+	return summaryLimits{sentences: 3, words: 60, detailLines: 25}
+}
+
+// Summarizer turns command output into spoken and on-screen summaries. It asks
+// the planning provider first and falls back to a deterministic summary.
+type Summarizer struct {
+	provider LLMProvider
+}
+
+// NewSummarizer creates a summarizer; a nil provider always uses the fallback
+func NewSummarizer(provider LLMProvider) *Summarizer {
+	return &Summarizer{provider: provider}
+}
+
+// Summarize never fails: any provider error produces the fallback summary
+func (s *Summarizer) Summarize(ctx context.Context, input SummaryInput, verbosity SummaryVerbosity) *Summary {
+	if s != nil && s.provider != nil {
+		if summary, err := s.summarizeWithLLM(ctx, input, verbosity); err == nil {
+			return summary
+		}
+	}
+	return fallbackSummary(input, verbosity)
+}
+
+func (s *Summarizer) summarizeWithLLM(ctx context.Context, input SummaryInput, verbosity SummaryVerbosity) (*Summary, error) {
+	limits := verbosity.limits()
+
+	prompt := fmt.Sprintf(`Summarize the following command result for a developer who will hear it read aloud on their phone.
+
+Reply with only a JSON object of the form {"spoken": "...", "detail": "..."}:
+- "spoken": at most %d sentence(s) and %d words of plain conversational English. No markdown, code, file paths, URLs, commit hashes or symbols.
+- "detail": a longer plain-text version for a screen, at most %d lines. File names are fine here.
+
+Outcome: %s
+
+Result:
+%s`, limits.sentences, limits.words, limits.detailLines, input.Headline, truncateText(input.Text, 8000))
+
+	response, err := s.provider.SendMessage(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start == -1 || end <= start {
+		return nil, fmt.Errorf("summary response is not JSON")
+	}
+
+	var parsed struct {
+		Spoken string `json:"spoken"`
+		Detail string `json:"detail"`
+	}
+	if err := json.Unmarshal([]byte(response[start:end+1]), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse summary response: %w", err)
+	}
+
+	// The model is asked for speakable text, but never trust it blindly
+	spoken := limitSpoken(cleanForSpeech(parsed.Spoken), limits)
+	if spoken == "" {
+		return nil, fmt.Errorf("summary response has no spoken text")
+	}
+
+	detail := strings.TrimSpace(parsed.Detail)
+	if detail == "" {
+		detail = fallbackDetail(input, limits)
+	}
+
+	return &Summary{
+		Verbosity: verbosity,
+		Spoken:    spoken,
+		Detail:    limitLines(detail, limits.detailLines),
+		Source:    SummarySourceLLM,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// fallbackSummary builds a summary from the headline and the first sentences of the text
+func fallbackSummary(input SummaryInput, verbosity SummaryVerbosity) *Summary {
+	limits := verbosity.limits()
+
+	sentences := splitSentences(cleanForSpeech(input.Headline))
+	sentences = append(sentences, splitSentences(cleanForSpeech(input.Text))...)
+
+	spoken := strings.Join(sentences, " ")
+	if spoken == "" {
+		spoken = "There is nothing to report."
+	}
+
+	return &Summary{
+		Verbosity: verbosity,
+		Spoken:    limitSpoken(spoken, limits),
+		Detail:    fallbackDetail(input, limits),
+		Source:    SummarySourceFallback,
+		CreatedAt: time.Now(),
+	}
+}
+
+func fallbackDetail(input SummaryInput, limits summaryLimits) string {
+	var detail strings.Builder
+	if input.Headline != "" {
+		detail.WriteString(input.Headline)
+	}
+
+	text := strings.TrimSpace(fencePattern.ReplaceAllStringFunc(input.Text, func(block string) string {
+		// Keep code on screen but drop the fence markers
+		lines := strings.Split(block, "\n")
+		if len(lines) <= 2 {
+			return ""
+		}
+		return strings.Join(lines[1:len(lines)-1], "\n")
+	}))
+	if text != "" {
+		if detail.Len() > 0 {
+			detail.WriteString("\n\n")
+		}
+		detail.WriteString(text)
+	}
+
+	return limitLines(detail.String(), limits.detailLines)
+}
+
+var (
+	fencePattern      = regexp.MustCompile("(?s)```[^\n]*\n.*?(```|$)")
+	inlineCodePattern = regexp.MustCompile("`([^`]*)`")
+	mdLinkPattern     = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	urlPattern        = regexp.MustCompile(`https?://\S+`)
+	pathPattern       = regexp.MustCompile(`(?:~|\.{1,2})?(?:[\w.@-]*[/\\][\w.@-]+)+[/\\]?|\b[\w-]+\.(?:go|mod|sum|js|jsx|ts|tsx|py|rb|rs|java|c|h|cpp|md|json|yaml|yml|toml|sh|sql|html|css|txt|lock)\b`)
+	hashPattern       = regexp.MustCompile(`\b[0-9a-f]{7,40}\b`)
+	listMarkerPattern = regexp.MustCompile(`^(?:#{1,6}\s+|>\s*|[-*+]\s+|\d+[.)]\s+)`)
+	emphasisPattern   = regexp.MustCompile(`[*_~]{1,3}`)
+	symbolPattern     = regexp.MustCompile(`[|<>{}\[\]=$#^\\]`)
+	spacePattern      = regexp.MustCompile(`\s+`)
+	spacePunctPattern = regexp.MustCompile(`\s+([.,;:!?])`)
+	emptyParenPattern = regexp.MustCompile(`\(\s*\)`)
+	plainWordsPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z -]*$`)
+	wordPattern       = regexp.MustCompile(`[A-Za-z]{2,}`)
+)
+
+// cleanForSpeech strips markdown, code, paths, URLs and hashes so text reads naturally aloud.
+// Each remaining line becomes its own sentence.
+func cleanForSpeech(text string) string {
+	text = fencePattern.ReplaceAllString(text, "\n")
+	text = mdLinkPattern.ReplaceAllString(text, "$1")
+	text = urlPattern.ReplaceAllString(text, "")
+	text = inlineCodePattern.ReplaceAllStringFunc(text, func(code string) string {
+		inner := strings.Trim(code, "`")
+		// Single plain words read fine; anything code-like is dropped
+		if plainWordsPattern.MatchString(inner) {
+			return inner
+		}
+		return ""
+	})
+
+	var sentences []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		line = listMarkerPattern.ReplaceAllString(line, "")
+		line = pathPattern.ReplaceAllString(line, "")
+		line = hashPattern.ReplaceAllStringFunc(line, func(word string) string {
+			// Only words with a digit are likely hashes rather than English
+			if strings.ContainsAny(word, "0123456789") {
+				return ""
+			}
+			return word
+		})
+		line = emphasisPattern.ReplaceAllString(line, "")
+		line = symbolPattern.ReplaceAllString(line, " ")
+		line = emptyParenPattern.ReplaceAllString(line, "")
+		line = spacePattern.ReplaceAllString(line, " ")
+		line = spacePunctPattern.ReplaceAllString(line, "$1")
+		line = strings.Trim(line, " :-,;")
+
+		// Skip lines with nothing worth saying
+		if !wordPattern.MatchString(line) {
+			continue
+		}
+
+		if !strings.ContainsAny(line[len(line)-1:], ".!?") {
+			line += "."
+		}
+		sentences = append(sentences, line)
+	}
+
+	return strings.Join(sentences, " ")
+}
+
+// splitSentences splits text after ., ! or ? followed by whitespace
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for i := 0; i < len(text); i++ {
+		if strings.ContainsRune(".!?", rune(text[i])) && (i+1 == len(text) || text[i+1] == ' ') {
+			if sentence := strings.TrimSpace(text[start : i+1]); sentence != "" {
+				sentences = append(sentences, sentence)
+			}
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(text[start:]); rest != "" {
+		sentences = append(sentences, rest)
+	}
+	return sentences
+}
+
+// limitSpoken caps a spoken summary at the sentence and word limits
+func limitSpoken(text string, limits summaryLimits) string {
+	sentences := splitSentences(text)
+	if len(sentences) > limits.sentences {
+		sentences = sentences[:limits.sentences]
+	}
+
+	words := strings.Fields(strings.Join(sentences, " "))
+	if len(words) <= limits.words {
+		return strings.Join(words, " ")
+	}
+
+	spoken := strings.TrimRight(strings.Join(words[:limits.words], " "), ".,;:!?")
+	return spoken + "..."
+}
+
+// limitLines caps text at max lines, noting how many were dropped
+func limitLines(text string, max int) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) <= max {
+		return strings.Join(lines, "\n")
+	}
+	return strings.Join(lines[:max], "\n") + fmt.Sprintf("\n... (%d more lines)", len(lines)-max)
+}
+
+// jobSummaryInput describes a job's outcome in plain words
+func jobSummaryInput(job *Job) SummaryInput {
+	var subject string
+	switch job.Kind {
+	case JobKindLLM:
+		subject = "The assistant request"
+	case JobKindGit:
+		switch job.Action {
+		case "commit":
+			subject = "The commit"
+		case "push":
+			subject = "The push"
+		default:
+			subject = "The commit and push"
+		}
+	case JobKindCheck:
+		subject = "The check"
+	case JobKindIssue:
+		switch job.Action {
+		case "create":
+			subject = "Creating the issue"
+		case "close":
+			subject = fmt.Sprintf("Closing issue %d", job.IssueNumber)
+		default:
+			subject = fmt.Sprintf("Commenting on issue %d", job.IssueNumber)
+		}
+	default:
+		subject = "The job"
+	}
+
+	var outcome string
+	switch job.Status {
+	case JobSucceeded:
+		outcome = "finished successfully"
+	case JobFailed:
+		outcome = "failed"
+	case JobCancelled:
+		outcome = "was cancelled"
+	case JobSkipped:
+		outcome = "was skipped"
+	case JobRunning:
+		outcome = "is still running"
+	default:
+		outcome = "is waiting in the queue"
+	}
+
+	text := job.Output
+	if job.Error != "" {
+		text = strings.TrimSpace(job.Error + "\n" + job.Output)
+	}
+
+	// A successful assistant reply is the content itself
+	headline := subject + " " + outcome + "."
+	if job.Kind == JobKindLLM && job.Status == JobSucceeded {
+		headline = ""
+	}
+
+	return SummaryInput{Headline: headline, Text: text}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// stubProvider returns a canned response or error
+type stubProvider struct {
+	response string
+	err      error
+}
+
+func (p *stubProvider) SendMessage(ctx context.Context, message string) (string, error) {
+	return p.response, p.err
+}
+
+func (p *stubProvider) SendMessageWithSession(ctx context.Context, message string, sessionID string) (string, error) {
+	return p.SendMessage(ctx, message)
+}
+
+func (p *stubProvider) GetProviderName() string { return "stub" }
+
+func (p *stubProvider) Close() error { return nil }
+
+// TestCleanForSpeech tests that code, paths, URLs and markdown are stripped
+func TestCleanForSpeech(t *testing.T) {
+	input := "## Changes\n" +
+		"- Updated **server/main.go** to add the `serve` command\n" +
+		"```go\nfunc main() {}\n```\n" +
+		"See [the docs](https://example.com/docs) or https://example.com\n" +
+		"Committed as 3f2a9c1 on branch main"
+
+	got := cleanForSpeech(input)
+
+	for _, unwanted := range []string{"```", "func main", "server/main.go", "https://", "**", "3f2a9c1", "#"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("Expected %q to be stripped, got %q", unwanted, got)
+		}
+	}
+	for _, wanted := range []string{"Changes.", "serve command.", "See the docs or.", "on branch main."} {
+		if !strings.Contains(got, wanted) {
+			t.Errorf("Expected %q in %q", wanted, got)
+		}
+	}
+}
+
+// TestFallbackSummaryVerbosity tests sentence limits and the job headline
+func TestFallbackSummaryVerbosity(t *testing.T) {
+	job := &Job{
+		Kind:   JobKindCheck,
+		Status: JobFailed,
+		Error:  "exit status 1",
+		Output: "--- FAIL: TestThing (0.00s)\nexpected 2 got 3\nFAIL relay 0.1s",
+	}
+
+	brief := fallbackSummary(jobSummaryInput(job), VerbosityBrief)
+	if brief.Spoken != "The check failed." {
+		t.Errorf("Unexpected brief summary %q", brief.Spoken)
+	}
+	if brief.Source != SummarySourceFallback {
+		t.Errorf("Expected fallback source, got %s", brief.Source)
+	}
+
+	detailed := fallbackSummary(jobSummaryInput(job), VerbosityDetailed)
+	if len(splitSentences(detailed.Spoken)) <= 1 || !strings.Contains(detailed.Spoken, "expected 2 got 3") {
+		t.Errorf("Expected detailed summary to include output, got %q", detailed.Spoken)
+	}
+	if !strings.Contains(detailed.Detail, "--- FAIL: TestThing") {
+		t.Errorf("Expected detail to keep raw output, got %q", detailed.Detail)
+	}
+}
+
+// TestSummarizerProviderAndFallback tests the planning provider path and the fallback on errors
+func TestSummarizerProviderAndFallback(t *testing.T) {
+	input := SummaryInput{Headline: "The push finished successfully.", Text: "To github.com:relay/relay.git\n   abc1234..def5678  main -> main"}
+
+	provider := &stubProvider{response: "Sure!\n{\"spoken\": \"Your changes are on `origin/main` now.\", \"detail\": \"Pushed main to origin.\"}"}
+	summary := NewSummarizer(provider).Summarize(context.Background(), input, VerbosityNormal)
+	if summary.Source != SummarySourceLLM || summary.Spoken != "Your changes are on now." || summary.Detail != "Pushed main to origin." {
+		t.Errorf("Unexpected provider summary: %+v", summary)
+	}
+
+	provider = &stubProvider{err: fmt.Errorf("rate limited")}
+	summary = NewSummarizer(provider).Summarize(context.Background(), input, VerbosityBrief)
+	if summary.Source != SummarySourceFallback || summary.Spoken != "The push finished successfully." {
+		t.Errorf("Expected fallback summary, got %+v", summary)
+	}
+
+	provider = &stubProvider{response: "not json"}
+	summary = NewSummarizer(provider).Summarize(context.Background(), input, VerbosityBrief)
+	if summary.Source != SummarySourceFallback {
+		t.Errorf("Expected fallback for unparseable response, got %+v", summary)
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme is the set of semantic colors every view draws with. Colors are
+// ANSI numbers ("12") or hex ("#5f87ff"); an empty color is the terminal's
+// default. Built-in themes can be picked or extended in ~/.relay/theme.json:
+//
+//	{"extends": "light", "bug": "#d70000"}
+type Theme struct {
+	Name    string `json:"name,omitempty"`
+	Extends string `json:"extends,omitempty"`  // Built-in theme theme.json starts from
+	NoColor bool   `json:"no_color,omitempty"` // Draw with bold, underline and reverse only
+
+	Title       string `json:"title,omitempty"`
+	Text        string `json:"text,omitempty"`
+	Selected    string `json:"selected,omitempty"`
+	Accent      string `json:"accent,omitempty"` // Key hints, links, focused fields
+	Help        string `json:"help,omitempty"`   // Help lines, history, secondary text
+	Prompt      string `json:"prompt,omitempty"`
+	Success     string `json:"success,omitempty"` // Done, open, starting actions
+	Warning     string `json:"warning,omitempty"` // Awaiting, dirty, edits
+	Error       string `json:"error,omitempty"`   // Failures and destructive actions
+	Info        string `json:"info,omitempty"`
+	Special     string `json:"special,omitempty"`
+	Closed      string `json:"closed,omitempty"`
+	Bug         string `json:"bug,omitempty"`
+	Enhancement string `json:"enhancement,omitempty"`
+	InProgress  string `json:"in_progress,omitempty"`
+
+	Added             string `json:"added,omitempty"`
+	Removed           string `json:"removed,omitempty"`
+	AddedBackground   string `json:"added_background,omitempty"`
+	RemovedBackground string `json:"removed_background,omitempty"`
+}
+
+// builtinThemes are the themes theme.json can name
+var builtinThemes = map[string]Theme{
+	"dark": {
+		Title: "12", Text: "15", Selected: "12", Accent: "12", Help: "8", Prompt: "2",
+		Success: "10", Warning: "11", Error: "9", Info: "14", Special: "13",
+		Closed: "8", Bug: "9", Enhancement: "10", InProgress: "4",
+		Added: "10", Removed: "9", AddedBackground: "22", RemovedBackground: "52",
+	},
+	// For terminals with a light background: no white text, darker accents
+	"light": {
+		Title: "4", Text: "0", Selected: "4", Accent: "4", Help: "244", Prompt: "28",
+		Success: "28", Warning: "130", Error: "160", Info: "30", Special: "90",
+		Closed: "244", Bug: "160", Enhancement: "28", InProgress: "25",
+		Added: "28", Removed: "160", AddedBackground: "194", RemovedBackground: "224",
+	},
+	// Bright colors only, and no gray text
+	"high-contrast": {
+		Title: "15", Text: "15", Selected: "11", Accent: "14", Help: "7", Prompt: "15",
+		Success: "10", Warning: "11", Error: "9", Info: "14", Special: "13",
+		Closed: "7", Bug: "9", Enhancement: "10", InProgress: "14",
+		Added: "10", Removed: "9", AddedBackground: "22", RemovedBackground: "52",
+	},
+	// Blue and orange in place of green and red, from the Okabe-Ito palette
+	"colorblind": {
+		Title: "#56B4E9", Text: "15", Selected: "#56B4E9", Accent: "#56B4E9", Help: "8", Prompt: "#0072B2",
+		Success: "#0072B2", Warning: "#F0E442", Error: "#E69F00", Info: "#56B4E9", Special: "#CC79A7",
+		Closed: "8", Bug: "#E69F00", Enhancement: "#0072B2", InProgress: "#009E73",
+		Added: "#0072B2", Removed: "#E69F00", AddedBackground: "#002b4d", RemovedBackground: "#4d3000",
+	},
+	"no-color": {NoColor: true},
+}
+
+// DefaultTheme is the theme without a theme.json
+const DefaultTheme = "dark"
+
+// theme is the theme in use; applyTheme changes it
+var theme = builtinTheme(DefaultTheme)
+
+func init() {
+	applyTheme(theme)
+}
+
+// applyTheme makes t the theme in use and rebuilds the shared styles from
+// it. Views build their own styles from theme as they render.
+func applyTheme(t Theme) {
+	theme = t
+
+	titleStyle = t.Fg(t.Title).Bold(true).MarginBottom(1)
+	selectedStyle = t.Fg(t.Prompt).Bold(true).
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(t.Color(t.Help)).
+		Padding(0, 1)
+	normalStyle = t.Fg(t.Prompt)
+	helpStyle = t.Fg(t.Help).MarginTop(1)
+	selectedIssueStyle = t.Fg(t.Selected).Bold(true)
+	unselectedIssueStyle = t.Fg(t.Text)
+	errorStyle = t.Fg(t.Error).Bold(true)
+	selectedActionStyle = t.Fg(t.Text)
+	unselectedActionStyle = t.Fg(t.Help)
+	historyStyle = t.Fg(t.Help)
+	closedStyle = t.Fg(t.Closed)
+	if t.NoColor {
+		closedStyle = closedStyle.Faint(true)
+	}
+	inProgressStyle = t.Fg(t.InProgress)
+	bugLabelStyle = t.Fg(t.Bug).Bold(true)
+	enhancementLabelStyle = t.Fg(t.Enhancement).Bold(true)
+	spinnerStyle = t.Fg(t.Accent).Bold(true)
+
+	mdHeadingStyle = t.Fg(t.Title).Bold(true)
+	mdTitleStyle = mdHeadingStyle.Underline(true)
+	mdCodeBlockStyle = t.Fg(t.Warning)
+	mdCodeFenceStyle = t.Fg(t.Help)
+	mdInlineCode = t.Fg(t.Warning)
+	mdLinkStyle = t.Fg(t.Accent).Underline(true)
+	mdQuoteStyle = t.Fg(t.Help).Italic(true)
+	mdBulletStyle = t.Fg(t.Accent)
+	mdDoneStyle = t.Fg(t.Success)
+
+	diffAddedBg, diffRemovedBg = "", ""
+	if !t.NoColor {
+		diffAddedBg, diffRemovedBg = lipgloss.Color(t.AddedBackground), lipgloss.Color(t.RemovedBackground)
+	}
+	diffAddedSign = t.Fg(t.Added).Bold(true)
+	diffRemovedSign = t.Fg(t.Removed).Bold(true)
+	codeKeywordStyle = t.Fg(t.Special)
+	codeStringStyle = t.Fg(t.Warning)
+	codeCommentStyle = t.Fg(t.Help).Italic(true)
+	codeNumberStyle = t.Fg(t.Info)
+}
+
+// Semantic styles, built by applyTheme
+var (
+	closedStyle           lipgloss.Style // Closed issues, grayed out
+	inProgressStyle       lipgloss.Style // Issues with a worktree
+	bugLabelStyle         lipgloss.Style
+	enhancementLabelStyle lipgloss.Style
+)
+
+// renderLabel colors the labels the theme has a color for
+func renderLabel(label string) string {
+	switch label {
+	case "bug":
+		return bugLabelStyle.Render(label)
+	case "enhancement":
+		return enhancementLabelStyle.Render(label)
+	}
+	return label
+}
+
+func builtinTheme(name string) Theme {
+	t := builtinThemes[name]
+	t.Name = name
+	return t
+}
+
+// ThemeNames lists the built-in themes
+func ThemeNames() []string {
+	names := make([]string, 0, len(builtinThemes))
+	for name := range builtinThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Color returns a theme color for lipgloss; nothing in no-color mode
+func (t Theme) Color(color string) lipgloss.TerminalColor {
+	if t.NoColor || color == "" {
+		return lipgloss.NoColor{}
+	}
+	return lipgloss.Color(color)
+}
+
+// Fg returns a style with a theme color as its foreground
+func (t Theme) Fg(color string) lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(t.Color(color))
+}
+
+var themeColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// validate checks that every color is an ANSI number or hex
+func (t Theme) validate() error {
+	value := reflect.ValueOf(t)
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		color, ok := value.Field(i).Interface().(string)
+		if !ok || field.Name == "Name" || field.Name == "Extends" || color == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(color); err == nil && n >= 0 && n <= 255 {
+			continue
+		}
+		if !themeColorPattern.MatchString(color) {
+			return fmt.Errorf("%s: %q is not an ANSI color number or #hex color", jsonName(field), color)
+		}
+	}
+	return nil
+}
+
+// jsonName returns the JSON name of a struct field
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	return name
+}
+
+// LoadTheme returns the theme to draw with: no-color when NO_COLOR is set,
+// else ~/.relay/theme.json over the built-in theme it extends, else the
+// default theme
+func LoadTheme(relayDir string) (Theme, error) {
+	if os.Getenv("NO_COLOR") != "" {
+		return builtinTheme("no-color"), nil
+	}
+
+	path := filepath.Join(relayDir, "theme.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return builtinTheme(DefaultTheme), nil
+		}
+		return Theme{}, fmt.Errorf("failed to read theme: %w", err)
+	}
+
+	var custom Theme
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return Theme{}, fmt.Errorf("failed to parse theme %s: %w", path, err)
+	}
+	base := custom.Extends
+	if base == "" {
+		base = DefaultTheme
+	}
+	if _, ok := builtinThemes[base]; !ok {
+		return Theme{}, fmt.Errorf("invalid theme %s: unknown theme %q to extend (built-in: %v)", path, base, ThemeNames())
+	}
+
+	// Fields in the file replace those of the base theme
+	t := builtinTheme(base)
+	if err := json.Unmarshal(data, &t); err != nil {
+		return Theme{}, fmt.Errorf("failed to parse theme %s: %w", path, err)
+	}
+	if custom.Name == "" {
+		t.Name = base + " (custom)"
+	}
+	if err := t.validate(); err != nil {
+		return Theme{}, fmt.Errorf("invalid theme %s: %w", path, err)
+	}
+	return t, nil
+}
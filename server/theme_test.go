@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestBuiltinThemes(t *testing.T) {
+	for _, name := range ThemeNames() {
+		theme := builtinTheme(name)
+		if err := theme.validate(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if name != "no-color" && (theme.Selected == "" || theme.Closed == "" || theme.Bug == "" || theme.InProgress == "" || theme.Error == "" || theme.Help == "") {
+			t.Errorf("%s is missing a semantic color: %+v", name, theme)
+		}
+	}
+}
+
+func TestLoadTheme(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    func(Theme) bool
+		wantErr string
+	}{
+		{"missing file", "", func(th Theme) bool { return th.Name == "dark" }, ""},
+		{"pick", `{"extends": "high-contrast"}`, func(th Theme) bool { return th.Help == "7" }, ""},
+		{"override", `{"extends": "light", "bug": "#d70000"}`, func(th Theme) bool {
+			return th.Bug == "#d70000" && th.Text == "0" && th.Name == "light (custom)"
+		}, ""},
+		{"unknown theme", `{"extends": "solarized"}`, nil, "unknown theme"},
+		{"invalid color", `{"closed": "grey"}`, nil, "closed"},
+		{"bad json", `{"bug": 9}`, nil, "failed to parse"},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		if tt.json != "" {
+			if err := os.WriteFile(filepath.Join(dir, "theme.json"), []byte(tt.json), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		theme, err := LoadTheme(dir)
+		if tt.wantErr == "" && (err != nil || !tt.want(theme)) {
+			t.Errorf("%s: got %+v, %v", tt.name, theme, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: got %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// TestNoColor tests that NO_COLOR wins over theme.json and leaves no color
+// in the shared styles
+func TestNoColor(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "theme.json"), []byte(`{"extends": "light"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("NO_COLOR", "1")
+	theme, err := LoadTheme(dir)
+	if err != nil || !theme.NoColor {
+		t.Fatalf("NO_COLOR should pick no-color: %+v, %v", theme, err)
+	}
+
+	applyTheme(theme)
+	t.Cleanup(func() { applyTheme(builtinTheme(DefaultTheme)) })
+	for _, style := range []lipgloss.Style{selectedIssueStyle, errorStyle, helpStyle, closedStyle, bugLabelStyle, inProgressStyle, mdLinkStyle} {
+		if _, ok := style.GetForeground().(lipgloss.NoColor); !ok {
+			t.Errorf("Style has a color in no-color mode: %v", style.GetForeground())
+		}
+	}
+	if diffAddedBg != "" || diffRemovedBg != "" {
+		t.Errorf("Diff backgrounds in no-color mode: %q, %q", diffAddedBg, diffRemovedBg)
+	}
+	if !closedStyle.GetFaint() {
+		t.Error("Closed issues should be faint without colors")
+	}
+}
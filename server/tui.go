@@ -1,6 +1,9 @@
 package main
 
 import (
+	"strings"
+	"time"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -19,6 +22,18 @@ const (
 	ViewIssueTrackerConfig
 	ViewLabelEditor
 	ViewCloseReason
+	ViewJobs
+	ViewMCP
+	ViewIssueRun
+	ViewPlan
+	ViewAgents
+	ViewCheckpoints
+	ViewAgentTerminal
+	ViewEditor
+	ViewBoard
+	ViewProjects
+	ViewDiff
+	ViewLog
 )
 
 // Main TUI model that orchestrates different views
@@ -38,14 +53,36 @@ type TUIModel struct {
 	confirmationModel ConfirmationModel
 	labelEditorModel  LabelEditorModel
 	closeReasonModel  CloseReasonModel
+	jobListModel      JobListModel
+	mcpListModel      MCPListModel
+	issueRunModel     IssueRunModel
+	planModel         PlanModel
+	agentModel        AgentDashboardModel
+	checkpointModel   CheckpointModel
+	terminalModel     AgentTerminalModel
+	editorModel       EditorModel
+	boardModel        BoardModel
+	projectsModel     ProjectDashboardModel
+	diffModel         DiffModel
+	logModel          LogModel
 
 	// Config components
 	configMenuModel         ConfigMenuModel
 	llmConfigModel          LLMConfigModel
 	issueTrackerConfigModel IssueTrackerConfigModel
 
+	// Warnings and errors logged while the TUI runs
+	toastCh <-chan Toast
+	toasts  []Toast
+
+	// Key bindings, and the palette or key overlay when open
+	keymap   *Keymap
+	palette  *CommandPalette
+	showKeys bool
+
 	// Navigation state
 	previousView ViewType
+	issueHome    ViewType // The issue list or board that issue views return to
 	err          error
 }
 
@@ -58,7 +95,7 @@ func InitTUI(replSession *REPLSession) TUIModel {
 	replModel.width = defaultWidth
 	replModel.height = defaultHeight
 
-	issueListModel := NewIssueListModel(replSession.issueManager, replSession.configManager, replSession.currentProject.Name, replSession.currentProject.Path)
+	issueListModel := NewIssueListModel(replSession.issueManager, replSession.configManager, replSession.progress, replSession.projectManager.db, replSession.currentProject.Name, replSession.currentProject.Path)
 	issueListModel.width = defaultWidth
 	issueListModel.height = defaultHeight
 
@@ -84,17 +121,33 @@ func InitTUI(replSession *REPLSession) TUIModel {
 		configMenuModel:         configMenuModel,
 		llmConfigModel:          llmConfigModel,
 		issueTrackerConfigModel: issueTrackerConfigModel,
+		keymap:                  DefaultKeymap(),
 		previousView:            ViewIssueList,
+		issueHome:               ViewIssueList,
 	}
 }
 
 func (m TUIModel) Init() tea.Cmd {
-	return tea.EnterAltScreen
+	return tea.Batch(tea.EnterAltScreen, waitForToast(m.toastCh), waitForProgress(m.replSession.progress), m.issueListModel.Init())
 }
 
 func (m TUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
+	// The keymap sees key presses first: overlays, global bindings and
+	// rebound view actions
+	switch key := msg.(type) {
+	case keyActionMsg:
+		msg = key.key
+	case tea.KeyMsg:
+		if key.String() != "ctrl+c" {
+			var handled bool
+			if m, msg, cmd, handled = m.applyKeymap(key); handled {
+				return m, cmd
+			}
+		}
+	}
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
@@ -105,17 +158,46 @@ func (m TUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.replModel.height = msg.Height
 		m.issueListModel.width = msg.Width
 		m.issueListModel.height = msg.Height
+		m.issueDetailModel.width = msg.Width
+		m.issueDetailModel.height = msg.Height
 		m.configMenuModel.width = msg.Width
 		m.configMenuModel.height = msg.Height
 		m.llmConfigModel.width = msg.Width
 		m.llmConfigModel.height = msg.Height
 		m.issueTrackerConfigModel.width = msg.Width
 		m.issueTrackerConfigModel.height = msg.Height
+		m.jobListModel.width = msg.Width
+		m.jobListModel.height = msg.Height
+		m.mcpListModel.width = msg.Width
+		m.mcpListModel.height = msg.Height
+		m.issueRunModel.width = msg.Width
+		m.issueRunModel.height = msg.Height
+		m.planModel.width = msg.Width
+		m.planModel.height = msg.Height
+		m.agentModel.width = msg.Width
+		m.agentModel.height = msg.Height
+		m.checkpointModel.width = msg.Width
+		m.checkpointModel.height = msg.Height
+		m.terminalModel.width = msg.Width
+		m.terminalModel.height = msg.Height
+		m.editorModel.width = msg.Width
+		m.editorModel.height = msg.Height
+		m.boardModel.width = msg.Width
+		m.boardModel.height = msg.Height
+		m.projectsModel.width = msg.Width
+		m.projectsModel.height = msg.Height
+		m.diffModel.width = msg.Width
+		m.diffModel.height = msg.Height
+		m.logModel.width = msg.Width
+		m.logModel.height = msg.Height
 
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c":
-			return m, tea.Quit
+			// In the terminal pane Ctrl+C goes to the agent
+			if m.currentView != ViewAgentTerminal {
+				return m, tea.Quit
+			}
 		}
 
 		// Handle view-specific navigation
@@ -130,13 +212,55 @@ func (m TUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		switch msg.View {
 		case ViewIssueList:
-			m.issueListModel = NewIssueListModel(m.replSession.issueManager, m.replSession.configManager, m.replSession.currentProject.Name, m.replSession.currentProject.Path)
+			// Coming back from an issue keeps the search
+			query := ""
+			if m.previousView == ViewIssueDetail {
+				query = m.issueListModel.query
+			}
+			m.issueListModel = NewIssueListModel(m.replSession.issueManager, m.replSession.configManager, m.replSession.progress, m.replSession.projectManager.db, m.replSession.currentProject.Name, m.replSession.currentProject.Path)
 			m.issueListModel.width = m.width
 			m.issueListModel.height = m.height
+			m.issueListModel.query = query
+			m.issueHome = ViewIssueList
+			return m, m.issueListModel.Init()
+		case ViewBoard:
+			back := m.boardModel.back
+			m.boardModel = NewBoardModel(m.replSession)
+			m.boardModel.width = m.width
+			m.boardModel.height = m.height
+			if m.previousView == ViewREPL || m.previousView == ViewIssueList || m.previousView == ViewProjects {
+				m.boardModel.back = m.previousView
+			} else if m.previousView == ViewIssueDetail {
+				m.boardModel.back = back
+			}
+			m.issueHome = ViewBoard
+			return m, m.boardModel.Init()
+		case ViewProjects:
+			m.projectsModel = NewProjectDashboardModel(m.replSession)
+			m.projectsModel.width = m.width
+			m.projectsModel.height = m.height
+			return m, m.projectsModel.Init()
+		case ViewDiff:
+			if data, ok := msg.Data.(DiffData); ok {
+				m.diffModel = NewDiffModel(data)
+				m.diffModel.width = m.width
+				m.diffModel.height = m.height
+				return m, m.diffModel.Init()
+			}
+		case ViewLog:
+			if data, ok := msg.Data.(LogData); ok {
+				m.logModel = NewLogModel(data)
+				m.logModel.width = m.width
+				m.logModel.height = m.height
+				return m, m.logModel.Init()
+			}
 		case ViewIssueDetail:
 			if msg.Data != nil {
 				if issue, ok := msg.Data.(Issue); ok {
 					m.issueDetailModel = NewIssueDetailModel(issue, m.replSession)
+					m.issueDetailModel.back = m.issueHome
+					m.issueDetailModel.width = m.width
+					m.issueDetailModel.height = m.height
 				}
 			}
 		case ViewTextInput:
@@ -179,6 +303,56 @@ func (m TUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.closeReasonModel.height = m.height
 				}
 			}
+		case ViewJobs:
+			m.jobListModel = NewJobListModel(m.replSession)
+			m.jobListModel.width = m.width
+			m.jobListModel.height = m.height
+			return m, m.jobListModel.Init()
+		case ViewMCP:
+			m.mcpListModel = NewMCPListModel(m.replSession)
+			m.mcpListModel.width = m.width
+			m.mcpListModel.height = m.height
+			return m, m.mcpListModel.Init()
+		case ViewIssueRun:
+			if runData, ok := msg.Data.(IssueRunData); ok {
+				m.issueRunModel = NewIssueRunModel(runData)
+				m.issueRunModel.width = m.width
+				m.issueRunModel.height = m.height
+				return m, m.issueRunModel.Init()
+			}
+		case ViewAgents:
+			m.agentModel = NewAgentDashboardModel(m.replSession)
+			m.agentModel.width = m.width
+			m.agentModel.height = m.height
+			return m, m.agentModel.Init()
+		case ViewCheckpoints:
+			if data, ok := msg.Data.(CheckpointData); ok {
+				m.checkpointModel = NewCheckpointModel(data)
+				m.checkpointModel.width = m.width
+				m.checkpointModel.height = m.height
+				return m, m.checkpointModel.Init()
+			}
+		case ViewAgentTerminal:
+			if data, ok := msg.Data.(AgentTerminalData); ok {
+				m.terminalModel = NewAgentTerminalModel(m.replSession.terminals, data)
+				m.terminalModel.width = m.width
+				m.terminalModel.height = m.height
+				return m, m.terminalModel.Init()
+			}
+		case ViewEditor:
+			if data, ok := msg.Data.(EditorData); ok {
+				m.editorModel = NewEditorModel(data)
+				m.editorModel.width = m.width
+				m.editorModel.height = m.height
+				m.editorModel.scrollToCursor()
+			}
+		case ViewPlan:
+			if issue, ok := msg.Data.(Issue); ok {
+				m.planModel = NewPlanModel(issue, m.replSession)
+				m.planModel.width = m.width
+				m.planModel.height = m.height
+				return m, m.planModel.Init()
+			}
 		case ViewREPL:
 			// Return to REPL, set context if provided
 			if msg.Data != nil {
@@ -196,6 +370,38 @@ func (m TUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case BackToPreviousViewMsg:
 		m.currentView = m.previousView
 		return m, nil
+
+	case toastMsg:
+		m.toasts = addToast(m.toasts, msg.toast)
+		return m, tea.Batch(waitForToast(m.toastCh), expireToast())
+
+	case toastExpiredMsg:
+		m.toasts = pruneToasts(m.toasts, time.Now())
+		return m, nil
+
+	case progressChangedMsg:
+		// Returning redraws the badges. After a project switch this waits
+		// on the new project's service.
+		m.issueListModel, _ = m.issueListModel.Update(msg)
+		m.boardModel, _ = m.boardModel.Update(msg)
+		return m, waitForProgress(m.replSession.progress)
+
+	case replIssuesMsg, replBranchesMsg:
+		// Issues and branches for tab completion; the REPL may be out of view by now
+		m.replModel, cmd = m.replModel.Update(msg)
+		return m, cmd
+
+	case replOpDoneMsg:
+		// A REPL operation keeps running when the user navigates away, and
+		// its result still belongs to the REPL
+		m.replModel, cmd = m.replModel.Update(msg)
+		return m, cmd
+
+	case spinnerTickMsg:
+		if m.replModel.op.owns(msg.opID) {
+			m.replModel, cmd = m.replModel.Update(msg)
+			return m, cmd
+		}
 	}
 
 	// Update the current view's model
@@ -220,12 +426,49 @@ func (m TUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.labelEditorModel, cmd = m.labelEditorModel.Update(msg)
 	case ViewCloseReason:
 		m.closeReasonModel, cmd = m.closeReasonModel.Update(msg)
+	case ViewJobs:
+		m.jobListModel, cmd = m.jobListModel.Update(msg)
+	case ViewMCP:
+		m.mcpListModel, cmd = m.mcpListModel.Update(msg)
+	case ViewIssueRun:
+		m.issueRunModel, cmd = m.issueRunModel.Update(msg)
+	case ViewPlan:
+		m.planModel, cmd = m.planModel.Update(msg)
+	case ViewAgents:
+		m.agentModel, cmd = m.agentModel.Update(msg)
+	case ViewCheckpoints:
+		m.checkpointModel, cmd = m.checkpointModel.Update(msg)
+	case ViewAgentTerminal:
+		m.terminalModel, cmd = m.terminalModel.Update(msg)
+	case ViewEditor:
+		m.editorModel, cmd = m.editorModel.Update(msg)
+	case ViewBoard:
+		m.boardModel, cmd = m.boardModel.Update(msg)
+	case ViewProjects:
+		m.projectsModel, cmd = m.projectsModel.Update(msg)
+	case ViewDiff:
+		m.diffModel, cmd = m.diffModel.Update(msg)
+	case ViewLog:
+		m.logModel, cmd = m.logModel.Update(msg)
 	}
 
 	return m, cmd
 }
 
 func (m TUIModel) View() string {
+	view := m.currentViewContent()
+	if m.palette != nil {
+		view = overlay(view, m.palette.View(m.width, m.height), m.width)
+	} else if m.showKeys {
+		view = overlay(view, m.keyHelpView(), m.width)
+	}
+	if toasts := renderToasts(m.toasts, m.width); toasts != "" {
+		view = strings.TrimRight(view, "\n") + "\n\n" + toasts + "\n"
+	}
+	return view
+}
+
+func (m TUIModel) currentViewContent() string {
 	switch m.currentView {
 	case ViewREPL:
 		return m.replModel.View()
@@ -247,6 +490,30 @@ func (m TUIModel) View() string {
 		return m.labelEditorModel.View()
 	case ViewCloseReason:
 		return m.closeReasonModel.View()
+	case ViewJobs:
+		return m.jobListModel.View()
+	case ViewMCP:
+		return m.mcpListModel.View()
+	case ViewIssueRun:
+		return m.issueRunModel.View()
+	case ViewPlan:
+		return m.planModel.View()
+	case ViewAgents:
+		return m.agentModel.View()
+	case ViewCheckpoints:
+		return m.checkpointModel.View()
+	case ViewAgentTerminal:
+		return m.terminalModel.View()
+	case ViewEditor:
+		return m.editorModel.View()
+	case ViewBoard:
+		return m.boardModel.View()
+	case ViewProjects:
+		return m.projectsModel.View()
+	case ViewDiff:
+		return m.diffModel.View()
+	case ViewLog:
+		return m.logModel.View()
 	}
 
 	return "Unknown view"
@@ -291,44 +558,16 @@ func BackToPreviousView() tea.Cmd {
 	}
 }
 
-// Shared styles
+// Shared styles, built from the theme by applyTheme
 var (
-	titleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("12")).
-			MarginBottom(1)
-
-	selectedStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("green")).
-			BorderStyle(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("8")).
-			Padding(0, 1)
-
-	normalStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("green"))
-
-	helpStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("8")).
-			MarginTop(1)
-
-	selectedIssueStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(lipgloss.Color("12"))
-
-	unselectedIssueStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("15"))
-
-	errorStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("9")).
-			Bold(true)
-
-	selectedActionStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("15")) // White for selected action text
-
-	unselectedActionStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("8")) // Gray for unselected action text
-
-	historyStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("8")) // Gray for command history
+	titleStyle            lipgloss.Style
+	selectedStyle         lipgloss.Style // The REPL prompt box
+	normalStyle           lipgloss.Style
+	helpStyle             lipgloss.Style
+	selectedIssueStyle    lipgloss.Style
+	unselectedIssueStyle  lipgloss.Style
+	errorStyle            lipgloss.Style
+	selectedActionStyle   lipgloss.Style
+	unselectedActionStyle lipgloss.Style
+	historyStyle          lipgloss.Style // Gray for command history
 )
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// terminalRefreshInterval is how often the pane redraws the session's screen
+const terminalRefreshInterval = 50 * time.Millisecond
+
+// terminalChrome is the number of lines around the session's screen: the
+// tabs, the status line and the help line
+const terminalChrome = 3
+
+// terminalTickMsg redraws the pane; ticks of an earlier attach are dropped
+type terminalTickMsg struct {
+	attach int64
+}
+
+// AgentTerminalData opens the pane on a session, returning to Back with
+// BackData when detaching
+type AgentTerminalData struct {
+	SessionID int
+	Back      ViewType
+	BackData  interface{}
+}
+
+// AgentTerminalModel shows the agent sessions as tabs. Keys go to the active
+// session except for the pane's own shortcuts, so Esc and Ctrl+C reach the agent.
+type AgentTerminalModel struct {
+	terminals *AgentTerminalManager
+	active    int // Session id of the active tab
+	scroll    int // Lines scrolled back from the bottom
+	attach    int64
+	back      ViewType
+	backData  interface{}
+	width     int
+	height    int
+}
+
+func NewAgentTerminalModel(terminals *AgentTerminalManager, data AgentTerminalData) AgentTerminalModel {
+	m := AgentTerminalModel{
+		terminals: terminals,
+		active:    data.SessionID,
+		attach:    lastAsyncOpID.Add(1),
+		back:      data.Back,
+		backData:  data.BackData,
+		width:     80,
+		height:    24,
+	}
+	if m.session() == nil {
+		if sessions := terminals.Sessions(); len(sessions) > 0 {
+			m.active = sessions[len(sessions)-1].ID
+		}
+	}
+	return m
+}
+
+func (m AgentTerminalModel) Init() tea.Cmd {
+	m.fit()
+	return m.tick()
+}
+
+func (m AgentTerminalModel) tick() tea.Cmd {
+	attach := m.attach
+	return tea.Tick(terminalRefreshInterval, func(time.Time) tea.Msg {
+		return terminalTickMsg{attach: attach}
+	})
+}
+
+func (m AgentTerminalModel) session() *AgentTerminal {
+	return m.terminals.Get(m.active)
+}
+
+// fit resizes the active session to the pane
+func (m AgentTerminalModel) fit() {
+	if term := m.session(); term != nil {
+		term.Resize(max(m.height-terminalChrome, 5), max(m.width, 20))
+	}
+}
+
+// switchTab activates the tab delta places away, wrapping around
+func (m AgentTerminalModel) switchTab(delta int) AgentTerminalModel {
+	sessions := m.terminals.Sessions()
+	if len(sessions) == 0 {
+		return m
+	}
+	index := 0
+	for i, term := range sessions {
+		if term.ID == m.active {
+			index = i
+		}
+	}
+	index = (index + delta + len(sessions)) % len(sessions)
+	m.active, m.scroll = sessions[index].ID, 0
+	m.fit()
+	return m
+}
+
+// closeTab stops the active session and removes its tab
+func (m AgentTerminalModel) closeTab() (AgentTerminalModel, tea.Cmd) {
+	m.terminals.Remove(m.active)
+	m.active = 0
+	if sessions := m.terminals.Sessions(); len(sessions) > 0 {
+		m.active = sessions[len(sessions)-1].ID
+		m.fit()
+		return m, nil
+	}
+	return m, SwitchToView(m.back, m.backData)
+}
+
+func (m AgentTerminalModel) Update(msg tea.Msg) (AgentTerminalModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case terminalTickMsg:
+		if msg.attach != m.attach {
+			return m, nil
+		}
+		m.fit()
+		return m, m.tick()
+
+	case tea.KeyMsg:
+		term := m.session()
+
+		switch msg.String() {
+		case "ctrl+]":
+			// Detach; the session keeps running
+			return m, SwitchToView(m.back, m.backData)
+		case "alt+right":
+			return m.switchTab(1), nil
+		case "alt+left":
+			return m.switchTab(-1), nil
+		case "alt+w":
+			if term != nil {
+				return m.closeTab()
+			}
+		case "shift+up":
+			if term != nil {
+				m.scroll = min(m.scroll+1, term.Screen().ScrollbackLen())
+			}
+			return m, nil
+		case "shift+down":
+			m.scroll = max(m.scroll-1, 0)
+			return m, nil
+		case "alt+up":
+			if term != nil {
+				m.scroll = min(m.scroll+(m.height-terminalChrome)/2, term.Screen().ScrollbackLen())
+			}
+			return m, nil
+		case "alt+down":
+			m.scroll = max(m.scroll-(m.height-terminalChrome)/2, 0)
+			return m, nil
+		}
+
+		if term == nil {
+			if msg.String() == "q" || msg.String() == "esc" {
+				return m, SwitchToView(m.back, m.backData)
+			}
+			return m, nil
+		}
+		if !term.Running() {
+			switch msg.String() {
+			case "enter", "x":
+				return m.closeTab()
+			case "q", "esc":
+				return m, SwitchToView(m.back, m.backData)
+			}
+			return m, nil
+		}
+
+		if input := terminalKeyBytes(msg); len(input) > 0 {
+			m.scroll = 0
+			term.Write(input)
+		}
+	}
+
+	return m, nil
+}
+
+// terminalKeyBytes encodes a key press the way a terminal would send it
+func terminalKeyBytes(msg tea.KeyMsg) []byte {
+	var sequence string
+	switch msg.Type {
+	case tea.KeyRunes:
+		sequence = string(msg.Runes)
+		if msg.Paste {
+			sequence = "\x1b[200~" + sequence + "\x1b[201~"
+		}
+	case tea.KeySpace:
+		sequence = " "
+	case tea.KeyShiftTab:
+		sequence = "\x1b[Z"
+	case tea.KeyDelete:
+		sequence = "\x1b[3~"
+	case tea.KeyUp:
+		sequence = "\x1b[A"
+	case tea.KeyDown:
+		sequence = "\x1b[B"
+	case tea.KeyRight:
+		sequence = "\x1b[C"
+	case tea.KeyLeft:
+		sequence = "\x1b[D"
+	case tea.KeyHome:
+		sequence = "\x1b[H"
+	case tea.KeyEnd:
+		sequence = "\x1b[F"
+	case tea.KeyPgUp:
+		sequence = "\x1b[5~"
+	case tea.KeyPgDown:
+		sequence = "\x1b[6~"
+	default:
+		// Enter, Tab, Backspace, Esc and the ctrl keys are their control codes
+		if msg.Type >= 0 && (msg.Type < 0x20 || msg.Type == 0x7f) {
+			sequence = string(rune(msg.Type))
+		}
+	}
+
+	if sequence != "" && msg.Alt {
+		sequence = "\x1b" + sequence
+	}
+	return []byte(sequence)
+}
+
+func (m AgentTerminalModel) View() string {
+	var content strings.Builder
+
+	activeTab := theme.Fg(theme.Accent).Reverse(true).Bold(true).Padding(0, 1)
+	inactiveTab := theme.Fg(theme.Text).Padding(0, 1)
+	exitedTab := inactiveTab.Foreground(theme.Color(theme.Help))
+
+	sessions := m.terminals.Sessions()
+	var tabs []string
+	for _, term := range sessions {
+		label := term.Label()
+		switch {
+		case term.ID == m.active:
+			tabs = append(tabs, activeTab.Render(label))
+		case term.Running():
+			tabs = append(tabs, inactiveTab.Render(label))
+		default:
+			tabs = append(tabs, exitedTab.Render(label+" ✗"))
+		}
+	}
+	content.WriteString(titleStyle.Render("🖥  Agents") + " " + strings.Join(tabs, "") + "\n")
+
+	rows := max(m.height-terminalChrome, 5)
+	term := m.session()
+	if term == nil {
+		content.WriteString("\nNo agent sessions. Press 's' on an issue to start one.\n")
+		return content.String()
+	}
+
+	lines, cursorRow, cursorCol := term.Screen().View(rows, m.scroll)
+	cursorStyle := lipgloss.NewStyle().Reverse(true)
+	for i := 0; i < rows; i++ {
+		var line []rune
+		if i < len(lines) {
+			line = []rune(lines[i])
+		}
+		if len(line) > m.width {
+			line = line[:m.width]
+		}
+		if i == cursorRow && m.scroll == 0 && term.Running() && cursorCol < m.width {
+			for len(line) <= cursorCol {
+				line = append(line, ' ')
+			}
+			content.WriteString(string(line[:cursorCol]) + cursorStyle.Render(string(line[cursorCol])) + string(line[cursorCol+1:]) + "\n")
+			continue
+		}
+		content.WriteString(string(line) + "\n")
+	}
+
+	status := fmt.Sprintf("%s • %s", term.Title, term.Status())
+	if term.TmuxSession != "" {
+		status += " • tmux " + term.TmuxSession
+	}
+	if m.scroll > 0 {
+		status += fmt.Sprintf(" • scrolled back %d lines", m.scroll)
+	}
+	content.WriteString(helpStyle.Render(truncateText(status, max(m.width-2, 20))) + "\n")
+
+	keyStyle := theme.Fg(theme.Accent).Bold(true)
+	backStyle := theme.Fg(theme.Help).Bold(true)
+	actionOptions := []string{
+		backStyle.Render("ctrl+]") + " Detach",
+		keyStyle.Render("alt+←/→") + " Tabs",
+		keyStyle.Render("shift+↑/↓") + " Scroll",
+		keyStyle.Render("alt+w") + " Close tab",
+	}
+	if !term.Running() {
+		actionOptions = []string{
+			keyStyle.Render("enter") + " Close tab",
+			keyStyle.Render("alt+←/→") + " Tabs",
+			keyStyle.Render("shift+↑/↓") + " Scroll",
+			backStyle.Render("q") + " Back",
+		}
+	}
+	content.WriteString(strings.Join(actionOptions, "  •  "))
+
+	return content.String()
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// agentTickMsg triggers a periodic refresh of the agent dashboard
+type agentTickMsg struct{}
+
+func agentTick() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return agentTickMsg{}
+	})
+}
+
+// AgentDashboardModel lists the headless agents with their issue, step,
+// elapsed time and token spend
+type AgentDashboardModel struct {
+	replSession *REPLSession
+	agents      []AgentStatus
+	selected    int
+	message     string
+	op          *asyncOp // Issue being loaded to follow a run
+	width       int
+	height      int
+}
+
+// agentIssueLoadedMsg carries the issue of the run being followed
+type agentIssueLoadedMsg struct {
+	opID   int64
+	issue  Issue
+	runner *IssueRunner
+}
+
+func NewAgentDashboardModel(session *REPLSession) AgentDashboardModel {
+	m := AgentDashboardModel{
+		replSession: session,
+		width:       80,
+		height:      24,
+	}
+	m.refresh()
+	return m
+}
+
+func (m AgentDashboardModel) Init() tea.Cmd {
+	return agentTick()
+}
+
+func (m *AgentDashboardModel) refresh() {
+	m.agents = m.replSession.agents.Statuses()
+	if m.selected >= len(m.agents) {
+		m.selected = len(m.agents) - 1
+	}
+	if m.selected < 0 {
+		m.selected = 0
+	}
+}
+
+func (m AgentDashboardModel) Update(msg tea.Msg) (AgentDashboardModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case agentTickMsg:
+		m.refresh()
+		return m, agentTick()
+
+	case spinnerTickMsg:
+		return m, m.op.advance(msg)
+
+	case agentIssueLoadedMsg:
+		if !m.op.owns(msg.opID) {
+			return m, nil
+		}
+		m.op.finish()
+		m.op = nil
+		return m, SwitchToView(ViewIssueRun, IssueRunData{Issue: msg.issue, Runner: msg.runner})
+
+	case tea.KeyMsg:
+		m.message = ""
+		if m.op != nil {
+			// Only cancelling is possible while the issue loads
+			if msg.String() == "esc" {
+				m.op.cancel()
+				m.op = nil
+			}
+			return m, nil
+		}
+		switch msg.String() {
+		case "q", "esc":
+			return m, SwitchToView(ViewREPL, nil)
+
+		case "up", "k":
+			if m.selected > 0 {
+				m.selected--
+			}
+
+		case "down", "j":
+			if m.selected < len(m.agents)-1 {
+				m.selected++
+			}
+
+		case "enter":
+			// Follow the agent's run, with its log and approvals
+			if m.selected < len(m.agents) {
+				status := m.agents[m.selected]
+				agent := m.replSession.agents.Agent(status.Project, status.IssueNumber)
+				if agent == nil || status.Project != m.replSession.currentProject.Name {
+					m.message = "Switch to the agent's project to follow its run"
+					return m, nil
+				}
+				op, _ := newAsyncOp(fmt.Sprintf("Loading issue #%d", status.IssueNumber))
+				m.op = op
+				issueManager, runner := m.replSession.issueManager, agent.Runner
+				return m, tea.Batch(op.tick(), func() tea.Msg {
+					// Follow the run with what the dashboard knows if GitHub can't be reached
+					issue := Issue{Number: status.IssueNumber, Title: status.Run.Title, State: "open"}
+					if loaded, err := issueManager.GetIssue(status.IssueNumber); err == nil {
+						issue = *loaded
+					}
+					return agentIssueLoadedMsg{opID: op.id, issue: issue, runner: runner}
+				})
+			}
+
+		case "x":
+			if m.selected < len(m.agents) {
+				status := m.agents[m.selected]
+				if err := m.replSession.agents.Cancel(status.Project, status.IssueNumber); err != nil {
+					m.message = err.Error()
+				} else {
+					m.message = fmt.Sprintf("Cancelling #%d", status.IssueNumber)
+				}
+			}
+
+		case "p":
+			if m.selected < len(m.agents) {
+				status := m.agents[m.selected]
+				if err := m.replSession.agents.Promote(status.Project, status.IssueNumber); err != nil {
+					m.message = err.Error()
+				} else {
+					m.message = fmt.Sprintf("Started #%d ahead of the queue", status.IssueNumber)
+				}
+			}
+
+		case "c":
+			m.replSession.agents.ClearFinished()
+		}
+		m.refresh()
+	}
+
+	return m, nil
+}
+
+// agentStep describes where an agent is: queued, or its stage and plan step
+func agentStep(status AgentStatus) string {
+	if status.Queued {
+		if status.Promoted {
+			return "queued (promoted)"
+		}
+		return "queued"
+	}
+	step := string(status.Run.Stage)
+	if status.Run.Stage == RunStageExecute && status.Run.StepsTotal > 0 {
+		step += fmt.Sprintf(" %d/%d", status.Run.StepsDone, status.Run.StepsTotal)
+	}
+	return step
+}
+
+// formatTokens shortens large token counts, e.g. 12.3k
+func formatTokens(tokens int64) string {
+	if tokens >= 1000 {
+		return fmt.Sprintf("%.1fk", float64(tokens)/1000)
+	}
+	return fmt.Sprintf("%d", tokens)
+}
+
+func (m AgentDashboardModel) View() string {
+	var content strings.Builder
+
+	title := titleStyle.Render("🤖 Agents")
+	content.WriteString(title + "\n")
+
+	running, queued := 0, 0
+	for _, agent := range m.agents {
+		switch {
+		case agent.Queued:
+			queued++
+		case agent.Run.FinishedAt == nil:
+			running++
+		}
+	}
+	content.WriteString(helpStyle.Render(fmt.Sprintf("%d running • %d queued • token spend is estimated", running, queued)) + "\n\n")
+
+	if len(m.agents) == 0 {
+		content.WriteString(helpStyle.Render("No agents. Start one with a on an issue or /agents run <issue>...") + "\n")
+	} else {
+		header := fmt.Sprintf("  %-14s %-28s %-18s %-18s %8s %6s %8s", "PROJECT", "ISSUE", "STATE", "STEP", "ELAPSED", "CALLS", "~TOKENS")
+		content.WriteString(helpStyle.Render(header) + "\n")
+
+		stateStyles := map[RunState]lipgloss.Style{
+			RunSucceeded: theme.Fg(theme.Success),
+			RunFailed:    theme.Fg(theme.Error),
+			RunCancelled: theme.Fg(theme.Help),
+			RunAwaiting:  theme.Fg(theme.Warning),
+			RunPaused:    theme.Fg(theme.Warning),
+		}
+
+		for i, agent := range m.agents {
+			state := string(agent.Run.State)
+			if agent.Queued {
+				state = "queued"
+			}
+			issue := truncateText(fmt.Sprintf("#%d %s", agent.IssueNumber, agent.Run.Title), 28)
+			line := fmt.Sprintf("%-14s %-28s %-18s %-18s %8s %6d %8s",
+				truncateText(agent.Project, 14), issue, state, agentStep(agent),
+				agent.Elapsed.Truncate(time.Second), agent.Requests, formatTokens(agent.InputTokens+agent.OutputTokens))
+
+			if i == m.selected {
+				content.WriteString(selectedIssueStyle.Render("> "+line) + "\n")
+			} else if style, ok := stateStyles[agent.Run.State]; ok && !agent.Queued {
+				content.WriteString(style.Render("  "+line) + "\n")
+			} else {
+				content.WriteString(unselectedIssueStyle.Render("  "+line) + "\n")
+			}
+		}
+
+		if m.selected < len(m.agents) && m.agents[m.selected].Run.Error != "" {
+			content.WriteString("\n" + errorStyle.Render("Error: "+m.agents[m.selected].Run.Error) + "\n")
+		}
+	}
+
+	if m.op != nil {
+		content.WriteString("\n" + m.op.View() + "\n")
+	} else if m.message != "" {
+		content.WriteString("\n" + helpStyle.Render(m.message) + "\n")
+	}
+
+	content.WriteString("\n")
+
+	openStyle := theme.Fg(theme.Accent).Bold(true)
+	promoteStyle := theme.Fg(theme.Success).Bold(true)
+	cancelStyle := theme.Fg(theme.Error).Bold(true)
+	backStyle := theme.Fg(theme.Help).Bold(true)
+
+	actionOptions := []string{
+		openStyle.Render("enter") + " Follow",
+		promoteStyle.Render("p") + " Promote",
+		cancelStyle.Render("x") + " Cancel",
+		openStyle.Render("c") + " Clear finished",
+		backStyle.Render("q") + " Back",
+	}
+	content.WriteString(strings.Join(actionOptions, "  •  ") + "\n")
+
+	return content.String()
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Slow work (LLM calls, git, gh) runs as a tea.Cmd so the TUI keeps
+// rendering. Each view tracks at most one asyncOp; its id tags the spinner
+// ticks and the result so messages from a cancelled op are ignored.
+
+const spinnerInterval = 100 * time.Millisecond
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+var (
+	spinnerStyle lipgloss.Style // Built from the theme by applyTheme
+
+	lastAsyncOpID atomic.Int64
+)
+
+// asyncOp is a running operation with its spinner and cancel function
+type asyncOp struct {
+	id      int64
+	label   string
+	started time.Time
+	cancel  context.CancelFunc
+	frame   int
+}
+
+// spinnerTickMsg advances the spinner of the op with the given id
+type spinnerTickMsg struct {
+	opID int64
+}
+
+// newAsyncOp starts an op and returns the context the work should run with
+func newAsyncOp(label string) (*asyncOp, context.Context) {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &asyncOp{
+		id:      lastAsyncOpID.Add(1),
+		label:   label,
+		started: time.Now(),
+		cancel:  cancel,
+	}, ctx
+}
+
+// tick schedules the next spinner frame
+func (op *asyncOp) tick() tea.Cmd {
+	id := op.id
+	return tea.Tick(spinnerInterval, func(time.Time) tea.Msg {
+		return spinnerTickMsg{opID: id}
+	})
+}
+
+// advance moves the spinner on a tick for this op; ticks of finished or
+// cancelled ops stop the loop
+func (op *asyncOp) advance(msg spinnerTickMsg) tea.Cmd {
+	if op == nil || msg.opID != op.id {
+		return nil
+	}
+	op.frame = (op.frame + 1) % len(spinnerFrames)
+	return op.tick()
+}
+
+// owns reports whether a result tagged with opID belongs to this op
+func (op *asyncOp) owns(opID int64) bool {
+	return op != nil && op.id == opID
+}
+
+// finish releases the op's context once its result has arrived
+func (op *asyncOp) finish() {
+	if op != nil {
+		op.cancel()
+	}
+}
+
+// View renders the spinner line, e.g. "⠹ Waiting for Claude (12s) • esc to cancel"
+func (op *asyncOp) View() string {
+	if op == nil {
+		return ""
+	}
+	elapsed := time.Since(op.started).Truncate(time.Second)
+	return spinnerStyle.Render(spinnerFrames[op.frame]) + " " + op.label +
+		helpStyle.Render(fmt.Sprintf(" (%s) • esc to cancel", elapsed))
+}
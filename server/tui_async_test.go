@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TestREPLModelCancel tests that Esc cancels a running operation's context
+// and that its late result is dropped
+func TestREPLModelCancel(t *testing.T) {
+	started := make(chan struct{})
+	m, cmd := NewREPLModel(nil).startOp("Waiting for Claude", func(ctx context.Context) []string {
+		close(started)
+		<-ctx.Done()
+		return []string{"late result"}
+	})
+	if m.op == nil || !strings.Contains(m.op.View(), "Waiting for Claude") {
+		t.Fatalf("Expected a running op with a spinner, got %+v", m.op)
+	}
+	opID := m.op.id
+
+	// Run the work the way bubbletea would, in the background; the first
+	// command of the batch is the spinner tick
+	batch, ok := cmd().(tea.BatchMsg)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("Expected a spinner tick and the work, got %T", cmd())
+	}
+	done := make(chan tea.Msg, 1)
+	go func() { done <- batch[1]() }()
+	<-started
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.op != nil {
+		t.Fatal("Expected Esc to clear the op")
+	}
+	if last := m.output[len(m.output)-1]; !strings.Contains(last, "Cancelled") {
+		t.Errorf("Expected a cancellation message, got %q", last)
+	}
+
+	select {
+	case msg := <-done:
+		if msg.(replOpDoneMsg).opID != opID {
+			t.Fatalf("Unexpected result: %+v", msg)
+		}
+		m, _ = m.Update(msg)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Esc to cancel the work's context")
+	}
+	for _, line := range m.output {
+		if line == "late result" {
+			t.Error("Expected the result of the cancelled op to be dropped")
+		}
+	}
+
+	// A new op's result is shown
+	m, cmd = m.startOp("Pushing", func(ctx context.Context) []string { return []string{"pushed"} })
+	if cmd == nil {
+		t.Fatal("Expected a command for the op")
+	}
+	m, _ = m.Update(replOpDoneMsg{opID: m.op.id, lines: []string{"pushed"}})
+	if m.op != nil || m.output[len(m.output)-1] != "pushed" {
+		t.Errorf("Expected the op to finish with its output, got %v", m.output)
+	}
+}
+
+// TestREPLOpOutOfView tests that a REPL operation finishing while another
+// view is showing still reaches the REPL
+func TestREPLOpOutOfView(t *testing.T) {
+	repl, _ := NewREPLModel(nil).startOp("Waiting for Claude", func(ctx context.Context) []string { return nil })
+	m := TUIModel{currentView: ViewConfig, replModel: repl}
+	opID := repl.op.id
+
+	model, cmd := m.Update(spinnerTickMsg{opID: opID})
+	if cmd == nil {
+		t.Error("Expected the spinner to keep ticking out of view")
+	}
+	model, _ = model.Update(replOpDoneMsg{opID: opID, lines: []string{"reply"}})
+	m = model.(TUIModel)
+	if m.replModel.op != nil || m.replModel.output[len(m.replModel.output)-1] != "reply" {
+		t.Errorf("Expected the REPL to get the result, got op %v, output %v", m.replModel.op, m.replModel.output)
+	}
+}
+
+// TestIssueDetailEdit tests that issue edits run as ops and that their
+// results update the issue or leave the view
+func TestIssueDetailEdit(t *testing.T) {
+	m := IssueDetailModel{issue: Issue{Number: 3, Title: "Old"}, back: ViewBoard}
+	run := func(m IssueDetailModel, msg issueEditMsg) (IssueDetailModel, tea.Cmd) {
+		t.Helper()
+		m, cmd := m.Update(msg)
+		if m.op == nil {
+			t.Fatal("Expected the edit to run as an op")
+		}
+		batch, ok := cmd().(tea.BatchMsg)
+		if !ok || len(batch) != 2 {
+			t.Fatalf("Expected a spinner tick and the edit, got %T", cmd())
+		}
+		return m.Update(batch[1]())
+	}
+
+	m, _ = run(m, issueEditMsg{label: "Renaming issue #3", edit: func(ctx context.Context) (Issue, error) {
+		return Issue{Number: 3, Title: "New"}, nil
+	}})
+	if m.op != nil || m.issue.Title != "New" {
+		t.Errorf("Expected the renamed issue, got op %v, issue %+v", m.op, m.issue)
+	}
+
+	m, cmd := run(m, issueEditMsg{label: "Closing issue #3", leave: true, edit: func(ctx context.Context) (Issue, error) {
+		return m.issue, errors.New("gh failed")
+	}})
+	if cmd != nil || m.err != "gh failed" {
+		t.Errorf("Expected a failed close to stay with the error, got %q", m.err)
+	}
+
+	_, cmd = run(m, issueEditMsg{label: "Closing issue #3", leave: true, edit: func(ctx context.Context) (Issue, error) {
+		return m.issue, nil
+	}})
+	if cmd == nil {
+		t.Fatal("Expected a closed issue to leave the view")
+	}
+	if msg, ok := cmd().(SwitchViewMsg); !ok || msg.View != ViewBoard {
+		t.Errorf("Expected to return to the board, got %+v", msg)
+	}
+}
+
+// TestClaudeCLICancel tests that cancelling the context kills the claude process
+func TestClaudeCLICancel(t *testing.T) {
+	dir := t.TempDir()
+	script := "#!/bin/sh\nexec sleep 30\n"
+	if err := os.WriteFile(filepath.Join(dir, "claude"), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake claude: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	cli, err := NewClaudeCLI(false, dir)
+	if err != nil {
+		t.Fatalf("NewClaudeCLI failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = cli.SendCommandContext(ctx, "hello")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Expected the process to be killed promptly, took %s", elapsed)
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// boardLoadedMsg carries the issues and project statuses fetched by a board op
+type boardLoadedMsg struct {
+	opID     int64
+	issues   []Issue
+	statuses map[int]string
+	err      error
+}
+
+// BoardModel shows the issues as cards in the columns of the board config.
+// It loads and changes issues through the IssueManager like the issue list.
+type BoardModel struct {
+	issueManager *IssueManager
+	progress     *IssueProgressService
+	board        BoardConfig
+	columns      []BoardColumn
+	issues       []Issue
+	statuses     map[int]string // Project status per issue, when columns use one
+	grouped      [][]Issue
+	column       int   // Focused column
+	rows         []int // Selected card per column
+	follow       int   // Issue to select once loaded, after a move
+	back         ViewType
+	loaded       bool
+	op           *asyncOp
+	err          string
+	width        int
+	height       int
+}
+
+func NewBoardModel(replSession *REPLSession) BoardModel {
+	board := replSession.configManager.GetConfig().Board
+	m := BoardModel{
+		issueManager: replSession.issueManager,
+		progress:     replSession.progress,
+		board:        board,
+		columns:      board.BoardColumns(),
+		back:         ViewIssueList,
+		width:        80,
+		height:       24,
+	}
+	m.rows = make([]int, len(m.columns))
+	m.grouped = make([][]Issue, len(m.columns))
+	if err := board.Validate(); err != nil {
+		m.err = err.Error()
+	}
+	return m
+}
+
+// Init loads the board in the background, like the issue list
+func (m BoardModel) Init() tea.Cmd {
+	return func() tea.Msg {
+		return issueListActionMsg{label: "Loading board"}
+	}
+}
+
+// startOp runs action and then reloads the issues, with a spinner
+func (m BoardModel) startOp(label string, action func(ctx context.Context) error) (BoardModel, tea.Cmd) {
+	if m.op != nil {
+		m.op.cancel()
+	}
+	op, ctx := newAsyncOp(label)
+	m.op = op
+
+	issueManager, board := m.issueManager, m.board
+	return m, tea.Batch(op.tick(), func() tea.Msg {
+		if action != nil {
+			if err := action(ctx); err != nil {
+				return boardLoadedMsg{opID: op.id, err: err}
+			}
+		}
+		issues, err := issueManager.ListIssuesContext(ctx, "", "")
+		if err != nil {
+			return boardLoadedMsg{opID: op.id, err: err}
+		}
+		var statuses map[int]string
+		if board.usesProjectStatus() {
+			if statuses, err = issueManager.ProjectStatuses(ctx, board); err != nil {
+				return boardLoadedMsg{opID: op.id, issues: issues, err: err}
+			}
+		}
+		return boardLoadedMsg{opID: op.id, issues: issues, statuses: statuses}
+	})
+}
+
+// regroup puts the issues in their columns, keeping the selection in range
+func (m BoardModel) regroup() BoardModel {
+	m.grouped = groupBoardIssues(m.columns, m.issues, m.progress.Get, m.statuses)
+	for i := range m.columns {
+		if m.follow != 0 {
+			for row, issue := range m.grouped[i] {
+				if issue.Number == m.follow {
+					m.column, m.rows[i] = i, row
+				}
+			}
+		}
+		m.rows[i] = max(min(m.rows[i], len(m.grouped[i])-1), 0)
+	}
+	m.follow = 0
+	return m
+}
+
+// selectedIssue returns the selected card's issue
+func (m BoardModel) selectedIssue() (Issue, bool) {
+	cards := m.grouped[m.column]
+	if len(cards) == 0 {
+		return Issue{}, false
+	}
+	return cards[m.rows[m.column]], true
+}
+
+// moveCard moves the selected card delta columns over
+func (m BoardModel) moveCard(delta int) (BoardModel, tea.Cmd) {
+	issue, ok := m.selectedIssue()
+	to := m.column + delta
+	if !ok || to < 0 || to >= len(m.columns) {
+		return m, nil
+	}
+
+	progress, status := m.progress.Get(issue.Number), m.statuses[issue.Number]
+	if _, err := planBoardMove(m.columns, issue, progress, status, to); err != nil {
+		m.err = err.Error()
+		return m, nil
+	}
+	m.err = ""
+	m.follow = issue.Number
+
+	issueManager, board := m.issueManager, m.board
+	return m.startOp(fmt.Sprintf("Moving #%d to %s", issue.Number, m.columns[to].Name), func(ctx context.Context) error {
+		return issueManager.MoveIssue(ctx, board, issue, progress, status, to)
+	})
+}
+
+func (m BoardModel) Update(msg tea.Msg) (BoardModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case spinnerTickMsg:
+		return m, m.op.advance(msg)
+
+	case issueListActionMsg:
+		return m.startOp(msg.label, msg.action)
+
+	case boardLoadedMsg:
+		if !m.op.owns(msg.opID) {
+			return m, nil
+		}
+		m.op.finish()
+		m.op = nil
+		if msg.err != nil {
+			m.err = msg.err.Error()
+		}
+		if msg.issues != nil {
+			m.issues, m.statuses, m.loaded = msg.issues, msg.statuses, true
+			m.progress.RequestRefresh()
+		}
+		return m.regroup(), nil
+
+	case progressChangedMsg:
+		return m.regroup(), nil
+
+	case tea.KeyMsg:
+		if m.op != nil {
+			// Only cancelling is possible while an operation runs
+			if msg.String() == "esc" {
+				m.op.cancel()
+				m.err = m.op.label + " cancelled"
+				m.op = nil
+			}
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "q", "esc":
+			return m, SwitchToView(m.back, nil)
+		case "r":
+			m.err = ""
+			return m.startOp("Loading board", nil)
+		case "left", "h":
+			if m.column > 0 {
+				m.column--
+			}
+		case "right", "l":
+			if m.column < len(m.columns)-1 {
+				m.column++
+			}
+		case "up", "k":
+			if m.rows[m.column] > 0 {
+				m.rows[m.column]--
+			}
+		case "down", "j":
+			if m.rows[m.column] < len(m.grouped[m.column])-1 {
+				m.rows[m.column]++
+			}
+		case "shift+left", "H":
+			return m.moveCard(-1)
+		case "shift+right", "L":
+			return m.moveCard(1)
+		case "enter", " ":
+			if issue, ok := m.selectedIssue(); ok {
+				return m, SwitchToView(ViewIssueDetail, issue)
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func (m BoardModel) View() string {
+	var content strings.Builder
+
+	content.WriteString(titleStyle.Render("🗂  Board") + "\n")
+	if m.op != nil {
+		content.WriteString(m.op.View() + "\n")
+	} else if m.err != "" {
+		content.WriteString(errorStyle.Render("⚠️  "+m.err) + "\n")
+	}
+
+	if m.loaded {
+		content.WriteString(m.columnsView() + "\n")
+	}
+	content.WriteString("\n")
+
+	keyStyle := theme.Fg(theme.Accent).Bold(true)
+	moveStyle := theme.Fg(theme.Warning).Bold(true)
+	backStyle := theme.Fg(theme.Help).Bold(true)
+	actionOptions := []string{
+		keyStyle.Render("←/→") + " Column",
+		keyStyle.Render("↑/↓") + " Card",
+		moveStyle.Render("shift+←/→") + " Move",
+		keyStyle.Render("enter") + " Open",
+		keyStyle.Render("r") + " Refresh",
+		backStyle.Render("q") + " Back",
+	}
+	content.WriteString(strings.Join(actionOptions, "  •  "))
+
+	return content.String()
+}
+
+// columnsView renders the columns side by side, each card as its title line
+// and a line of badges
+func (m BoardModel) columnsView() string {
+	if len(m.columns) == 0 {
+		return ""
+	}
+	columnWidth := max((m.width-len(m.columns)+1)/len(m.columns), 16)
+	maxCards := max((m.height-8)/2, 1)
+
+	headerStyle := theme.Fg(theme.Title).Bold(true)
+	focusedHeader := headerStyle.Reverse(true)
+	labelStyle := theme.Fg(theme.Help)
+	cell := lipgloss.NewStyle().Width(columnWidth).MaxWidth(columnWidth)
+
+	var rendered []string
+	for i, column := range m.columns {
+		cards := m.grouped[i]
+		header := headerStyle
+		if i == m.column {
+			header = focusedHeader
+		}
+		lines := []string{
+			cell.Render(header.Render(truncateText(fmt.Sprintf(" %s (%d) ", column.Name, len(cards)), columnWidth))),
+			cell.Render(labelStyle.Render(strings.Repeat("─", columnWidth))),
+		}
+
+		start := 0
+		if m.rows[i] >= maxCards {
+			start = m.rows[i] - maxCards + 1
+		}
+		for row := start; row < len(cards) && row < start+maxCards; row++ {
+			issue := cards[row]
+			title := truncateText(fmt.Sprintf("#%d %s", issue.Number, issue.Title), columnWidth-2)
+			if i == m.column && row == m.rows[i] {
+				title = selectedIssueStyle.Render("> " + title)
+			} else {
+				title = unselectedIssueStyle.Render("  " + title)
+			}
+
+			badges := []string{}
+			if badge := m.progress.Get(issue.Number).Badge(); badge != "" {
+				badges = append(badges, badge)
+			}
+			if len(issue.Labels) > 0 {
+				badges = append(badges, labelStyle.Render(strings.Join(issue.Labels, ", ")))
+			}
+			lines = append(lines, cell.Render(title), cell.Render("  "+strings.Join(badges, " ")))
+		}
+		if hidden := len(cards) - start - maxCards; hidden > 0 {
+			lines = append(lines, cell.Render(helpStyle.Render(fmt.Sprintf("  … %d more", hidden))))
+		}
+		if i > 0 {
+			rendered = append(rendered, " ")
+		}
+		rendered = append(rendered, strings.Join(lines, "\n"))
+	}
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, rendered...)
+}
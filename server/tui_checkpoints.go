@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// checkpointEntry is a checkpoint with its diffstat for the timeline
+type checkpointEntry struct {
+	checkpoint *Checkpoint
+	diffstat   string
+}
+
+// checkpointsLoadedMsg carries the timeline loaded in the background
+type checkpointsLoadedMsg struct {
+	entries []checkpointEntry
+	err     error
+}
+
+// checkpointRestoredMsg reports the outcome of a restore
+type checkpointRestoredMsg struct {
+	restored *Checkpoint
+	safety   *Checkpoint
+	err      error
+}
+
+// CheckpointModel is a timeline of a worktree's checkpoints; any of them can
+// be restored without losing the later ones
+type CheckpointModel struct {
+	dir      string
+	back     ViewType
+	backData interface{}
+	entries  []checkpointEntry
+	selected int
+	loading  bool
+	message  string
+	err      error
+	width    int
+	height   int
+}
+
+// CheckpointData opens the timeline for a worktree, returning to Back with BackData
+type CheckpointData struct {
+	Dir      string
+	Back     ViewType
+	BackData interface{}
+}
+
+func NewCheckpointModel(data CheckpointData) CheckpointModel {
+	return CheckpointModel{
+		dir:      data.Dir,
+		back:     data.Back,
+		backData: data.BackData,
+		loading:  true,
+		width:    80,
+		height:   24,
+	}
+}
+
+func (m CheckpointModel) Init() tea.Cmd {
+	return loadCheckpoints(m.dir)
+}
+
+func loadCheckpoints(dir string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		checkpoints, err := ListCheckpoints(ctx, dir)
+		if err != nil {
+			return checkpointsLoadedMsg{err: err}
+		}
+		entries := make([]checkpointEntry, 0, len(checkpoints))
+		for _, checkpoint := range checkpoints {
+			entries = append(entries, checkpointEntry{checkpoint: checkpoint, diffstat: CheckpointDiffstat(ctx, checkpoint)})
+		}
+		return checkpointsLoadedMsg{entries: entries}
+	}
+}
+
+func (m CheckpointModel) Update(msg tea.Msg) (CheckpointModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case checkpointsLoadedMsg:
+		m.loading = false
+		m.entries = msg.entries
+		m.err = msg.err
+		if m.selected >= len(m.entries) {
+			m.selected = 0
+		}
+		return m, nil
+
+	case checkpointRestoredMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.message = fmt.Sprintf("Restored the checkpoint from %s; the previous state was saved as a new checkpoint",
+			msg.restored.CreatedAt.Format("15:04:05"))
+		m.loading = true
+		m.selected = 0
+		return m, loadCheckpoints(m.dir)
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc":
+			return m, SwitchToView(m.back, m.backData)
+
+		case "up", "k":
+			if m.selected > 0 {
+				m.selected--
+			}
+
+		case "down", "j":
+			if m.selected < len(m.entries)-1 {
+				m.selected++
+			}
+
+		case "r":
+			m.loading = true
+			return m, loadCheckpoints(m.dir)
+
+		case "enter":
+			if m.loading || m.selected >= len(m.entries) {
+				return m, nil
+			}
+			checkpoint := m.entries[m.selected].checkpoint
+			dir := m.dir
+			confirmData := ConfirmationData{
+				Message: fmt.Sprintf("Restore the checkpoint from %s (%s)? The current state is checkpointed first.",
+					checkpoint.CreatedAt.Format("2006-01-02 15:04:05"), checkpoint.Trigger),
+				OnConfirm: func(confirmed bool) tea.Cmd {
+					if !confirmed {
+						return BackToPreviousView()
+					}
+					return tea.Sequence(BackToPreviousView(), func() tea.Msg {
+						safety, err := RestoreCheckpoint(context.Background(), dir, checkpoint)
+						return checkpointRestoredMsg{restored: checkpoint, safety: safety, err: err}
+					})
+				},
+			}
+			return m, SwitchToView(ViewConfirmation, confirmData)
+		}
+	}
+
+	return m, nil
+}
+
+func (m CheckpointModel) View() string {
+	var content strings.Builder
+
+	title := titleStyle.Render("⏪ Checkpoints")
+	content.WriteString(title + "\n")
+	content.WriteString(helpStyle.Render(m.dir) + "\n\n")
+
+	switch {
+	case m.loading:
+		content.WriteString(normalStyle.Render("Loading checkpoints...") + "\n")
+	case m.err != nil:
+		content.WriteString(errorStyle.Render("Error: "+m.err.Error()) + "\n")
+	case len(m.entries) == 0:
+		content.WriteString(helpStyle.Render("No checkpoints yet. Relay takes one before smart commits, prompts and agent steps.") + "\n")
+	default:
+		// Keep the selection visible in long timelines
+		maxRows := (m.height - 10) / 2
+		if maxRows < 3 {
+			maxRows = 3
+		}
+		start := 0
+		if m.selected >= maxRows {
+			start = m.selected - maxRows + 1
+		}
+		end := start + maxRows
+		if end > len(m.entries) {
+			end = len(m.entries)
+		}
+
+		for i := start; i < end; i++ {
+			entry := m.entries[i]
+			line := fmt.Sprintf("%s  %s", entry.checkpoint.CreatedAt.Format("01-02 15:04:05"), truncateText(entry.checkpoint.Trigger, 70))
+			if i == m.selected {
+				content.WriteString(selectedIssueStyle.Render("> "+line) + "\n")
+			} else {
+				content.WriteString(unselectedIssueStyle.Render("  "+line) + "\n")
+			}
+			content.WriteString(historyStyle.Render("      "+entry.diffstat) + "\n")
+		}
+	}
+
+	if m.message != "" {
+		content.WriteString("\n" + helpStyle.Render(m.message) + "\n")
+	}
+
+	content.WriteString("\n")
+
+	restoreStyle := theme.Fg(theme.Warning).Bold(true)
+	refreshStyle := theme.Fg(theme.Accent).Bold(true)
+	backStyle := theme.Fg(theme.Help).Bold(true)
+
+	actionOptions := []string{
+		restoreStyle.Render("enter") + " Restore",
+		refreshStyle.Render("r") + " Refresh",
+		backStyle.Render("q") + " Back",
+	}
+	content.WriteString(strings.Join(actionOptions, "  •  ") + "\n")
+
+	return content.String()
+}
@@ -5,7 +5,6 @@ import (
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 )
 
 type CloseReasonModel struct {
@@ -86,10 +85,10 @@ func (m CloseReasonModel) View() string {
 	var content strings.Builder
 
 	// Styles
-	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
-	selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Bold(true)
-	normalStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("7"))
-	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	titleStyle := theme.Fg(theme.Title).Bold(true)
+	selectedStyle := theme.Fg(theme.Success).Bold(true)
+	normalStyle := theme.Fg(theme.Text)
+	helpStyle := theme.Fg(theme.Help)
 
 	// Header
 	title := fmt.Sprintf("Close Issue #%d", m.issueID)
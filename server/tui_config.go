@@ -5,7 +5,6 @@ import (
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 )
 
 // Config Menu Models
@@ -112,7 +111,7 @@ func (m ConfigMenuModel) View() string {
 	content.WriteString(helpStyle.Render(fmt.Sprintf("Executing LLM: %s", config.LLMs.Executing.Type)) + "\n\n")
 
 	// Define color styles for different action types (matching issues page)
-	backStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Bold(true) // Gray for back
+	backStyle := theme.Fg(theme.Help).Bold(true) // Gray for back
 
 	// Help with colored action keys
 	actionOptions := []string{
@@ -245,8 +244,8 @@ func (m LLMConfigModel) View() string {
 	content.WriteString(helpStyle.Render(strings.Join(m.llmOptions, ", ")) + "\n\n")
 
 	// Define color styles for different action types (matching issues page)
-	editStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Bold(true) // Yellow for edit
-	backStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Bold(true)  // Gray for back
+	editStyle := theme.Fg(theme.Warning).Bold(true) // Yellow for edit
+	backStyle := theme.Fg(theme.Help).Bold(true)    // Gray for back
 
 	// Help with colored action keys
 	actionOptions := []string{
@@ -376,8 +375,8 @@ func (m IssueTrackerConfigModel) View() string {
 	content.WriteString(helpStyle.Render(strings.Join(m.providerOptions, ", ")) + "\n\n")
 
 	// Define color styles for different action types (matching issues page)
-	editStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Bold(true) // Yellow for edit
-	backStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Bold(true)  // Gray for back
+	editStyle := theme.Fg(theme.Warning).Bold(true) // Yellow for edit
+	backStyle := theme.Fg(theme.Help).Bold(true)    // Gray for back
 
 	// Help with colored action keys
 	actionOptions := []string{
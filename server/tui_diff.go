@@ -0,0 +1,514 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// DiffData opens the diff view on a repository or worktree, returning to
+// Back with BackData
+type DiffData struct {
+	Dir      string
+	Title    string   // What Dir is, e.g. "Issue #12 worktree"
+	Mode     DiffMode // The comparison to start with
+	Base     string   // Branch the branch diff compares against
+	Commit   string   // Commit shown in DiffCommit mode
+	Back     ViewType
+	BackData interface{}
+}
+
+// diffLoadedMsg carries the files of a diff op
+type diffLoadedMsg struct {
+	opID  int64
+	files []DiffFile
+	err   error
+}
+
+// DiffModel shows a diff one file at a time with highlighted hunks. In the
+// unstaged and staged diffs the selected hunk can be staged or unstaged.
+type DiffModel struct {
+	data    DiffData
+	mode    DiffMode
+	files   []DiffFile
+	file    int // Shown file
+	hunk    int // Selected hunk of the file
+	offset  int // First line of the file shown
+	loaded  bool
+	op      *asyncOp
+	err     string
+	message string
+	width   int
+	height  int
+}
+
+func NewDiffModel(data DiffData) DiffModel {
+	if data.Base == "" {
+		data.Base = "main"
+	}
+	return DiffModel{data: data, mode: data.Mode, width: 80, height: 24}
+}
+
+// Init loads the diff in the background
+func (m DiffModel) Init() tea.Cmd {
+	return func() tea.Msg {
+		return issueListActionMsg{label: "Loading diff"}
+	}
+}
+
+// startOp runs action and then reloads the diff, with a spinner
+func (m DiffModel) startOp(label string, action func(ctx context.Context) error) (DiffModel, tea.Cmd) {
+	if m.op != nil {
+		m.op.cancel()
+	}
+	op, ctx := newAsyncOp(label)
+	m.op = op
+
+	data, mode := m.data, m.mode
+	return m, tea.Batch(op.tick(), func() tea.Msg {
+		if action != nil {
+			if err := action(ctx); err != nil {
+				return diffLoadedMsg{opID: op.id, err: err}
+			}
+		}
+		files, err := LoadDiff(ctx, data.Dir, mode, data.Base, data.Commit)
+		return diffLoadedMsg{opID: op.id, files: files, err: err}
+	})
+}
+
+// applyHunk stages or unstages the selected hunk
+func (m DiffModel) applyHunk(unstage bool) (DiffModel, tea.Cmd) {
+	want := DiffUnstaged
+	if unstage {
+		want = DiffStaged
+	}
+	if m.mode != want {
+		m.err = fmt.Sprintf("Hunks can be %s only in the %s diff; press m to switch", map[bool]string{false: "staged", true: "unstaged"}[unstage], want)
+		return m, nil
+	}
+	if m.file >= len(m.files) || m.hunk >= len(m.files[m.file].Hunks) {
+		return m, nil
+	}
+
+	m.err = ""
+	file, hunk, dir := m.files[m.file], m.hunk, m.data.Dir
+	label := fmt.Sprintf("Staging hunk %d of %s", hunk+1, file.Path())
+	m.message = fmt.Sprintf("Staged hunk %d of %s", hunk+1, file.Path())
+	if unstage {
+		label = fmt.Sprintf("Unstaging hunk %d of %s", hunk+1, file.Path())
+		m.message = fmt.Sprintf("Unstaged hunk %d of %s", hunk+1, file.Path())
+	}
+	return m.startOp(label, func(ctx context.Context) error {
+		return ApplyHunk(ctx, dir, file, hunk, unstage)
+	})
+}
+
+// hunkLines returns the line of the file view each hunk header is on
+func (m DiffModel) hunkLines() []int {
+	var starts []int
+	line := 0
+	for _, hunk := range m.files[m.file].Hunks {
+		starts = append(starts, line)
+		line += 1 + len(hunk.Lines)
+	}
+	return starts
+}
+
+// fileLines returns the rendered lines of the shown file
+func (m DiffModel) fileLines() []string {
+	file := m.files[m.file]
+	if file.Binary {
+		return []string{helpStyle.UnsetMarginTop().Render("Binary file")}
+	}
+	if len(file.Hunks) == 0 {
+		return []string{helpStyle.UnsetMarginTop().Render(strings.Join(file.Header[1:], "\n"))}
+	}
+
+	ext := strings.TrimPrefix(filepath.Ext(file.Path()), ".")
+	codeWidth := max(m.width-2, 20)
+	hunkStyle := theme.Fg(theme.Info)
+	var lines []string
+	for i, hunk := range file.Hunks {
+		marker := "  "
+		if i == m.hunk {
+			marker = selectedIssueStyle.Render("▶ ")
+		}
+		lines = append(lines, marker+hunkStyle.Render(truncateText(hunk.Header, codeWidth)))
+		for _, line := range hunk.Lines {
+			lines = append(lines, "  "+diffLine(line, ext, codeWidth))
+		}
+	}
+	return lines
+}
+
+// visibleLines is how many lines of the file fit under the header
+func (m DiffModel) visibleLines() int {
+	return max(m.height-9, 3)
+}
+
+// scrollTo moves the view so line is shown, and selects the hunk it is in
+func (m DiffModel) scrollTo(offset int) DiffModel {
+	total := len(m.fileLines())
+	m.offset = max(min(offset, total-m.visibleLines()), 0)
+	m.hunk = 0
+	for i, start := range m.hunkLines() {
+		if start <= m.offset {
+			m.hunk = i
+		}
+	}
+	return m
+}
+
+// selectHunk selects hunk i and scrolls to its header
+func (m DiffModel) selectHunk(i int) DiffModel {
+	starts := m.hunkLines()
+	if i < 0 || i >= len(starts) {
+		return m
+	}
+	m = m.scrollTo(starts[i])
+	m.hunk = i
+	return m
+}
+
+// selectFile shows file i from its first hunk
+func (m DiffModel) selectFile(i int) DiffModel {
+	if i < 0 || i >= len(m.files) {
+		return m
+	}
+	m.file, m.hunk, m.offset = i, 0, 0
+	return m
+}
+
+func (m DiffModel) Update(msg tea.Msg) (DiffModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case spinnerTickMsg:
+		return m, m.op.advance(msg)
+
+	case issueListActionMsg:
+		return m.startOp(msg.label, msg.action)
+
+	case diffLoadedMsg:
+		if !m.op.owns(msg.opID) {
+			return m, nil
+		}
+		m.op.finish()
+		m.op = nil
+		if msg.err != nil {
+			m.err = msg.err.Error()
+			m.message = ""
+			return m, nil
+		}
+
+		// Stay on the same file after staging, at the hunk now in its place
+		path := ""
+		if m.file < len(m.files) {
+			path = m.files[m.file].Path()
+		}
+		hunk := m.hunk
+		m.files, m.loaded = msg.files, true
+		m.file = min(m.file, max(len(m.files)-1, 0))
+		for i, file := range m.files {
+			if file.Path() == path {
+				m.file = i
+			}
+		}
+		if m.file < len(m.files) {
+			m = m.selectHunk(min(hunk, len(m.files[m.file].Hunks)-1))
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.op != nil {
+			// Only cancelling is possible while an operation runs
+			if msg.String() == "esc" {
+				m.op.cancel()
+				m.err = m.op.label + " cancelled"
+				m.op = nil
+			}
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "q", "esc":
+			return m, SwitchToView(m.data.Back, m.data.BackData)
+		case "r":
+			m.err, m.message = "", ""
+			return m.startOp("Loading diff", nil)
+		case "m":
+			// Commits have a single diff
+			if m.mode != DiffCommit {
+				m.mode = (m.mode + 1) % DiffCommit
+				m.file, m.hunk, m.offset = 0, 0, 0
+				m.err, m.message = "", ""
+				return m.startOp("Loading diff", nil)
+			}
+		case "s":
+			return m.applyHunk(false)
+		case "u":
+			return m.applyHunk(true)
+		}
+
+		if len(m.files) == 0 {
+			return m, nil
+		}
+		switch msg.String() {
+		case "tab", "]":
+			return m.selectFile(m.file + 1), nil
+		case "shift+tab", "[":
+			return m.selectFile(m.file - 1), nil
+		case "down", "j":
+			return m.scrollTo(m.offset + 1), nil
+		case "up", "k":
+			return m.scrollTo(m.offset - 1), nil
+		case "pgdown", "ctrl+d":
+			return m.scrollTo(m.offset + m.visibleLines()/2), nil
+		case "pgup", "ctrl+u":
+			return m.scrollTo(m.offset - m.visibleLines()/2), nil
+		case "n", "J":
+			return m.selectHunk(m.hunk + 1), nil
+		case "p", "K":
+			return m.selectHunk(m.hunk - 1), nil
+		}
+	}
+
+	return m, nil
+}
+
+func (m DiffModel) View() string {
+	var content strings.Builder
+
+	title := "Diff"
+	if m.data.Title != "" {
+		title += ": " + m.data.Title
+	}
+	switch m.mode {
+	case DiffBranch:
+		title += fmt.Sprintf(" (%s...HEAD)", m.data.Base)
+	case DiffCommit:
+		title += fmt.Sprintf(" (%s)", shortHash(m.data.Commit))
+	default:
+		title += fmt.Sprintf(" (%s)", m.mode)
+	}
+	content.WriteString(titleStyle.Render("🔀 "+title) + "\n")
+
+	switch {
+	case m.op != nil:
+		content.WriteString(m.op.View() + "\n")
+	case m.err != "":
+		content.WriteString(errorStyle.Render("⚠️  "+m.err) + "\n")
+	case m.message != "":
+		content.WriteString(normalStyle.Render(m.message) + "\n")
+	}
+
+	if m.loaded && len(m.files) == 0 {
+		content.WriteString(helpStyle.Render(fmt.Sprintf("No %s changes.", m.mode)) + "\n")
+	}
+	if len(m.files) > 0 {
+		file := m.files[m.file]
+		added, removed := file.Stats()
+		addedStyle := theme.Fg(theme.Success)
+		removedStyle := theme.Fg(theme.Error)
+		content.WriteString(fmt.Sprintf("%s %s %s %s\n",
+			historyStyle.Render(fmt.Sprintf("File %d/%d", m.file+1, len(m.files))),
+			selectedIssueStyle.Render(file.Path()),
+			addedStyle.Render(fmt.Sprintf("+%d", added)),
+			removedStyle.Render(fmt.Sprintf("-%d", removed))))
+		content.WriteString(historyStyle.Render(strings.Repeat("─", max(min(m.width, 100), 20))) + "\n")
+
+		lines := m.fileLines()
+		end := min(m.offset+m.visibleLines(), len(lines))
+		content.WriteString(strings.Join(lines[m.offset:end], "\n") + "\n")
+		if end < len(lines) {
+			content.WriteString(helpStyle.UnsetMarginTop().Render(fmt.Sprintf("  … %d more lines", len(lines)-end)) + "\n")
+		}
+	}
+	content.WriteString("\n")
+
+	keyStyle := theme.Fg(theme.Accent).Bold(true)
+	stageStyle := theme.Fg(theme.Success).Bold(true)
+	backStyle := theme.Fg(theme.Help).Bold(true)
+	actionOptions := []string{
+		keyStyle.Render("tab") + " File",
+		keyStyle.Render("n/p") + " Hunk",
+		keyStyle.Render("↑/↓") + " Scroll",
+	}
+	switch m.mode {
+	case DiffUnstaged:
+		actionOptions = append(actionOptions, stageStyle.Render("s")+" Stage hunk")
+	case DiffStaged:
+		actionOptions = append(actionOptions, stageStyle.Render("u")+" Unstage hunk")
+	}
+	if m.mode != DiffCommit {
+		actionOptions = append(actionOptions, keyStyle.Render("m")+" Unstaged/staged/branch")
+	}
+	actionOptions = append(actionOptions, keyStyle.Render("r")+" Refresh", backStyle.Render("q")+" Back")
+	content.WriteString(strings.Join(actionOptions, "  •  "))
+
+	return content.String()
+}
+
+// issueWorktreeDir returns the worktree of an issue, or an error when the
+// issue has none
+func issueWorktreeDir(session *REPLSession, number int) (string, error) {
+	dir := filepath.Join(session.currentProject.Path, generateWorktreeName(session.currentProject.Name, number))
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("issue #%d has no worktree; start it first", number)
+	}
+	return dir, nil
+}
+
+// sessionBaseBranch is the branch issue worktrees start from
+func sessionBaseBranch(session *REPLSession) string {
+	if base := session.configManager.GetConfig().Runner.BaseBranch; base != "" {
+		return base
+	}
+	return "main"
+}
+
+// shortHash abbreviates a commit hash for display
+func shortHash(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
+}
+
+// Built from the theme by applyTheme
+var (
+	diffAddedBg     lipgloss.Color // Empty in no-color mode
+	diffRemovedBg   lipgloss.Color
+	diffAddedSign   lipgloss.Style
+	diffRemovedSign lipgloss.Style
+
+	codeKeywordStyle lipgloss.Style
+	codeStringStyle  lipgloss.Style
+	codeCommentStyle lipgloss.Style
+	codeNumberStyle  lipgloss.Style
+	codePlainStyle   = lipgloss.NewStyle()
+)
+
+// diffLine renders one hunk line: the +/- sign, then the highlighted code
+// on a green or red background
+func diffLine(line, ext string, width int) string {
+	if line == "" {
+		return ""
+	}
+	sign, code := line[:1], strings.ReplaceAll(line[1:], "\t", "    ")
+	code = truncateText(code, max(width-1, 1))
+	switch sign {
+	case "+":
+		return diffAddedSign.Background(diffAddedBg).Render("+") + highlightCode(code, ext, diffAddedBg)
+	case "-":
+		return diffRemovedSign.Background(diffRemovedBg).Render("-") + highlightCode(code, ext, diffRemovedBg)
+	case "\\":
+		return historyStyle.Render(line)
+	}
+	return " " + highlightCode(code, ext, "")
+}
+
+// codeKeywords are the keywords highlighted per file extension
+var codeKeywords = map[string][]string{
+	"go": {"break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough", "for", "func",
+		"go", "goto", "if", "import", "interface", "map", "package", "range", "return", "select", "struct", "switch",
+		"type", "var", "nil", "true", "false"},
+	"js": {"async", "await", "break", "case", "catch", "class", "const", "continue", "default", "delete", "else",
+		"export", "extends", "false", "finally", "for", "from", "function", "if", "import", "in", "instanceof", "let",
+		"new", "null", "of", "return", "static", "super", "switch", "this", "throw", "true", "try", "typeof",
+		"undefined", "var", "void", "while", "yield", "interface", "type", "enum", "implements"},
+	"py": {"and", "as", "assert", "async", "await", "break", "class", "continue", "def", "del", "elif", "else",
+		"except", "False", "finally", "for", "from", "global", "if", "import", "in", "is", "lambda", "None", "not",
+		"or", "pass", "raise", "return", "True", "try", "while", "with", "yield"},
+	"rs": {"as", "async", "await", "break", "const", "continue", "crate", "else", "enum", "false", "fn", "for", "if",
+		"impl", "in", "let", "loop", "match", "mod", "move", "mut", "pub", "ref", "return", "self", "Self", "static",
+		"struct", "super", "trait", "true", "type", "unsafe", "use", "where", "while"},
+	"sh": {"if", "then", "else", "elif", "fi", "for", "while", "do", "done", "case", "esac", "function", "in",
+		"return", "local", "export"},
+}
+
+// codeLanguages maps file extensions to the keyword sets and comment style they use
+var codeLanguages = map[string]string{
+	"go": "go", "js": "js", "jsx": "js", "ts": "js", "tsx": "js", "mjs": "js",
+	"py": "py", "rs": "rs", "sh": "sh", "bash": "sh", "zsh": "sh",
+	"c": "go", "h": "go", "cc": "go", "cpp": "go", "java": "js", "kt": "js", "swift": "js",
+}
+
+// highlightCode colors keywords, strings, comments and numbers. It is a
+// tokenizer, not a parser: good enough for a line of a diff.
+func highlightCode(code, ext string, background lipgloss.Color) string {
+	language := codeLanguages[ext]
+	keywords := make(map[string]bool)
+	for _, keyword := range codeKeywords[language] {
+		keywords[keyword] = true
+	}
+	hashComments := language == "py" || language == "sh" || ext == "yaml" || ext == "yml" || ext == "toml" || ext == "rb"
+
+	style := func(s lipgloss.Style) lipgloss.Style {
+		if background != "" {
+			return s.Background(background)
+		}
+		return s
+	}
+
+	var out strings.Builder
+	runes := []rune(code)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case (r == '/' && i+1 < len(runes) && runes[i+1] == '/' && !hashComments) || (r == '#' && hashComments):
+			out.WriteString(style(codeCommentStyle).Render(string(runes[i:])))
+			i = len(runes)
+
+		case r == '"' || r == '\'' || r == '`':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				if runes[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(runes))
+			out.WriteString(style(codeStringStyle).Render(string(runes[i:end])))
+			i = end
+
+		case unicode.IsDigit(r):
+			end := i
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || unicode.IsLetter(runes[end]) || runes[end] == '.' || runes[end] == '_') {
+				end++
+			}
+			out.WriteString(style(codeNumberStyle).Render(string(runes[i:end])))
+			i = end
+
+		case unicode.IsLetter(r) || r == '_':
+			end := i
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_') {
+				end++
+			}
+			word := string(runes[i:end])
+			if keywords[word] {
+				out.WriteString(style(codeKeywordStyle).Render(word))
+			} else {
+				out.WriteString(style(codePlainStyle).Render(word))
+			}
+			i = end
+
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsLetter(runes[end]) && !unicode.IsDigit(runes[end]) && runes[end] != '_' &&
+				runes[end] != '"' && runes[end] != '\'' && runes[end] != '`' && runes[end] != '#' && !(runes[end] == '/' && end+1 < len(runes) && runes[end+1] == '/') {
+				end++
+			}
+			if end == i {
+				end++
+			}
+			out.WriteString(style(codePlainStyle).Render(string(runes[i:end])))
+			i = end
+		}
+	}
+	return out.String()
+}
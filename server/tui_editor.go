@@ -0,0 +1,473 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxEditorUndo bounds the undo history of the editor
+const maxEditorUndo = 200
+
+// EditorData opens the multi-line editor; OnSave gets the edited text and
+// Esc returns to the previous view without saving
+type EditorData struct {
+	Title   string
+	Content string
+	OnSave  func(content string) tea.Cmd
+}
+
+// editorSnapshot is the text and cursor saved for undo
+type editorSnapshot struct {
+	lines    []string
+	row, col int
+}
+
+// externalEditorMsg carries the text read back from $EDITOR
+type externalEditorMsg struct {
+	content string
+	err     error
+}
+
+// EditorModel is a multi-line text editor with soft wrap, word navigation,
+// undo and a hand-off to $EDITOR
+type EditorModel struct {
+	title    string
+	lines    [][]rune
+	row, col int
+	undo     []editorSnapshot
+	lastEdit string // Kind of the last edit, so typing a word is one undo step
+	top      int    // First visual line shown
+	onSave   func(string) tea.Cmd
+	message  string
+	width    int
+	height   int
+}
+
+func NewEditorModel(data EditorData) EditorModel {
+	m := EditorModel{title: data.Title, onSave: data.OnSave, width: 80, height: 24}
+	m.setText(data.Content)
+	m.row = len(m.lines) - 1
+	m.col = len(m.lines[m.row])
+	return m
+}
+
+func (m EditorModel) Init() tea.Cmd {
+	return nil
+}
+
+// Text returns the edited text
+func (m EditorModel) Text() string {
+	lines := make([]string, len(m.lines))
+	for i, line := range m.lines {
+		lines[i] = string(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m *EditorModel) setText(text string) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	m.lines = nil
+	for _, line := range strings.Split(text, "\n") {
+		m.lines = append(m.lines, []rune(line))
+	}
+}
+
+// snapshot saves the state for undo; consecutive edits of the same kind,
+// like typing letters, share one snapshot
+func (m *EditorModel) snapshot(kind string) {
+	if kind != "" && kind == m.lastEdit {
+		return
+	}
+	m.lastEdit = kind
+	snapshot := editorSnapshot{row: m.row, col: m.col}
+	for _, line := range m.lines {
+		snapshot.lines = append(snapshot.lines, string(line))
+	}
+	m.undo = append(m.undo, snapshot)
+	if len(m.undo) > maxEditorUndo {
+		m.undo = m.undo[1:]
+	}
+}
+
+func (m *EditorModel) undoLast() {
+	if len(m.undo) == 0 {
+		m.message = "Nothing to undo"
+		return
+	}
+	snapshot := m.undo[len(m.undo)-1]
+	m.undo = m.undo[:len(m.undo)-1]
+	m.setText(strings.Join(snapshot.lines, "\n"))
+	m.row, m.col = snapshot.row, snapshot.col
+	m.lastEdit = ""
+}
+
+// insert adds text at the cursor, splitting lines at newlines
+func (m *EditorModel) insert(text string) {
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+	line := m.lines[m.row]
+	tail := append([]rune(nil), line[m.col:]...)
+	m.lines[m.row] = line[:m.col]
+
+	for i, part := range strings.Split(text, "\n") {
+		if i > 0 {
+			m.row++
+			m.lines = append(m.lines[:m.row], append([][]rune{nil}, m.lines[m.row:]...)...)
+			m.col = 0
+		}
+		m.lines[m.row] = append(m.lines[m.row], []rune(part)...)
+		m.col = len(m.lines[m.row])
+	}
+	m.lines[m.row] = append(m.lines[m.row], tail...)
+}
+
+func (m *EditorModel) backspace() {
+	switch {
+	case m.col > 0:
+		line := m.lines[m.row]
+		m.lines[m.row] = append(line[:m.col-1], line[m.col:]...)
+		m.col--
+	case m.row > 0:
+		m.col = len(m.lines[m.row-1])
+		m.lines[m.row-1] = append(m.lines[m.row-1], m.lines[m.row]...)
+		m.lines = append(m.lines[:m.row], m.lines[m.row+1:]...)
+		m.row--
+	}
+}
+
+func (m *EditorModel) deleteForward() {
+	switch {
+	case m.col < len(m.lines[m.row]):
+		line := m.lines[m.row]
+		m.lines[m.row] = append(line[:m.col], line[m.col+1:]...)
+	case m.row < len(m.lines)-1:
+		m.lines[m.row] = append(m.lines[m.row], m.lines[m.row+1]...)
+		m.lines = append(m.lines[:m.row+1], m.lines[m.row+2:]...)
+	}
+}
+
+// wordLeft moves to the start of the previous word, across lines
+func (m *EditorModel) wordLeft() {
+	if m.col == 0 {
+		if m.row > 0 {
+			m.row--
+			m.col = len(m.lines[m.row])
+		}
+		return
+	}
+	line := m.lines[m.row]
+	for m.col > 0 && !isWordRune(line[m.col-1]) {
+		m.col--
+	}
+	for m.col > 0 && isWordRune(line[m.col-1]) {
+		m.col--
+	}
+}
+
+// wordRight moves past the end of the next word, across lines
+func (m *EditorModel) wordRight() {
+	line := m.lines[m.row]
+	if m.col == len(line) {
+		if m.row < len(m.lines)-1 {
+			m.row++
+			m.col = 0
+		}
+		return
+	}
+	for m.col < len(line) && !isWordRune(line[m.col]) {
+		m.col++
+	}
+	for m.col < len(line) && isWordRune(line[m.col]) {
+		m.col++
+	}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// moveVertical moves the cursor by visual lines so wrapped lines can be walked
+func (m *EditorModel) moveVertical(delta int) {
+	rows := m.visualRows()
+	current := m.cursorVisualRow(rows)
+	target := min(max(current+delta, 0), len(rows)-1)
+	offset := m.col - rows[current].start
+	row := rows[target]
+	m.row, m.col = row.line, min(row.start+offset, row.end)
+}
+
+// visualRow is a soft-wrapped piece of a line
+type visualRow struct {
+	line, start, end int
+}
+
+func (m EditorModel) textWidth() int {
+	return max(m.width-6, 10)
+}
+
+// visualRows soft-wraps the lines at word boundaries to the editor width
+func (m EditorModel) visualRows() []visualRow {
+	width := m.textWidth()
+	var rows []visualRow
+	for i, line := range m.lines {
+		start := 0
+		for len(line)-start > width {
+			end := start + width
+			// Break after the last space in the row, if there is one
+			for j := end; j > start+width/2; j-- {
+				if line[j-1] == ' ' {
+					end = j
+					break
+				}
+			}
+			rows = append(rows, visualRow{line: i, start: start, end: end})
+			start = end
+		}
+		rows = append(rows, visualRow{line: i, start: start, end: len(line)})
+	}
+	return rows
+}
+
+func (m EditorModel) cursorVisualRow(rows []visualRow) int {
+	for i, row := range rows {
+		next := i + 1
+		if row.line == m.row && m.col >= row.start && (m.col < row.end || next == len(rows) || rows[next].line != m.row) {
+			return i
+		}
+	}
+	return 0
+}
+
+// openExternalEditor hands the text to $VISUAL or $EDITOR, falling back to
+// vi, in a temp file and reads it back when the editor exits
+func (m EditorModel) openExternalEditor() tea.Cmd {
+	file, err := os.CreateTemp("", "relay-*.md")
+	if err != nil {
+		return func() tea.Msg { return externalEditorMsg{err: fmt.Errorf("failed to create temp file: %w", err)} }
+	}
+	path := file.Name()
+	_, err = file.WriteString(m.Text())
+	file.Close()
+	if err != nil {
+		os.Remove(path)
+		return func() tea.Msg { return externalEditorMsg{err: fmt.Errorf("failed to write temp file: %w", err)} }
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// The editor may carry flags, e.g. "code --wait"
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], path)...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		defer os.Remove(path)
+		if err != nil {
+			return externalEditorMsg{err: fmt.Errorf("%s failed: %w", parts[0], err)}
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return externalEditorMsg{err: fmt.Errorf("failed to read back the edited file: %w", err)}
+		}
+		return externalEditorMsg{content: strings.TrimRight(string(content), "\n")}
+	})
+}
+
+func (m EditorModel) Update(msg tea.Msg) (EditorModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case externalEditorMsg:
+		if msg.err != nil {
+			m.message = msg.err.Error()
+			return m, nil
+		}
+		if msg.content != m.Text() {
+			m.snapshot("")
+			m.setText(msg.content)
+			m.row = len(m.lines) - 1
+			m.col = len(m.lines[m.row])
+		}
+		m.message = "Read back from the external editor"
+		return m, nil
+
+	case tea.KeyMsg:
+		m.message = ""
+		switch msg.String() {
+		case "ctrl+s":
+			if m.onSave != nil {
+				return m, m.onSave(m.Text())
+			}
+			return m, BackToPreviousView()
+		case "esc":
+			return m, BackToPreviousView()
+		case "ctrl+o":
+			return m, m.openExternalEditor()
+		case "ctrl+z":
+			m.undoLast()
+		case "enter":
+			m.snapshot("")
+			m.insert("\n")
+		case "backspace", "ctrl+h":
+			m.snapshot("delete")
+			m.backspace()
+		case "delete", "ctrl+d":
+			m.snapshot("delete")
+			m.deleteForward()
+		case "ctrl+w", "alt+backspace":
+			m.snapshot("")
+			row, end := m.row, m.col
+			m.wordLeft()
+			if m.row == row {
+				line := m.lines[m.row]
+				m.lines[m.row] = append(line[:m.col], line[end:]...)
+			} else {
+				// At the start of a line, join it to the previous one
+				m.row, m.col = row, end
+				m.backspace()
+			}
+		case "left", "ctrl+b":
+			if m.col > 0 {
+				m.col--
+			} else if m.row > 0 {
+				m.row--
+				m.col = len(m.lines[m.row])
+			}
+			m.lastEdit = ""
+		case "right", "ctrl+f":
+			if m.col < len(m.lines[m.row]) {
+				m.col++
+			} else if m.row < len(m.lines)-1 {
+				m.row++
+				m.col = 0
+			}
+			m.lastEdit = ""
+		case "alt+left", "ctrl+left", "alt+b":
+			m.wordLeft()
+			m.lastEdit = ""
+		case "alt+right", "ctrl+right", "alt+f":
+			m.wordRight()
+			m.lastEdit = ""
+		case "up", "ctrl+p":
+			m.moveVertical(-1)
+			m.lastEdit = ""
+		case "down", "ctrl+n":
+			m.moveVertical(1)
+			m.lastEdit = ""
+		case "pgup":
+			m.moveVertical(-m.textHeight())
+		case "pgdown":
+			m.moveVertical(m.textHeight())
+		case "home", "ctrl+a":
+			m.col = 0
+		case "end", "ctrl+e":
+			m.col = len(m.lines[m.row])
+		case "ctrl+c":
+			return m, tea.Quit
+		default:
+			if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+				kind := "type"
+				if msg.Paste {
+					kind = ""
+				}
+				m.snapshot(kind)
+				m.insert(string(msg.Runes))
+			} else if msg.Type == tea.KeyTab {
+				m.snapshot("")
+				m.insert("    ")
+			}
+		}
+		m.scrollToCursor()
+	}
+
+	return m, nil
+}
+
+func (m EditorModel) textHeight() int {
+	return max(m.height-6, 3)
+}
+
+// scrollToCursor keeps the cursor's visual line on screen
+func (m *EditorModel) scrollToCursor() {
+	current := m.cursorVisualRow(m.visualRows())
+	if current < m.top {
+		m.top = current
+	} else if current >= m.top+m.textHeight() {
+		m.top = current - m.textHeight() + 1
+	}
+}
+
+func (m EditorModel) View() string {
+	var content strings.Builder
+
+	content.WriteString(titleStyle.Render(m.title) + "\n")
+
+	rows := m.visualRows()
+	current := m.cursorVisualRow(rows)
+	cursorStyle := lipgloss.NewStyle().Reverse(true)
+	gutterStyle := theme.Fg(theme.Help)
+
+	var body strings.Builder
+	end := min(m.top+m.textHeight(), len(rows))
+	for i := m.top; i < end; i++ {
+		row := rows[i]
+		text := m.lines[row.line][row.start:row.end]
+
+		gutter := "   "
+		if row.start == 0 {
+			gutter = fmt.Sprintf("%3d", row.line+1)
+		}
+		body.WriteString(gutterStyle.Render(gutter) + " ")
+
+		if i == current {
+			col := m.col - row.start
+			under := " "
+			if col < len(text) {
+				under = string(text[col])
+			}
+			body.WriteString(string(text[:min(col, len(text))]) + cursorStyle.Render(under))
+			if col+1 < len(text) {
+				body.WriteString(string(text[col+1:]))
+			}
+		} else {
+			body.WriteString(string(text))
+		}
+		if i < end-1 {
+			body.WriteString("\n")
+		}
+	}
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(theme.Color(theme.Help)).
+		Width(m.width - 2).
+		Render(body.String())
+	content.WriteString(box + "\n")
+
+	status := fmt.Sprintf("Ln %d, Col %d", m.row+1, m.col+1)
+	if m.message != "" {
+		status += " • " + m.message
+	}
+	content.WriteString(helpStyle.Render(status) + "\n")
+
+	keyStyle := theme.Fg(theme.Success).Bold(true)
+	editStyle := theme.Fg(theme.Accent).Bold(true)
+	backStyle := theme.Fg(theme.Help).Bold(true)
+	actionOptions := []string{
+		keyStyle.Render("ctrl+s") + " Save",
+		editStyle.Render("ctrl+z") + " Undo",
+		editStyle.Render("alt+←/→") + " Word",
+		editStyle.Render("ctrl+o") + " $EDITOR",
+		backStyle.Render("esc") + " Cancel",
+	}
+	content.WriteString(strings.Join(actionOptions, "  •  "))
+
+	return content.String()
+}
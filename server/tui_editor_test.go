@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func typeKeys(m EditorModel, keys ...tea.KeyMsg) EditorModel {
+	for _, key := range keys {
+		m, _ = m.Update(key)
+	}
+	return m
+}
+
+func runes(text string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(text)}
+}
+
+// TestEditorModel tests multi-line editing, word navigation and undo
+func TestEditorModel(t *testing.T) {
+	m := NewEditorModel(EditorData{Content: "hello world"})
+
+	m = typeKeys(m, tea.KeyMsg{Type: tea.KeyEnter}, runes("s"), runes("e"), runes("c"), runes("ond"))
+	if m.Text() != "hello world\nsecond" {
+		t.Fatalf("Unexpected text after typing: %q", m.Text())
+	}
+
+	// Word navigation crosses lines
+	m = typeKeys(m, tea.KeyMsg{Type: tea.KeyLeft, Alt: true}, tea.KeyMsg{Type: tea.KeyLeft, Alt: true})
+	if m.row != 0 || m.col != len("hello world") {
+		t.Errorf("Expected the cursor at the end of line 1, got %d:%d", m.row, m.col)
+	}
+	m = typeKeys(m, tea.KeyMsg{Type: tea.KeyLeft, Alt: true}, tea.KeyMsg{Type: tea.KeyCtrlW})
+	if m.Text() != "world\nsecond" || m.col != 0 {
+		t.Errorf("Expected ctrl+w to delete the previous word, got %q at %d", m.Text(), m.col)
+	}
+
+	// Undo the deletion, then the typed word and the new line
+	m = typeKeys(m, tea.KeyMsg{Type: tea.KeyCtrlZ})
+	if m.Text() != "hello world\nsecond" {
+		t.Errorf("Unexpected text after the first undo: %q", m.Text())
+	}
+	m = typeKeys(m, tea.KeyMsg{Type: tea.KeyCtrlZ}, tea.KeyMsg{Type: tea.KeyCtrlZ})
+	if m.Text() != "hello world" {
+		t.Errorf("Expected the original text after undoing everything, got %q", m.Text())
+	}
+
+	// Pasted text keeps its lines
+	m = typeKeys(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("\n- a\n- b"), Paste: true})
+	if m.Text() != "hello world\n- a\n- b" || m.row != 2 {
+		t.Errorf("Unexpected text after paste: %q (row %d)", m.Text(), m.row)
+	}
+
+	var saved string
+	m.onSave = func(content string) tea.Cmd {
+		saved = content
+		return nil
+	}
+	typeKeys(m, tea.KeyMsg{Type: tea.KeyCtrlS})
+	if saved != m.Text() {
+		t.Errorf("Expected ctrl+s to save the text, got %q", saved)
+	}
+}
+
+// TestEditorSoftWrap tests that long lines wrap at spaces and the cursor
+// moves through the wrapped rows
+func TestEditorSoftWrap(t *testing.T) {
+	m := NewEditorModel(EditorData{Content: "aaaa bbbb cccc dddd eeee"})
+	m.width = 16 // 10 columns of text
+
+	rows := m.visualRows()
+	if len(rows) != 3 || rows[0].end != 10 {
+		t.Fatalf("Expected three rows breaking at spaces, got %+v", rows)
+	}
+
+	m.row, m.col = 0, 2
+	m = typeKeys(m, tea.KeyMsg{Type: tea.KeyDown})
+	if m.row != 0 || m.col != 12 {
+		t.Errorf("Expected down to move within the wrapped line, got %d:%d", m.row, m.col)
+	}
+}
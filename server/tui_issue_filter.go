@@ -0,0 +1,494 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The issue list narrows with a "/" search, fuzzy over number, title, labels
+// and body, and with a filter bar for state, label, assignee and in
+// progress. The filters and sort mode are saved per project.
+
+// IssueSortMode is the order of the issue list
+type IssueSortMode string
+
+const (
+	SortByNumber  IssueSortMode = "number"
+	SortByUpdated IssueSortMode = "updated"
+	SortByCreated IssueSortMode = "created"
+	SortByLabel   IssueSortMode = "label"
+)
+
+var issueSortModes = []IssueSortMode{SortByNumber, SortByUpdated, SortByCreated, SortByLabel}
+
+// issueListFilterSetting is the project setting holding the saved filters
+const issueListFilterSetting = "issue_list.filter"
+
+// IssueListFilter is the filtering and sorting chosen in the issue list
+type IssueListFilter struct {
+	State      string        `json:"state,omitempty"` // "open", "closed" or "" for both
+	Label      string        `json:"label,omitempty"`
+	Assignee   string        `json:"assignee,omitempty"`
+	InProgress bool          `json:"in_progress,omitempty"`
+	Sort       IssueSortMode `json:"sort,omitempty"`
+}
+
+// loadIssueListFilter returns the project's saved filters, or none
+func loadIssueListFilter(db *Database, projectName string) IssueListFilter {
+	var filter IssueListFilter
+	if db == nil {
+		return filter
+	}
+	value, err := db.GetProjectSetting(projectName, issueListFilterSetting)
+	if err != nil {
+		slog.Warn("Could not load issue filters", "error", err)
+		return filter
+	}
+	if value != "" {
+		if err := json.Unmarshal([]byte(value), &filter); err != nil {
+			slog.Warn("Ignoring invalid saved issue filters", "error", err)
+			return IssueListFilter{}
+		}
+	}
+	return filter
+}
+
+// saveIssueListFilter saves the filters for the project's next run
+func saveIssueListFilter(db *Database, projectName string, filter IssueListFilter) {
+	if db == nil {
+		return
+	}
+	data, err := json.Marshal(filter)
+	if err == nil {
+		err = db.SetProjectSetting(projectName, issueListFilterSetting, string(data))
+	}
+	if err != nil {
+		slog.Warn("Could not save issue filters", "error", err)
+	}
+}
+
+// Match reports whether an issue passes the filters
+func (f IssueListFilter) Match(issue Issue, progress IssueProgress) bool {
+	if f.State != "" && issue.State != f.State {
+		return false
+	}
+	if f.Label != "" && !containsString(issue.Labels, f.Label) {
+		return false
+	}
+	if f.Assignee != "" && !containsString(issue.Assignees, f.Assignee) {
+		return false
+	}
+	if f.InProgress && !progress.InProgress() {
+		return false
+	}
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// fuzzyMatch scores pattern as a case-insensitive subsequence of text.
+// Consecutive characters and characters at the start of words score higher.
+func fuzzyMatch(pattern, text string) (int, bool) {
+	pattern, text = strings.ToLower(pattern), strings.ToLower(text)
+	patternRunes, textRunes := []rune(pattern), []rune(text)
+	if len(patternRunes) == 0 {
+		return 0, true
+	}
+
+	score, p, previous := 0, 0, -2
+	for i, r := range textRunes {
+		if p == len(patternRunes) {
+			break
+		}
+		if r != patternRunes[p] {
+			continue
+		}
+		score++
+		if i == previous+1 {
+			score += 3
+		}
+		if i == 0 || !unicode.IsLetter(textRunes[i-1]) && !unicode.IsDigit(textRunes[i-1]) {
+			score += 2
+		}
+		previous = i
+		p++
+	}
+	if p < len(patternRunes) {
+		return 0, false
+	}
+	return score, true
+}
+
+// searchScore matches every word of the query against an issue. A word
+// matches a number prefix, fuzzily matches the title or a label, or appears
+// in the body; title matches count the most.
+func searchScore(query string, issue Issue) (int, bool) {
+	total := 0
+	for _, word := range strings.Fields(query) {
+		best, matched := 0, false
+		if digits := strings.TrimPrefix(word, "#"); digits != "" {
+			if _, err := strconv.Atoi(digits); err == nil && strings.HasPrefix(strconv.Itoa(issue.Number), digits) {
+				best, matched = 100, true
+			}
+		}
+		if score, ok := fuzzyMatch(word, issue.Title); ok && score*2 > best {
+			best, matched = score*2, true
+		}
+		for _, label := range issue.Labels {
+			if score, ok := fuzzyMatch(word, label); ok && score > best {
+				best, matched = score, true
+			}
+		}
+		if !matched && strings.Contains(strings.ToLower(issue.Body), strings.ToLower(word)) {
+			best, matched = 1, true
+		}
+		if !matched {
+			return 0, false
+		}
+		total += best
+	}
+	return total, true
+}
+
+// filterIssues returns the issues passing the filters and search query,
+// best search matches first and otherwise in the filter's sort order
+func filterIssues(issues []Issue, filter IssueListFilter, query string, progress func(int) IssueProgress) []Issue {
+	type scored struct {
+		issue Issue
+		score int
+	}
+	var matches []scored
+	for _, issue := range issues {
+		if !filter.Match(issue, progress(issue.Number)) {
+			continue
+		}
+		score, ok := searchScore(query, issue)
+		if !ok {
+			continue
+		}
+		matches = append(matches, scored{issue, score})
+	}
+
+	less := issueLess(filter.Sort)
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return less(matches[i].issue, matches[j].issue)
+	})
+
+	result := make([]Issue, len(matches))
+	for i, match := range matches {
+		result[i] = match.issue
+	}
+	return result
+}
+
+// issueLess orders issues for a sort mode; newest first except for labels
+func issueLess(mode IssueSortMode) func(a, b Issue) bool {
+	switch mode {
+	case SortByUpdated:
+		return func(a, b Issue) bool { return a.UpdatedAt.After(b.UpdatedAt) }
+	case SortByCreated:
+		return func(a, b Issue) bool { return a.CreatedAt.After(b.CreatedAt) }
+	case SortByLabel:
+		return func(a, b Issue) bool {
+			// Unlabelled issues go last
+			if len(a.Labels) == 0 || len(b.Labels) == 0 {
+				if len(a.Labels) != len(b.Labels) {
+					return len(b.Labels) == 0
+				}
+				return a.Number > b.Number
+			}
+			la, lb := sortedCopy(a.Labels)[0], sortedCopy(b.Labels)[0]
+			if la != lb {
+				return la < lb
+			}
+			return a.Number > b.Number
+		}
+	default:
+		return func(a, b Issue) bool { return a.Number > b.Number }
+	}
+}
+
+func sortedCopy(values []string) []string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// nextSortMode returns the sort mode after mode
+func nextSortMode(mode IssueSortMode) IssueSortMode {
+	for i, m := range issueSortModes {
+		if m == mode {
+			return issueSortModes[(i+1)%len(issueSortModes)]
+		}
+	}
+	return issueSortModes[1]
+}
+
+// Filter bar fields, in the order they are shown
+const (
+	filterFieldState = iota
+	filterFieldLabel
+	filterFieldAssignee
+	filterFieldInProgress
+	filterFieldSort
+	filterFieldCount
+)
+
+// filterChoices returns the values a filter bar field cycles through; ""
+// means any
+func (m IssueListModel) filterChoices(field int) []string {
+	collect := func(values func(Issue) []string) []string {
+		seen := map[string]bool{}
+		choices := []string{""}
+		for _, issue := range m.allIssues {
+			for _, v := range values(issue) {
+				if !seen[v] {
+					seen[v] = true
+					choices = append(choices, v)
+				}
+			}
+		}
+		sort.Strings(choices[1:])
+		return choices
+	}
+
+	switch field {
+	case filterFieldState:
+		return []string{"", "open", "closed"}
+	case filterFieldLabel:
+		return collect(func(issue Issue) []string { return issue.Labels })
+	case filterFieldAssignee:
+		return collect(func(issue Issue) []string { return issue.Assignees })
+	case filterFieldInProgress:
+		return []string{"", "yes"}
+	default:
+		choices := make([]string, len(issueSortModes))
+		for i, mode := range issueSortModes {
+			choices[i] = string(mode)
+		}
+		return choices
+	}
+}
+
+// filterValue returns the current value of a filter bar field
+func (f IssueListFilter) filterValue(field int) string {
+	switch field {
+	case filterFieldState:
+		return f.State
+	case filterFieldLabel:
+		return f.Label
+	case filterFieldAssignee:
+		return f.Assignee
+	case filterFieldInProgress:
+		if f.InProgress {
+			return "yes"
+		}
+		return ""
+	default:
+		if f.Sort == "" {
+			return string(SortByNumber)
+		}
+		return string(f.Sort)
+	}
+}
+
+// withFilterValue returns the filter with a field set
+func (f IssueListFilter) withFilterValue(field int, value string) IssueListFilter {
+	switch field {
+	case filterFieldState:
+		f.State = value
+	case filterFieldLabel:
+		f.Label = value
+	case filterFieldAssignee:
+		f.Assignee = value
+	case filterFieldInProgress:
+		f.InProgress = value != ""
+	default:
+		f.Sort = IssueSortMode(value)
+	}
+	return f
+}
+
+// cycleFilter moves the focused filter bar field to its next or previous value
+func (m IssueListModel) cycleFilter(delta int) IssueListModel {
+	choices := m.filterChoices(m.filterFocus)
+	current := m.filter.filterValue(m.filterFocus)
+	index := 0
+	for i, choice := range choices {
+		if choice == current {
+			index = i
+		}
+	}
+	index = (index + delta + len(choices)) % len(choices)
+	return m.setFilter(m.filter.withFilterValue(m.filterFocus, choices[index]))
+}
+
+// setFilter applies and saves new filters
+func (m IssueListModel) setFilter(filter IssueListFilter) IssueListModel {
+	m.filter = filter
+	saveIssueListFilter(m.db, m.projectName, filter)
+	return m.applyFilter()
+}
+
+// applyFilter recomputes the visible issues, keeping the selected issue
+// selected when it is still shown
+func (m IssueListModel) applyFilter() IssueListModel {
+	selectedNumber := 0
+	if m.selected < len(m.issues) {
+		selectedNumber = m.issues[m.selected].Number
+	}
+	m.issues = filterIssues(m.allIssues, m.filter, m.query, m.progress.Get)
+	m.selected = 0
+	for i, issue := range m.issues {
+		if issue.Number == selectedNumber {
+			m.selected = i
+		}
+	}
+	return m
+}
+
+// updateSearch handles keys while typing a "/" search
+func (m IssueListModel) updateSearch(msg tea.KeyMsg) (IssueListModel, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.searching, m.query = false, ""
+		return m.applyFilter(), nil
+	case tea.KeyEnter:
+		m.searching = false
+		return m, nil
+	case tea.KeyUp:
+		if m.selected > 0 {
+			m.selected--
+		}
+		return m, nil
+	case tea.KeyDown:
+		if m.selected < len(m.issues)-1 {
+			m.selected++
+		}
+		return m, nil
+	case tea.KeyBackspace:
+		if query := []rune(m.query); len(query) > 0 {
+			m.query = string(query[:len(query)-1])
+		}
+	case tea.KeyCtrlU:
+		m.query = ""
+	case tea.KeySpace:
+		m.query += " "
+	case tea.KeyRunes:
+		m.query += string(msg.Runes)
+	default:
+		return m, nil
+	}
+	// The best match goes to the top
+	m = m.applyFilter()
+	m.selected = 0
+	return m, nil
+}
+
+// updateFilterBar handles keys while the filter bar is focused
+func (m IssueListModel) updateFilterBar(msg tea.KeyMsg) (IssueListModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "enter", "F":
+		m.filterBar = false
+	case "left", "h", "shift+tab":
+		m.filterFocus = (m.filterFocus + filterFieldCount - 1) % filterFieldCount
+	case "right", "l", "tab":
+		m.filterFocus = (m.filterFocus + 1) % filterFieldCount
+	case "down", "j", " ":
+		return m.cycleFilter(1), nil
+	case "up", "k":
+		return m.cycleFilter(-1), nil
+	case "backspace", "x":
+		// Clears the field; sort falls back to number
+		return m.setFilter(m.filter.withFilterValue(m.filterFocus, "")), nil
+	case "X":
+		return m.setFilter(IssueListFilter{Sort: m.filter.Sort}), nil
+	}
+	return m, nil
+}
+
+// filterBarView renders the filter bar, or a summary of the active filters
+// while the bar is closed
+func (m IssueListModel) filterBarView() string {
+	names := []string{"state", "label", "assignee", "in progress", "sort"}
+	focused := theme.Fg(theme.Accent).Reverse(true).Padding(0, 1)
+	field := theme.Fg(theme.Accent).Padding(0, 1)
+
+	if !m.filterBar {
+		var active []string
+		for i, name := range names {
+			if value := m.filter.filterValue(i); value != "" && (i != filterFieldSort || value != string(SortByNumber)) {
+				active = append(active, name+": "+value)
+			}
+		}
+		if len(active) == 0 {
+			return ""
+		}
+		return helpStyle.Render(fmt.Sprintf("Filters: %s • %d of %d issues • F to change", strings.Join(active, ", "), len(m.issues), len(m.allIssues))) + "\n"
+	}
+
+	var fields []string
+	for i, name := range names {
+		value := m.filter.filterValue(i)
+		if value == "" {
+			value = "any"
+		}
+		if i == m.filterFocus {
+			fields = append(fields, focused.Render(name+": "+value))
+		} else {
+			fields = append(fields, field.Render(name+": "+value))
+		}
+	}
+	return strings.Join(fields, " ") + "\n" +
+		helpStyle.Render("←/→ field • ↑/↓ value • x clear • X clear all • enter done") + "\n"
+}
+
+// searchView renders the search prompt while searching or filtered by a query
+func (m IssueListModel) searchView() string {
+	if !m.searching && m.query == "" {
+		return ""
+	}
+	promptStyle := theme.Fg(theme.Accent).Bold(true)
+	line := promptStyle.Render("/") + " " + m.query
+	if m.searching {
+		line += lipgloss.NewStyle().Reverse(true).Render(" ")
+	}
+	hint := "esc to clear"
+	if m.searching {
+		hint = "enter to keep • esc to clear"
+	}
+	return line + helpStyle.Render(fmt.Sprintf("  %d matches • %s", len(m.issues), hint)) + "\n"
+}
+
+// jumpTo selects the issue numbered by the digits typed so far
+func (m IssueListModel) jumpTo(digits string) IssueListModel {
+	m.jump = digits
+	number, err := strconv.Atoi(digits)
+	if err != nil {
+		return m
+	}
+	for i, issue := range m.issues {
+		if issue.Number == number {
+			m.selected = i
+		}
+	}
+	return m
+}
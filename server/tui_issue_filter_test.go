@@ -0,0 +1,150 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func testIssues() []Issue {
+	day := 24 * time.Hour
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	return []Issue{
+		{Number: 3, Title: "Fix login redirect", State: "open", Labels: []string{"bug"}, Assignees: []string{"ana"},
+			CreatedAt: base, UpdatedAt: base.Add(5 * day)},
+		{Number: 12, Title: "Add dark theme", State: "open", Labels: []string{"enhancement"},
+			CreatedAt: base.Add(day), UpdatedAt: base.Add(2 * day)},
+		{Number: 7, Title: "Update docs", Body: "Mention the login flow", State: "closed",
+			CreatedAt: base.Add(2 * day), UpdatedAt: base.Add(3 * day)},
+		{Number: 120, Title: "Speed up startup", State: "open", Labels: []string{"bug", "perf"}, Assignees: []string{"bo"},
+			CreatedAt: base.Add(3 * day), UpdatedAt: base.Add(day)},
+	}
+}
+
+func issueNumbers(issues []Issue) []int {
+	numbers := make([]int, len(issues))
+	for i, issue := range issues {
+		numbers[i] = issue.Number
+	}
+	return numbers
+}
+
+func noProgress(int) IssueProgress { return IssueProgress{} }
+
+func TestFuzzyMatch(t *testing.T) {
+	if _, ok := fuzzyMatch("lgn", "Fix login redirect"); !ok {
+		t.Error("Expected subsequence to match")
+	}
+	if _, ok := fuzzyMatch("xyz", "Fix login redirect"); ok {
+		t.Error("Expected no match")
+	}
+	consecutive, _ := fuzzyMatch("log", "Fix login")
+	scattered, _ := fuzzyMatch("log", "long trip")
+	if consecutive <= scattered {
+		t.Errorf("Consecutive match scored %d, scattered %d", consecutive, scattered)
+	}
+}
+
+func TestFilterIssues(t *testing.T) {
+	issues := testIssues()
+	tests := []struct {
+		name   string
+		filter IssueListFilter
+		query  string
+		want   []int
+	}{
+		{"default sort", IssueListFilter{}, "", []int{120, 12, 7, 3}},
+		{"updated", IssueListFilter{Sort: SortByUpdated}, "", []int{3, 7, 12, 120}},
+		{"created", IssueListFilter{Sort: SortByCreated}, "", []int{120, 7, 12, 3}},
+		{"label", IssueListFilter{Sort: SortByLabel}, "", []int{120, 3, 12, 7}},
+		{"state", IssueListFilter{State: "closed"}, "", []int{7}},
+		{"label filter", IssueListFilter{Label: "bug"}, "", []int{120, 3}},
+		{"assignee", IssueListFilter{Assignee: "bo"}, "", []int{120}},
+		{"title beats body", IssueListFilter{}, "login", []int{3, 7}},
+		{"number prefix", IssueListFilter{}, "#12", []int{120, 12}},
+		{"every word", IssueListFilter{}, "bug start", []int{120}},
+	}
+	for _, tt := range tests {
+		got := issueNumbers(filterIssues(issues, tt.filter, tt.query, noProgress))
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+
+	inProgress := func(n int) IssueProgress { return IssueProgress{HasBranch: true, HasWorktree: n == 12} }
+	if got := issueNumbers(filterIssues(issues, IssueListFilter{InProgress: true}, "", inProgress)); len(got) != 1 || got[0] != 12 {
+		t.Errorf("In progress filter: got %v", got)
+	}
+}
+
+// TestIssueListSearchAndJump tests the search mode, multi-digit jumps and
+// that filters are saved per project
+func TestIssueListSearchAndJump(t *testing.T) {
+	db, err := openDatabase(filepath.Join(t.TempDir(), "relay.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.AddProject("demo", t.TempDir()); err != nil {
+		t.Fatalf("Failed to add project: %v", err)
+	}
+
+	m := NewIssueListModel(nil, nil, nil, db, "demo", "")
+	m, _ = m.Update(issueListActionMsg{label: "Loading issues"})
+	m, _ = m.Update(issuesLoadedMsg{opID: m.op.id, issues: testIssues()})
+	update := func(keys ...tea.KeyMsg) {
+		for _, key := range keys {
+			m, _ = m.Update(key)
+		}
+	}
+
+	// Jumping to #12 passes #1 on the way
+	update(runes("1"), runes("2"))
+	if m.issues[m.selected].Number != 12 || m.jump != "12" {
+		t.Errorf("Jump selected #%d with %q typed", m.issues[m.selected].Number, m.jump)
+	}
+	update(runes("0"))
+	if m.issues[m.selected].Number != 120 {
+		t.Errorf("Jump selected #%d, want #120", m.issues[m.selected].Number)
+	}
+	update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.jump != "" {
+		t.Errorf("Esc left jump %q", m.jump)
+	}
+
+	update(runes("/"), runes("d"), runes("a"), runes("r"), runes("k"))
+	if !m.searching || len(m.issues) != 1 || m.issues[0].Number != 12 {
+		t.Errorf("Search for dark: searching %v, issues %v", m.searching, issueNumbers(m.issues))
+	}
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.searching || m.query != "dark" {
+		t.Errorf("Enter should keep the query, got searching %v query %q", m.searching, m.query)
+	}
+	update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.query != "" || len(m.issues) != 4 {
+		t.Errorf("Esc should clear the query, got %q with %d issues", m.query, len(m.issues))
+	}
+
+	// Filter bar: cycle the state to open, then sort by update
+	update(runes("F"), tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyEnter}, runes("s"))
+	if got := issueNumbers(m.issues); len(got) != 3 || got[0] != 3 {
+		t.Errorf("Open issues by update: got %v", got)
+	}
+
+	saved := NewIssueListModel(nil, nil, nil, db, "demo", "").filter
+	if saved.State != "open" || saved.Sort != SortByUpdated {
+		t.Errorf("Filters were not saved: %+v", saved)
+	}
+	if other := loadIssueListFilter(db, "other"); other != (IssueListFilter{}) {
+		t.Errorf("Another project got filters %+v", other)
+	}
+}
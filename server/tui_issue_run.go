@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// IssueRunData opens the run view for an issue's headless run
+type IssueRunData struct {
+	Issue  Issue
+	Runner *IssueRunner
+}
+
+// issueRunTickMsg triggers a periodic refresh of the run view
+type issueRunTickMsg struct{}
+
+func issueRunTick() tea.Cmd {
+	return tea.Tick(500*time.Millisecond, func(time.Time) tea.Msg {
+		return issueRunTickMsg{}
+	})
+}
+
+// IssueRunModel follows a headless issue run: stage progress, live log and approvals.
+// Leaving the view doesn't stop the run.
+type IssueRunModel struct {
+	issue  Issue
+	runner *IssueRunner
+	status IssueRunStatus
+	width  int
+	height int
+}
+
+func NewIssueRunModel(data IssueRunData) IssueRunModel {
+	return IssueRunModel{
+		issue:  data.Issue,
+		runner: data.Runner,
+		status: data.Runner.Status(),
+		width:  80,
+		height: 24,
+	}
+}
+
+func (m IssueRunModel) Init() tea.Cmd {
+	return issueRunTick()
+}
+
+func (m IssueRunModel) Update(msg tea.Msg) (IssueRunModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case issueRunTickMsg:
+		m.status = m.runner.Status()
+		return m, issueRunTick()
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc":
+			return m, SwitchToView(ViewIssueDetail, m.issue)
+
+		case "p":
+			m.runner.TogglePause()
+
+		case "y":
+			m.runner.Answer(true)
+
+		case "n":
+			m.runner.Answer(false)
+
+		case "t":
+			// Toggle approval checkpoints for the rest of the run
+			m.runner.SetApprovalsEnabled(!m.status.ApprovalsEnabled)
+
+		case "x":
+			m.runner.Cancel()
+
+		case "u":
+			// Checkpoints taken before each step in the issue's worktree
+			if m.status.Worktree != "" {
+				return m, SwitchToView(ViewCheckpoints, CheckpointData{
+					Dir:      m.status.Worktree,
+					Back:     ViewIssueRun,
+					BackData: IssueRunData{Issue: m.issue, Runner: m.runner},
+				})
+			}
+		}
+		m.status = m.runner.Status()
+	}
+
+	return m, nil
+}
+
+// stageProgress renders the stages with the current one highlighted
+func (m IssueRunModel) stageProgress() string {
+	doneStyle := theme.Fg(theme.Success)
+	currentStyle := theme.Fg(theme.Accent).Bold(true)
+	failedStyle := theme.Fg(theme.Error).Bold(true)
+
+	current := len(runStages)
+	for i, stage := range runStages {
+		if stage == m.status.Stage {
+			current = i
+		}
+	}
+
+	var parts []string
+	for i, stage := range runStages {
+		switch {
+		case i < current:
+			parts = append(parts, doneStyle.Render("✓ "+string(stage)))
+		case i == current && (m.status.State == RunFailed || m.status.State == RunCancelled):
+			parts = append(parts, failedStyle.Render("✗ "+string(stage)))
+		case i == current:
+			parts = append(parts, currentStyle.Render("● "+string(stage)))
+		default:
+			parts = append(parts, helpStyle.Render("○ "+string(stage)))
+		}
+	}
+	return strings.Join(parts, " → ")
+}
+
+func (m IssueRunModel) View() string {
+	var content strings.Builder
+
+	title := titleStyle.Render(fmt.Sprintf("🤖 Issue #%d run: %s", m.status.IssueNumber, m.issue.Title))
+	content.WriteString(title + "\n")
+	content.WriteString(m.stageProgress() + "\n\n")
+
+	approvals := "off"
+	if m.status.ApprovalsEnabled {
+		approvals = "on"
+	}
+	info := fmt.Sprintf("State: %s • Approvals: %s", m.status.State, approvals)
+	if m.status.Branch != "" {
+		info += " • " + m.status.Branch
+	}
+	content.WriteString(helpStyle.Render(info) + "\n")
+
+	if m.status.PRURL != "" {
+		content.WriteString(normalStyle.Render("Pull request: "+m.status.PRURL) + "\n")
+	}
+	if m.status.Error != "" {
+		content.WriteString(errorStyle.Render("Error: "+m.status.Error) + "\n")
+	}
+
+	// Pending checkpoint with what is being approved
+	reserved := 10
+	if m.status.Pending != nil {
+		summary := strings.Split(strings.TrimSpace(m.status.Pending.Summary), "\n")
+		if len(summary) > 12 {
+			summary = append(summary[:12], fmt.Sprintf("... (%d more lines)", len(summary)-12))
+		}
+		reserved += len(summary) + 3
+
+		approveStyle := theme.Fg(theme.Warning).Bold(true)
+		content.WriteString("\n" + approveStyle.Render(fmt.Sprintf("Approve %s? (y/n)", m.status.Pending.Stage)) + "\n")
+		content.WriteString(strings.Join(summary, "\n") + "\n")
+	}
+
+	// Live log tail
+	content.WriteString("\n" + helpStyle.Render(strings.Repeat("─", 40)) + "\n")
+	maxLines := m.height - reserved
+	if maxLines < 5 {
+		maxLines = 5
+	}
+	logs := m.status.Logs
+	if len(logs) > maxLines {
+		logs = logs[len(logs)-maxLines:]
+	}
+	for _, entry := range logs {
+		content.WriteString(historyStyle.Render(entry.Time.Format("15:04:05")) + " " + entry.Message + "\n")
+	}
+
+	content.WriteString("\n")
+
+	pauseStyle := theme.Fg(theme.Accent).Bold(true)
+	approveStyle := theme.Fg(theme.Success).Bold(true)
+	cancelStyle := theme.Fg(theme.Error).Bold(true)
+	backStyle := theme.Fg(theme.Help).Bold(true)
+
+	pauseLabel := " Pause"
+	if m.status.State == RunPaused {
+		pauseLabel = " Resume"
+	}
+
+	actionOptions := []string{pauseStyle.Render("p") + pauseLabel}
+	if m.status.Pending != nil {
+		actionOptions = append(actionOptions,
+			approveStyle.Render("y")+" Approve",
+			cancelStyle.Render("n")+" Reject")
+	}
+	actionOptions = append(actionOptions,
+		pauseStyle.Render("t")+" Toggle approvals",
+		pauseStyle.Render("u")+" Checkpoints",
+		cancelStyle.Render("x")+" Cancel",
+		backStyle.Render("q")+" Back")
+	content.WriteString(strings.Join(actionOptions, "  •  ") + "\n")
+
+	return content.String()
+}
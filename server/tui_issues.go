@@ -1,13 +1,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os/exec"
 	"runtime"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 )
 
 type IssueListModel struct {
@@ -15,40 +16,195 @@ type IssueListModel struct {
 	configManager *ConfigManager
 	projectName   string
 	projectPath   string
-	issues        []Issue
+	db            *Database
+	allIssues     []Issue // Issues as fetched
+	issues        []Issue // Issues passing the filters and search, as shown
 	selected      int
 	width         int
 	height        int
-	filterStatus  string
-	filterLabel   string
+	filter        IssueListFilter
+	filterBar     bool     // Whether the filter bar has focus
+	filterFocus   int      // Focused filter bar field
+	searching     bool     // Whether a "/" search is being typed
+	query         string   // Search query
+	jump          string   // Digits typed to jump to an issue number
+	loaded        bool     // Whether issues have been fetched at least once
+	op            *asyncOp // Running fetch or action, if any
+	err           string   // Error of the last fetch or action
+	progress      *IssueProgressService
 }
 
-func NewIssueListModel(issueManager *IssueManager, configManager *ConfigManager, projectName string, projectPath string) IssueListModel {
-	issues := issueManager.ListIssues("", "")
+// issueListActionMsg asks the issue list to run an action, if any, and then
+// refetch the issues in the background
+type issueListActionMsg struct {
+	label  string
+	action func(ctx context.Context) error
+}
+
+// issuesLoadedMsg carries the issues fetched by an issue list op
+type issuesLoadedMsg struct {
+	opID   int64
+	issues []Issue
+	err    error
+}
+
+// refreshIssueList returns a command that reloads the issue list after
+// running action
+func refreshIssueList(label string, action func(ctx context.Context) error) tea.Cmd {
+	return func() tea.Msg {
+		return issueListActionMsg{label: label, action: action}
+	}
+}
+
+// progressChangedMsg is sent when the cached issue progress changes or its
+// service is closed
+type progressChangedMsg struct{}
+
+// waitForProgress waits for the next change of the issue progress badges
+func waitForProgress(progress *IssueProgressService) tea.Cmd {
+	if progress == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		select {
+		case <-progress.Changes():
+		case <-progress.closed:
+		}
+		return progressChangedMsg{}
+	}
+}
+
+func NewIssueListModel(issueManager *IssueManager, configManager *ConfigManager, progress *IssueProgressService, db *Database, projectName string, projectPath string) IssueListModel {
 	return IssueListModel{
 		issueManager:  issueManager,
 		configManager: configManager,
+		progress:      progress,
+		db:            db,
+		filter:        loadIssueListFilter(db, projectName),
 		projectName:   projectName,
 		projectPath:   projectPath,
-		issues:        issues,
 		selected:      0,
 		width:         80, // Default width
 		height:        24, // Default height
 	}
 }
 
+// Init fetches the issues from GitHub in the background
 func (m IssueListModel) Init() tea.Cmd {
-	return nil
+	return refreshIssueList("Loading issues", nil)
+}
+
+// startOp runs action and then fetches the issues, with a spinner
+func (m IssueListModel) startOp(label string, action func(ctx context.Context) error) (IssueListModel, tea.Cmd) {
+	if m.op != nil {
+		m.op.cancel()
+	}
+	op, ctx := newAsyncOp(label)
+	m.op = op
+	m.err = ""
+
+	issueManager := m.issueManager
+	return m, tea.Batch(op.tick(), func() tea.Msg {
+		if action != nil {
+			if err := action(ctx); err != nil {
+				return issuesLoadedMsg{opID: op.id, err: err}
+			}
+		}
+		// Filtering happens in the list so changing filters needs no refetch
+		issues, err := issueManager.ListIssuesContext(ctx, "", "")
+		return issuesLoadedMsg{opID: op.id, issues: issues, err: err}
+	})
 }
 
 func (m IssueListModel) Update(msg tea.Msg) (IssueListModel, tea.Cmd) {
 	switch msg := msg.(type) {
+	case spinnerTickMsg:
+		return m, m.op.advance(msg)
+
+	case issueListActionMsg:
+		return m.startOp(msg.label, msg.action)
+
+	case issuesLoadedMsg:
+		// Results of cancelled operations arrive late and are dropped
+		if !m.op.owns(msg.opID) {
+			return m, nil
+		}
+		m.op.finish()
+		m.op = nil
+		if msg.err != nil {
+			m.err = msg.err.Error()
+			return m, nil
+		}
+		m.allIssues = msg.issues
+		m.loaded = true
+		// Pull requests may have changed along with the issues
+		m.progress.RequestRefresh()
+		return m.applyFilter(), nil
+
+	case progressChangedMsg:
+		// The in progress filter depends on the badges
+		return m.applyFilter(), nil
+
 	case tea.KeyMsg:
+		if m.op != nil {
+			// Only cancelling is possible while an operation runs
+			if msg.String() == "esc" {
+				m.op.cancel()
+				m.err = m.op.label + " cancelled"
+				m.op = nil
+			}
+			return m, nil
+		}
+		if m.searching {
+			return m.updateSearch(msg)
+		}
+		if m.filterBar {
+			return m.updateFilterBar(msg)
+		}
+
+		// Digits jump to an issue number; any other key ends the jump
+		if key := msg.String(); len(key) == 1 && key >= "0" && key <= "9" {
+			return m.jumpTo(m.jump + key), nil
+		}
+		if m.jump != "" {
+			jump := m.jump
+			m.jump = ""
+			switch msg.String() {
+			case "backspace":
+				return m.jumpTo(jump[:len(jump)-1]), nil
+			case "esc":
+				return m, nil
+			}
+		}
+
 		switch msg.String() {
 		case "q", "esc":
-			// Return to REPL
+			// Clear the search first, then return to REPL
+			if msg.String() == "esc" && m.query != "" {
+				m.query = ""
+				return m.applyFilter(), nil
+			}
 			return m, SwitchToView(ViewREPL, nil)
 
+		case "/":
+			m.searching = true
+			return m, nil
+
+		case "F":
+			m.filterBar = true
+			return m, nil
+
+		case "b":
+			return m, SwitchToView(ViewBoard, nil)
+
+		case "s":
+			filter := m.filter
+			filter.Sort = nextSortMode(filter.Sort)
+			return m.setFilter(filter), nil
+
+		case "r":
+			return m.startOp("Loading issues", nil)
+
 		case "up", "k":
 			if m.selected > 0 {
 				m.selected--
@@ -74,13 +230,11 @@ func (m IssueListModel) Update(msg tea.Msg) (IssueListModel, tea.Cmd) {
 					IssueID:    selectedIssue.Number,
 					IssueTitle: selectedIssue.Title,
 					OnConfirm: func(reason string) tea.Cmd {
-						err := m.issueManager.CloseIssue(selectedIssue.Number, reason)
-						if err != nil {
-							// Handle error - could show error message
-							return BackToPreviousView()
-						}
-						// Return to issue list
-						return SwitchToView(ViewIssueList, nil)
+						// Return to the issue list, which closes the issue and reloads
+						issueManager := m.issueManager
+						return tea.Sequence(BackToPreviousView(), refreshIssueList(fmt.Sprintf("Closing issue #%d", selectedIssue.Number), func(ctx context.Context) error {
+							return issueManager.CloseIssue(selectedIssue.Number, reason)
+						}))
 					},
 				}
 				return m, SwitchToView(ViewCloseReason, closeData)
@@ -101,7 +255,7 @@ func (m IssueListModel) Update(msg tea.Msg) (IssueListModel, tea.Cmd) {
 			// Finish in-progress issue
 			if len(m.issues) > 0 {
 				selectedIssue := m.issues[m.selected]
-				if selectedIssue.State != "closed" && isIssueInProgress(m.projectName, selectedIssue.Number) {
+				if selectedIssue.State != "closed" && m.progress.Get(selectedIssue.Number).InProgress() {
 					return m, m.finishIssue(selectedIssue)
 				}
 			}
@@ -112,31 +266,18 @@ func (m IssueListModel) Update(msg tea.Msg) (IssueListModel, tea.Cmd) {
 				Prompt:      "New Issue",
 				Placeholder: "Enter issue description...",
 				OnComplete: func(content string) tea.Cmd {
-					if content != "" {
-						_, err := m.issueManager.AddIssue(content)
-						if err == nil {
-							// Refresh issue list
-							m.issues = m.issueManager.ListIssues(m.filterStatus, m.filterLabel)
-						}
+					if content == "" {
+						return BackToPreviousView()
 					}
-					return BackToPreviousView()
+					issueManager := m.issueManager
+					return tea.Sequence(BackToPreviousView(), refreshIssueList("Creating issue", func(ctx context.Context) error {
+						_, err := issueManager.AddIssue(content)
+						return err
+					}))
 				},
 			}
 			return m, SwitchToView(ViewTextInput, inputData)
 
-		default:
-			// Check for number keys (0-9) to select issues directly
-			if len(msg.String()) == 1 && msg.String() >= "0" && msg.String() <= "9" {
-				issueNum := int(msg.String()[0] - '0')
-				// Find issue with matching GitHub number
-				for i, issue := range m.issues {
-					if issue.Number == issueNum {
-						// Select this issue and open detail view
-						m.selected = i
-						return m, SwitchToView(ViewIssueDetail, issue)
-					}
-				}
-			}
 		}
 	}
 
@@ -151,8 +292,20 @@ func (m IssueListModel) View() string {
 	title := titleStyle.Render(fmt.Sprintf("📋 Issues"))
 	content.WriteString(title + "\n")
 
-	if len(m.issues) == 0 {
+	if m.op != nil {
+		content.WriteString(m.op.View() + "\n")
+	} else if m.err != "" {
+		content.WriteString(errorStyle.Render("⚠️  "+m.err) + helpStyle.Render(" • r to retry") + "\n")
+	}
+	content.WriteString(m.searchView())
+	content.WriteString(m.filterBarView())
+
+	if !m.loaded {
+		// Nothing to show until the first fetch finishes
+	} else if len(m.allIssues) == 0 {
 		content.WriteString("No issues found. Press 'n' to add your first issue!\n")
+	} else if len(m.issues) == 0 {
+		content.WriteString("No issues match. Press esc to clear the search or F to change the filters.\n")
 	} else {
 		// Issue list
 		// Use a sensible default if height is not set
@@ -161,6 +314,14 @@ func (m IssueListModel) View() string {
 			height = 24 // Default terminal height
 		}
 		maxLines := height - 8 // Reserve space for title and help
+		if m.searching || m.query != "" {
+			maxLines--
+		}
+		if m.filterBar {
+			maxLines -= 2
+		} else if m.filterBarView() != "" {
+			maxLines--
+		}
 		if maxLines < 5 {
 			maxLines = 5 // Minimum visible lines
 		}
@@ -186,13 +347,14 @@ func (m IssueListModel) View() string {
 			issue := m.issues[i]
 			relativeTime := formatRelativeTime(issue.CreatedAt)
 			isClosed := issue.State == "closed"
-			inProgress := !isClosed && isIssueInProgress(m.projectName, issue.Number)
+			progress := m.progress.Get(issue.Number)
 
-			// Add "in progress" indicator to time if applicable
+			// Add the progress badge to the time; closed issues are grayed out below
 			timeWithStatus := relativeTime
-			if inProgress {
-				blueStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("4"))
-				timeWithStatus = relativeTime + " " + blueStyle.Render("(in progress)")
+			if isClosed && progress.Label() != "" {
+				timeWithStatus = relativeTime + " " + progress.Label()
+			} else if progress.Badge() != "" {
+				timeWithStatus = relativeTime + " " + progress.Badge()
 			}
 
 			// Format labels with colors (only if labels exist)
@@ -208,14 +370,7 @@ func (m IssueListModel) View() string {
 						// For closed issues, render labels in plain text (will be grayed out below)
 						labelParts = append(labelParts, label)
 					} else {
-						switch label {
-						case "bug":
-							labelParts = append(labelParts, lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true).Render(label))
-						case "enhancement":
-							labelParts = append(labelParts, lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Bold(true).Render(label))
-						default:
-							labelParts = append(labelParts, label)
-						}
+						labelParts = append(labelParts, renderLabel(label))
 					}
 				}
 				styledLabels := strings.Join(labelParts, ", ")
@@ -226,16 +381,14 @@ func (m IssueListModel) View() string {
 			if i == m.selected {
 				if isClosed {
 					// Apply gray styling to closed issues, even when selected
-					graySelectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Bold(true)
-					content.WriteString(graySelectedStyle.Render("> "+line) + "\n")
+					content.WriteString(closedStyle.Bold(true).Render("> "+line) + "\n")
 				} else {
 					content.WriteString(selectedIssueStyle.Render("> "+line) + "\n")
 				}
 			} else {
 				if isClosed {
 					// Apply gray styling to closed issues
-					grayStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
-					content.WriteString(grayStyle.Render("  "+line) + "\n")
+					content.WriteString(closedStyle.Render("  "+line) + "\n")
 				} else {
 					content.WriteString(unselectedIssueStyle.Render("  "+line) + "\n")
 				}
@@ -253,31 +406,41 @@ func (m IssueListModel) View() string {
 	content.WriteString("\n")
 
 	// Define color styles for different action types
-	deleteStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true)  // Red for delete
-	createStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Bold(true) // Yellow for new/create
-	backStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Bold(true)    // Gray for back
+	deleteStyle := theme.Fg(theme.Error).Bold(true)   // Red for delete
+	createStyle := theme.Fg(theme.Warning).Bold(true) // Yellow for new/create
+	backStyle := theme.Fg(theme.Help).Bold(true)      // Gray for back
 
 	// Action options displayed horizontally
-	chatStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("12")).Bold(true) // Blue for chat
-	finishStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Bold(true) // Green for finish
+	chatStyle := theme.Fg(theme.Accent).Bold(true)    // Blue for chat
+	finishStyle := theme.Fg(theme.Success).Bold(true) // Green for finish
 
 	actionOptions := []string{
 		chatStyle.Render("o") + " Chat",
 		deleteStyle.Render("c") + " Close",
 		createStyle.Render("n") + " New",
+		chatStyle.Render("/") + " Search",
+		chatStyle.Render("F") + " Filter",
+		chatStyle.Render("s") + " Sort",
+		chatStyle.Render("b") + " Board",
+		chatStyle.Render("r") + " Refresh",
 		backStyle.Render("q") + " Back",
 	}
 
 	// Add finish option if selected issue is in progress
 	if len(m.issues) > 0 && m.selected < len(m.issues) {
 		selectedIssue := m.issues[m.selected]
-		if selectedIssue.State != "closed" && isIssueInProgress(m.projectName, selectedIssue.Number) {
+		if selectedIssue.State != "closed" && m.progress.Get(selectedIssue.Number).InProgress() {
 			// Insert finish option before "New"
 			actionOptions = []string{
 				chatStyle.Render("o") + " Chat",
 				deleteStyle.Render("c") + " Close",
 				finishStyle.Render("f") + " Finish",
 				createStyle.Render("n") + " New",
+				chatStyle.Render("/") + " Search",
+				chatStyle.Render("F") + " Filter",
+				chatStyle.Render("s") + " Sort",
+				chatStyle.Render("b") + " Board",
+				chatStyle.Render("r") + " Refresh",
 				backStyle.Render("q") + " Back",
 			}
 		}
@@ -288,7 +451,11 @@ func (m IssueListModel) View() string {
 	content.WriteString(optionsLine + "\n\n")
 
 	// Controls section (minimal)
-	content.WriteString(helpStyle.Render("Controls: Use keys above to interact, or press issue number to select") + "\n")
+	if m.jump != "" {
+		content.WriteString(helpStyle.Render(fmt.Sprintf("Jump to #%s_ • enter to open • esc to cancel", m.jump)) + "\n")
+	} else {
+		content.WriteString(helpStyle.Render("Controls: Use keys above to interact, type an issue number to select it, ctrl+p for all commands or ? for keys") + "\n")
+	}
 
 	return content.String()
 }
@@ -300,12 +467,13 @@ func (m IssueListModel) finishIssue(issue Issue) tea.Cmd {
 		worktreeName := generateWorktreeName(m.projectName, issue.Number)
 		branchName := generateBranchName(issue.Number)
 		worktreeAbsPath := fmt.Sprintf("%s/%s", m.projectPath, worktreeName)
+		ctx := WithAuditScope(context.Background(), AuditScope{Project: m.projectName, IssueNumber: issue.Number, Actor: AuditActorUser})
 		
 		// Stage all changes
 		cmd := exec.Command("git", "add", ".")
 		cmd.Dir = worktreeAbsPath
-		if err := cmd.Run(); err != nil {
-			fmt.Printf("Failed to stage changes: %v\n", err)
+		if err := auditedRun(ctx, cmd); err != nil {
+			slog.Error("Failed to stage changes", "error", err)
 			return BackToPreviousView()
 		}
 		
@@ -313,7 +481,7 @@ func (m IssueListModel) finishIssue(issue Issue) tea.Cmd {
 		cmd = exec.Command("git", "diff", "--cached", "--quiet")
 		cmd.Dir = worktreeAbsPath
 		if cmd.Run() == nil {
-			fmt.Printf("No changes to commit\n")
+			notifyUser("No changes to commit")
 			return BackToPreviousView()
 		}
 		
@@ -321,16 +489,16 @@ func (m IssueListModel) finishIssue(issue Issue) tea.Cmd {
 		commitMsg := fmt.Sprintf("feat: resolve issue #%d - %s", issue.Number, issue.Title)
 		cmd = exec.Command("git", "commit", "-m", commitMsg)
 		cmd.Dir = worktreeAbsPath
-		if err := cmd.Run(); err != nil {
-			fmt.Printf("Failed to commit changes: %v\n", err)
+		if err := auditedRun(ctx, cmd); err != nil {
+			slog.Error("Failed to commit changes", "error", err)
 			return BackToPreviousView()
 		}
 		
 		// Push branch
 		cmd = exec.Command("git", "push", "-u", "origin", branchName)
 		cmd.Dir = worktreeAbsPath
-		if err := cmd.Run(); err != nil {
-			fmt.Printf("Failed to push branch: %v\n", err)
+		if err := auditedRun(ctx, cmd); err != nil {
+			slog.Error("Failed to push branch", "error", err)
 			return BackToPreviousView()
 		}
 		
@@ -339,13 +507,13 @@ func (m IssueListModel) finishIssue(issue Issue) tea.Cmd {
 		prBody := fmt.Sprintf("Closes #%d\n\n%s", issue.Number, issue.Body)
 		cmd = exec.Command("gh", "pr", "create", "--title", prTitle, "--body", prBody)
 		cmd.Dir = worktreeAbsPath
-		output, err := cmd.Output()
+		output, err := auditedOutput(ctx, cmd)
 		if err != nil {
-			fmt.Printf("Failed to create PR: %v\n", err)
+			slog.Error("Failed to create PR", "error", err)
 			return BackToPreviousView()
 		}
 		
-		fmt.Printf("✅ Issue #%d finished successfully!\nPR created: %s\n", issue.Number, string(output))
+		notifyUser(fmt.Sprintf("✅ Issue #%d finished successfully! PR created: %s", issue.Number, strings.TrimSpace(string(output))))
 		return BackToPreviousView()
 	}
 }
@@ -358,6 +526,32 @@ type IssueDetailModel struct {
 	width       int
 	height      int
 	fields      []string
+	back        ViewType // The issue list or board to return to
+	op          *asyncOp // Running edit, if any
+	err         string   // Error of the last edit
+}
+
+// issueEditMsg asks the issue detail view to run an edit with a spinner
+type issueEditMsg struct {
+	label string
+	edit  func(ctx context.Context) (Issue, error) // Returns the edited issue
+	leave bool                                     // Return to the issue list or board once done
+}
+
+// issueEditedMsg carries the result of an issue detail edit
+type issueEditedMsg struct {
+	opID  int64
+	issue Issue
+	leave bool
+	err   error
+}
+
+// runIssueEdit returns to the issue detail view from an input view and runs
+// edit there in the background
+func runIssueEdit(label string, leave bool, edit func(ctx context.Context) (Issue, error)) tea.Cmd {
+	return tea.Sequence(BackToPreviousView(), func() tea.Msg {
+		return issueEditMsg{label: label, edit: edit, leave: leave}
+	})
 }
 
 func NewIssueDetailModel(issue Issue, replSession *REPLSession) IssueDetailModel {
@@ -373,6 +567,7 @@ func NewIssueDetailModel(issue Issue, replSession *REPLSession) IssueDetailModel
 		replSession: replSession,
 		selected:    0,
 		fields:      fields,
+		back:        ViewIssueList,
 	}
 }
 
@@ -380,13 +575,60 @@ func (m IssueDetailModel) Init() tea.Cmd {
 	return nil
 }
 
+// startEdit runs an edit with a spinner
+func (m IssueDetailModel) startEdit(msg issueEditMsg) (IssueDetailModel, tea.Cmd) {
+	if m.op != nil {
+		m.op.cancel()
+	}
+	op, ctx := newAsyncOp(msg.label)
+	m.op = op
+	m.err = ""
+
+	return m, tea.Batch(op.tick(), func() tea.Msg {
+		issue, err := msg.edit(ctx)
+		return issueEditedMsg{opID: op.id, issue: issue, leave: msg.leave, err: err}
+	})
+}
+
 func (m IssueDetailModel) Update(msg tea.Msg) (IssueDetailModel, tea.Cmd) {
 	switch msg := msg.(type) {
+	case spinnerTickMsg:
+		return m, m.op.advance(msg)
+
+	case issueEditMsg:
+		return m.startEdit(msg)
+
+	case issueEditedMsg:
+		// Results of cancelled edits arrive late and are dropped
+		if !m.op.owns(msg.opID) {
+			return m, nil
+		}
+		m.op.finish()
+		m.op = nil
+		if msg.err != nil {
+			m.err = msg.err.Error()
+			return m, nil
+		}
+		if msg.leave {
+			return m, SwitchToView(m.back, nil)
+		}
+		m.issue = msg.issue
+		return m, nil
+
 	case tea.KeyMsg:
+		if m.op != nil {
+			// Only cancelling is possible while an edit runs
+			if msg.String() == "esc" {
+				m.op.cancel()
+				m.err = m.op.label + " cancelled"
+				m.op = nil
+			}
+			return m, nil
+		}
 		switch msg.String() {
 		case "q", "esc":
-			// Back to issue list
-			return m, SwitchToView(ViewIssueList, nil)
+			// Back to issue list or board
+			return m, SwitchToView(m.back, nil)
 
 		case "up", "k":
 			if m.selected > 0 {
@@ -406,16 +648,37 @@ func (m IssueDetailModel) Update(msg tea.Msg) (IssueDetailModel, tea.Cmd) {
 			return m.handleClose()
 
 		case "s":
-			// Start with Claude Code shortcut
+			// Start Claude Code in the embedded terminal, or attach to its session
 			return m.handleOpenInClaudeCode()
 
+		case "S":
+			// Start Claude Code in an external terminal window
+			return m, m.openClaudeCodeTerminal()
+
+		case "t":
+			// Jump to the issue's agent session
+			if term := m.replSession.terminals.ForIssue(m.replSession.currentProject.Name, m.issue.Number); term != nil {
+				return m, SwitchToView(ViewAgentTerminal, AgentTerminalData{SessionID: term.ID, Back: ViewIssueDetail, BackData: m.issue})
+			}
+
 		case "o":
 			// Close issue shortcut
 			return m.handleClose()
 
+		case "a":
+			// Run the issue headlessly: plan, implement, check and open a PR
+			if m.issue.State != "closed" {
+				runner := m.replSession.StartIssueRun(m.issue.Number)
+				return m, SwitchToView(ViewIssueRun, IssueRunData{Issue: m.issue, Runner: runner})
+			}
+
+		case "p":
+			// Review and edit the issue's step plan
+			return m, SwitchToView(ViewPlan, m.issue)
+
 		case "f":
 			// Finish in-progress issue
-			if m.issue.State != "closed" && isIssueInProgress(m.replSession.currentProject.Name, m.issue.Number) {
+			if m.issue.State != "closed" && m.replSession.progress.Get(m.issue.Number).InProgress() {
 				return m, m.finishIssueDetail()
 			}
 
@@ -427,12 +690,35 @@ func (m IssueDetailModel) Update(msg tea.Msg) (IssueDetailModel, tea.Cmd) {
 				DisplayName: fmt.Sprintf("Issue %d: %s", m.issue.Number, m.issue.Title),
 			}
 			return m, SwitchToView(ViewREPL, context)
+
+		case "D":
+			// Review the worktree's changes and stage them hunk by hunk
+			return m, m.openWorktreeView(ViewDiff)
+
+		case "L":
+			// Commits on the issue branch
+			return m, m.openWorktreeView(ViewLog)
 		}
 	}
 
 	return m, nil
 }
 
+// openWorktreeView opens the diff or log view on the issue's worktree
+func (m IssueDetailModel) openWorktreeView(view ViewType) tea.Cmd {
+	dir, err := issueWorktreeDir(m.replSession, m.issue.Number)
+	if err != nil {
+		slog.Warn("Cannot open the issue worktree", "error", err)
+		return nil
+	}
+	title := fmt.Sprintf("Issue #%d worktree", m.issue.Number)
+	base := sessionBaseBranch(m.replSession)
+	if view == ViewLog {
+		return SwitchToView(ViewLog, LogData{Dir: dir, Title: title, Revisions: []string{base + "..HEAD"}, Back: ViewIssueDetail, BackData: m.issue})
+	}
+	return SwitchToView(ViewDiff, DiffData{Dir: dir, Title: title, Base: base, Back: ViewIssueDetail, BackData: m.issue})
+}
+
 func (m IssueDetailModel) editSelectedField() (IssueDetailModel, tea.Cmd) {
 	switch m.selected {
 	case 0: // Title
@@ -448,7 +734,21 @@ func (m IssueDetailModel) editSelectedField() (IssueDetailModel, tea.Cmd) {
 }
 
 func (m IssueDetailModel) handleOpenInClaudeCode() (IssueDetailModel, tea.Cmd) {
-	return m, m.openClaudeCodeTerminal()
+	term, err := m.replSession.terminals.Start(AgentTerminalSpec{
+		Project:     m.replSession.currentProject.Name,
+		IssueNumber: m.issue.Number,
+		Title:       fmt.Sprintf("Issue #%d: %s", m.issue.Number, m.issue.Title),
+		Dir:         m.replSession.currentProject.Path,
+		// Keep a shell open in the worktree once Claude Code exits
+		Command: m.claudeCodeSetupCommand() + "; exec ${SHELL:-sh}",
+		Rows:    m.height - terminalChrome,
+		Cols:    m.width,
+	})
+	if err != nil {
+		slog.Error("Failed to start agent session", "issue", m.issue.Number, "error", err)
+		return m, nil
+	}
+	return m, SwitchToView(ViewAgentTerminal, AgentTerminalData{SessionID: term.ID, Back: ViewIssueDetail, BackData: m.issue})
 }
 
 // generateWorktreeName creates a descriptive worktree directory name
@@ -461,87 +761,66 @@ func generateBranchName(issueID int) string {
 	return fmt.Sprintf("feature/issue-%d", issueID)
 }
 
-// checkFeatureBranchExists checks if a feature branch exists for the given issue
-func checkFeatureBranchExists(issueID int) bool {
-	branchName := generateBranchName(issueID)
-	cmd := exec.Command("git", "branch", "--list", branchName)
-	output, err := cmd.Output()
-	if err != nil {
-		return false
+// claudeCodeSetupCommand is the shell command that prepares the issue's
+// worktree and starts Claude Code in it with the issue as the prompt
+func (m IssueDetailModel) claudeCodeSetupCommand() string {
+	// Generate worktree and branch names
+	worktreeName := generateWorktreeName(m.replSession.currentProject.Name, m.issue.Number)
+	branchName := generateBranchName(m.issue.Number)
+
+	// Build the prompt for Claude Code
+	var promptBuilder strings.Builder
+
+	// Add issue information
+	promptBuilder.WriteString(fmt.Sprintf("Issue #%d: %s\n", m.issue.Number, m.issue.Title))
+	promptBuilder.WriteString(fmt.Sprintf("Labels: %s\n", strings.Join(m.issue.Labels, ", ")))
+	promptBuilder.WriteString(fmt.Sprintf("Status: %s\n", m.issue.State))
+	promptBuilder.WriteString(fmt.Sprintf("Created: %s\n", formatRelativeTime(m.issue.CreatedAt)))
+
+	// Add issue body if exists
+	if m.issue.Body != "" {
+		promptBuilder.WriteString(fmt.Sprintf("\nDescription:\n%s\n", m.issue.Body))
 	}
-	return strings.TrimSpace(string(output)) != ""
-}
 
-// checkWorktreeExists checks if a worktree exists for the given issue
-func checkWorktreeExists(projectName string, issueID int) bool {
-	worktreeSuffix := fmt.Sprintf("%s-issue-%d", projectName, issueID)
-	cmd := exec.Command("git", "worktree", "list")
-	output, err := cmd.Output()
-	if err != nil {
-		return false
+	// Add planning instruction
+	promptBuilder.WriteString(fmt.Sprintf("\nWhat follows is a GitHub issue. Read the Issue and Description and create a plan for implementing it then ask me what I think of the plan. Do not start implementing the plan or make any changes.\n"))
+
+	// Add worktree information to the prompt
+	promptBuilder.WriteString(fmt.Sprintf("\nWorktree Setup:\n"))
+	promptBuilder.WriteString(fmt.Sprintf("- Working in isolated worktree: %s\n", worktreeName))
+	promptBuilder.WriteString(fmt.Sprintf("- Feature branch: %s\n", branchName))
+	promptBuilder.WriteString(fmt.Sprintf("\nWhen you're done:\n"))
+	promptBuilder.WriteString(fmt.Sprintf("1. Push: git push -u origin %s\n", branchName))
+	promptBuilder.WriteString(fmt.Sprintf("2. Return to main: cd %s\n", m.replSession.currentProject.Path))
+	promptBuilder.WriteString(fmt.Sprintf("3. Merge: git checkout main && git pull origin main && git merge %s && git push\n", branchName))
+	promptBuilder.WriteString(fmt.Sprintf("4. Cleanup: git worktree remove %s && git branch -d %s\n", worktreeName, branchName))
+
+	prompt := promptBuilder.String()
+
+	// Build claude command - escape quotes properly
+	claudeCmd := fmt.Sprintf("claude \"%s\"", strings.ReplaceAll(prompt, "\"", "\\\""))
+
+	// Check if worktree and branch already exist
+	var worktreeSetupCmd string
+	if m.replSession.progress.Get(m.issue.Number).InProgress() {
+		// Both exist - just navigate to worktree and start Claude
+		// Need to get the absolute path to the existing worktree
+		worktreeAbsPath := fmt.Sprintf("%s/%s", m.replSession.currentProject.Path, worktreeName)
+		worktreeSetupCmd = fmt.Sprintf("cd \"%s\" && %s",
+			worktreeAbsPath, claudeCmd)
+	} else {
+		// Create worktree and branch from latest main, then start Claude
+		worktreeSetupCmd = fmt.Sprintf("cd \"%s\" && git fetch origin main && git checkout main && git pull origin main && git worktree add %s -b %s && cd %s && %s",
+			m.replSession.currentProject.Path, worktreeName, branchName, worktreeName, claudeCmd)
 	}
-	return strings.Contains(string(output), worktreeSuffix)
-}
 
-// isIssueInProgress checks if an issue has both a feature branch and worktree
-func isIssueInProgress(projectName string, issueID int) bool {
-	return checkFeatureBranchExists(issueID) && checkWorktreeExists(projectName, issueID)
+	return worktreeSetupCmd
 }
 
+// openClaudeCodeTerminal starts Claude Code for the issue in a new terminal window
 func (m IssueDetailModel) openClaudeCodeTerminal() tea.Cmd {
 	return func() tea.Msg {
-		// Generate worktree and branch names
-		worktreeName := generateWorktreeName(m.replSession.currentProject.Name, m.issue.Number)
-		branchName := generateBranchName(m.issue.Number)
-
-		// Build the prompt for Claude Code
-		var promptBuilder strings.Builder
-
-		// Add issue information
-		promptBuilder.WriteString(fmt.Sprintf("Issue #%d: %s\n", m.issue.Number, m.issue.Title))
-		promptBuilder.WriteString(fmt.Sprintf("Labels: %s\n", strings.Join(m.issue.Labels, ", ")))
-		promptBuilder.WriteString(fmt.Sprintf("Status: %s\n", m.issue.State))
-		promptBuilder.WriteString(fmt.Sprintf("Created: %s\n", formatRelativeTime(m.issue.CreatedAt)))
-
-		// Add issue body if exists
-		if m.issue.Body != "" {
-			promptBuilder.WriteString(fmt.Sprintf("\nDescription:\n%s\n", m.issue.Body))
-		}
-
-		// Add planning instruction
-		promptBuilder.WriteString(fmt.Sprintf("\nWhat follows is a GitHub issue. Read the Issue and Description and create a plan for implementing it then ask me what I think of the plan. Do not start implementing the plan or make any changes.\n"))
-
-		// Add worktree information to the prompt
-		promptBuilder.WriteString(fmt.Sprintf("\nWorktree Setup:\n"))
-		promptBuilder.WriteString(fmt.Sprintf("- Working in isolated worktree: %s\n", worktreeName))
-		promptBuilder.WriteString(fmt.Sprintf("- Feature branch: %s\n", branchName))
-		promptBuilder.WriteString(fmt.Sprintf("\nWhen you're done:\n"))
-		promptBuilder.WriteString(fmt.Sprintf("1. Push: git push -u origin %s\n", branchName))
-		promptBuilder.WriteString(fmt.Sprintf("2. Return to main: cd %s\n", m.replSession.currentProject.Path))
-		promptBuilder.WriteString(fmt.Sprintf("3. Merge: git checkout main && git pull origin main && git merge %s && git push\n", branchName))
-		promptBuilder.WriteString(fmt.Sprintf("4. Cleanup: git worktree remove %s && git branch -d %s\n", worktreeName, branchName))
-
-		prompt := promptBuilder.String()
-
-		// Build claude command - escape quotes properly
-		claudeCmd := fmt.Sprintf("claude \"%s\"", strings.ReplaceAll(prompt, "\"", "\\\""))
-
-		// Check if worktree and branch already exist
-		branchExists := checkFeatureBranchExists(m.issue.Number)
-		worktreeExists := checkWorktreeExists(m.replSession.currentProject.Name, m.issue.Number)
-
-		var worktreeSetupCmd string
-		if branchExists && worktreeExists {
-			// Both exist - just navigate to worktree and start Claude
-			// Need to get the absolute path to the existing worktree
-			worktreeAbsPath := fmt.Sprintf("%s/%s", m.replSession.currentProject.Path, worktreeName)
-			worktreeSetupCmd = fmt.Sprintf("cd \"%s\" && %s",
-				worktreeAbsPath, claudeCmd)
-		} else {
-			// Create worktree and branch from latest main, then start Claude
-			worktreeSetupCmd = fmt.Sprintf("cd \"%s\" && git fetch origin main && git checkout main && git pull origin main && git worktree add %s -b %s && cd %s && %s",
-				m.replSession.currentProject.Path, worktreeName, branchName, worktreeName, claudeCmd)
-		}
+		worktreeSetupCmd := m.claudeCodeSetupCommand()
 
 		// Open new terminal with worktree setup and claude command based on OS
 		var cmd *exec.Cmd
@@ -575,19 +854,16 @@ func (m IssueDetailModel) openClaudeCodeTerminal() tea.Cmd {
 			// Use cmd.exe to open new window with worktree setup
 			cmd = exec.Command("cmd", "/c", "start", "cmd", "/k", worktreeSetupCmd)
 
-		default:
-			// Fallback - just run the setup commands directly
-			setupCmd := exec.Command("bash", "-c", worktreeSetupCmd)
-			setupCmd.Start()
 		}
 
-		if cmd != nil {
-			err := cmd.Start()
-			if err != nil {
-				// If opening terminal fails, fallback to running setup directly
-				fallbackCmd := exec.Command("bash", "-c", worktreeSetupCmd)
-				fallbackCmd.Start()
-			}
+		// Without a terminal window there is nowhere to show the session, so
+		// point at the embedded terminal instead of running it unseen
+		if cmd == nil {
+			slog.Error("No terminal emulator found; press 's' to use the embedded terminal")
+			return nil
+		}
+		if err := cmd.Start(); err != nil {
+			slog.Error("Failed to open terminal; press 's' to use the embedded terminal", "error", err)
 		}
 
 		return nil
@@ -596,52 +872,64 @@ func (m IssueDetailModel) openClaudeCodeTerminal() tea.Cmd {
 
 
 func (m IssueDetailModel) handleEditBody() (IssueDetailModel, tea.Cmd) {
-	inputData := TextInputData{
-		Prompt:      fmt.Sprintf("Edit body for issue #%d", m.issue.Number),
-		Placeholder: m.issue.Body,
-		OnComplete: func(body string) tea.Cmd {
-			if body != m.issue.Body {
-				err := m.replSession.issueManager.UpdateIssueBody(m.issue.Number, body)
-				if err == nil {
-					m.issue.Body = body
-				}
+	issue, issueManager := m.issue, m.replSession.issueManager
+	editorData := EditorData{
+		Title:   fmt.Sprintf("Edit body for issue #%d", m.issue.Number),
+		Content: m.issue.Body,
+		OnSave: func(body string) tea.Cmd {
+			if body == issue.Body {
+				return BackToPreviousView()
 			}
-			return BackToPreviousView()
+			return runIssueEdit(fmt.Sprintf("Updating issue #%d", issue.Number), false, func(ctx context.Context) (Issue, error) {
+				if err := issueManager.UpdateIssueBody(issue.Number, body); err != nil {
+					return issue, fmt.Errorf("failed to update issue body: %w", err)
+				}
+				issue.Body = body
+				return issue, nil
+			})
 		},
 	}
-	return m, SwitchToView(ViewTextInput, inputData)
+	return m, SwitchToView(ViewEditor, editorData)
 }
 
 func (m IssueDetailModel) handleRename() (IssueDetailModel, tea.Cmd) {
+	issue, issueManager := m.issue, m.replSession.issueManager
 	inputData := TextInputData{
 		Prompt:      fmt.Sprintf("Rename issue #%d", m.issue.Number),
 		Placeholder: m.issue.Title,
 		OnComplete: func(newTitle string) tea.Cmd {
-			if newTitle != "" {
-				err := m.replSession.issueManager.UpdateIssueTitle(m.issue.Number, newTitle)
-				if err == nil {
-					m.issue.Title = newTitle
-				}
+			if newTitle == "" || newTitle == issue.Title {
+				return BackToPreviousView()
 			}
-			return BackToPreviousView()
+			return runIssueEdit(fmt.Sprintf("Renaming issue #%d", issue.Number), false, func(ctx context.Context) (Issue, error) {
+				if err := issueManager.UpdateIssueTitle(issue.Number, newTitle); err != nil {
+					return issue, err
+				}
+				issue.Title = newTitle
+				return issue, nil
+			})
 		},
 	}
 	return m, SwitchToView(ViewTextInput, inputData)
 }
 
 func (m IssueDetailModel) handleEditStatus() (IssueDetailModel, tea.Cmd) {
+	issue, issueManager := m.issue, m.replSession.issueManager
 	inputData := TextInputData{
 		Prompt:      fmt.Sprintf("Edit state for issue #%d (open/closed)", m.issue.Number),
 		Placeholder: m.issue.State,
 		OnComplete: func(newState string) tea.Cmd {
-			if newState != "" && newState != m.issue.State && (newState == "open" || newState == "closed") {
-				err := m.replSession.issueManager.UpdateIssueStatus(m.issue.Number, newState)
-				if err == nil {
-					m.issue.State = newState
-				}
+			if newState != "" && newState != issue.State && (newState == "open" || newState == "closed") {
+				return runIssueEdit(fmt.Sprintf("Updating issue #%d", issue.Number), false, func(ctx context.Context) (Issue, error) {
+					if err := issueManager.UpdateIssueStatus(issue.Number, newState); err != nil {
+						return issue, err
+					}
+					issue.State = newState
+					return issue, nil
+				})
 			} else if newState != "open" && newState != "closed" && newState != "" {
 				// Show error for invalid state
-				fmt.Printf("💡 GitHub issues only support 'open' and 'closed' states.\n")
+				notifyUser("💡 GitHub issues only support 'open' and 'closed' states.")
 			}
 			return BackToPreviousView()
 		},
@@ -650,49 +938,50 @@ func (m IssueDetailModel) handleEditStatus() (IssueDetailModel, tea.Cmd) {
 }
 
 func (m IssueDetailModel) handleEditLabels() (IssueDetailModel, tea.Cmd) {
+	issue, issueManager := m.issue, m.replSession.issueManager
 	labelData := LabelEditorData{
 		IssueID:       m.issue.Number,
 		CurrentLabels: append([]string(nil), m.issue.Labels...), // Copy slice
 		OnComplete: func(newLabels []string) tea.Cmd {
 			// Update the issue labels
-			err := m.replSession.issueManager.UpdateIssueLabels(m.issue.Number, newLabels)
-			if err == nil {
-				m.issue.Labels = newLabels
-			}
-			return BackToPreviousView()
+			return runIssueEdit(fmt.Sprintf("Updating labels of issue #%d", issue.Number), false, func(ctx context.Context) (Issue, error) {
+				if err := issueManager.UpdateIssueLabels(issue.Number, newLabels); err != nil {
+					return issue, err
+				}
+				issue.Labels = newLabels
+				return issue, nil
+			})
 		},
 	}
 	return m, SwitchToView(ViewLabelEditor, labelData)
 }
 
 func (m IssueDetailModel) handleDelete() (IssueDetailModel, tea.Cmd) {
+	issue, issueManager := m.issue, m.replSession.issueManager
 	confirmData := ConfirmationData{
 		Message: fmt.Sprintf("Delete issue #%d: \"%s\"? (This will close the issue on GitHub)", m.issue.Number, m.issue.Title),
 		OnConfirm: func(confirmed bool) tea.Cmd {
-			if confirmed {
-				err := m.replSession.issueManager.DeleteIssue(m.issue.Number)
-				if err == nil {
-					return SwitchToView(ViewIssueList, nil)
-				}
+			if !confirmed {
+				return BackToPreviousView()
 			}
-			return BackToPreviousView()
+			return runIssueEdit(fmt.Sprintf("Deleting issue #%d", issue.Number), true, func(ctx context.Context) (Issue, error) {
+				return issue, issueManager.DeleteIssue(issue.Number)
+			})
 		},
 	}
 	return m, SwitchToView(ViewConfirmation, confirmData)
 }
 
 func (m IssueDetailModel) handleClose() (IssueDetailModel, tea.Cmd) {
+	issue, issueManager := m.issue, m.replSession.issueManager
 	closeData := CloseReasonData{
 		IssueID:    m.issue.Number,
 		IssueTitle: m.issue.Title,
 		OnConfirm: func(reason string) tea.Cmd {
-			err := m.replSession.issueManager.CloseIssue(m.issue.Number, reason)
-			if err != nil {
-				// Handle error - could show error message
-				return BackToPreviousView()
-			}
-			// Return to issue list
-			return SwitchToView(ViewIssueList, nil)
+			// Return to the issue list or board once closed
+			return runIssueEdit(fmt.Sprintf("Closing issue #%d", issue.Number), true, func(ctx context.Context) (Issue, error) {
+				return issue, issueManager.CloseIssue(issue.Number, reason)
+			})
 		},
 	}
 	return m, SwitchToView(ViewCloseReason, closeData)
@@ -705,12 +994,13 @@ func (m IssueDetailModel) finishIssueDetail() tea.Cmd {
 		worktreeName := generateWorktreeName(m.replSession.currentProject.Name, m.issue.Number)
 		branchName := generateBranchName(m.issue.Number)
 		worktreeAbsPath := fmt.Sprintf("%s/%s", m.replSession.currentProject.Path, worktreeName)
+		ctx := WithAuditScope(context.Background(), AuditScope{Project: m.replSession.currentProject.Name, IssueNumber: m.issue.Number, Actor: AuditActorUser})
 		
 		// Stage all changes
 		cmd := exec.Command("git", "add", ".")
 		cmd.Dir = worktreeAbsPath
-		if err := cmd.Run(); err != nil {
-			fmt.Printf("Failed to stage changes: %v\n", err)
+		if err := auditedRun(ctx, cmd); err != nil {
+			slog.Error("Failed to stage changes", "error", err)
 			return BackToPreviousView()
 		}
 		
@@ -718,7 +1008,7 @@ func (m IssueDetailModel) finishIssueDetail() tea.Cmd {
 		cmd = exec.Command("git", "diff", "--cached", "--quiet")
 		cmd.Dir = worktreeAbsPath
 		if cmd.Run() == nil {
-			fmt.Printf("No changes to commit\n")
+			notifyUser("No changes to commit")
 			return BackToPreviousView()
 		}
 		
@@ -726,16 +1016,16 @@ func (m IssueDetailModel) finishIssueDetail() tea.Cmd {
 		commitMsg := fmt.Sprintf("feat: resolve issue #%d - %s", m.issue.Number, m.issue.Title)
 		cmd = exec.Command("git", "commit", "-m", commitMsg)
 		cmd.Dir = worktreeAbsPath
-		if err := cmd.Run(); err != nil {
-			fmt.Printf("Failed to commit changes: %v\n", err)
+		if err := auditedRun(ctx, cmd); err != nil {
+			slog.Error("Failed to commit changes", "error", err)
 			return BackToPreviousView()
 		}
 		
 		// Push branch
 		cmd = exec.Command("git", "push", "-u", "origin", branchName)
 		cmd.Dir = worktreeAbsPath
-		if err := cmd.Run(); err != nil {
-			fmt.Printf("Failed to push branch: %v\n", err)
+		if err := auditedRun(ctx, cmd); err != nil {
+			slog.Error("Failed to push branch", "error", err)
 			return BackToPreviousView()
 		}
 		
@@ -744,13 +1034,13 @@ func (m IssueDetailModel) finishIssueDetail() tea.Cmd {
 		prBody := fmt.Sprintf("Closes #%d\n\n%s", m.issue.Number, m.issue.Body)
 		cmd = exec.Command("gh", "pr", "create", "--title", prTitle, "--body", prBody)
 		cmd.Dir = worktreeAbsPath
-		output, err := cmd.Output()
+		output, err := auditedOutput(ctx, cmd)
 		if err != nil {
-			fmt.Printf("Failed to create PR: %v\n", err)
+			slog.Error("Failed to create PR", "error", err)
 			return BackToPreviousView()
 		}
 		
-		fmt.Printf("✅ Issue #%d finished successfully!\nPR created: %s\n", m.issue.Number, string(output))
+		notifyUser(fmt.Sprintf("✅ Issue #%d finished successfully! PR created: %s", m.issue.Number, strings.TrimSpace(string(output))))
 		return BackToPreviousView()
 	}
 }
@@ -763,6 +1053,12 @@ func (m IssueDetailModel) View() string {
 	content.WriteString(title + "\n")
 	content.WriteString(strings.Repeat("=", 15) + "\n\n")
 
+	if m.op != nil {
+		content.WriteString(m.op.View() + "\n\n")
+	} else if m.err != "" {
+		content.WriteString(errorStyle.Render("⚠️  "+m.err) + "\n\n")
+	}
+
 	// Format labels for display - only show if labels exist
 	var labelsStr string
 	if len(m.issue.Labels) > 0 {
@@ -778,7 +1074,8 @@ func (m IssueDetailModel) View() string {
 		{"Title", m.issue.Title},
 		{"Body", func() string {
 			if m.issue.Body != "" {
-				return m.issue.Body
+				// The rendered body follows the fields
+				return truncateText(strings.SplitN(strings.TrimSpace(m.issue.Body), "\n", 2)[0], 60)
 			}
 			return "<empty - press Enter to set>"
 		}()},
@@ -802,27 +1099,71 @@ func (m IssueDetailModel) View() string {
 
 	content.WriteString("\n")
 
+	if m.issue.Body != "" {
+		width := m.width
+		if width <= 0 {
+			width = 80
+		}
+		content.WriteString(renderMarkdown(m.issue.Body, width-2) + "\n\n")
+	}
+
 	// Created timestamp in gray below selection area
-	grayStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Faint(true)
+	grayStyle := theme.Fg(theme.Help).Faint(true)
 	content.WriteString(grayStyle.Render(fmt.Sprintf("Created: %s", formatRelativeTime(m.issue.CreatedAt))) + "\n")
-	content.WriteString(grayStyle.Render(fmt.Sprintf("URL: %s", m.issue.URL)) + "\n\n")
+	content.WriteString(grayStyle.Render(fmt.Sprintf("URL: %s", m.issue.URL)) + "\n")
+	if badge := m.replSession.progress.Get(m.issue.Number).Badge(); badge != "" {
+		content.WriteString(grayStyle.Render("Progress: ") + badge + "\n")
+	}
+	content.WriteString("\n")
+
+	// Interactive agent session in the terminal pane, if any
+	term := m.replSession.terminals.ForIssue(m.replSession.currentProject.Name, m.issue.Number)
+	if term != nil {
+		liveStyle := theme.Fg(theme.Success).Bold(true)
+		if term.Running() {
+			content.WriteString(liveStyle.Render("● Agent session live") + grayStyle.Render(fmt.Sprintf(" since %s - press t to attach", formatRelativeTime(term.Started))) + "\n\n")
+		} else {
+			content.WriteString(grayStyle.Render(fmt.Sprintf("Agent session %s - press t to view", term.Status())) + "\n\n")
+		}
+	}
 
 	// Define color styles for different action types
-	chatStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("12")).Bold(true)  // Blue for chat
-	openStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Bold(true)  // Green for start
-	deleteStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true) // Red for delete
-	backStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Bold(true)   // Gray for back
+	chatStyle := theme.Fg(theme.Accent).Bold(true)  // Blue for chat
+	openStyle := theme.Fg(theme.Success).Bold(true) // Green for start
+	deleteStyle := theme.Fg(theme.Error).Bold(true) // Red for delete
+	backStyle := theme.Fg(theme.Help).Bold(true)    // Gray for back
 
 	// Check if issue is in progress to show appropriate action text
 	var startAction string
 	var actionData []string
 	
-	if isIssueInProgress(m.replSession.currentProject.Name, m.issue.Number) {
+	// Headless agent run; shows progress when one is already going
+	agentAction := openStyle.Render("a") + " Agent run"
+	if runner := m.replSession.IssueRun(m.issue.Number); runner != nil {
+		agentAction = openStyle.Render("a") + fmt.Sprintf(" Agent run (%s)", runner.Status().State)
+	}
+	planAction := chatStyle.Render("p") + " Plan"
+	if plan, err := m.replSession.projectManager.db.GetPlan(m.replSession.currentProject.Name, m.issue.Number); err == nil && plan != nil && len(plan.Steps) > 0 {
+		done, total := plan.Progress()
+		planAction = chatStyle.Render("p") + fmt.Sprintf(" Plan (%d/%d)", done, total)
+	}
+
+	terminalAction := chatStyle.Render("S") + " External terminal"
+	if term != nil {
+		terminalAction = chatStyle.Render("t") + " Attach"
+	}
+
+	if m.replSession.progress.Get(m.issue.Number).InProgress() {
 		startAction = openStyle.Render("s") + " Continue"
-		finishStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Bold(true) // Green for finish
+		diffActions := chatStyle.Render("D") + " Diff  •  " + chatStyle.Render("L") + " Log"
+		finishStyle := theme.Fg(theme.Success).Bold(true) // Green for finish
 		actionData = []string{
 			chatStyle.Render("d") + " Chat",
+			planAction,
 			startAction,
+			terminalAction,
+			agentAction,
+			diffActions,
 			finishStyle.Render("f") + " Finish",
 			deleteStyle.Render("c") + " Close",
 			backStyle.Render("q") + " Back",
@@ -831,7 +1172,10 @@ func (m IssueDetailModel) View() string {
 		startAction = openStyle.Render("s") + " Start"
 		actionData = []string{
 			chatStyle.Render("d") + " Chat",
+			planAction,
 			startAction,
+			terminalAction,
+			agentAction,
 			deleteStyle.Render("c") + " Close",
 			backStyle.Render("q") + " Back",
 		}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// jobsTickMsg triggers a periodic refresh of the job panel
+type jobsTickMsg struct{}
+
+func jobsTick() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return jobsTickMsg{}
+	})
+}
+
+// jobSummaryMsg delivers a summary requested from the job panel
+type jobSummaryMsg struct {
+	jobID   int64
+	summary *Summary
+	err     error
+}
+
+// JobListModel shows queued, running and finished jobs with their output
+type JobListModel struct {
+	session     *REPLSession
+	queue       *JobQueue
+	projectName string
+	jobs        []*Job
+	selected    int
+	verbosity   SummaryVerbosity
+	summaries   map[int64]*Summary // Summaries at the current verbosity
+	summarizing map[int64]bool
+	showDetail  bool
+	width       int
+	height      int
+	err         error
+}
+
+func NewJobListModel(session *REPLSession) JobListModel {
+	m := JobListModel{
+		session:     session,
+		queue:       session.jobQueue,
+		projectName: session.currentProject.Name,
+		verbosity:   VerbosityNormal,
+		summaries:   make(map[int64]*Summary),
+		summarizing: make(map[int64]bool),
+		width:       80,
+		height:      24,
+	}
+	m.refresh()
+	return m
+}
+
+func (m *JobListModel) refresh() {
+	jobs, err := m.queue.List(m.projectName, 50)
+	m.jobs = jobs
+	m.err = err
+	if m.selected >= len(m.jobs) {
+		m.selected = len(m.jobs) - 1
+	}
+	if m.selected < 0 {
+		m.selected = 0
+	}
+}
+
+func (m JobListModel) Init() tea.Cmd {
+	return jobsTick()
+}
+
+// summarize requests a summary of a job in the background
+func (m JobListModel) summarize(job *Job) tea.Cmd {
+	session := m.session
+	verbosity := m.verbosity
+	return func() tea.Msg {
+		summary, err := session.SummarizeJob(context.Background(), job, verbosity)
+		return jobSummaryMsg{jobID: job.ID, summary: summary, err: err}
+	}
+}
+
+func (m JobListModel) Update(msg tea.Msg) (JobListModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case jobsTickMsg:
+		m.refresh()
+		return m, jobsTick()
+
+	case jobSummaryMsg:
+		delete(m.summarizing, msg.jobID)
+		if msg.err != nil {
+			m.err = msg.err
+		} else if msg.summary.Verbosity == m.verbosity {
+			m.summaries[msg.jobID] = msg.summary
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc":
+			return m, SwitchToView(ViewREPL, nil)
+
+		case "up", "k":
+			if m.selected > 0 {
+				m.selected--
+			}
+
+		case "down", "j":
+			if m.selected < len(m.jobs)-1 {
+				m.selected++
+			}
+
+		case "x":
+			// Cancel selected job
+			if len(m.jobs) > 0 {
+				if err := m.queue.Cancel(m.jobs[m.selected].ID); err != nil {
+					m.err = err
+				}
+				m.refresh()
+			}
+
+		case "s":
+			// Summarize selected job with the planning provider
+			if len(m.jobs) > 0 {
+				job := m.jobs[m.selected]
+				if job.IsFinished() && !m.summarizing[job.ID] {
+					m.summarizing[job.ID] = true
+					return m, m.summarize(job)
+				}
+			}
+
+		case "v":
+			// Cycle summary verbosity; summaries at the old level no longer apply
+			m.verbosity = m.verbosity.Next()
+			m.summaries = make(map[int64]*Summary)
+
+		case "d":
+			m.showDetail = !m.showDetail
+
+		case "r":
+			m.refresh()
+		}
+	}
+
+	return m, nil
+}
+
+// jobStatusIcon returns a short status marker for a job
+func jobStatusIcon(status JobStatus) string {
+	switch status {
+	case JobQueued:
+		return "⏳"
+	case JobRunning:
+		return "🔄"
+	case JobSucceeded:
+		return "✅"
+	case JobFailed:
+		return "❌"
+	case JobCancelled:
+		return "🚫"
+	case JobSkipped:
+		return "⏭️"
+	default:
+		return "❓"
+	}
+}
+
+func (m JobListModel) View() string {
+	var content strings.Builder
+
+	title := titleStyle.Render("🧵 Jobs")
+	content.WriteString(title + "\n")
+
+	if len(m.jobs) == 0 {
+		content.WriteString("No jobs yet. Queue one from the REPL with /queue.\n")
+	} else {
+		// Keep the list to the top half so the output pane has room
+		maxLines := (m.height - 8) / 2
+		if maxLines < 5 {
+			maxLines = 5
+		}
+
+		startIdx := 0
+		if m.selected >= maxLines {
+			startIdx = m.selected - maxLines + 1
+		}
+		endIdx := startIdx + maxLines
+		if endIdx > len(m.jobs) {
+			endIdx = len(m.jobs)
+		}
+
+		for i := startIdx; i < endIdx; i++ {
+			job := m.jobs[i]
+			line := fmt.Sprintf("%s #%-3d %-9s %s", jobStatusIcon(job.Status), job.ID, job.Status, job.Describe())
+			if job.DependsOn != 0 {
+				line += helpStyle.Render(fmt.Sprintf(" (%s #%d)", job.Condition, job.DependsOn))
+			}
+
+			if i == m.selected {
+				content.WriteString(selectedIssueStyle.Render("> "+line) + "\n")
+			} else {
+				content.WriteString(unselectedIssueStyle.Render("  "+line) + "\n")
+			}
+		}
+
+		// Output of the selected job
+		job := m.jobs[m.selected]
+		content.WriteString("\n" + helpStyle.Render(strings.Repeat("─", 40)) + "\n")
+
+		var timing string
+		switch {
+		case job.StartedAt != nil && job.FinishedAt != nil:
+			timing = fmt.Sprintf("took %s", job.FinishedAt.Sub(*job.StartedAt).Round(time.Second))
+		case job.StartedAt != nil:
+			timing = fmt.Sprintf("running for %s", time.Since(*job.StartedAt).Round(time.Second))
+		default:
+			timing = fmt.Sprintf("queued %s", formatRelativeTime(job.CreatedAt))
+		}
+		content.WriteString(helpStyle.Render(fmt.Sprintf("Job #%d • %s", job.ID, timing)) + "\n")
+
+		if job.Error != "" {
+			content.WriteString(errorStyle.Render("Error: "+job.Error) + "\n")
+		}
+
+		// Spoken summary: the planning provider's once requested, otherwise the quick fallback
+		if job.IsFinished() {
+			summary, ok := m.summaries[job.ID]
+			if !ok {
+				summary = fallbackSummary(jobSummaryInput(job), m.verbosity)
+			}
+
+			label := fmt.Sprintf("🔊 Summary (%s, %s)", m.verbosity, summary.Source)
+			if m.summarizing[job.ID] {
+				label += " • summarizing..."
+			}
+			content.WriteString(historyStyle.Render(label) + "\n")
+			content.WriteString(summary.Spoken + "\n\n")
+
+			if m.showDetail {
+				content.WriteString(summary.Detail + "\n")
+			}
+		}
+
+		output := strings.TrimSpace(job.Output)
+		if m.showDetail && job.IsFinished() {
+			output = "" // The detail version replaces the raw output
+		}
+		if output == "" && !job.IsFinished() {
+			output = "(no output yet)"
+		}
+		outputLines := strings.Split(output, "\n")
+		if output == "" {
+			outputLines = nil
+		}
+		maxOutput := m.height - maxLines - 16
+		if maxOutput < 3 {
+			maxOutput = 3
+		}
+		if len(outputLines) > maxOutput {
+			outputLines = append(outputLines[:maxOutput], fmt.Sprintf("... (%d more lines)", len(outputLines)-maxOutput))
+		}
+		if len(outputLines) > 0 {
+			content.WriteString(strings.Join(outputLines, "\n") + "\n")
+		}
+	}
+
+	if m.err != nil {
+		content.WriteString("\n" + errorStyle.Render(m.err.Error()) + "\n")
+	}
+
+	content.WriteString("\n")
+
+	cancelStyle := theme.Fg(theme.Error).Bold(true)
+	refreshStyle := theme.Fg(theme.Accent).Bold(true)
+	summaryStyle := theme.Fg(theme.Special).Bold(true)
+	backStyle := theme.Fg(theme.Help).Bold(true)
+
+	actionOptions := []string{
+		cancelStyle.Render("x") + " Cancel",
+		summaryStyle.Render("s") + " Summarize",
+		summaryStyle.Render("v") + " Verbosity",
+		summaryStyle.Render("d") + " Detail",
+		refreshStyle.Render("r") + " Refresh",
+		backStyle.Render("q") + " Back",
+	}
+	content.WriteString(strings.Join(actionOptions, "  •  ") + "\n")
+
+	return content.String()
+}
@@ -4,7 +4,6 @@ import (
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 )
 
 // LabelEditorModel handles interactive label editing
@@ -104,13 +103,7 @@ func (m LabelEditorModel) View() string {
 		}
 
 		// Add styled label
-		if label == "bug" {
-			line += lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true).Render(label)
-		} else if label == "enhancement" {
-			line += lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Bold(true).Render(label)
-		} else {
-			line += label
-		}
+		line += renderLabel(label)
 
 		// Highlight selected item
 		if i == m.selected {
@@ -130,11 +123,11 @@ func (m LabelEditorModel) View() string {
 		currentLabelsStr = "none"
 	}
 
-	grayStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Faint(true)
+	grayStyle := theme.Fg(theme.Help).Faint(true)
 	content.WriteString(grayStyle.Render("Current labels: "+currentLabelsStr) + "\n\n")
 
 	// Help
-	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	helpStyle := theme.Fg(theme.Help)
 	content.WriteString(helpStyle.Render("↑↓ Navigate  •  Enter Toggle  •  s Save  •  q Cancel") + "\n")
 
 	return content.String()
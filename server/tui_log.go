@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// logLimit is how many commits the log view lists
+const logLimit = 200
+
+// LogData opens the commit log of a repository or worktree, returning to
+// Back with BackData
+type LogData struct {
+	Dir       string
+	Title     string   // What Dir is, e.g. "Issue #12 worktree"
+	Revisions []string // What to list, e.g. "main..HEAD"; none means HEAD
+	Selected  int      // Commit selected when the log opens
+	Back      ViewType
+	BackData  interface{}
+}
+
+// logLoadedMsg carries the commits of a log op
+type logLoadedMsg struct {
+	opID    int64
+	commits []CommitEntry
+	err     error
+}
+
+// LogModel lists commits; opening one shows its diff
+type LogModel struct {
+	data     LogData
+	commits  []CommitEntry
+	selected int
+	loaded   bool
+	op       *asyncOp
+	err      string
+	width    int
+	height   int
+}
+
+func NewLogModel(data LogData) LogModel {
+	return LogModel{data: data, selected: data.Selected, width: 80, height: 24}
+}
+
+// Init loads the log in the background
+func (m LogModel) Init() tea.Cmd {
+	return func() tea.Msg {
+		return issueListActionMsg{label: "Loading log"}
+	}
+}
+
+// startOp loads the log with a spinner
+func (m LogModel) startOp(label string) (LogModel, tea.Cmd) {
+	if m.op != nil {
+		m.op.cancel()
+	}
+	op, ctx := newAsyncOp(label)
+	m.op = op
+
+	dir, revisions := m.data.Dir, m.data.Revisions
+	return m, tea.Batch(op.tick(), func() tea.Msg {
+		commits, err := LoadLog(ctx, dir, logLimit, revisions...)
+		return logLoadedMsg{opID: op.id, commits: commits, err: err}
+	})
+}
+
+// openCommit shows the selected commit's diff, coming back to it afterwards
+func (m LogModel) openCommit() tea.Cmd {
+	if m.selected >= len(m.commits) {
+		return nil
+	}
+	back := m.data
+	back.Selected = m.selected
+	commit := m.commits[m.selected]
+	return SwitchToView(ViewDiff, DiffData{
+		Dir:      m.data.Dir,
+		Title:    commit.Subject,
+		Mode:     DiffCommit,
+		Commit:   commit.Hash,
+		Back:     ViewLog,
+		BackData: back,
+	})
+}
+
+func (m LogModel) Update(msg tea.Msg) (LogModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case spinnerTickMsg:
+		return m, m.op.advance(msg)
+
+	case issueListActionMsg:
+		return m.startOp(msg.label)
+
+	case logLoadedMsg:
+		if !m.op.owns(msg.opID) {
+			return m, nil
+		}
+		m.op.finish()
+		m.op = nil
+		if msg.err != nil {
+			m.err = msg.err.Error()
+			return m, nil
+		}
+		m.commits, m.loaded = msg.commits, true
+		m.selected = min(m.selected, max(len(m.commits)-1, 0))
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.op != nil {
+			// Only cancelling is possible while an operation runs
+			if msg.String() == "esc" {
+				m.op.cancel()
+				m.err = m.op.label + " cancelled"
+				m.op = nil
+			}
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "q", "esc":
+			return m, SwitchToView(m.data.Back, m.data.BackData)
+		case "up", "k":
+			if m.selected > 0 {
+				m.selected--
+			}
+		case "down", "j":
+			if m.selected < len(m.commits)-1 {
+				m.selected++
+			}
+		case "enter", " ":
+			return m, m.openCommit()
+		case "r":
+			m.err = ""
+			return m.startOp("Loading log")
+		}
+	}
+
+	return m, nil
+}
+
+func (m LogModel) View() string {
+	var content strings.Builder
+
+	title := "Log"
+	if m.data.Title != "" {
+		title += ": " + m.data.Title
+	}
+	if len(m.data.Revisions) > 0 {
+		title += fmt.Sprintf(" (%s)", strings.Join(m.data.Revisions, " "))
+	}
+	content.WriteString(titleStyle.Render("📜 "+title) + "\n")
+
+	if m.op != nil {
+		content.WriteString(m.op.View() + "\n")
+	} else if m.err != "" {
+		content.WriteString(errorStyle.Render("⚠️  "+m.err) + "\n")
+	}
+
+	if m.loaded && len(m.commits) == 0 {
+		content.WriteString(helpStyle.Render("No commits.") + "\n")
+	}
+
+	// Keep the selected commit in view
+	visible := max(m.height-7, 3)
+	start := 0
+	if m.selected >= visible {
+		start = m.selected - visible + 1
+	}
+	end := min(start+visible, len(m.commits))
+
+	hashStyle := theme.Fg(theme.Warning)
+	for i := start; i < end; i++ {
+		commit := m.commits[i]
+		meta := fmt.Sprintf("  %s, %s", commit.Author, timeAgo(commit.Date))
+		subject := truncateText(commit.Subject, max(m.width-len(commit.Short)-len(meta)-6, 20))
+		if i == m.selected {
+			subject = selectedIssueStyle.Render("▶ " + subject)
+		} else {
+			subject = unselectedIssueStyle.Render("  " + subject)
+		}
+		content.WriteString(hashStyle.Render(commit.Short) + " " + subject + historyStyle.Render(meta) + "\n")
+	}
+	if end < len(m.commits) {
+		content.WriteString(helpStyle.UnsetMarginTop().Render(fmt.Sprintf("  … %d more", len(m.commits)-end)) + "\n")
+	}
+	content.WriteString("\n")
+
+	keyStyle := theme.Fg(theme.Accent).Bold(true)
+	backStyle := theme.Fg(theme.Help).Bold(true)
+	actionOptions := []string{
+		keyStyle.Render("enter") + " Diff",
+		keyStyle.Render("r") + " Refresh",
+		backStyle.Render("q") + " Back",
+	}
+	content.WriteString(strings.Join(actionOptions, "  •  "))
+
+	return content.String()
+}
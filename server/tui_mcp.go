@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// mcpTickMsg triggers a periodic refresh of the MCP panel
+type mcpTickMsg struct{}
+
+func mcpTick() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return mcpTickMsg{}
+	})
+}
+
+// mcpHealthMsg reports that a health check finished
+type mcpHealthMsg struct{}
+
+// MCPListModel shows connected MCP servers, their health and their tools
+type MCPListModel struct {
+	manager  *MCPManager
+	servers  []MCPServerStatus
+	selected int
+	checking bool
+	width    int
+	height   int
+}
+
+func NewMCPListModel(session *REPLSession) MCPListModel {
+	m := MCPListModel{
+		manager: session.mcpManager,
+		width:   80,
+		height:  24,
+	}
+	m.refresh()
+	return m
+}
+
+func (m *MCPListModel) refresh() {
+	if m.manager == nil {
+		return
+	}
+	m.servers = m.manager.Statuses()
+	if m.selected >= len(m.servers) {
+		m.selected = len(m.servers) - 1
+	}
+	if m.selected < 0 {
+		m.selected = 0
+	}
+}
+
+func (m MCPListModel) Init() tea.Cmd {
+	return mcpTick()
+}
+
+// checkHealth pings every server in the background
+func (m MCPListModel) checkHealth() tea.Cmd {
+	manager := m.manager
+	return func() tea.Msg {
+		manager.CheckHealth(context.Background())
+		return mcpHealthMsg{}
+	}
+}
+
+func (m MCPListModel) Update(msg tea.Msg) (MCPListModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case mcpTickMsg:
+		m.refresh()
+		return m, mcpTick()
+
+	case mcpHealthMsg:
+		m.checking = false
+		m.refresh()
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc":
+			return m, SwitchToView(ViewREPL, nil)
+
+		case "up", "k":
+			if m.selected > 0 {
+				m.selected--
+			}
+
+		case "down", "j":
+			if m.selected < len(m.servers)-1 {
+				m.selected++
+			}
+
+		case "r":
+			// Ping every server to refresh its health
+			if m.manager != nil && !m.checking {
+				m.checking = true
+				return m, m.checkHealth()
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// mcpStateIcon returns a short status marker for a server
+func mcpStateIcon(state MCPServerState) string {
+	switch state {
+	case MCPStarting:
+		return "⏳"
+	case MCPConnected:
+		return "🟢"
+	case MCPUnhealthy:
+		return "🟡"
+	case MCPFailed:
+		return "🔴"
+	case MCPStopped:
+		return "⚫"
+	case MCPDisabled:
+		return "⏸️"
+	default:
+		return "❓"
+	}
+}
+
+func (m MCPListModel) View() string {
+	var content strings.Builder
+
+	title := titleStyle.Render("🔌 MCP Servers")
+	content.WriteString(title + "\n")
+
+	if len(m.servers) == 0 {
+		content.WriteString("No MCP servers configured. Add them under \"mcpServers\" in .relay/config.json.\n")
+	} else {
+		for i, server := range m.servers {
+			line := fmt.Sprintf("%s %-20s %-10s %d tools", mcpStateIcon(server.State), server.Name, server.State, len(server.Tools))
+			if server.Latency > 0 {
+				line += helpStyle.Render(fmt.Sprintf(" (%s)", server.Latency.Round(time.Millisecond)))
+			}
+
+			if i == m.selected {
+				content.WriteString(selectedIssueStyle.Render("> "+line) + "\n")
+			} else {
+				content.WriteString(unselectedIssueStyle.Render("  "+line) + "\n")
+			}
+		}
+
+		// Details of the selected server
+		server := m.servers[m.selected]
+		content.WriteString("\n" + helpStyle.Render(strings.Repeat("─", 40)) + "\n")
+
+		info := server.Name
+		if server.ServerName != "" {
+			info = fmt.Sprintf("%s • %s %s", server.Name, server.ServerName, server.Version)
+		}
+		if !server.LastChecked.IsZero() {
+			info += fmt.Sprintf(" • checked %s", formatRelativeTime(server.LastChecked))
+		}
+		content.WriteString(helpStyle.Render(info) + "\n")
+
+		if server.Error != "" {
+			content.WriteString(errorStyle.Render("Error: "+server.Error) + "\n")
+		}
+
+		if len(server.Tools) > 0 {
+			content.WriteString(historyStyle.Render("Tools") + "\n")
+			for _, tool := range server.Tools {
+				description := strings.SplitN(tool.Description, "\n", 2)[0]
+				if len(description) > 70 {
+					description = description[:67] + "..."
+				}
+				content.WriteString(fmt.Sprintf("  %s  %s\n", tool.Name, helpStyle.Render(description)))
+			}
+		}
+
+		// Servers log to stderr, which is the only clue when one fails to start
+		if len(server.Stderr) > 0 && server.State != MCPConnected {
+			content.WriteString("\n" + historyStyle.Render("Server log") + "\n")
+			for _, line := range server.Stderr {
+				content.WriteString("  " + line + "\n")
+			}
+		}
+	}
+
+	if m.checking {
+		content.WriteString("\n" + helpStyle.Render("Checking health...") + "\n")
+	}
+
+	content.WriteString("\n")
+
+	refreshStyle := theme.Fg(theme.Accent).Bold(true)
+	backStyle := theme.Fg(theme.Help).Bold(true)
+
+	actionOptions := []string{
+		refreshStyle.Render("r") + " Check health",
+		backStyle.Render("q") + " Back",
+	}
+	content.WriteString(strings.Join(actionOptions, "  •  ") + "\n")
+
+	return content.String()
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// keyActionMsg runs a view action from the palette: the view gets the
+// action's default key, which the keymap leaves alone
+type keyActionMsg struct {
+	key tea.KeyMsg
+}
+
+// keyMode says which bindings apply in the current view
+type keyMode int
+
+const (
+	keysOwned  keyMode = iota // The view takes every key, as in the editor
+	keysTyping                // Text is being typed; only keys like ctrl+p are bindings
+	keysBound                 // Every binding applies
+)
+
+// keyScope returns the scope of the current view's actions and which
+// bindings apply
+func (m TUIModel) keyScope() (string, keyMode) {
+	switch m.currentView {
+	case ViewTextInput, ViewEditor, ViewAgentTerminal:
+		return "", keysOwned
+	case ViewREPL:
+		return "", keysTyping
+	case ViewIssueList:
+		if m.issueListModel.searching || m.issueListModel.filterBar {
+			return "", keysTyping
+		}
+	}
+	return viewScopes[m.currentView], keysBound
+}
+
+// applyKeymap handles a key press before the view does. Open overlays and
+// global bindings take it; a key bound to a view action reaches the view as
+// the action's default key. handled is true when the view must not see msg.
+func (m TUIModel) applyKeymap(msg tea.KeyMsg) (TUIModel, tea.Msg, tea.Cmd, bool) {
+	if m.palette != nil {
+		model, cmd := m.updatePalette(msg)
+		return model, msg, cmd, true
+	}
+	if m.showKeys {
+		// Any key closes the overlay
+		m.showKeys = false
+		return m, msg, nil, true
+	}
+
+	scope, mode := m.keyScope()
+	if mode == keysOwned {
+		return m, msg, nil, false
+	}
+	key := msg.String()
+	printable := msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace
+	if action, ok := m.keymap.Lookup(globalScope, key); ok && (mode == keysBound || !printable) {
+		model, cmd := m.runAction(action)
+		return model, msg, cmd, true
+	}
+	if mode != keysBound || scope == "" {
+		return m, msg, nil, false
+	}
+
+	if action, ok := m.keymap.Lookup(scope, key); ok {
+		if !containsString(action.Keys, key) {
+			return m, keyMsg(action.Keys[0]), nil, false
+		}
+		return m, msg, nil, false
+	}
+	if m.keymap.Unbound(scope, key) {
+		return m, msg, nil, true
+	}
+	return m, msg, nil, false
+}
+
+// runAction performs an action of the palette or a global binding
+func (m TUIModel) runAction(action KeyAction) (TUIModel, tea.Cmd) {
+	switch {
+	case action.ID == "global.palette":
+		return m.openPalette(), nil
+	case action.ID == "global.help":
+		m.showKeys = true
+		return m, nil
+	case action.run != nil:
+		return action.run(m)
+	case len(action.Keys) > 0:
+		key := keyMsg(action.Keys[0])
+		return m, func() tea.Msg { return keyActionMsg{key: key} }
+	}
+	return m, nil
+}
+
+// paletteEntry is an action listed in the command palette
+type paletteEntry struct {
+	action KeyAction
+	title  string // Scope and title, which the search matches
+	keys   string
+}
+
+// CommandPalette lists the actions available in the current view and
+// context, narrowed by a fuzzy search
+type CommandPalette struct {
+	entries  []paletteEntry
+	matches  []paletteEntry
+	query    string
+	selected int
+}
+
+// openPalette opens the palette with the current view's actions first,
+// then the global ones
+func (m TUIModel) openPalette() TUIModel {
+	scope, _ := m.keyScope()
+	scopes := []string{globalScope}
+	if scope != "" {
+		scopes = []string{scope, globalScope}
+	}
+
+	palette := &CommandPalette{}
+	for _, scope := range scopes {
+		for _, action := range m.keymap.Actions(scope) {
+			if action.navigation || action.ID == "global.palette" {
+				continue
+			}
+			if action.available != nil && !action.available(m) {
+				continue
+			}
+			palette.entries = append(palette.entries, paletteEntry{
+				action: action,
+				title:  scopeTitles[scope] + ": " + action.Title,
+				keys:   keyLabel(m.keymap.Keys(action.ID)),
+			})
+		}
+	}
+	palette.matches = palette.entries
+	m.palette = palette
+	return m
+}
+
+// filter narrows the entries to those matching the query, best first
+func (p *CommandPalette) filter() {
+	type scored struct {
+		entry paletteEntry
+		score int
+	}
+	var matches []scored
+	for _, entry := range p.entries {
+		if score, ok := fuzzyMatch(p.query, entry.title); ok {
+			matches = append(matches, scored{entry, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	p.matches = make([]paletteEntry, len(matches))
+	for i, match := range matches {
+		p.matches[i] = match.entry
+	}
+	p.selected = 0
+}
+
+func (m TUIModel) updatePalette(msg tea.KeyMsg) (TUIModel, tea.Cmd) {
+	p := m.palette
+	if action, ok := m.keymap.Lookup(globalScope, msg.String()); ok && action.ID == "global.palette" {
+		m.palette = nil
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		m.palette = nil
+	case "enter":
+		m.palette = nil
+		if p.selected < len(p.matches) {
+			return m.runAction(p.matches[p.selected].action)
+		}
+	case "up", "ctrl+k":
+		if p.selected > 0 {
+			p.selected--
+		}
+	case "down", "ctrl+j", "tab":
+		if p.selected < len(p.matches)-1 {
+			p.selected++
+		}
+	case "backspace":
+		if p.query != "" {
+			runes := []rune(p.query)
+			p.query = string(runes[:len(runes)-1])
+			p.filter()
+		}
+	default:
+		switch msg.Type {
+		case tea.KeyRunes:
+			p.query += string(msg.Runes)
+			p.filter()
+		case tea.KeySpace:
+			p.query += " "
+			p.filter()
+		}
+	}
+	return m, nil
+}
+
+// overlayStyle frames the palette and the key overlay
+var overlayStyle = lipgloss.NewStyle().
+	BorderStyle(lipgloss.RoundedBorder()).
+	BorderForeground(theme.Color(theme.Accent)).
+	Padding(0, 1)
+
+func (p *CommandPalette) View(width, height int) string {
+	var content strings.Builder
+	boxWidth := min(max(width-8, 30), 70)
+	keyStyle := theme.Fg(theme.Help)
+
+	content.WriteString(titleStyle.Render("Command palette") + "\n")
+	content.WriteString("> " + p.query + "█\n\n")
+
+	rows := max(height-10, 3)
+	start := 0
+	if p.selected >= rows {
+		start = p.selected - rows + 1
+	}
+	if len(p.matches) == 0 {
+		content.WriteString(helpStyle.Render("No matching actions") + "\n")
+	}
+	for i := start; i < len(p.matches) && i < start+rows; i++ {
+		entry := p.matches[i]
+		keys := keyStyle.Render(entry.keys)
+		title := truncateText(entry.title, boxWidth-lipgloss.Width(entry.keys)-6)
+		padding := max(boxWidth-4-lipgloss.Width(title)-lipgloss.Width(entry.keys), 1)
+		if i == p.selected {
+			title = selectedIssueStyle.Render("> " + title)
+		} else {
+			title = unselectedIssueStyle.Render("  " + title)
+		}
+		content.WriteString(title + strings.Repeat(" ", padding) + keys + "\n")
+	}
+	content.WriteString(helpStyle.Render("↑/↓ select • enter run • esc close"))
+
+	return overlayStyle.Width(boxWidth).Render(content.String())
+}
+
+// keyHelpView lists the active bindings of the current view and the global ones
+func (m TUIModel) keyHelpView() string {
+	var content strings.Builder
+	keyStyle := theme.Fg(theme.Accent).Bold(true)
+
+	scope, _ := m.keyScope()
+	scopes := []string{globalScope}
+	if scope != "" {
+		scopes = []string{scope, globalScope}
+	}
+	for i, scope := range scopes {
+		if i > 0 {
+			content.WriteString("\n")
+		}
+		content.WriteString(titleStyle.UnsetMarginBottom().Render(scopeTitles[scope]) + "\n")
+		for _, action := range m.keymap.Actions(scope) {
+			keys := m.keymap.Keys(action.ID)
+			if len(keys) == 0 {
+				continue
+			}
+			content.WriteString(fmt.Sprintf("%s %s\n", keyStyle.Render(fmt.Sprintf("%-14s", keyLabel(keys))), action.Title))
+		}
+	}
+	content.WriteString(helpStyle.Render("Rebind keys in ~/.relay/keymap.json • any key closes"))
+
+	return overlayStyle.Render(content.String())
+}
+
+// overlay draws box over the top of view, centered
+func overlay(view, box string, width int) string {
+	lines := strings.Split(view, "\n")
+	boxLines := strings.Split(box, "\n")
+	for len(lines) < len(boxLines)+1 {
+		lines = append(lines, "")
+	}
+	for i, line := range boxLines {
+		lines[i+1] = lipgloss.PlaceHorizontal(width, lipgloss.Center, line)
+	}
+	return strings.Join(lines, "\n")
+}
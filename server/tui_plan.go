@@ -0,0 +1,463 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/relay/mcp/shared"
+)
+
+// planGeneratedMsg carries a plan written by the planning provider
+type planGeneratedMsg struct {
+	plan *Plan
+	err  error
+}
+
+// planSaveMsg asks the plan view to save its plan after a step was edited
+type planSaveMsg struct{}
+
+// planOpDoneMsg carries the issue as GitHub has it after a plan view op
+type planOpDoneMsg struct {
+	opID  int64
+	issue *Issue
+	leave bool // Return to the issue detail view
+	err   error
+}
+
+// PlanModel shows and edits an issue's step plan. Edits are saved to the
+// database straight away and mirrored to the issue body in the background.
+type PlanModel struct {
+	issue       Issue
+	replSession *REPLSession
+	plan        *Plan
+	selected    int
+	generating  bool
+	op          *asyncOp // Running mirror or issue fetch, if any
+	message     string
+	err         error
+	width       int
+	height      int
+}
+
+func NewPlanModel(issue Issue, session *REPLSession) PlanModel {
+	m := PlanModel{
+		issue:       issue,
+		replSession: session,
+		width:       80,
+		height:      24,
+	}
+
+	plan, err := session.projectManager.db.GetPlan(session.currentProject.Name, issue.Number)
+	if err != nil {
+		m.err = err
+	}
+	if plan == nil {
+		// Start from a plan already written on the issue, if any
+		if existing, ok := shared.ExtractPlanSection(issue.Body); ok {
+			if steps := parsePlanMarkdown(existing); len(steps) > 0 {
+				plan = NewPlan(session.currentProject.Name, issue.Number, steps)
+			}
+		}
+	}
+	m.plan = plan
+	return m
+}
+
+func (m PlanModel) Init() tea.Cmd {
+	return nil
+}
+
+// locked reports whether an agent run is using the plan, in which case it can't be edited
+func (m PlanModel) locked() bool {
+	runner := m.replSession.IssueRun(m.issue.Number)
+	return runner != nil && !runner.IsFinished()
+}
+
+// startOp runs work with a spinner; work returns the issue as GitHub has it
+func (m PlanModel) startOp(label string, leave bool, work func() (*Issue, error)) (PlanModel, tea.Cmd) {
+	if m.op != nil {
+		m.op.cancel()
+	}
+	op, _ := newAsyncOp(label)
+	m.op = op
+	return m, tea.Batch(op.tick(), func() tea.Msg {
+		issue, err := work()
+		return planOpDoneMsg{opID: op.id, issue: issue, leave: leave, err: err}
+	})
+}
+
+func (m PlanModel) Update(msg tea.Msg) (PlanModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case spinnerTickMsg:
+		return m, m.op.advance(msg)
+
+	case planOpDoneMsg:
+		// Results of cancelled ops arrive late and are dropped
+		if !m.op.owns(msg.opID) {
+			return m, nil
+		}
+		m.op.finish()
+		m.op = nil
+		if msg.issue != nil {
+			m.issue = *msg.issue
+		}
+		if msg.leave {
+			return m, SwitchToView(ViewIssueDetail, m.issue)
+		}
+		if msg.err != nil {
+			m.err = msg.err
+		}
+		return m, nil
+
+	case planSaveMsg:
+		return m.save()
+
+	case planGeneratedMsg:
+		m.generating = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.plan = msg.plan
+		m.selected = 0
+		m.message = fmt.Sprintf("Generated %d steps", len(m.plan.Steps))
+		return m.save()
+
+	case tea.KeyMsg:
+		m.message = ""
+		key := msg.String()
+
+		if m.op != nil {
+			// Only cancelling is possible while an op runs
+			if key == "esc" {
+				m.op.cancel()
+				m.message = m.op.label + " cancelled"
+				m.op = nil
+			}
+			return m, nil
+		}
+
+		switch key {
+		case "q", "esc":
+			// The body may have changed with the mirrored plan
+			issueManager, number := m.replSession.issueManager, m.issue.Number
+			return m.startOp(fmt.Sprintf("Loading issue #%d", number), true, func() (*Issue, error) {
+				return issueManager.GetIssue(number)
+			})
+
+		case "up", "k":
+			if m.selected > 0 {
+				m.selected--
+			}
+			return m, nil
+
+		case "down", "j":
+			if m.plan != nil && m.selected < len(m.plan.Steps)-1 {
+				m.selected++
+			}
+			return m, nil
+
+		case "r":
+			// Hand the plan to a headless run, which executes it step by step
+			if m.plan == nil || len(m.plan.Steps) == 0 || m.issue.State == "closed" {
+				return m, nil
+			}
+			runner := m.replSession.StartIssueRun(m.issue.Number)
+			return m, SwitchToView(ViewIssueRun, IssueRunData{Issue: m.issue, Runner: runner})
+		}
+
+		if m.generating {
+			return m, nil
+		}
+		if m.locked() {
+			m.message = "An agent run is using this plan; edit it when the run finishes"
+			return m, nil
+		}
+
+		switch key {
+		case "g":
+			m.generating = true
+			m.err = nil
+			return m, m.generate()
+
+		case "n":
+			return m.addStep()
+		}
+
+		step := m.selectedStep()
+		if step == nil {
+			return m, nil
+		}
+
+		switch key {
+		case "enter", "e":
+			return m, m.editField(fmt.Sprintf("Goal for step %d", step.Position), step.Goal, func(value string) {
+				step.Goal = value
+			})
+
+		case "f":
+			return m, m.editField(fmt.Sprintf("Files for step %d (comma separated)", step.Position), strings.Join(step.Files, ", "), func(value string) {
+				step.Files = splitPlanFiles(value)
+			})
+
+		case "a":
+			return m, m.editField(fmt.Sprintf("Acceptance criteria for step %d", step.Position), step.Acceptance, func(value string) {
+				step.Acceptance = value
+			})
+
+		case "d":
+			m.plan.Steps = append(m.plan.Steps[:m.selected], m.plan.Steps[m.selected+1:]...)
+			if m.selected >= len(m.plan.Steps) && m.selected > 0 {
+				m.selected--
+			}
+			return m.save()
+
+		case "K", "shift+up":
+			if m.selected > 0 {
+				steps := m.plan.Steps
+				steps[m.selected-1], steps[m.selected] = steps[m.selected], steps[m.selected-1]
+				m.selected--
+				return m.save()
+			}
+
+		case "J", "shift+down":
+			if m.selected < len(m.plan.Steps)-1 {
+				steps := m.plan.Steps
+				steps[m.selected+1], steps[m.selected] = steps[m.selected], steps[m.selected+1]
+				m.selected++
+				return m.save()
+			}
+
+		case "s":
+			// Skip a step, or bring a skipped one back
+			if step.Status == PlanStepSkipped {
+				step.Status = PlanStepPending
+			} else {
+				step.Status = PlanStepSkipped
+			}
+			return m.save()
+
+		case "u":
+			// Mark the step to run again
+			step.Status = PlanStepPending
+			step.Output = ""
+			step.Error = ""
+			step.StartedAt = nil
+			step.FinishedAt = nil
+			return m.save()
+		}
+	}
+
+	return m, nil
+}
+
+func (m PlanModel) selectedStep() *PlanStep {
+	if m.plan == nil || m.selected < 0 || m.selected >= len(m.plan.Steps) {
+		return nil
+	}
+	return m.plan.Steps[m.selected]
+}
+
+// addStep asks for a goal and inserts the new step after the selected one
+func (m PlanModel) addStep() (PlanModel, tea.Cmd) {
+	if m.plan == nil {
+		m.plan = NewPlan(m.replSession.currentProject.Name, m.issue.Number, nil)
+	}
+	plan := m.plan
+	index := len(plan.Steps)
+	if len(plan.Steps) > 0 {
+		index = m.selected + 1
+	}
+	m.selected = index
+
+	inputData := TextInputData{
+		Prompt: "Goal for the new step",
+		OnComplete: func(goal string) tea.Cmd {
+			goal = strings.TrimSpace(goal)
+			if goal != "" {
+				step := &PlanStep{Goal: goal, Status: PlanStepPending}
+				plan.Steps = append(plan.Steps[:index], append([]*PlanStep{step}, plan.Steps[index:]...)...)
+				return tea.Sequence(BackToPreviousView(), savePlan)
+			}
+			return BackToPreviousView()
+		},
+	}
+	return m, SwitchToView(ViewTextInput, inputData)
+}
+
+// editField opens a text input for a step field; an empty answer keeps the current value
+func (m PlanModel) editField(prompt, current string, apply func(string)) tea.Cmd {
+	inputData := TextInputData{
+		Prompt:      prompt,
+		Placeholder: current,
+		OnComplete: func(value string) tea.Cmd {
+			value = strings.TrimSpace(value)
+			if value != "" && value != current {
+				apply(value)
+				return tea.Sequence(BackToPreviousView(), savePlan)
+			}
+			return BackToPreviousView()
+		},
+	}
+	return SwitchToView(ViewTextInput, inputData)
+}
+
+// generate asks the planning provider for a fresh plan in the background
+func (m PlanModel) generate() tea.Cmd {
+	planner := m.replSession.llmManager.GetPlanningProvider()
+	project := m.replSession.currentProject.Name
+	issue := m.issue
+	return func() tea.Msg {
+		plan, err := GeneratePlan(context.Background(), planner, project, &issue)
+		return planGeneratedMsg{plan: plan, err: err}
+	}
+}
+
+// savePlan is returned by text inputs that changed the plan
+func savePlan() tea.Msg {
+	return planSaveMsg{}
+}
+
+// save stores the plan and mirrors it to the issue body in the background
+func (m PlanModel) save() (PlanModel, tea.Cmd) {
+	if err := m.replSession.projectManager.db.SavePlan(m.plan); err != nil {
+		m.err = err
+		return m, nil
+	}
+	m.err = nil
+
+	// The markdown is taken now, so later edits can't race the mirror
+	issueManager, number, markdown := m.replSession.issueManager, m.plan.IssueNumber, m.plan.Markdown()
+	return m.startOp(fmt.Sprintf("Saving the plan to issue #%d", number), false, func() (*Issue, error) {
+		return mirrorPlanToIssue(issueManager, number, markdown)
+	})
+}
+
+// mirrorPlanToIssue writes the plan markdown into the issue body and returns
+// the updated issue
+func mirrorPlanToIssue(issueManager *IssueManager, number int, markdown string) (*Issue, error) {
+	issue, err := issueManager.GetIssue(number)
+	if err != nil {
+		return nil, err
+	}
+	body := shared.MergePlanSection(issue.Body, markdown)
+	if body == issue.Body {
+		return issue, nil
+	}
+	if err := issueManager.UpdateIssueBody(number, body); err != nil {
+		return issue, fmt.Errorf("failed to save plan to issue: %w", err)
+	}
+	issue.Body = body
+	return issue, nil
+}
+
+// planStepIcon shows a step's status
+func planStepIcon(status PlanStepStatus) string {
+	switch status {
+	case PlanStepDone:
+		return theme.Fg(theme.Success).Render("✓")
+	case PlanStepRunning:
+		return theme.Fg(theme.Accent).Render("●")
+	case PlanStepFailed:
+		return theme.Fg(theme.Error).Render("✗")
+	case PlanStepSkipped:
+		return helpStyle.Render("–")
+	default:
+		return helpStyle.Render("○")
+	}
+}
+
+func (m PlanModel) View() string {
+	var content strings.Builder
+
+	title := titleStyle.Render(fmt.Sprintf("📋 Plan for issue #%d: %s", m.issue.Number, m.issue.Title))
+	content.WriteString(title + "\n")
+
+	if m.plan != nil && len(m.plan.Steps) > 0 {
+		done, total := m.plan.Progress()
+		content.WriteString(helpStyle.Render(fmt.Sprintf("%d/%d steps done • planned with %s, executed with %s",
+			done, total, m.replSession.llmManager.GetPlanningProvider().GetProviderName(),
+			m.replSession.llmManager.GetExecutingProvider().GetProviderName())) + "\n\n")
+	} else {
+		content.WriteString("\n")
+	}
+
+	if m.op != nil {
+		content.WriteString(m.op.View() + "\n\n")
+	}
+
+	switch {
+	case m.generating:
+		content.WriteString(normalStyle.Render("Generating a plan...") + "\n")
+	case m.plan == nil || len(m.plan.Steps) == 0:
+		content.WriteString(helpStyle.Render("No plan yet. Press g to generate one or n to add a step.") + "\n")
+	default:
+		for i, step := range m.plan.Steps {
+			line := fmt.Sprintf("%s %d. %s", planStepIcon(step.Status), step.Position, step.Goal)
+			if i == m.selected {
+				content.WriteString(selectedIssueStyle.Render("> "+line) + "\n")
+			} else {
+				content.WriteString(unselectedIssueStyle.Render("  "+line) + "\n")
+			}
+		}
+
+		// Details of the selected step
+		if step := m.selectedStep(); step != nil {
+			content.WriteString("\n" + helpStyle.Render(strings.Repeat("─", 40)) + "\n")
+			files := "(none)"
+			if len(step.Files) > 0 {
+				files = strings.Join(step.Files, ", ")
+			}
+			acceptance := step.Acceptance
+			if acceptance == "" {
+				acceptance = "(none)"
+			}
+			content.WriteString(normalStyle.Render("Status: "+string(step.Status)) + "\n")
+			content.WriteString(normalStyle.Render("Files: "+files) + "\n")
+			content.WriteString(normalStyle.Render("Done when: "+acceptance) + "\n")
+			if step.Error != "" {
+				content.WriteString(errorStyle.Render("Error: "+step.Error) + "\n")
+			}
+			if step.Output != "" {
+				output := strings.Split(step.Output, "\n")
+				if len(output) > 6 {
+					output = append(output[:6], fmt.Sprintf("... (%d more lines)", len(output)-6))
+				}
+				content.WriteString(historyStyle.Render(strings.Join(output, "\n")) + "\n")
+			}
+		}
+	}
+
+	if m.err != nil {
+		content.WriteString("\n" + errorStyle.Render("Error: "+m.err.Error()) + "\n")
+	}
+	if m.message != "" {
+		content.WriteString("\n" + helpStyle.Render(m.message) + "\n")
+	}
+
+	content.WriteString("\n")
+
+	editStyle := theme.Fg(theme.Accent).Bold(true)
+	runStyle := theme.Fg(theme.Success).Bold(true)
+	deleteStyle := theme.Fg(theme.Error).Bold(true)
+	backStyle := theme.Fg(theme.Help).Bold(true)
+
+	actionOptions := []string{
+		editStyle.Render("e") + " Goal",
+		editStyle.Render("f") + " Files",
+		editStyle.Render("a") + " Acceptance",
+		editStyle.Render("n") + " New",
+		deleteStyle.Render("d") + " Delete",
+		editStyle.Render("K/J") + " Move",
+		editStyle.Render("s") + " Skip",
+		editStyle.Render("u") + " Rerun",
+		editStyle.Render("g") + " Generate",
+		runStyle.Render("r") + " Run",
+		backStyle.Render("q") + " Back",
+	}
+	content.WriteString(strings.Join(actionOptions, "  •  ") + "\n")
+
+	return content.String()
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// projectCountsTimeout bounds the gh calls behind one project's counts
+const projectCountsTimeout = 30 * time.Second
+
+// projectsLoadedMsg carries the git columns of every project
+type projectsLoadedMsg struct {
+	opID     int64
+	statuses []ProjectStatus
+	err      error
+}
+
+// projectCountsMsg carries one project's GitHub counts
+type projectCountsMsg struct {
+	project     string
+	issues, prs int
+	err         error
+}
+
+// ProjectDashboardModel lists every project with its branch, working copy
+// state, issues in progress and GitHub counts. Opening a project switches the
+// session to it.
+type ProjectDashboardModel struct {
+	replSession *REPLSession
+	projects    []ProjectStatus
+	selected    int
+	loading     map[string]bool // Projects whose counts are being fetched
+	loaded      bool
+	op          *asyncOp
+	err         string
+	width       int
+	height      int
+}
+
+func NewProjectDashboardModel(replSession *REPLSession) ProjectDashboardModel {
+	return ProjectDashboardModel{
+		replSession: replSession,
+		loading:     make(map[string]bool),
+		width:       80,
+		height:      24,
+	}
+}
+
+// Init loads the git columns in the background
+func (m ProjectDashboardModel) Init() tea.Cmd {
+	return func() tea.Msg {
+		return issueListActionMsg{label: "Loading projects"}
+	}
+}
+
+// startOp lists the projects and reads their git columns, with a spinner
+func (m ProjectDashboardModel) startOp(label string) (ProjectDashboardModel, tea.Cmd) {
+	if m.op != nil {
+		m.op.cancel()
+	}
+	op, ctx := newAsyncOp(label)
+	m.op = op
+
+	projectManager := m.replSession.projectManager
+	return m, tea.Batch(op.tick(), func() tea.Msg {
+		projects, err := projectManager.ListProjects()
+		if err != nil {
+			return projectsLoadedMsg{opID: op.id, err: err}
+		}
+		return projectsLoadedMsg{opID: op.id, statuses: LoadProjectStatuses(ctx, projects)}
+	})
+}
+
+// loadCounts fetches the selected project's GitHub counts unless they are
+// loaded or on their way
+func (m ProjectDashboardModel) loadCounts() tea.Cmd {
+	if m.selected >= len(m.projects) {
+		return nil
+	}
+	status := m.projects[m.selected]
+	if status.CountsLoaded || m.loading[status.Project.Name] {
+		return nil
+	}
+	m.loading[status.Project.Name] = true
+
+	project := status.Project
+	var db *Database // Machine-local settings, such as a detected repository
+	if pm := m.replSession.projectManager; pm != nil {
+		db = pm.db
+	}
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), projectCountsTimeout)
+		defer cancel()
+		issues, prs, err := LoadProjectCounts(ctx, project, db)
+		return projectCountsMsg{project: project.Name, issues: issues, prs: prs, err: err}
+	}
+}
+
+// open switches the session to the selected project and shows view
+func (m ProjectDashboardModel) open(view ViewType) (ProjectDashboardModel, tea.Cmd) {
+	if m.selected >= len(m.projects) {
+		return m, nil
+	}
+	project := m.projects[m.selected].Project
+	if err := m.replSession.SwitchProject(project.Name); err != nil {
+		m.err = err.Error()
+		return m, nil
+	}
+	return m, SwitchToView(view, nil)
+}
+
+func (m ProjectDashboardModel) Update(msg tea.Msg) (ProjectDashboardModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case spinnerTickMsg:
+		return m, m.op.advance(msg)
+
+	case issueListActionMsg:
+		return m.startOp(msg.label)
+
+	case projectsLoadedMsg:
+		if !m.op.owns(msg.opID) {
+			return m, nil
+		}
+		m.op.finish()
+		m.op = nil
+		if msg.err != nil {
+			m.err = msg.err.Error()
+			return m, nil
+		}
+
+		// Keep counts already fetched, and start on the current project
+		previous := make(map[string]ProjectStatus)
+		for _, status := range m.projects {
+			previous[status.Project.Name] = status
+		}
+		for i, status := range msg.statuses {
+			if old, ok := previous[status.Project.Name]; ok && old.CountsLoaded {
+				msg.statuses[i].CountsLoaded, msg.statuses[i].OpenIssues, msg.statuses[i].OpenPRs = true, old.OpenIssues, old.OpenPRs
+			}
+			if !m.loaded && status.Project.Name == m.replSession.currentProject.Name {
+				m.selected = i
+			}
+		}
+		m.projects, m.loaded = msg.statuses, true
+		m.selected = min(m.selected, max(len(m.projects)-1, 0))
+		return m, m.loadCounts()
+
+	case projectCountsMsg:
+		delete(m.loading, msg.project)
+		for i, status := range m.projects {
+			if status.Project.Name == msg.project {
+				m.projects[i].CountsLoaded = true
+				m.projects[i].OpenIssues, m.projects[i].OpenPRs, m.projects[i].CountsErr = msg.issues, msg.prs, msg.err
+			}
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.op != nil {
+			// Only cancelling is possible while an operation runs
+			if msg.String() == "esc" {
+				m.op.cancel()
+				m.err = m.op.label + " cancelled"
+				m.op = nil
+			}
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "q", "esc":
+			return m, SwitchToView(ViewREPL, nil)
+		case "up", "k":
+			if m.selected > 0 {
+				m.selected--
+			}
+			return m, m.loadCounts()
+		case "down", "j":
+			if m.selected < len(m.projects)-1 {
+				m.selected++
+			}
+			return m, m.loadCounts()
+		case "enter", " ":
+			return m.open(ViewIssueList)
+		case "b":
+			return m.open(ViewBoard)
+		case "s":
+			return m.open(ViewREPL)
+		case "r":
+			// Counts are fetched again as projects are selected
+			for i := range m.projects {
+				m.projects[i].CountsLoaded = false
+			}
+			m.err = ""
+			return m.startOp("Loading projects")
+		}
+	}
+
+	return m, nil
+}
+
+func (m ProjectDashboardModel) View() string {
+	var content strings.Builder
+
+	content.WriteString(titleStyle.Render("🏠 Projects") + "\n")
+	if m.op != nil {
+		content.WriteString(m.op.View() + "\n")
+	} else if m.err != "" {
+		content.WriteString(errorStyle.Render("⚠️  "+m.err) + "\n")
+	}
+
+	if m.loaded && len(m.projects) == 0 {
+		content.WriteString(helpStyle.Render("No projects. Add one with relay add -p <path>.") + "\n")
+	}
+	if len(m.projects) > 0 {
+		content.WriteString(m.tableView() + "\n")
+	}
+	content.WriteString("\n")
+
+	keyStyle := theme.Fg(theme.Accent).Bold(true)
+	backStyle := theme.Fg(theme.Help).Bold(true)
+	actionOptions := []string{
+		keyStyle.Render("enter") + " Issues",
+		keyStyle.Render("b") + " Board",
+		keyStyle.Render("s") + " Switch",
+		keyStyle.Render("r") + " Refresh",
+		backStyle.Render("q") + " Back",
+	}
+	content.WriteString(strings.Join(actionOptions, "  •  "))
+
+	return content.String()
+}
+
+// tableView renders a row per project. Counts show … while they load.
+func (m ProjectDashboardModel) tableView() string {
+	dirtyStyle := theme.Fg(theme.Warning)
+	cleanStyle := theme.Fg(theme.Success)
+	currentStyle := theme.Fg(theme.Success).Bold(true)
+
+	nameWidth, branchWidth := 8, 8
+	for _, status := range m.projects {
+		nameWidth = max(nameWidth, min(len(status.Project.Name), 24))
+		branchWidth = max(branchWidth, min(len(status.Branch), 28))
+	}
+
+	var lines []string
+	header := fmt.Sprintf("  %-*s  %-*s  %-5s  %6s  %4s  %-14s  %s", nameWidth, "Project", branchWidth, "Branch", "State", "Issues", "PRs", "In progress", "Activity")
+	lines = append(lines, historyStyle.Render(header))
+
+	for i, status := range m.projects {
+		name := truncateText(status.Project.Name, nameWidth)
+		marker := "  "
+		if status.Project.Name == m.replSession.currentProject.Name {
+			marker = currentStyle.Render("● ")
+		}
+
+		var state string
+		switch {
+		case status.Err != nil:
+			state = errorStyle.Render("error")
+		case status.Dirty:
+			state = dirtyStyle.Render("dirty")
+		default:
+			state = cleanStyle.Render("clean")
+		}
+
+		issues, prs := "…", "…"
+		if status.CountsLoaded {
+			if status.CountsErr != nil {
+				issues, prs = "?", "?"
+			} else {
+				issues, prs = fmt.Sprint(status.OpenIssues), fmt.Sprint(status.OpenPRs)
+			}
+		} else if !m.loading[status.Project.Name] {
+			issues, prs = "-", "-"
+		}
+
+		inProgress := make([]string, len(status.InProgress))
+		for j, number := range status.InProgress {
+			inProgress[j] = fmt.Sprintf("#%d", number)
+		}
+
+		row := fmt.Sprintf("%-*s  %-*s  ", nameWidth, name, branchWidth, truncateText(status.Branch, branchWidth))
+		if i == m.selected {
+			row = selectedIssueStyle.Render(row)
+		} else {
+			row = unselectedIssueStyle.Render(row)
+		}
+		row = marker + row + state + fmt.Sprintf("  %6s  %4s  %-14s  %s", issues, prs, truncateText(strings.Join(inProgress, " "), 14), timeAgo(status.LastActivity))
+		lines = append(lines, row)
+	}
+
+	if m.selected < len(m.projects) {
+		selected := m.projects[m.selected]
+		detail := selected.Project.Path
+		if selected.Err != nil {
+			detail += " • " + selected.Err.Error()
+		} else if selected.CountsErr != nil {
+			detail += " • " + selected.CountsErr.Error()
+		}
+		lines = append(lines, "", helpStyle.Render(truncateText(detail, max(m.width-2, 20))))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// timeAgo formats how long ago t was, roughly
+func timeAgo(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	elapsed := time.Since(t)
+	switch {
+	case elapsed < time.Minute:
+		return "just now"
+	case elapsed < time.Hour:
+		return fmt.Sprintf("%dm ago", int(elapsed.Minutes()))
+	case elapsed < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(elapsed.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(elapsed.Hours()/24))
+	}
+}
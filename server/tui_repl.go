@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"strconv"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -15,15 +17,34 @@ type REPLContext struct {
 }
 
 type REPLModel struct {
-	replSession *REPLSession
-	input       string
-	history     []string
-	output      []string
-	cursor      int
-	width       int
-	height      int
-	maxHistory  int
-	context     *REPLContext // Current context
+	replSession     *REPLSession
+	input           string
+	tail            int // Runes of input after the caret
+	history         []string
+	historyProject  string // Project the history was loaded for
+	output          []string
+	cursor          int
+	width           int
+	height          int
+	maxHistory      int
+	context         *REPLContext      // Current context
+	op              *asyncOp          // Running prompt or command, if any
+	search          *historySearch    // Reverse search, while ctrl+r is active
+	completions     []string          // Candidates listed by the last tab
+	issues          []Issue           // Open issues, once tab completion fetched them
+	loadingIssues   bool              // Whether tab completion is fetching issues
+	branches        []string          // Local branches, once tab completion fetched them
+	loadingBranches bool              // Whether tab completion is fetching branches
+	transcript      []transcriptEntry // Prompts and replies, for /pin
+}
+
+// replOpDoneMsg carries the output lines of a finished REPL operation and,
+// for a prompt, the exchange for the transcript
+type replOpDoneMsg struct {
+	opID     int64
+	lines    []string
+	exchange *transcriptEntry
+	then     tea.Cmd // Run once the op is done, e.g. to open a view
 }
 
 func NewREPLModel(replSession *REPLSession) REPLModel {
@@ -33,7 +54,7 @@ func NewREPLModel(replSession *REPLSession) REPLModel {
 		history:     []string{},
 		output:      []string{},
 		cursor:      0,
-		maxHistory:  50,
+		maxHistory:  replHistoryLimit,
 		context:     nil,
 	}
 }
@@ -41,8 +62,8 @@ func NewREPLModel(replSession *REPLSession) REPLModel {
 // SetContext sets the current REPL context and clears input
 func (m *REPLModel) SetContext(context *REPLContext) {
 	m.context = context
-	m.input = "" // Clear any existing input
-	m.cursor = 0 // Reset history cursor
+	m.setInput("") // Clear any existing input
+	m.cursor = 0   // Reset history cursor
 
 	// Add context message to output
 	if context != nil {
@@ -61,29 +82,106 @@ func (m REPLModel) Init() tea.Cmd {
 
 func (m REPLModel) Update(msg tea.Msg) (REPLModel, tea.Cmd) {
 	switch msg := msg.(type) {
+	case spinnerTickMsg:
+		return m, m.op.advance(msg)
+
+	case replOpDoneMsg:
+		// Results of cancelled operations arrive late and are dropped
+		if !m.op.owns(msg.opID) {
+			return m, nil
+		}
+		m.op.finish()
+		m.op = nil
+		m.output = append(m.output, msg.lines...)
+		if msg.exchange != nil {
+			m.transcript = append(m.transcript, *msg.exchange)
+		}
+		return m, msg.then
+
+	case replIssuesMsg:
+		m.loadingIssues = false
+		if msg.project != m.historyProject {
+			return m, nil
+		}
+		if msg.err != nil {
+			slog.Warn("Failed to fetch issues for completion", "error", msg.err)
+			m.issues = []Issue{}
+			return m, nil
+		}
+		m.issues = append([]Issue{}, msg.issues...)
+		// Complete again now that the issues are known, unless the user moved on
+		if msg.input == m.input {
+			return m.complete()
+		}
+		return m, nil
+
+	case replBranchesMsg:
+		m.loadingBranches = false
+		if msg.project != m.historyProject {
+			return m, nil
+		}
+		if msg.err != nil {
+			slog.Warn("Failed to list branches for completion", "error", msg.err)
+			m.branches = []string{}
+			return m, nil
+		}
+		m.branches = append([]string{}, msg.branches...)
+		if msg.input == m.input {
+			return m.complete()
+		}
+		return m, nil
+
 	case tea.KeyMsg:
+		m.syncHistory()
+		if m.search != nil {
+			var handled bool
+			if m, handled = m.updateSearch(msg); handled {
+				return m, nil
+			}
+		}
+		if isNewlineKey(msg) {
+			m.insert("\n")
+			return m, nil
+		}
+		if msg.String() != "tab" {
+			m.completions = nil
+		}
+
 		switch msg.String() {
+		case "tab":
+			return m.complete()
+
+		case "ctrl+r":
+			m.search = &historySearch{match: -1, original: m.input}
+			return m, nil
+
+		case "esc":
+			if m.op != nil {
+				m.op.cancel()
+				m.output = append(m.output, fmt.Sprintf("🚫 Cancelled: %s", m.op.label))
+				m.op = nil
+			}
+			return m, nil
+
 		case "enter":
 			if m.input == "" {
 				return m, nil
 			}
+			if m.op != nil {
+				m.output = append(m.output, "⏳ Still working - press Esc to cancel first")
+				return m, nil
+			}
 
 			// Add to history
-			m.history = append(m.history, m.input)
-			if len(m.history) > m.maxHistory {
-				m.history = m.history[1:]
-			}
+			input := m.input
+			m.recordHistory(input)
+			m.setInput(input)
 
 			// Add input to output
-			m.output = append(m.output, fmt.Sprintf("> %s", m.input))
+			m.output = append(m.output, fmt.Sprintf("> %s", input))
 
 			// Process the command
-			return m.processCommand(m.input)
-
-		case "backspace":
-			if len(m.input) > 0 {
-				m.input = m.input[:len(m.input)-1]
-			}
+			return m.processCommand(input)
 
 		case "ctrl+c":
 			return m, tea.Quit
@@ -91,24 +189,27 @@ func (m REPLModel) Update(msg tea.Msg) (REPLModel, tea.Cmd) {
 		case "up":
 			if len(m.history) > 0 && m.cursor < len(m.history) {
 				m.cursor++
-				m.input = m.history[len(m.history)-m.cursor]
+				m.setInput(m.history[len(m.history)-m.cursor])
 			}
 
 		case "down":
 			if m.cursor > 1 {
 				m.cursor--
-				m.input = m.history[len(m.history)-m.cursor]
+				m.setInput(m.history[len(m.history)-m.cursor])
 			} else if m.cursor == 1 {
 				m.cursor = 0
-				m.input = ""
+				m.setInput("")
 			}
 
 		default:
-			// Add character to input
-			if len(msg.String()) == 1 {
-				m.input += msg.String()
-				m.cursor = 0 // Reset history cursor when typing
-			}
+			m.editInput(msg)
+		}
+
+	default:
+		// Shift+enter arrives as an unknown sequence, not a key
+		if isNewlineKey(msg) {
+			m.syncHistory()
+			m.insert("\n")
 		}
 	}
 
@@ -116,6 +217,9 @@ func (m REPLModel) Update(msg tea.Msg) (REPLModel, tea.Cmd) {
 }
 
 func (m REPLModel) processCommand(input string) (REPLModel, tea.Cmd) {
+	// Commands may create or delete branches, so the next tab fetches them again
+	m.branches = nil
+
 	// Handle REPL commands (starting with /)
 	if strings.HasPrefix(input, "/") {
 		return m.handleREPLCommand(input)
@@ -147,6 +251,14 @@ func (m REPLModel) handleREPLCommand(input string) (REPLModel, tea.Cmd) {
 		m.input = ""
 		return m, SwitchToView(ViewIssueList, nil)
 
+	case "/board":
+		m.input = ""
+		return m, SwitchToView(ViewBoard, nil)
+
+	case "/projects":
+		m.input = ""
+		return m, SwitchToView(ViewProjects, nil)
+
 	case "/issue":
 		if len(parts) < 2 {
 			m.output = append(m.output, "Error: usage: /issue <content>")
@@ -184,6 +296,88 @@ func (m REPLModel) handleREPLCommand(input string) (REPLModel, tea.Cmd) {
 		m.input = ""
 		return m, SwitchToView(ViewConfig, nil)
 
+	case "/queue":
+		m.handleQueue(parts[1:])
+
+	case "/jobs":
+		m.input = ""
+		return m, SwitchToView(ViewJobs, nil)
+
+	case "/mcp":
+		m.input = ""
+		return m, SwitchToView(ViewMCP, nil)
+
+	case "/agents":
+		if len(parts) > 2 && parts[1] == "run" {
+			// Queue several issues at once; the scheduler runs them within the caps
+			var started []string
+			for _, arg := range parts[2:] {
+				number, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+				if err != nil {
+					m.output = append(m.output, fmt.Sprintf("Error: invalid issue number '%s'", arg))
+					continue
+				}
+				m.replSession.StartIssueRun(number)
+				started = append(started, fmt.Sprintf("#%d", number))
+			}
+			if len(started) > 0 {
+				m.output = append(m.output, fmt.Sprintf("🤖 Queued agents for %s", strings.Join(started, ", ")))
+			}
+		} else {
+			m.input = ""
+			return m, SwitchToView(ViewAgents, nil)
+		}
+
+	case "/pin":
+		m, cmd := m.handlePin(parts[1:])
+		m.setInput("")
+		return m, cmd
+
+	case "/diff", "/log":
+		return m.handleGitView(command, parts[1:])
+
+	case "/checkpoints", "/undo":
+		m.input = ""
+		return m, SwitchToView(ViewCheckpoints, CheckpointData{Dir: m.replSession.currentProject.Path, Back: ViewREPL})
+
+	case "/terminal":
+		data := AgentTerminalData{Back: ViewREPL}
+		if len(parts) > 1 {
+			number, err := strconv.Atoi(strings.TrimPrefix(parts[1], "#"))
+			if err != nil {
+				m.output = append(m.output, fmt.Sprintf("Error: invalid issue number '%s'", parts[1]))
+				break
+			}
+			term := m.replSession.terminals.ForIssue(m.replSession.currentProject.Name, number)
+			if term == nil {
+				m.output = append(m.output, fmt.Sprintf("No agent session for issue #%d - press 's' on the issue to start one", number))
+				break
+			}
+			data.SessionID = term.ID
+		}
+		m.input = ""
+		return m, SwitchToView(ViewAgentTerminal, data)
+
+	case "/plan":
+		if len(parts) < 2 {
+			m.output = append(m.output, "Error: usage: /plan <issue>")
+		} else if number, err := strconv.Atoi(strings.TrimPrefix(parts[1], "#")); err != nil {
+			m.output = append(m.output, fmt.Sprintf("Error: invalid issue number '%s'", parts[1]))
+		} else {
+			return m.openPlan(number)
+		}
+
+	case "/cancel":
+		if len(parts) < 2 {
+			m.output = append(m.output, "Error: usage: /cancel <job>")
+		} else if id, err := strconv.ParseInt(strings.TrimPrefix(parts[1], "#"), 10, 64); err != nil {
+			m.output = append(m.output, fmt.Sprintf("Error: invalid job id '%s'", parts[1]))
+		} else if err := m.replSession.jobQueue.Cancel(id); err != nil {
+			m.output = append(m.output, fmt.Sprintf("Error: %v", err))
+		} else {
+			m.output = append(m.output, fmt.Sprintf("🚫 Cancelling job #%d", id))
+		}
+
 	default:
 		m.output = append(m.output, fmt.Sprintf("Error: unknown command: %s (type /help for available commands)", command))
 	}
@@ -192,6 +386,27 @@ func (m REPLModel) handleREPLCommand(input string) (REPLModel, tea.Cmd) {
 	return m, nil
 }
 
+// handleQueue parses a /queue command and adds the job to the queue
+func (m *REPLModel) handleQueue(args []string) {
+	job, err := parseQueueCommand(m.replSession.currentProject.Name, args)
+	if err != nil {
+		m.output = append(m.output, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	job, err = m.replSession.jobQueue.Enqueue(job)
+	if err != nil {
+		m.output = append(m.output, fmt.Sprintf("Error queueing job: %v", err))
+		return
+	}
+
+	if job.DependsOn != 0 {
+		m.output = append(m.output, fmt.Sprintf("🧵 Queued job #%d: %s (%s #%d) - /jobs to watch", job.ID, job.Describe(), job.Condition, job.DependsOn))
+	} else {
+		m.output = append(m.output, fmt.Sprintf("🧵 Queued job #%d: %s - /jobs to watch", job.ID, job.Describe()))
+	}
+}
+
 func (m REPLModel) handleClaudeCommand(input string) (REPLModel, tea.Cmd) {
 	// Build context-aware command
 	var contextualInput string
@@ -214,16 +429,57 @@ func (m REPLModel) handleClaudeCommand(input string) (REPLModel, tea.Cmd) {
 
 	m.output = append(m.output, fmt.Sprintf("🤖 Sending to Claude: %s", input))
 
-	// In a real implementation, this would be async
-	response, err := m.replSession.llmManager.GetExecutingProvider().SendMessage(context.Background(), contextualInput)
-	if err != nil {
-		m.output = append(m.output, fmt.Sprintf("Claude error: %v", err))
-	} else {
-		m.output = append(m.output, fmt.Sprintf("Claude: %s", response))
-	}
+	// Like startOp, with the exchange for the transcript
+	op, ctx := newAsyncOp("Waiting for Claude")
+	m.op = op
+	m.input = ""
+
+	session, width := m.replSession, m.width
+	return m, tea.Batch(op.tick(), func() tea.Msg {
+		var lines []string
+
+		// Snapshot the project first so the prompt's changes can be undone
+		if _, err := CreateCheckpoint(ctx, session.currentProject.Path, "prompt: "+truncateText(input, 120)); err != nil {
+			lines = append(lines, fmt.Sprintf("⚠️  No checkpoint: %v", err))
+		}
 
+		response, err := session.llmManager.GetExecutingProvider().SendMessage(session.auditContext(ctx), contextualInput)
+		if err != nil {
+			return replOpDoneMsg{opID: op.id, lines: append(lines, fmt.Sprintf("Claude error: %v", err))}
+		}
+		return replOpDoneMsg{
+			opID:     op.id,
+			lines:    append(lines, "Claude:\n"+renderMarkdown(response, width)),
+			exchange: &transcriptEntry{prompt: input, reply: response},
+		}
+	})
+}
+
+// startOp runs work as a tea.Cmd with a spinner; Esc cancels its context
+func (m REPLModel) startOp(label string, work func(ctx context.Context) []string) (REPLModel, tea.Cmd) {
+	op, ctx := newAsyncOp(label)
+	m.op = op
 	m.input = ""
-	return m, nil
+
+	return m, tea.Batch(op.tick(), func() tea.Msg {
+		return replOpDoneMsg{opID: op.id, lines: work(ctx)}
+	})
+}
+
+// openPlan fetches an issue with a spinner and then opens its plan
+func (m REPLModel) openPlan(number int) (REPLModel, tea.Cmd) {
+	op, _ := newAsyncOp(fmt.Sprintf("Loading issue #%d", number))
+	m.op = op
+	m.input = ""
+
+	issueManager := m.replSession.issueManager
+	return m, tea.Batch(op.tick(), func() tea.Msg {
+		issue, err := issueManager.GetIssue(number)
+		if err != nil {
+			return replOpDoneMsg{opID: op.id, lines: []string{fmt.Sprintf("Error: %v", err)}}
+		}
+		return replOpDoneMsg{opID: op.id, then: SwitchToView(ViewPlan, *issue)}
+	})
 }
 
 func (m REPLModel) buildIssuesContext(input string, issues []Issue) string {
@@ -271,22 +527,18 @@ func (m REPLModel) buildIssueContext(input string, issue Issue) string {
 }
 
 func (m REPLModel) handleAddIssue(content string) (REPLModel, tea.Cmd) {
-	issue, err := m.replSession.issueManager.AddIssue(content)
-	if err != nil {
-		m.output = append(m.output, fmt.Sprintf("Error adding issue: %v", err))
-	} else {
-		var issueMsg string
+	issueManager := m.replSession.issueManager
+	return m.startOp("Creating issue", func(ctx context.Context) []string {
+		issue, err := issueManager.AddIssue(content)
+		if err != nil {
+			return []string{fmt.Sprintf("Error adding issue: %v", err)}
+		}
 		if len(issue.Labels) > 0 {
 			labelsStr := strings.Join(issue.Labels, ", ")
-			issueMsg = fmt.Sprintf("📋 Issue #%d created: \"%s\" [%s]", issue.Number, issue.Title, labelsStr)
-		} else {
-			issueMsg = fmt.Sprintf("📋 Issue #%d created: \"%s\"", issue.Number, issue.Title)
+			return []string{fmt.Sprintf("📋 Issue #%d created: \"%s\" [%s]", issue.Number, issue.Title, labelsStr)}
 		}
-		m.output = append(m.output, issueMsg)
-	}
-
-	m.input = ""
-	return m, nil
+		return []string{fmt.Sprintf("📋 Issue #%d created: \"%s\"", issue.Number, issue.Title)}
+	})
 }
 
 func (m REPLModel) handleStatus() (REPLModel, tea.Cmd) {
@@ -295,51 +547,47 @@ func (m REPLModel) handleStatus() (REPLModel, tea.Cmd) {
 	m.output = append(m.output, fmt.Sprintf("Last Opened: %s", m.replSession.currentProject.LastOpened.Format("2006-01-02 15:04:05")))
 
 	// Get git status through Claude
-	response, err := m.replSession.llmManager.GetExecutingProvider().SendMessage(context.Background(), "Show me the current git status and a brief summary of any changes.")
-	if err != nil {
-		m.output = append(m.output, fmt.Sprintf("Failed to get git status: %v", err))
-	} else {
-		m.output = append(m.output, fmt.Sprintf("Git Status:\n%s", response))
-	}
-
-	m.input = ""
-	return m, nil
+	session, width := m.replSession, m.width
+	return m.startOp("Getting git status", func(ctx context.Context) []string {
+		response, err := session.llmManager.GetExecutingProvider().SendMessage(session.auditContext(ctx), "Show me the current git status and a brief summary of any changes.")
+		if err != nil {
+			return []string{fmt.Sprintf("Failed to get git status: %v", err)}
+		}
+		return []string{"Git Status:\n" + renderMarkdown(response, width)}
+	})
 }
 
 func (m REPLModel) handleCommit() (REPLModel, tea.Cmd) {
-	err := m.replSession.gitOps.SmartCommit()
-	if err != nil {
-		m.output = append(m.output, fmt.Sprintf("Commit failed: %v", err))
-	} else {
-		m.output = append(m.output, "✅ Smart commit completed successfully")
-	}
-
-	m.input = ""
-	return m, nil
+	gitOps := m.replSession.gitOps
+	return m.startOp("Committing", func(ctx context.Context) []string {
+		response, err := gitOps.RunSmartCommit(ctx)
+		if err != nil {
+			return []string{fmt.Sprintf("Commit failed: %v", err)}
+		}
+		return []string{"✅ Smart commit completed successfully", response}
+	})
 }
 
 func (m REPLModel) handlePush() (REPLModel, tea.Cmd) {
-	err := m.replSession.gitOps.Push("")
-	if err != nil {
-		m.output = append(m.output, fmt.Sprintf("Push failed: %v", err))
-	} else {
-		m.output = append(m.output, "✅ Push completed successfully")
-	}
-
-	m.input = ""
-	return m, nil
+	gitOps := m.replSession.gitOps
+	return m.startOp("Pushing", func(ctx context.Context) []string {
+		response, err := gitOps.RunPush(ctx, "")
+		if err != nil {
+			return []string{fmt.Sprintf("Push failed: %v", err)}
+		}
+		return []string{"✅ Push completed successfully", response}
+	})
 }
 
 func (m REPLModel) handleCommitPush() (REPLModel, tea.Cmd) {
-	err := m.replSession.gitOps.SmartCommitAndPush()
-	if err != nil {
-		m.output = append(m.output, fmt.Sprintf("Commit and push failed: %v", err))
-	} else {
-		m.output = append(m.output, "✅ Smart commit and push completed successfully")
-	}
-
-	m.input = ""
-	return m, nil
+	gitOps := m.replSession.gitOps
+	return m.startOp("Committing and pushing", func(ctx context.Context) []string {
+		response, err := gitOps.RunSmartCommitAndPush(ctx)
+		if err != nil {
+			return []string{fmt.Sprintf("Commit and push failed: %v", err)}
+		}
+		return []string{"✅ Smart commit and push completed successfully", response}
+	})
 }
 
 func (m REPLModel) handleListProjects() (REPLModel, tea.Cmd) {
@@ -395,13 +643,53 @@ func (m REPLModel) getHelpText() string {
   /push               Push to current branch
   /commit-push        Smart commit and push
   /list               List all projects
+  /projects           Dashboard of all projects; open one to switch to it
   /pwd                Show current working directory
   /info               Show detailed project information
   /config             Open configuration menu
 
+Job Queue:
+  /queue <job> [--after|--on-success|--on-failure <id>]
+                      Queue llm <prompt>, commit, push, commit-push,
+                      check <cmd>, close <n>, comment <n> <text> or create <title>
+                      e.g. /queue check go test ./...
+                           /queue commit --on-success 12
+  /jobs               Show queued, running and finished jobs
+  /cancel <id>        Cancel a queued or running job
+
+MCP:
+  /mcp                Show MCP servers, their health and tools
+  /plan <issue>       Review, edit and generate an issue's step plan
+  /diff [issue] [staged|branch]
+                      Diff of the project or an issue's worktree; stage hunks
+  /log [issue]        Commit log of the project, or of an issue's branch
+  /pin [issue]        Comment the prompts and replies of this session on an issue
+                      (the issue in context when none is given)
+  /checkpoints        Timeline of checkpoints; restore any of them (alias /undo)
+  /agents             Show parallel agents: step, elapsed time and token spend
+  /agents run <n>...  Run headless agents for several issues at once
+  /terminal [issue]   Attach to the interactive agent sessions, one tab per issue
+
 Issue Management:
   /issue <content>    Capture a new development issue
   /issues             Interactive issue browser
+  /board              Issues as cards in the columns of .relay/config.json
+
+Keys:
+  up/down             Previous and next input; history is kept per project
+  ctrl+r              Search the history; ctrl+r again for older matches
+  tab                 Complete commands, #issues, branches and file paths
+  shift+enter         New line in the input (alt+enter or ctrl+j where the
+                      terminal does not report shift+enter)
+  ctrl+p              Command palette: every action of the current view
+  ?                   Key bindings of the current view (outside the REPL)
+                      Rebind any action in ~/.relay/keymap.json
+
+Colors:
+  ~/.relay/theme.json Pick a built-in theme with {"extends": "light"} and
+                      override its colors; built in: dark, light,
+                      high-contrast, colorblind, no-color
+  NO_COLOR=1          Turn colors off
 
 Direct Claude Commands:
   <any text>          Send directly to Claude AI
@@ -446,9 +734,9 @@ func (m REPLModel) View() string {
 
 	var prompt string
 	if promptPrefix != "" {
-		prompt = fmt.Sprintf("%s> %s", promptPrefix, m.input)
+		prompt = fmt.Sprintf("%s> %s", promptPrefix, m.inputView())
 	} else {
-		prompt = fmt.Sprintf("> %s", m.input)
+		prompt = fmt.Sprintf("> %s", m.inputView())
 	}
 
 	// Create full-width prompt box with text wrapping
@@ -456,11 +744,54 @@ func (m REPLModel) View() string {
 		Width(m.width - 2) // Account for border
 		//Align(lipgloss.Left)
 
+	if m.op != nil {
+		content.WriteString("\n" + m.op.View())
+	}
 	content.WriteString("\n" + promptStyle.Render(prompt))
+	if len(m.completions) > 0 {
+		content.WriteString("\n" + m.completionsView())
+	}
 
 	// Help text
-	help := helpStyle.Render("Type /help for commands, /issues for issue management, Ctrl+C to quit")
+	help := helpStyle.Render("Type /help for commands, /issues for issue management, Tab to complete, Ctrl+R to search history, Ctrl+C to quit")
 	content.WriteString("\n\n" + help)
 
 	return content.String()
 }
+
+// handleGitView opens the diff or log view on the project or, given an issue
+// number, on the issue's worktree
+func (m REPLModel) handleGitView(command string, args []string) (REPLModel, tea.Cmd) {
+	session := m.replSession
+	dir, title := session.currentProject.Path, session.currentProject.Name
+	base := sessionBaseBranch(session)
+	mode := DiffUnstaged
+	var revisions []string
+
+	for _, arg := range args {
+		switch {
+		case command == "/diff" && arg == "staged":
+			mode = DiffStaged
+		case command == "/diff" && arg == "branch":
+			mode = DiffBranch
+		default:
+			number, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+			if err != nil {
+				m.output = append(m.output, fmt.Sprintf("Error: invalid issue number '%s'", arg))
+				return m, nil
+			}
+			if dir, err = issueWorktreeDir(session, number); err != nil {
+				m.output = append(m.output, fmt.Sprintf("Error: %v", err))
+				return m, nil
+			}
+			title = fmt.Sprintf("Issue #%d worktree", number)
+			revisions = []string{base + "..HEAD"}
+		}
+	}
+
+	m.input = ""
+	if command == "/log" {
+		return m, SwitchToView(ViewLog, LogData{Dir: dir, Title: title, Revisions: revisions, Back: ViewREPL})
+	}
+	return m, SwitchToView(ViewDiff, DiffData{Dir: dir, Title: title, Mode: mode, Base: base, Back: ViewREPL})
+}
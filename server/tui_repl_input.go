@@ -0,0 +1,507 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// replHistoryLimit is how many inputs of a project the REPL loads for up,
+// down and ctrl+r; the database keeps them all
+const replHistoryLimit = 1000
+
+// maxPinnedTranscript bounds a pinned transcript below GitHub's comment limit
+const maxPinnedTranscript = 60000
+
+// replCommands are the slash-commands tab completes
+var replCommands = []string{
+	"/agents", "/board", "/cancel", "/checkpoints", "/commit", "/commit-push", "/config", "/diff", "/exit",
+	"/help", "/info", "/issue", "/issues", "/jobs", "/list", "/log", "/mcp", "/pin", "/plan", "/projects",
+	"/push", "/pwd", "/queue", "/quit", "/status", "/switch", "/terminal", "/undo",
+}
+
+// replIssueCommands take an issue number as their first argument
+var replIssueCommands = map[string]bool{
+	"/plan": true, "/terminal": true, "/diff": true, "/log": true, "/pin": true, "/agents": true,
+}
+
+// shiftEnterSequences are what terminals with extended key reporting send
+// for shift+enter. Bubble Tea does not know them and reports them as
+// unknown CSI sequences, which print as these strings.
+var shiftEnterSequences = map[string]bool{
+	"?CSI[49 51 59 50 117]?":          true, // CSI 13;2u
+	"?CSI[50 55 59 50 59 49 51 126]?": true, // CSI 27;2;13~
+}
+
+// isNewlineKey reports whether msg asks for a newline in the input rather
+// than submitting it: shift+enter where the terminal reports it, and
+// alt+enter or ctrl+j elsewhere
+func isNewlineKey(msg tea.Msg) bool {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		return msg.String() == "alt+enter" || msg.String() == "ctrl+j"
+	case fmt.Stringer:
+		return shiftEnterSequences[msg.String()]
+	}
+	return false
+}
+
+// historySearch is a ctrl+r reverse search through the history
+type historySearch struct {
+	query    string
+	match    int    // Index of the shown entry in history, -1 for none
+	original string // Input to restore when the search is cancelled
+}
+
+// transcriptEntry is a prompt sent to the provider and its reply
+type transcriptEntry struct {
+	prompt string
+	reply  string
+}
+
+// completion is a tab completion candidate
+type completion struct {
+	text  string // Replaces the word before the caret
+	label string // Shown in the candidate list
+}
+
+// replIssuesMsg carries the issues tab completion fetched
+type replIssuesMsg struct {
+	project string
+	input   string // Input when tab was pressed
+	issues  []Issue
+	err     error
+}
+
+// replBranchesMsg carries the local branches tab completion fetched
+type replBranchesMsg struct {
+	project  string
+	input    string // Input when tab was pressed
+	branches []string
+	err      error
+}
+
+// caret returns the position of the caret in the input, in runes
+func (m REPLModel) caret() int {
+	length := len([]rune(m.input))
+	return length - min(m.tail, length)
+}
+
+// setInput replaces the input and puts the caret at its end
+func (m *REPLModel) setInput(input string) {
+	m.input, m.tail = input, 0
+}
+
+// insert adds text at the caret
+func (m *REPLModel) insert(text string) {
+	runes := []rune(m.input)
+	caret := m.caret()
+	m.input = string(runes[:caret]) + text + string(runes[caret:])
+	m.tail = len(runes) - caret
+}
+
+// editInput handles the keys that edit the input and move the caret. It
+// reports whether msg was one of them.
+func (m *REPLModel) editInput(msg tea.KeyMsg) bool {
+	runes := []rune(m.input)
+	caret := m.caret()
+	switch msg.String() {
+	case "backspace", "ctrl+h":
+		if caret > 0 {
+			m.input = string(runes[:caret-1]) + string(runes[caret:])
+		}
+	case "delete", "ctrl+d":
+		if caret < len(runes) {
+			m.input = string(runes[:caret]) + string(runes[caret+1:])
+			m.tail--
+		}
+	case "ctrl+w", "alt+backspace":
+		start := caret
+		for start > 0 && runes[start-1] == ' ' {
+			start--
+		}
+		for start > 0 && runes[start-1] != ' ' && runes[start-1] != '\n' {
+			start--
+		}
+		m.input = string(runes[:start]) + string(runes[caret:])
+	case "left", "ctrl+b":
+		m.tail = min(m.tail+1, len(runes))
+	case "right", "ctrl+f":
+		m.tail = max(len(runes)-caret-1, 0)
+	case "home", "ctrl+a":
+		m.tail = len(runes)
+	case "end", "ctrl+e":
+		m.tail = 0
+	default:
+		if msg.Type != tea.KeyRunes && msg.Type != tea.KeySpace {
+			return false
+		}
+		m.insert(string(msg.Runes))
+		m.cursor = 0 // Reset history cursor when typing
+	}
+	return true
+}
+
+// syncHistory loads the project's history the first time the REPL needs
+// it, and again after the session switched projects
+func (m *REPLModel) syncHistory() {
+	if m.replSession == nil || m.replSession.currentProject == nil {
+		return
+	}
+	project := m.replSession.currentProject.Name
+	if m.historyProject == project {
+		return
+	}
+	m.historyProject = project
+	m.history, m.cursor, m.issues, m.branches = nil, 0, nil, nil
+
+	if m.replSession.projectManager == nil || m.replSession.projectManager.db == nil {
+		return
+	}
+	history, err := m.replSession.projectManager.db.ListHistory(project, m.maxHistory)
+	if err != nil {
+		slog.Warn("Failed to load REPL history", "error", err)
+		return
+	}
+	m.history = history
+}
+
+// recordHistory adds input to the history and the database, skipping an
+// input that repeats the previous one
+func (m *REPLModel) recordHistory(input string) {
+	m.cursor = 0
+	if len(m.history) > 0 && m.history[len(m.history)-1] == input {
+		return
+	}
+	m.history = append(m.history, input)
+	if len(m.history) > m.maxHistory {
+		m.history = m.history[1:]
+	}
+
+	if m.replSession == nil || m.replSession.projectManager == nil || m.replSession.projectManager.db == nil {
+		return
+	}
+	if err := m.replSession.projectManager.db.AddHistoryEntry(m.historyProject, input); err != nil {
+		slog.Warn("Failed to save REPL history", "error", err)
+	}
+}
+
+// find returns the newest history entry before index from that contains the
+// query, or -1
+func (s *historySearch) find(history []string, from int) int {
+	for i := min(from, len(history)-1); i >= 0; i-- {
+		if strings.Contains(history[i], s.query) {
+			return i
+		}
+	}
+	return -1
+}
+
+// updateSearch handles a key during a reverse search. Keys that do not
+// refine the search end it with the match as the input; handled is false
+// when the key should then be handled as usual.
+func (m REPLModel) updateSearch(msg tea.KeyMsg) (model REPLModel, handled bool) {
+	search := m.search
+	switch msg.String() {
+	case "ctrl+r":
+		if match := search.find(m.history, search.match-1); match >= 0 {
+			search.match = match
+		}
+		return m, true
+	case "esc", "ctrl+g":
+		m.setInput(search.original)
+		m.search = nil
+		return m, true
+	case "backspace", "ctrl+h":
+		if runes := []rune(search.query); len(runes) > 0 {
+			search.query = string(runes[:len(runes)-1])
+			search.match = search.find(m.history, len(m.history)-1)
+		}
+		return m, true
+	}
+	if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+		// Keep the match while it still contains the query
+		from := len(m.history) - 1
+		if search.match >= 0 {
+			from = search.match
+		}
+		search.query += string(msg.Runes)
+		search.match = search.find(m.history, from)
+		return m, true
+	}
+
+	if search.match >= 0 {
+		m.setInput(m.history[search.match])
+	} else {
+		m.setInput(search.original)
+	}
+	m.search = nil
+	return m, false
+}
+
+// complete completes the word before the caret: slash-commands, issue
+// numbers, branch names and paths in the project. A single candidate is
+// inserted; several are listed and their common prefix is inserted.
+func (m REPLModel) complete() (REPLModel, tea.Cmd) {
+	m.completions = nil
+	before := string([]rune(m.input)[:m.caret()])
+	start := strings.LastIndexAny(before, " \n") + 1
+	word := before[start:]
+	fields := strings.Fields(before)
+	command := ""
+	if len(fields) > 0 && strings.HasPrefix(before, "/") {
+		command = fields[0]
+	}
+
+	var candidates []completion
+	var cmd tea.Cmd
+	switch {
+	case start == 0 && strings.HasPrefix(word, "/"):
+		for _, name := range replCommands {
+			if strings.HasPrefix(name, word) {
+				candidates = append(candidates, completion{text: name, label: name})
+			}
+		}
+	case strings.HasPrefix(word, "#") || (replIssueCommands[command] && isDigits(word)):
+		candidates, cmd = m.issueCompletions(word)
+		m.loadingIssues = m.loadingIssues || cmd != nil
+	case word != "":
+		candidates, cmd = m.branchCompletions(word)
+		m.loadingBranches = m.loadingBranches || cmd != nil
+		candidates = append(candidates, m.pathCompletions(word)...)
+	}
+
+	switch len(candidates) {
+	case 0:
+		return m, cmd
+	case 1:
+		text := candidates[0].text
+		if !strings.HasSuffix(text, "/") {
+			text += " "
+		}
+		m.insert(text[len(word):])
+		return m, cmd
+	}
+
+	prefix := candidates[0].text
+	for _, candidate := range candidates[1:] {
+		for !strings.HasPrefix(candidate.text, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	if len(prefix) > len(word) {
+		m.insert(prefix[len(word):])
+	}
+	for _, candidate := range candidates {
+		m.completions = append(m.completions, candidate.label)
+	}
+	return m, cmd
+}
+
+func isDigits(s string) bool {
+	_, err := strconv.Atoi(s)
+	return s == "" || err == nil
+}
+
+// issueCompletions lists the issues whose number starts with the word. The
+// issues are fetched from GitHub on the first tab; until they arrive only
+// the issues in progress are offered.
+func (m REPLModel) issueCompletions(word string) ([]completion, tea.Cmd) {
+	hash := strings.HasPrefix(word, "#")
+	digits := strings.TrimPrefix(word, "#")
+
+	titles := make(map[int]string)
+	if m.replSession.progress != nil {
+		for _, number := range m.replSession.progress.InProgress() {
+			titles[number] = "in progress"
+		}
+	}
+	for _, issue := range m.issues {
+		titles[issue.Number] = issue.Title
+	}
+
+	var cmd tea.Cmd
+	if m.issues == nil && !m.loadingIssues {
+		issueManager, project, input := m.replSession.issueManager, m.historyProject, m.input
+		cmd = func() tea.Msg {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			issues, err := issueManager.ListIssuesContext(ctx, "open", "")
+			return replIssuesMsg{project: project, input: input, issues: issues, err: err}
+		}
+	}
+
+	numbers := make([]int, 0, len(titles))
+	for number := range titles {
+		if strings.HasPrefix(strconv.Itoa(number), digits) {
+			numbers = append(numbers, number)
+		}
+	}
+	sort.Ints(numbers)
+
+	candidates := make([]completion, len(numbers))
+	for i, number := range numbers {
+		text := strconv.Itoa(number)
+		if hash {
+			text = "#" + text
+		}
+		candidates[i] = completion{text: text, label: fmt.Sprintf("#%d %s", number, truncateText(titles[number], 40))}
+	}
+	return candidates, cmd
+}
+
+// branchCompletions lists the local branches starting with the word. The
+// branches are listed in the background on the first tab, like issues.
+func (m REPLModel) branchCompletions(word string) ([]completion, tea.Cmd) {
+	var cmd tea.Cmd
+	if m.branches == nil && !m.loadingBranches {
+		dir, project, input := m.replSession.currentProject.Path, m.historyProject, m.input
+		cmd = func() tea.Msg {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			output, err := projectGit(ctx, dir, "for-each-ref", "--format=%(refname:short)", "refs/heads")
+			return replBranchesMsg{project: project, input: input, branches: strings.Fields(output), err: err}
+		}
+	}
+
+	var candidates []completion
+	for _, branch := range m.branches {
+		if strings.HasPrefix(branch, word) {
+			candidates = append(candidates, completion{text: branch, label: branch})
+		}
+	}
+	return candidates, cmd
+}
+
+// pathCompletions lists the files and directories of the project starting
+// with the word; directories end with a slash
+func (m REPLModel) pathCompletions(word string) []completion {
+	dir, prefix := filepath.Split(word)
+	entries, err := os.ReadDir(filepath.Join(m.replSession.currentProject.Path, dir))
+	if err != nil {
+		return nil
+	}
+	var candidates []completion
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) || name == ".git" || (strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".")) {
+			continue
+		}
+		if entry.IsDir() {
+			name += "/"
+		}
+		candidates = append(candidates, completion{text: dir + name, label: name})
+	}
+	return candidates
+}
+
+// handlePin queues the transcript of the conversation as a comment on an
+// issue: the one given, or the issue in context
+func (m REPLModel) handlePin(args []string) (REPLModel, tea.Cmd) {
+	number := 0
+	if len(args) > 0 {
+		n, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+		if err != nil {
+			m.output = append(m.output, fmt.Sprintf("Error: invalid issue number '%s'", args[0]))
+			return m, nil
+		}
+		number = n
+	} else if m.context != nil {
+		if issue, ok := m.context.Data.(Issue); ok {
+			number = issue.Number
+		}
+	}
+	if number == 0 {
+		m.output = append(m.output, "Error: usage: /pin <issue>")
+		return m, nil
+	}
+	if len(m.transcript) == 0 {
+		m.output = append(m.output, "Nothing to pin yet - the transcript holds prompts sent to Claude and their replies")
+		return m, nil
+	}
+
+	job, err := m.replSession.jobQueue.Enqueue(&Job{
+		Project:     m.replSession.currentProject.Name,
+		Kind:        JobKindIssue,
+		Action:      "comment",
+		IssueNumber: number,
+		Input:       formatTranscript(m.transcript),
+	})
+	if err != nil {
+		m.output = append(m.output, fmt.Sprintf("Error queueing job: %v", err))
+		return m, nil
+	}
+	m.output = append(m.output, fmt.Sprintf("📌 Pinning %d exchanges to issue #%d as job #%d - /jobs to watch", len(m.transcript), number, job.ID))
+	return m, nil
+}
+
+// formatTranscript renders the transcript as a Markdown comment, dropping
+// the oldest exchanges when it is too long for one
+func formatTranscript(transcript []transcriptEntry) string {
+	var exchanges []string
+	size := 0
+	for i := len(transcript) - 1; i >= 0; i-- {
+		entry := transcript[i]
+		quoted := "> " + strings.ReplaceAll(strings.TrimSpace(entry.prompt), "\n", "\n> ")
+		exchange := quoted + "\n\n" + strings.TrimSpace(entry.reply)
+		if size+len(exchange) > maxPinnedTranscript && len(exchanges) > 0 {
+			break
+		}
+		size += len(exchange)
+		exchanges = append([]string{exchange}, exchanges...)
+	}
+
+	header := "**Relay REPL transcript**"
+	if left := len(transcript) - len(exchanges); left > 0 {
+		header += fmt.Sprintf(" (latest %d of %d exchanges)", len(exchanges), len(transcript))
+	}
+	return header + "\n\n" + strings.Join(exchanges, "\n\n---\n\n")
+}
+
+// inputView renders the input with the caret, or the reverse search
+func (m REPLModel) inputView() string {
+	caretStyle := lipgloss.NewStyle().Reverse(true)
+	if m.search != nil {
+		match := ""
+		if m.search.match >= 0 {
+			match = m.history[m.search.match]
+		}
+		label := "reverse-i-search"
+		if m.search.match < 0 && m.search.query != "" {
+			label = "failing reverse-i-search"
+		}
+		return fmt.Sprintf("(%s)'%s'%s: %s", label, m.search.query, caretStyle.Render(" "), match)
+	}
+
+	runes := []rune(m.input)
+	caret := m.caret()
+	under := " "
+	after := ""
+	if caret < len(runes) {
+		under, after = string(runes[caret]), string(runes[caret+1:])
+		if under == "\n" {
+			under, after = " ", "\n"+after
+		}
+	}
+	return string(runes[:caret]) + caretStyle.Render(under) + after
+}
+
+// completionsView lists the candidates of the last tab completion
+func (m REPLModel) completionsView() string {
+	const shown = 12
+	labels := m.completions
+	more := ""
+	if len(labels) > shown {
+		labels, more = labels[:shown], fmt.Sprintf("  … %d more", len(m.completions)-shown)
+	}
+	return historyStyle.Render(strings.Join(labels, "  ") + more)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newREPLTestModel returns a REPL on a project in a temp repository with a
+// fresh database
+func newREPLTestModel(t *testing.T, project string) (REPLModel, *REPLSession) {
+	t.Helper()
+	db, err := openDatabase(filepath.Join(t.TempDir(), "relay.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	session := &REPLSession{
+		projectManager: &ProjectManager{db: db},
+		currentProject: &Project{Name: project, Path: newCheckpointTestRepo(t)},
+	}
+	return NewREPLModel(session), session
+}
+
+func typeInto(m REPLModel, keys ...tea.KeyMsg) REPLModel {
+	for _, key := range keys {
+		m, _ = m.Update(key)
+	}
+	return m
+}
+
+// TestREPLHistory tests that history survives a new REPL, is kept per
+// project and can be searched with ctrl+r
+func TestREPLHistory(t *testing.T) {
+	m, session := newREPLTestModel(t, "demo")
+	for _, input := range []string{"/pwd", "/info", "/pwd", "/pwd"} {
+		m = typeInto(m, runes(input), tea.KeyMsg{Type: tea.KeyEnter})
+	}
+
+	m = NewREPLModel(session)
+	m = typeInto(m, tea.KeyMsg{Type: tea.KeyUp}, tea.KeyMsg{Type: tea.KeyUp})
+	if m.input != "/info" || len(m.history) != 3 {
+		t.Errorf("History after a restart: input %q, history %v", m.input, m.history)
+	}
+
+	m = typeInto(m, tea.KeyMsg{Type: tea.KeyCtrlR}, runes("in"))
+	if m.search == nil || m.search.match != 1 || !strings.Contains(m.View(), "reverse-i-search)'in'") {
+		t.Fatalf("Search for 'in': %+v", m.search)
+	}
+	m = typeInto(m, runes("x"))
+	if m.search.match != -1 || !strings.Contains(m.View(), "failing") {
+		t.Errorf("Search for 'inx' should fail: %+v", m.search)
+	}
+	m = typeInto(m, tea.KeyMsg{Type: tea.KeyBackspace}, tea.KeyMsg{Type: tea.KeyRight})
+	if m.search != nil || m.input != "/info" {
+		t.Errorf("Right should accept the match, got %q", m.input)
+	}
+	m = typeInto(m, tea.KeyMsg{Type: tea.KeyCtrlR}, runes("zzz"), tea.KeyMsg{Type: tea.KeyEsc})
+	if m.search != nil || m.input != "/info" {
+		t.Errorf("Esc should restore the input, got %q", m.input)
+	}
+
+	// Another project starts with its own history
+	session.currentProject = &Project{Name: "other", Path: session.currentProject.Path}
+	m = typeInto(m, tea.KeyMsg{Type: tea.KeyUp})
+	if len(m.history) != 0 {
+		t.Errorf("Other project's history: %v", m.history)
+	}
+}
+
+func TestREPLInputEditing(t *testing.T) {
+	m, _ := newREPLTestModel(t, "demo")
+	m = typeInto(m, runes("fix tests"), tea.KeyMsg{Type: tea.KeyHome}, runes("please "))
+	if m.input != "please fix tests" {
+		t.Errorf("Insert at home: %q", m.input)
+	}
+	m = typeInto(m, tea.KeyMsg{Type: tea.KeyEnd}, tea.KeyMsg{Type: tea.KeyEnter, Alt: true}, runes("and lint"))
+	if m.input != "please fix tests\nand lint" {
+		t.Errorf("Alt+enter should insert a newline: %q", m.input)
+	}
+	m = typeInto(m, tea.KeyMsg{Type: tea.KeyCtrlW}, tea.KeyMsg{Type: tea.KeyLeft}, tea.KeyMsg{Type: tea.KeyBackspace})
+	if m.input != "please fix tests\nan " {
+		t.Errorf("Word and character deletion: %q", m.input)
+	}
+
+	// Shift+enter reported with CSI u arrives as an unknown sequence
+	m.setInput("a")
+	m, _ = m.Update(fakeSequence("?CSI[49 51 59 50 117]?"))
+	if m.input != "a\n" {
+		t.Errorf("Shift+enter: %q", m.input)
+	}
+}
+
+type fakeSequence string
+
+func (s fakeSequence) String() string { return string(s) }
+
+func TestREPLCompletion(t *testing.T) {
+	m, session := newREPLTestModel(t, "demo")
+	dir := session.currentProject.Path
+	runTestGit(t, dir, "branch", "feature/issue-3")
+	runTestGit(t, dir, "branch", "feature/issue-4")
+	if err := os.MkdirAll(filepath.Join(dir, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "docs", "guide.md"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tab := tea.KeyMsg{Type: tea.KeyTab}
+	m = typeInto(m, runes("/che"), tab)
+	if m.input != "/checkpoints " {
+		t.Errorf("Command completion: %q", m.input)
+	}
+
+	m.setInput("/p")
+	m = typeInto(m, tab)
+	if m.input != "/p" || len(m.completions) != 5 || !strings.Contains(m.View(), "/projects") {
+		t.Errorf("Ambiguous command: %q, %v", m.input, m.completions)
+	}
+
+	// Branches are listed in the background, then complete
+	m.setInput("git checkout feat")
+	m, cmd := m.Update(tab)
+	if cmd == nil || !m.loadingBranches || m.input != "git checkout feat" {
+		t.Fatal("Tab on a word should list the branches in the background")
+	}
+	m, _ = m.Update(cmd())
+	if m.input != "git checkout feature/issue-" || len(m.completions) != 2 {
+		t.Errorf("Branch completion: %q, %v", m.input, m.completions)
+	}
+
+	m.setInput("read do")
+	m = typeInto(m, tab, tab)
+	if m.input != "read docs/guide.md " {
+		t.Errorf("Path completion: %q", m.input)
+	}
+
+	// Issues come from GitHub once, then complete
+	m.setInput("look at #4")
+	m, cmd = m.Update(tab)
+	if cmd == nil || !m.loadingIssues {
+		t.Fatal("Tab on an issue number should fetch the issues")
+	}
+	m, _ = m.Update(replIssuesMsg{project: "demo", input: "look at #4", issues: []Issue{{Number: 42, Title: "Dark mode"}, {Number: 7}}})
+	if m.input != "look at #42 " {
+		t.Errorf("Issue completion: %q", m.input)
+	}
+}
+
+func TestFormatTranscript(t *testing.T) {
+	transcript := []transcriptEntry{
+		{prompt: "why does\nit fail?", reply: "Because."},
+		{prompt: "fix it", reply: strings.Repeat("x", maxPinnedTranscript)},
+	}
+	comment := formatTranscript(transcript[:1])
+	if !strings.Contains(comment, "> why does\n> it fail?\n\nBecause.") || !strings.HasPrefix(comment, "**Relay REPL transcript**\n") {
+		t.Errorf("Transcript:\n%s", comment)
+	}
+
+	// The oldest exchanges give way when the comment gets too long
+	comment = formatTranscript(transcript)
+	if strings.Contains(comment, "why does") || !strings.Contains(comment, "(latest 1 of 2 exchanges)") {
+		t.Errorf("Long transcript header: %s", comment[:80])
+	}
+}
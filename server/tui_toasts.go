@@ -0,0 +1,97 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// toastLifetime is how long a toast stays on screen
+	toastLifetime = 6 * time.Second
+
+	// maxToasts bounds how many toasts are shown at once
+	maxToasts = 3
+)
+
+// toastMsg carries a log record to show as a toast
+type toastMsg struct {
+	toast Toast
+}
+
+// toastExpiredMsg removes toasts that have been shown long enough
+type toastExpiredMsg struct{}
+
+// waitForToast waits for the next warning or error logged while the TUI runs
+func waitForToast(toasts <-chan Toast) tea.Cmd {
+	if toasts == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		toast, ok := <-toasts
+		if !ok {
+			return nil
+		}
+		return toastMsg{toast: toast}
+	}
+}
+
+func expireToast() tea.Cmd {
+	return tea.Tick(toastLifetime, func(time.Time) tea.Msg {
+		return toastExpiredMsg{}
+	})
+}
+
+// addToast keeps the newest toasts, dropping the oldest beyond the limit
+func addToast(toasts []Toast, toast Toast) []Toast {
+	toast.Time = time.Now()
+	toasts = append(toasts, toast)
+	if len(toasts) > maxToasts {
+		toasts = toasts[len(toasts)-maxToasts:]
+	}
+	return toasts
+}
+
+// pruneToasts drops the toasts that have expired
+func pruneToasts(toasts []Toast, now time.Time) []Toast {
+	var kept []Toast
+	for _, toast := range toasts {
+		if now.Sub(toast.Time) < toastLifetime {
+			kept = append(kept, toast)
+		}
+	}
+	return kept
+}
+
+// renderToasts draws the toasts, newest last, to go below the current view
+func renderToasts(toasts []Toast, width int) string {
+	if len(toasts) == 0 {
+		return ""
+	}
+
+	maxWidth := width - 4
+	if maxWidth < 20 {
+		maxWidth = 20
+	}
+
+	var lines []string
+	for _, toast := range toasts {
+		color, icon := theme.Accent, "ℹ️ "
+		switch {
+		case toast.Level >= slog.LevelError:
+			color, icon = theme.Error, "❌"
+		case toast.Level >= slog.LevelWarn:
+			color, icon = theme.Warning, "⚠️ "
+		}
+		style := theme.Fg(color).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(theme.Color(color)).
+			Padding(0, 1)
+		lines = append(lines, style.Render(icon+" "+truncateText(toast.Message, maxWidth-6)))
+	}
+
+	return strings.Join(lines, "\n")
+}
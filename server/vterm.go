@@ -0,0 +1,456 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// vtScreen is a small VT100-style terminal emulator for the agent pane. It
+// keeps the text of the screen and the lines scrolled off its top; colors
+// and other attributes are dropped.
+type vtScreen struct {
+	mu            sync.Mutex
+	rows, cols    int
+	lines         [][]rune
+	cx, cy        int
+	wrapPending   bool
+	top, bottom   int // Scroll region, inclusive
+	savedX        int
+	savedY        int
+	scrollback    []string
+	maxScrollback int
+	altLines      [][]rune // Main screen while the alternate screen is shown
+	state         vtState
+	params        []byte
+	partial       []byte // Incomplete UTF-8 sequence from the last write
+}
+
+type vtState int
+
+const (
+	vtGround vtState = iota
+	vtEscape
+	vtCSI
+	vtOSC
+	vtOSCEscape
+	vtCharset
+)
+
+const defaultScrollback = 5000
+
+func newVTScreen(rows, cols, maxScrollback int) *vtScreen {
+	s := &vtScreen{maxScrollback: maxScrollback}
+	s.rows, s.cols = max(rows, 1), max(cols, 1)
+	s.lines = blankLines(s.rows, s.cols)
+	s.bottom = s.rows - 1
+	return s
+}
+
+func blankLine(cols int) []rune {
+	line := make([]rune, cols)
+	for i := range line {
+		line[i] = ' '
+	}
+	return line
+}
+
+func blankLines(rows, cols int) [][]rune {
+	lines := make([][]rune, rows)
+	for i := range lines {
+		lines[i] = blankLine(cols)
+	}
+	return lines
+}
+
+// Write feeds program output to the screen
+func (s *vtScreen) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := append(s.partial, p...)
+	s.partial = nil
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size <= 1 && !utf8.FullRune(data) {
+			s.partial = append([]byte(nil), data...)
+			break
+		}
+		data = data[size:]
+		s.feed(r)
+	}
+	return len(p), nil
+}
+
+func (s *vtScreen) feed(r rune) {
+	switch s.state {
+	case vtEscape:
+		s.escape(r)
+		return
+	case vtCSI:
+		if r >= 0x40 && r <= 0x7e {
+			s.state = vtGround
+			s.csi(r)
+		} else if r >= 0x20 && r <= 0x3f {
+			s.params = append(s.params, byte(r))
+		} else {
+			s.state = vtGround
+		}
+		return
+	case vtOSC:
+		if r == 0x07 {
+			s.state = vtGround
+		} else if r == 0x1b {
+			s.state = vtOSCEscape
+		}
+		return
+	case vtOSCEscape:
+		s.state = vtGround
+		return
+	case vtCharset:
+		s.state = vtGround
+		return
+	}
+
+	switch r {
+	case 0x1b:
+		s.state = vtEscape
+	case '\r':
+		s.cx, s.wrapPending = 0, false
+	case '\n', 0x0b, 0x0c:
+		s.lineFeed()
+	case '\b':
+		if s.cx > 0 {
+			s.cx--
+		}
+		s.wrapPending = false
+	case '\t':
+		s.cx = min((s.cx/8+1)*8, s.cols-1)
+	default:
+		if r < 0x20 || r == 0x7f {
+			return
+		}
+		if s.wrapPending {
+			s.cx, s.wrapPending = 0, false
+			s.lineFeed()
+		}
+		s.lines[s.cy][s.cx] = r
+		if s.cx == s.cols-1 {
+			s.wrapPending = true
+		} else {
+			s.cx++
+		}
+	}
+}
+
+func (s *vtScreen) escape(r rune) {
+	s.state = vtGround
+	switch r {
+	case '[':
+		s.state, s.params = vtCSI, s.params[:0]
+	case ']':
+		s.state = vtOSC
+	case '(', ')', '*', '+':
+		s.state = vtCharset
+	case '7':
+		s.savedX, s.savedY = s.cx, s.cy
+	case '8':
+		s.moveTo(s.savedY, s.savedX)
+	case 'D':
+		s.lineFeed()
+	case 'E':
+		s.cx = 0
+		s.lineFeed()
+	case 'M':
+		if s.cy == s.top {
+			s.scrollDown(1)
+		} else if s.cy > 0 {
+			s.cy--
+		}
+	case 'c':
+		s.reset()
+	}
+}
+
+// reset clears the screen, cursor and modes in place, keeping the scrollback
+func (s *vtScreen) reset() {
+	s.lines = blankLines(s.rows, s.cols)
+	s.cx, s.cy, s.wrapPending = 0, 0, false
+	s.savedX, s.savedY = 0, 0
+	s.top, s.bottom = 0, s.rows-1
+	s.altLines = nil
+	s.state = vtGround
+	s.params = s.params[:0]
+}
+
+func (s *vtScreen) csi(final rune) {
+	params := string(s.params)
+	private := strings.HasPrefix(params, "?")
+	params = strings.TrimLeft(params, "?>=")
+	var args []int
+	if params != "" {
+		for _, field := range strings.Split(params, ";") {
+			n, _ := strconv.Atoi(field)
+			args = append(args, n)
+		}
+	}
+	arg := func(i, fallback int) int {
+		if i < len(args) && args[i] > 0 {
+			return args[i]
+		}
+		return fallback
+	}
+
+	switch final {
+	case 'A':
+		s.moveTo(s.cy-arg(0, 1), s.cx)
+	case 'B', 'e':
+		s.moveTo(s.cy+arg(0, 1), s.cx)
+	case 'C', 'a':
+		s.moveTo(s.cy, s.cx+arg(0, 1))
+	case 'D':
+		s.moveTo(s.cy, s.cx-arg(0, 1))
+	case 'E':
+		s.moveTo(s.cy+arg(0, 1), 0)
+	case 'F':
+		s.moveTo(s.cy-arg(0, 1), 0)
+	case 'G', '`':
+		s.moveTo(s.cy, arg(0, 1)-1)
+	case 'd':
+		s.moveTo(arg(0, 1)-1, s.cx)
+	case 'H', 'f':
+		s.moveTo(arg(0, 1)-1, arg(1, 1)-1)
+	case 'J':
+		s.eraseDisplay(arg(0, 0))
+	case 'K':
+		s.eraseLine(s.cy, arg(0, 0))
+	case 'L':
+		if s.cy >= s.top && s.cy <= s.bottom {
+			s.shiftDown(s.cy, s.bottom, arg(0, 1))
+		}
+	case 'M':
+		if s.cy >= s.top && s.cy <= s.bottom {
+			s.shiftUp(s.cy, s.bottom, arg(0, 1))
+		}
+	case 'P':
+		line, n := s.lines[s.cy], min(arg(0, 1), s.cols-s.cx)
+		copy(line[s.cx:], line[s.cx+n:])
+		for i := s.cols - n; i < s.cols; i++ {
+			line[i] = ' '
+		}
+	case '@':
+		line, n := s.lines[s.cy], min(arg(0, 1), s.cols-s.cx)
+		copy(line[s.cx+n:], line[s.cx:])
+		for i := s.cx; i < s.cx+n; i++ {
+			line[i] = ' '
+		}
+	case 'X':
+		for i := s.cx; i < min(s.cx+arg(0, 1), s.cols); i++ {
+			s.lines[s.cy][i] = ' '
+		}
+	case 'S':
+		s.scrollUp(arg(0, 1))
+	case 'T':
+		s.scrollDown(arg(0, 1))
+	case 'r':
+		top, bottom := arg(0, 1)-1, arg(1, s.rows)-1
+		if top < bottom && bottom < s.rows {
+			s.top, s.bottom = top, bottom
+			s.moveTo(0, 0)
+		}
+	case 's':
+		s.savedX, s.savedY = s.cx, s.cy
+	case 'u':
+		s.moveTo(s.savedY, s.savedX)
+	case 'h', 'l':
+		if private {
+			for _, mode := range args {
+				if mode == 47 || mode == 1047 || mode == 1049 {
+					s.setAltScreen(final == 'h')
+				}
+			}
+		}
+	}
+}
+
+func (s *vtScreen) moveTo(row, col int) {
+	s.cy = min(max(row, 0), s.rows-1)
+	s.cx = min(max(col, 0), s.cols-1)
+	s.wrapPending = false
+}
+
+func (s *vtScreen) lineFeed() {
+	s.wrapPending = false
+	if s.cy == s.bottom {
+		s.scrollUp(1)
+	} else if s.cy < s.rows-1 {
+		s.cy++
+	}
+}
+
+// scrollUp moves the scroll region up; lines leaving the top of the main
+// screen go to the scrollback
+func (s *vtScreen) scrollUp(n int) {
+	if s.top == 0 && s.altLines == nil {
+		for i := 0; i < min(n, s.bottom+1); i++ {
+			s.scrollback = append(s.scrollback, strings.TrimRight(string(s.lines[i]), " "))
+		}
+		if over := len(s.scrollback) - s.maxScrollback; over > 0 {
+			s.scrollback = append([]string(nil), s.scrollback[over:]...)
+		}
+	}
+	s.shiftUp(s.top, s.bottom, n)
+}
+
+func (s *vtScreen) scrollDown(n int) {
+	s.shiftDown(s.top, s.bottom, n)
+}
+
+// shiftUp removes n lines at from, pulling up the lines below it to bottom
+func (s *vtScreen) shiftUp(from, bottom, n int) {
+	n = min(n, bottom-from+1)
+	copy(s.lines[from:bottom+1], s.lines[from+n:bottom+1])
+	for i := bottom - n + 1; i <= bottom; i++ {
+		s.lines[i] = blankLine(s.cols)
+	}
+}
+
+// shiftDown inserts n blank lines at from, pushing the lines below down to bottom
+func (s *vtScreen) shiftDown(from, bottom, n int) {
+	n = min(n, bottom-from+1)
+	copy(s.lines[from+n:bottom+1], s.lines[from:bottom+1-n])
+	for i := from; i < from+n; i++ {
+		s.lines[i] = blankLine(s.cols)
+	}
+}
+
+func (s *vtScreen) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		s.eraseLine(s.cy, 0)
+		for i := s.cy + 1; i < s.rows; i++ {
+			s.lines[i] = blankLine(s.cols)
+		}
+	case 1:
+		s.eraseLine(s.cy, 1)
+		for i := 0; i < s.cy; i++ {
+			s.lines[i] = blankLine(s.cols)
+		}
+	case 2, 3:
+		s.lines = blankLines(s.rows, s.cols)
+		if mode == 3 {
+			s.scrollback = nil
+		}
+	}
+}
+
+func (s *vtScreen) eraseLine(row, mode int) {
+	start, end := s.cx, s.cols
+	switch mode {
+	case 1:
+		start, end = 0, s.cx+1
+	case 2:
+		start = 0
+	}
+	for i := start; i < end; i++ {
+		s.lines[row][i] = ' '
+	}
+}
+
+func (s *vtScreen) setAltScreen(on bool) {
+	if on == (s.altLines != nil) {
+		return
+	}
+	if on {
+		s.altLines = s.lines
+		s.savedX, s.savedY = s.cx, s.cy
+		s.lines = blankLines(s.rows, s.cols)
+	} else {
+		s.lines = s.altLines
+		s.altLines = nil
+		s.moveTo(s.savedY, s.savedX)
+	}
+}
+
+// Resize changes the screen size; lines pushed off the top go to the scrollback
+func (s *vtScreen) Resize(rows, cols int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rows, cols = max(rows, 1), max(cols, 1)
+	if rows == s.rows && cols == s.cols {
+		return
+	}
+
+	resize := func(lines [][]rune) [][]rune {
+		for i, line := range lines {
+			if cols < len(line) {
+				lines[i] = line[:cols]
+			} else {
+				lines[i] = append(line, blankLine(cols-len(line))...)
+			}
+		}
+		for len(lines) < rows {
+			lines = append(lines, blankLine(cols))
+		}
+		return lines
+	}
+
+	s.cols = cols
+	s.lines = resize(s.lines)
+	if s.altLines != nil {
+		s.altLines = resize(s.altLines)[:rows]
+	}
+	if s.cy >= rows {
+		// Keep the cursor line visible by scrolling the top away
+		s.top, s.bottom = 0, len(s.lines)-1
+		s.scrollUp(s.cy - rows + 1)
+		s.cy = rows - 1
+	}
+	s.lines = s.lines[:rows]
+	s.rows = rows
+	s.top, s.bottom = 0, rows-1
+	s.moveTo(s.cy, s.cx)
+}
+
+// View returns height lines ending offset lines above the bottom of the
+// scrollback plus the screen, and the cursor row within them or -1
+func (s *vtScreen) View(height, offset int) ([]string, int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all := make([]string, 0, len(s.scrollback)+s.rows)
+	if s.altLines == nil {
+		all = append(all, s.scrollback...)
+	}
+	for _, line := range s.lines {
+		all = append(all, strings.TrimRight(string(line), " "))
+	}
+
+	offset = min(max(offset, 0), max(len(all)-height, 0))
+	end := len(all) - offset
+	start := max(end-height, 0)
+
+	cursorRow := len(all) - s.rows + s.cy - start
+	if cursorRow < 0 || cursorRow >= end-start {
+		cursorRow = -1
+	}
+	return all[start:end], cursorRow, s.cx
+}
+
+// ScrollbackLen returns how many lines can be scrolled back
+func (s *vtScreen) ScrollbackLen() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.altLines != nil {
+		return 0
+	}
+	return len(s.scrollback)
+}
+
+// Text returns the screen's visible text, for tests and plain output
+func (s *vtScreen) Text() string {
+	lines, _, _ := s.View(s.rows, 0)
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
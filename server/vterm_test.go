@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestVTScreen tests printing, cursor movement, erasing and wrapping
+func TestVTScreen(t *testing.T) {
+	screen := newVTScreen(4, 10, 100)
+	screen.Write([]byte("hello\r\nworld"))
+	screen.Write([]byte("\x1b[1;1HJ"))            // Move home and overwrite
+	screen.Write([]byte("\x1b[2;3H\x1b[K"))       // Erase the rest of line 2
+	screen.Write([]byte("\x1b[31mred\x1b[0m"))    // Colors are dropped
+	screen.Write([]byte("\x1b]0;title\x07"))      // Titles are skipped
+	screen.Write([]byte("\x1b[3;1H0123456789ab")) // Wraps at the last column
+
+	expected := "Jello\nwored\n0123456789\nab"
+	if text := screen.Text(); text != expected {
+		t.Errorf("Expected %q, got %q", expected, text)
+	}
+
+	// UTF-8 split across writes
+	screen.Write([]byte("\x1b[2J\x1b[H"))
+	screen.Write([]byte("caf\xc3"))
+	screen.Write([]byte("\xa9"))
+	if text := screen.Text(); text != "café" {
+		t.Errorf("Expected the split rune to be joined, got %q", text)
+	}
+
+	// A full reset clears the screen while Write holds the lock
+	screen.Write([]byte("hello\x1bcworld"))
+	if text := screen.Text(); text != "world" {
+		t.Errorf("Expected the screen to be reset, got %q", text)
+	}
+}
+
+// TestVTScreenScrollback tests that lines leaving the top are kept and that
+// the alternate screen does not add to them
+func TestVTScreenScrollback(t *testing.T) {
+	screen := newVTScreen(3, 20, 5)
+	for i := 0; i < 10; i++ {
+		screen.Write([]byte("line " + string(rune('0'+i)) + "\r\n"))
+	}
+	if n := screen.ScrollbackLen(); n != 5 {
+		t.Fatalf("Expected the scrollback to be capped at 5, got %d", n)
+	}
+
+	lines, _, _ := screen.View(3, 2)
+	if strings.Join(lines, "|") != "line 6|line 7|line 8" {
+		t.Errorf("Unexpected scrolled view: %q", lines)
+	}
+
+	screen.Write([]byte("\x1b[?1049h"))
+	for i := 0; i < 5; i++ {
+		screen.Write([]byte("full screen\r\n"))
+	}
+	screen.Write([]byte("\x1b[?1049l"))
+	if n := screen.ScrollbackLen(); n != 5 {
+		t.Errorf("Expected the alternate screen not to scroll back, got %d lines", n)
+	}
+	if text := screen.Text(); !strings.Contains(text, "line 9") {
+		t.Errorf("Expected the main screen back, got %q", text)
+	}
+}